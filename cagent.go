@@ -1,22 +1,33 @@
 package cagent
 
 import (
+	"crypto/sha256"
 	"fmt"
+	"math/rand"
 	"net/http"
 	"runtime"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/process"
 	"github.com/sirupsen/logrus"
 
 	"github.com/cloudradar-monitoring/selfupdate"
 
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/blockdev"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/cgroups"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/dns"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/fs"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/lvm"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/networking"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/sensors"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/services"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/updates"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/vmstat"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/vmstat/types"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
 	"github.com/cloudradar-monitoring/cagent/pkg/smart"
 )
 
@@ -28,6 +39,8 @@ var (
 	SelfUpdatesFeedURL = "https://repo.cloudradar.io/windows/cagent/feed/rolling"
 )
 
+const hardwareCommandRetryDelay = time.Second
+
 type Cagent struct {
 	Config         *Config
 	ConfigLocation string
@@ -40,13 +53,70 @@ type Cagent struct {
 	cpuWatcher             *CPUWatcher
 	cpuUtilisationAnalyser *CPUUtilisationAnalyser
 
-	fsWatcher  *fs.FileSystemWatcher
-	netWatcher *networking.NetWatcher
+	fsWatcher       *fs.FileSystemWatcher
+	netWatcher      *networking.NetWatcher
+	cgroupWatcher   *cgroups.Watcher
+	blockdevWatcher *blockdev.Watcher
+	dnsChecker      *dns.Checker
+	updatesWatcher  *updates.Watcher
+
+	serviceRestarts *services.RestartTracker
+	throttleWatcher *sensors.ThrottleWatcher
+	perfCounters    *winperf.Collector
+
+	prevSwapStat *swapStatMeasurement
+
+	lvm *lvm.LVM
 
 	vmstatLazyInit sync.Once
 	vmWatchers     map[string]types.Provider
 	hwInventory    sync.Once
 	smart          *smart.SMART
+	smartCache     smartCache
+
+	selfProcess     *process.Process
+	selfProcessOnce sync.Once
+
+	// metadataSent is set once the metrics metadata is reported successfully
+	metadataSent bool
+
+	// hubLastSent holds all measurements of the last successful send to the Hub, used by hub_send_changed_only
+	hubLastSent       common.MeasurementsMap
+	hubLastFullSentAt time.Time
+	// hubLastSentDigest is the SHA-256 of the measurements of the last successful send to the Hub, used by hub_skip_unchanged
+	hubLastSentDigest [sha256.Size]byte
+
+	// hubCredentials is set by SetHubCredentialProvider to be used instead of hub_user and hub_password
+	hubCredentials     *hubCredentialCache
+	hubCredentialsLock sync.Mutex
+
+	// hubPausedUntil is set when the Hub replies with HTTP 429, no requests are sent till then
+	hubPausedUntil time.Time
+	hubPauseLock   sync.Mutex
+	// hubBuffer holds the results rejected with HTTP 429, see hub_buffer_on_429
+	hubBuffer []*Result
+
+	collectors *collectorRunner
+	// collectedAt holds the collection times of the last collected measurements by collectors, see collector_timestamps
+	collectedAt map[string]time.Time
+
+	// selfTestReport collects the outcomes of the collectors while SelfTest is running
+	selfTestReport *SelfTestReport
+
+	// startAt is the time of the first collection and heartbeat according to startup_delay
+	startAt time.Time
+
+	// mockMetrics is set with mock_metrics, its measurements are reported instead of the collected ones
+	mockMetrics *mockMetrics
+
+	// socketOutput receives the results in io_mode="socket"
+	socketOutput *socketOutput
+
+	// configHash is reported as agent.config_hash, see ConfigHash
+	configHash string
+
+	transforms     []MeasurementsTransform
+	transformsLock sync.Mutex
 }
 
 func New(cfg *Config, cfgPath string) (*Cagent, error) {
@@ -54,18 +124,60 @@ func New(cfg *Config, cfgPath string) (*Cagent, error) {
 		Config:         cfg,
 		ConfigLocation: cfgPath,
 		vmWatchers:     make(map[string]types.Provider),
+		collectors:     newCollectorRunner(cfg.CollectorConcurrency, cfg.MetricSampleEvery),
 	}
+	ca.smartCache.interval = secToDuration(cfg.SMARTInterval)
+	ca.configHash = ConfigHash(cfg)
+	ca.startAt = time.Now().Add(startupDelay(cfg.StartupDelay, cfg.StartupDelayRandom))
 
 	ca.configureLogger()
 
 	if ca.Config.SMARTMonitoring && ca.Config.SMARTCtl != "" {
 		var err error
-		ca.smart, err = smart.New(smart.Executable(ca.Config.SMARTCtl, false))
+		ca.smart, err = smart.New(
+			smart.Executable(ca.Config.SMARTCtl, false),
+			smart.HealthOnly(ca.Config.SMARTHealthOnly),
+			smart.CommandRetries(ca.Config.HardwareCommandRetries, hardwareCommandRetryDelay),
+		)
+		if err = ca.handleStartupError(FatalErrorSMARTCtl, err); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(ca.Config.WindowsPerfCounters) > 0 && runtime.GOOS == "windows" {
+		var err error
+		// invalid counters are reported once here and skipped in the collections
+		ca.perfCounters, err = winperf.New(ca.Config.WindowsPerfCounters)
 		if err != nil {
-			logrus.Error(err.Error())
+			if err = ca.handleStartupError(FatalErrorWindowsPerfCounters, errors.Wrap(err, "windows_perf_counters")); err != nil {
+				return nil, err
+			}
 		}
 	}
 
+	if ca.Config.MockMetrics != "" {
+		var err error
+		if ca.mockMetrics, err = loadMockMetrics(ca.Config.MockMetrics); err != nil {
+			return nil, err
+		}
+		logrus.Warnf("mock_metrics is set, the measurements from '%s' are reported instead of the collected ones", ca.Config.MockMetrics)
+	}
+
+	if ca.Config.HubCredentialsFile != "" {
+		provider := NewFileHubCredentialProvider(ca.Config.HubCredentialsFile)
+		provider.user, provider.pass = ca.Config.HubUser, ca.Config.HubPassword
+		// the file is checked for changes before each request, re-reading it is skipped while it's unchanged
+		ca.SetHubCredentialProvider(provider, 0)
+	}
+
+	if ca.Config.IOMode == IOModeSocket {
+		ca.socketOutput = newSocketOutput(ca.Config.OutSocket)
+	}
+
+	if err := ca.checkHubReachable(); err != nil {
+		return nil, err
+	}
+
 	err := ca.configureAutomaticSelfUpdates()
 	if err != nil {
 		logrus.Error(err.Error())
@@ -75,6 +187,31 @@ func New(cfg *Config, cfgPath string) (*Cagent, error) {
 	return ca, nil
 }
 
+// startupDelay returns the delay before the first collection: delay seconds plus up to random seconds
+func startupDelay(delay, random float64) time.Duration {
+	if random > 0 {
+		delay += rand.New(rand.NewSource(time.Now().UnixNano())).Float64() * random
+	}
+
+	return secToDuration(delay)
+}
+
+// waitForStart blocks until the startup delay passes. Returns false if interrupted meanwhile
+func (ca *Cagent) waitForStart(interrupt chan struct{}) bool {
+	wait := time.Until(ca.startAt)
+	if wait <= 0 {
+		return true
+	}
+
+	logrus.Infof("waiting %v before the first collection according to startup_delay", wait.Round(time.Millisecond))
+	select {
+	case <-interrupt:
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
 func (ca *Cagent) configureAutomaticSelfUpdates() error {
 	if !ca.Config.Updates.Enabled {
 		return nil
@@ -98,16 +235,44 @@ func (ca *Cagent) configureAutomaticSelfUpdates() error {
 	return nil
 }
 
+// hardwareCommandInvoker returns invoker for hardware tools like dmidecode which retries transient failures.
+// The error output, e.g. warnings of sudo, is not mixed into the parsed standard output
+func (ca *Cagent) hardwareCommandInvoker() common.Invoker {
+	return &common.RetryInvoker{
+		Invoker: common.Invoke{StdoutOnly: true},
+		Retries: ca.Config.HardwareCommandRetries,
+		Delay:   hardwareCommandRetryDelay,
+	}
+}
+
 func (ca *Cagent) userAgent() string {
+	return userAgent(ca.Config)
+}
+
+// userAgent returns User-Agent header value used for requests to the Hub, it can be overridden with hub_user_agent
+func userAgent(cfg *Config) string {
+	if cfg.HubUserAgent != "" {
+		return cfg.HubUserAgent
+	}
+
 	if Version == "" {
 		Version = "{undefined}"
 	}
-	return fmt.Sprintf("Cagent v%s %s %s", Version, runtime.GOOS, runtime.GOARCH)
+	return fmt.Sprintf("cagent/%s (%s/%s)", Version, runtime.GOOS, runtime.GOARCH)
 }
 
 func (ca *Cagent) Shutdown() {
 	defer sensors.Shutdown()
-	defer updates.Shutdown()
+	defer func() {
+		if ca.socketOutput != nil {
+			ca.socketOutput.Close()
+		}
+	}()
+	defer func() {
+		if ca.updatesWatcher != nil {
+			ca.updatesWatcher.Shutdown()
+		}
+	}()
 	defer func() {
 		if ca.selfUpdater != nil {
 			ca.selfUpdater.Shutdown()
@@ -11,10 +11,13 @@ import (
 	"os/signal"
 	"path/filepath"
 	"runtime"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 	"syscall"
+	"text/tabwriter"
+	"time"
 
 	"github.com/cloudradar-monitoring/selfupdate"
 	"github.com/kardianos/service"
@@ -63,13 +66,16 @@ func main() {
 
 	// Setup flag pointers
 	outputFilePtr := flag.String("o", "", "file to write the results (default ./results.out)")
-	cfgPathPtr := flag.String("c", cagent.DefaultCfgPath, "config file path")
+	cfgPathPtr := flag.String("c", cagent.DefaultCfgPath, "config file path, \"-\" to read the config from stdin or http(s):// URL to fetch it")
 	logLevelPtr := flag.String("v", "", "log level – overrides the level in config file (values \"error\",\"info\",\"debug\")")
 	daemonizeModePtr := flag.Bool("d", false, "daemonize – run the process in background")
 	oneRunOnlyModePtr := flag.Bool("r", false, "one run only – perform checks once and exit. Overwrites output file")
 	serviceUninstallPtr := flag.Bool("u", false, fmt.Sprintf("stop and uninstall the system service(%s)", systemManager.String()))
 	printConfigPtr := flag.Bool("p", false, "print the active config")
+	showChangesPtr := flag.Bool("show-changes", false, "print the config settings which differ from the defaults")
+	generateConfigPtr := flag.String("generate-config", "", "write a new config file to the path of -c and exit (values \"minimal\",\"full\"). \"full\" includes all settings with their descriptions")
 	testConfigPtr := flag.Bool("t", false, "test the HUB config")
+	selfTestPtr := flag.Bool("selftest", false, "run every enabled collector once, print their status and timing and exit. Exit code is 1 if the cpu, mem or fs collector failed")
 	assumeYesPtr := flag.Bool("y", false, "automatic yes to prompts. Assume 'yes' as answer to all prompts and run non-interactively")
 	flagServiceStatusPtr := flag.Bool("service_status", false, "check status of cagent within system service")
 	flagServiceStartPtr := flag.Bool("service_start", false, "start cagent as system service")
@@ -84,6 +90,7 @@ func main() {
 	}
 
 	versionPtr := flag.Bool("version", false, "show the cagent version")
+	printMetricsPtr := flag.Bool("print-metrics", false, "print the keys of every metric this build can report with their units and the config options enabling them")
 
 	// some OS specific flags
 	if runtime.GOOS == "windows" {
@@ -96,6 +103,7 @@ func main() {
 
 	// version should be handled first to ensure it will be accessible in case of fatal errors before
 	handleFlagVersion(*versionPtr)
+	handleFlagPrintMetrics(*printMetricsPtr)
 
 	// check some incompatible flags
 	if serviceInstallUserPtr != nil && *serviceInstallUserPtr != "" ||
@@ -109,6 +117,8 @@ func main() {
 		}
 	}
 
+	handleFlagGenerateConfig(*generateConfigPtr, *cfgPathPtr)
+
 	cfg, err := cagent.HandleAllConfigSetup(*cfgPathPtr)
 	if err != nil {
 		log.WithError(err).Fatalln("Failed to handle Cagent configuration")
@@ -120,6 +130,7 @@ func main() {
 	}
 
 	handleFlagPrintConfig(*printConfigPtr, cfg)
+	handleFlagShowChanges(*showChangesPtr, cfg)
 	handleFlagSearchUpdates(searchUpdatesPtr)
 	handleFlagUpdate(updatePtr, assumeYesPtr)
 
@@ -130,6 +141,7 @@ func main() {
 	}
 
 	handleFlagTest(*testConfigPtr, ca)
+	handleFlagSelfTest(*selfTestPtr, ca)
 	handleFlagSettings(settingsPtr, ca)
 
 	if len(*outputFilePtr) == 0 && cfg.IOMode == cagent.IOModeFile {
@@ -153,12 +165,13 @@ func main() {
 	handleFlagServiceInstall(ca, serviceInstallUserPtr, serviceInstallPtr, *cfgPathPtr, assumeYesPtr)
 	handleFlagDaemonizeMode(*daemonizeModePtr)
 
-	output := handleFlagOutput(*outputFilePtr, *oneRunOnlyModePtr)
+	output := handleFlagOutput(*outputFilePtr, *oneRunOnlyModePtr || ca.Config.OperationMode == cagent.OperationModeCheck)
 	if output != nil {
 		defer output.Close()
 	}
 
 	handleFlagOneRunOnlyMode(ca, *oneRunOnlyModePtr, output)
+	handleOperationModeCheck(ca, output)
 
 	log.Errorf("cagent v%s starting...", cagent.Version)
 
@@ -196,6 +209,20 @@ func handleFlagVersion(versionFlag bool) {
 	}
 }
 
+func handleFlagPrintMetrics(printMetrics bool) {
+	if !printMetrics {
+		return
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "KEY\tUNIT\tCONFIG OPTION")
+	for _, d := range cagent.ListMetricDescriptors() {
+		fmt.Fprintf(w, "%s\t%s\t%s\n", d.Key, d.Unit, d.ConfigOption)
+	}
+	_ = w.Flush()
+	os.Exit(0)
+}
+
 func handleServiceCommand(ca *cagent.Cagent, check, start, stop, restart bool) {
 	if !check && !start && !stop && !restart {
 		return
@@ -270,6 +297,53 @@ func handleFlagPrintConfig(printConfig bool, cfg *cagent.Config) {
 	}
 }
 
+func handleFlagGenerateConfig(mode string, cfgPath string) {
+	if mode == "" {
+		return
+	}
+
+	if !cagent.IsConfigFileLocation(cfgPath) {
+		log.Fatalf("Config can't be generated at '%s', pass the path of the config file with -c", cfgPath)
+	}
+
+	if _, err := os.Stat(cfgPath); err == nil {
+		log.Fatalf("Config file already exists at path: %s", cfgPath)
+	}
+
+	var err error
+	switch mode {
+	case "minimal":
+		err = cagent.GenerateDefaultConfigFile(cagent.NewMinimumConfig(), cfgPath)
+	case "full":
+		err = cagent.GenerateFullConfigFile(cagent.NewConfig(), cfgPath)
+	default:
+		log.Fatalf("Invalid -generate-config value '%s'. Must be one of: minimal, full", mode)
+	}
+
+	if err != nil {
+		log.WithError(err).Fatalln("Failed to generate config file")
+	}
+
+	fmt.Printf("Config file written to %s\n", cfgPath)
+	os.Exit(0)
+}
+
+func handleFlagShowChanges(showChanges bool, cfg *cagent.Config) {
+	if showChanges {
+		diff := cagent.ConfigDiff(cfg)
+		keys := make([]string, 0, len(diff))
+		for key := range diff {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			fmt.Printf("%s = %v\n", key, diff[key])
+		}
+		os.Exit(0)
+	}
+}
+
 func handleFlagSettings(settingsUI *bool, ca *cagent.Cagent) {
 	if settingsUI != nil && *settingsUI {
 		windowsShowSettingsUI(ca, false)
@@ -407,6 +481,18 @@ func handleFlagOneRunOnlyMode(ca *cagent.Cagent, oneRunOnlyMode bool, output *os
 	}
 }
 
+func handleOperationModeCheck(ca *cagent.Cagent, output *os.File) {
+	if ca.Config.OperationMode != cagent.OperationModeCheck {
+		return
+	}
+
+	code, err := ca.RunCheck(output)
+	if err != nil {
+		log.Error(err)
+	}
+	os.Exit(code)
+}
+
 func handleFlagDaemonizeMode(daemonizeMode bool) {
 	if daemonizeMode && os.Getenv("cagent_FORK") != "1" {
 		err := rerunDetached()
@@ -604,6 +690,33 @@ func handleFlagTest(testConfig bool, ca *cagent.Cagent) {
 	os.Exit(0)
 }
 
+func handleFlagSelfTest(selfTest bool, ca *cagent.Cagent) {
+	if !selfTest {
+		return
+	}
+
+	report, err := ca.SelfTest()
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "COLLECTOR\tSTATUS\tMEASUREMENTS\tDURATION\tMESSAGE")
+	for _, c := range report.Collectors {
+		name := c.Name
+		if c.Mandatory {
+			name += " (mandatory)"
+		}
+		fmt.Fprintf(w, "%s\t%s\t%d\t%v\t%s\n", name, c.Status, c.Measurements, c.Duration.Round(time.Millisecond), c.Message)
+	}
+	_ = w.Flush()
+
+	if err != nil {
+		fmt.Printf("Self-test failed: %s\n", err.Error())
+		os.Exit(1)
+	}
+
+	fmt.Println("Self-test passed")
+	os.Exit(0)
+}
+
 func rerunDetached() error {
 	cwd, err := os.Getwd()
 	if err != nil {
@@ -677,6 +790,9 @@ func getServiceFromFlags(ca *cagent.Cagent, configPath, userName string) (servic
 	prg := &serviceWrapper{Cagent: ca}
 
 	if configPath != "" {
+		if !cagent.IsConfigFileLocation(configPath) {
+			return nil, fmt.Errorf("the service can't be configured with config location '%s', pass the path of the config file with -c", configPath)
+		}
 		if !filepath.IsAbs(configPath) {
 			var err error
 			configPath, err = filepath.Abs(configPath)
@@ -0,0 +1,36 @@
+package cagent
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var (
+	// errorURLCredentialsRegexp matches the user info of URLs, e.g. "https://user:password@"
+	errorURLCredentialsRegexp = regexp.MustCompile(`([a-zA-Z][a-zA-Z0-9+.-]*://)[^/\s@]+@`)
+	// errorPathDirsRegexp matches the directories of absolute paths, e.g. "/home/user/.ssh/" of "/home/user/.ssh/id_rsa"
+	errorPathDirsRegexp = regexp.MustCompile(`(^|[\s'"(=\[])((?:[a-zA-Z]:)?[/\\](?:[^\s/\\'"]+[/\\])+)`)
+)
+
+// collectorErrorMeasurements returns the errors of the collectors by their names, the errors of one collector are joined with "; "
+func collectorErrorMeasurements(errs map[string][]string) common.MeasurementsMap {
+	res := common.MeasurementsMap{}
+	for name, messages := range errs {
+		scrubbed := make([]string, 0, len(messages))
+		for _, msg := range messages {
+			scrubbed = append(scrubbed, scrubErrorMessage(msg))
+		}
+		res[name] = strings.Join(scrubbed, "; ")
+	}
+
+	return res
+}
+
+// scrubErrorMessage hides the parts of the error message which may reveal the details of the host:
+// the credentials in URLs and the directories of absolute paths, the file names are kept
+func scrubErrorMessage(msg string) string {
+	msg = errorURLCredentialsRegexp.ReplaceAllString(msg, "${1}***@")
+	return errorPathDirsRegexp.ReplaceAllString(msg, "${1}.../")
+}
@@ -0,0 +1,258 @@
+package cagent
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// collectorFunc collects the measurements, ctx is cancelled once the run is abandoned
+type collectorFunc func(ctx context.Context) (common.MeasurementsMap, error)
+
+type collectorResult struct {
+	measurements common.MeasurementsMap
+	err          error
+	collectedAt  time.Time
+}
+
+type collectorState struct {
+	running bool
+	last    common.MeasurementsMap
+	// lastAt is the time the last measurements were collected at
+	lastAt time.Time
+
+	// every is the number of cycles the last measurements are reported for, see collectorRunner.sampleEveryFor
+	every int
+	// skippedCycles is the number of cycles served from last since the collector finished
+	skippedCycles int
+}
+
+// setLast stores measurements of the run finished at collectedAt, they are reported for the next every-1 cycles
+func (s *collectorState) setLast(measurements common.MeasurementsMap, collectedAt time.Time, every int) {
+	s.last = measurements
+	s.lastAt = collectedAt
+	s.every = every
+	s.skippedCycles = 0
+}
+
+// collectorRunner runs collectors till the deadline of the collection cycle
+// collectors which didn't finish in time are abandoned and their previous measurements are reported instead.
+// The context of the abandoned run is cancelled and its result is discarded once it finishes.
+// A collector is never run again while its previous run is still in progress
+// No more than concurrency collectors are executed at the same time. Abandoned runs give their slot back,
+// so a hung collector doesn't block the other ones in the later cycles
+// Collectors which measurements match sampleEvery prefixes are run only every Nth cycle, their previous measurements are reported in between
+type collectorRunner struct {
+	mu     sync.Mutex
+	states map[string]*collectorState
+
+	slots       chan struct{}
+	sampleEvery map[string]int
+}
+
+func newCollectorRunner(concurrency int, sampleEvery map[string]int) *collectorRunner {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	return &collectorRunner{
+		states:      make(map[string]*collectorState),
+		slots:       make(chan struct{}, concurrency),
+		sampleEvery: sampleEvery,
+	}
+}
+
+// sampleEveryFor returns the number of cycles the measurements are valid for: the smallest N of the measurement keys,
+// where N of a key is the one of the longest metric_sample_every prefix matching it, 1 if none matches
+func (r *collectorRunner) sampleEveryFor(measurements common.MeasurementsMap) int {
+	if len(measurements) == 0 {
+		return 1
+	}
+
+	every := 0
+	for key := range measurements {
+		n := r.sampleEveryForKey(key)
+		if every == 0 || n < every {
+			every = n
+		}
+	}
+
+	return every
+}
+
+func (r *collectorRunner) sampleEveryForKey(key string) int {
+	every, longest := 1, -1
+	for prefix, n := range r.sampleEvery {
+		if key != prefix && !strings.HasPrefix(key, prefix+".") {
+			continue
+		}
+		if len(prefix) > longest {
+			every, longest = n, len(prefix)
+		}
+	}
+
+	return every
+}
+
+func (r *collectorRunner) state(name string) *collectorState {
+	state, exists := r.states[name]
+	if !exists {
+		state = &collectorState{}
+		r.states[name] = state
+	}
+
+	return state
+}
+
+// slotLease is a slot of the runner taken by a single run
+type slotLease struct {
+	mu       sync.Mutex
+	slots    chan struct{}
+	held     bool
+	released bool
+}
+
+// acquire waits for a free slot till abandoned is closed, false is returned if the run was abandoned meanwhile
+func (l *slotLease) acquire(abandoned <-chan struct{}) bool {
+	select {
+	case l.slots <- struct{}{}:
+	case <-abandoned:
+		return false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.released {
+		<-l.slots
+		return false
+	}
+	l.held = true
+
+	return true
+}
+
+// release gives the slot back, it's safe to call it multiple times
+func (l *slotLease) release() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.held {
+		<-l.slots
+		l.held = false
+	}
+	l.released = true
+}
+
+// exec runs f once a free slot is available, waiting for the slot counts towards the collector's deadline.
+// If abandoned is closed before a slot is available, f is not run
+func (r *collectorRunner) exec(ctx context.Context, lease *slotLease, abandoned <-chan struct{}, f collectorFunc) *collectorResult {
+	if !lease.acquire(abandoned) {
+		return &collectorResult{}
+	}
+	defer lease.release()
+
+	measurements, err := f(ctx)
+	return &collectorResult{measurements: measurements, err: err, collectedAt: time.Now()}
+}
+
+// CollectedAt returns the time the measurements of the collector reported by the last Run were collected at.
+// Zero time is returned if the collector hasn't finished yet
+func (r *collectorRunner) CollectedAt(name string) time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if state, exists := r.states[name]; exists {
+		return state.lastAt
+	}
+
+	return time.Time{}
+}
+
+// Run executes collector f and waits for it till deadline. Zero deadline means to wait until f finishes
+// If the previous run of collector is still in progress it is not started again
+// Collectors sampled every N cycles are run only if they didn't finish during the last N-1 cycles.
+// The measurements of a failed run are not reused, the collector is run again in the next cycle
+func (r *collectorRunner) Run(name string, deadline time.Time, f collectorFunc) (common.MeasurementsMap, error) {
+	r.mu.Lock()
+	state := r.state(name)
+
+	if state.skippedCycles+1 < state.every {
+		state.skippedCycles++
+		last := state.last
+		r.mu.Unlock()
+
+		return last, nil
+	}
+
+	if state.running {
+		last := state.last
+		r.mu.Unlock()
+
+		return last, fmt.Errorf("collector %s is still running since the previous collection cycle, previous measurements are reported", name)
+	}
+
+	var timeout <-chan time.Time
+	if !deadline.IsZero() {
+		d := time.Until(deadline)
+		if d <= 0 {
+			last := state.last
+			r.mu.Unlock()
+
+			return last, fmt.Errorf("collector %s skipped as collection deadline exceeded, previous measurements are reported", name)
+		}
+
+		timer := time.NewTimer(d)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	state.running = true
+	r.mu.Unlock()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	lease := &slotLease{slots: r.slots}
+	abandoned := make(chan struct{})
+	done := make(chan *collectorResult, 1)
+	go func() {
+		done <- r.exec(ctx, lease, abandoned, f)
+	}()
+
+	select {
+	case res := <-done:
+		every := 1
+		if res.err == nil {
+			every = r.sampleEveryFor(res.measurements)
+		}
+
+		r.mu.Lock()
+		state.running = false
+		state.setLast(res.measurements, res.collectedAt, every)
+		r.mu.Unlock()
+
+		return res.measurements, res.err
+	case <-timeout:
+	}
+
+	cancel()
+	close(abandoned)
+	lease.release()
+
+	go func() {
+		// the result is stale by now, it is discarded
+		<-done
+		r.mu.Lock()
+		state.running = false
+		r.mu.Unlock()
+	}()
+
+	r.mu.Lock()
+	last := state.last
+	r.mu.Unlock()
+
+	return last, fmt.Errorf("collector %s abandoned as collection deadline exceeded, previous measurements are reported", name)
+}
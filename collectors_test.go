@@ -0,0 +1,193 @@
+package cagent
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestCollectorRunnerAbandonsSlowCollector(t *testing.T) {
+	r := newCollectorRunner(4, nil)
+	release := make(chan struct{})
+	defer close(release)
+
+	var calls int32
+	slow := func(context.Context) (common.MeasurementsMap, error) {
+		n := atomic.AddInt32(&calls, 1)
+		if n > 1 {
+			<-release
+		}
+		return common.MeasurementsMap{"slow.value": int(n)}, nil
+	}
+
+	res, err := r.Run("slow", time.Now().Add(time.Second), slow)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"slow.value": 1}, res)
+
+	start := time.Now()
+	res, err = r.Run("slow", time.Now().Add(50*time.Millisecond), slow)
+	assert.True(t, time.Since(start) < time.Second, "collection cycle must finish on time")
+	assert.Error(t, err)
+	assert.Equal(t, common.MeasurementsMap{"slow.value": 1}, res, "previous measurements must be reused")
+
+	// abandoned collector is not started again while it is still running
+	res, err = r.Run("slow", time.Now().Add(50*time.Millisecond), slow)
+	assert.Error(t, err)
+	assert.Equal(t, common.MeasurementsMap{"slow.value": 1}, res)
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+}
+
+func TestCollectorRunnerDiscardsLateResult(t *testing.T) {
+	r := newCollectorRunner(4, nil)
+	cancelled := make(chan struct{})
+
+	res, err := r.Run("late", time.Now().Add(20*time.Millisecond), func(ctx context.Context) (common.MeasurementsMap, error) {
+		<-ctx.Done()
+		close(cancelled)
+		return common.MeasurementsMap{"late.value": 42}, nil
+	})
+	assert.Error(t, err)
+	assert.Nil(t, res, "no previous measurements to reuse")
+
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatal("context of the abandoned collector must be cancelled")
+	}
+
+	for i := 0; i < 100; i++ {
+		r.mu.Lock()
+		running := r.states["late"].running
+		r.mu.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	res, err = r.Run("late", time.Now().Add(time.Second), func(context.Context) (common.MeasurementsMap, error) {
+		return common.MeasurementsMap{"late.value": 43}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"late.value": 43}, res, "late result of the abandoned run must be discarded")
+}
+
+func TestCollectorRunnerSkipsAfterDeadline(t *testing.T) {
+	r := newCollectorRunner(4, nil)
+	res, err := r.Run("skipped", time.Now().Add(-time.Second), func(context.Context) (common.MeasurementsMap, error) {
+		t.Error("collector must not run after deadline")
+		return nil, nil
+	})
+	assert.Error(t, err)
+	assert.Nil(t, res)
+
+	res, err = r.Run("nodeadline", time.Time{}, func(context.Context) (common.MeasurementsMap, error) {
+		return common.MeasurementsMap{"value": 1}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"value": 1}, res)
+}
+
+func TestCollectorRunnerReleasesSlotOfAbandonedRun(t *testing.T) {
+	r := newCollectorRunner(1, nil)
+	release := make(chan struct{})
+	defer close(release)
+
+	_, err := r.Run("hung", time.Now().Add(20*time.Millisecond), func(context.Context) (common.MeasurementsMap, error) {
+		<-release
+		return nil, nil
+	})
+	assert.Error(t, err)
+
+	// the only slot is not kept by the hung collector
+	for i := 0; i < 3; i++ {
+		res, err := r.Run(fmt.Sprintf("collector%d", i), time.Now().Add(time.Second), func(context.Context) (common.MeasurementsMap, error) {
+			return common.MeasurementsMap{"value": 1}, nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, common.MeasurementsMap{"value": 1}, res)
+	}
+}
+
+func TestCollectorRunnerAbandonedWhileWaitingForSlot(t *testing.T) {
+	r := newCollectorRunner(1, nil)
+
+	// occupies the only slot
+	lease := &slotLease{slots: r.slots}
+	assert.True(t, lease.acquire(nil))
+
+	_, err := r.Run("waiting", time.Now().Add(20*time.Millisecond), func(context.Context) (common.MeasurementsMap, error) {
+		t.Error("collector abandoned before it got a slot must not run")
+		return nil, nil
+	})
+	assert.Error(t, err)
+
+	lease.release()
+	for i := 0; i < 100; i++ {
+		r.mu.Lock()
+		running := r.states["waiting"].running
+		r.mu.Unlock()
+		if !running {
+			break
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	res, err := r.Run("waiting", time.Now().Add(time.Second), func(context.Context) (common.MeasurementsMap, error) {
+		return common.MeasurementsMap{"value": 1}, nil
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"value": 1}, res)
+}
+
+func TestCollectorRunnerSampleEvery(t *testing.T) {
+	r := newCollectorRunner(4, map[string]int{"hw": 3, "hw.sensors": 1, "cpu": 2})
+
+	run := func(name string, collector collectorFunc, cycles int) []interface{} {
+		var reported []interface{}
+		for cycle := 1; cycle <= cycles; cycle++ {
+			res, _ := r.Run(name, time.Now().Add(time.Second), collector)
+			reported = append(reported, res["hw.calls"])
+		}
+		return reported
+	}
+
+	var calls int
+	collector := func(context.Context) (common.MeasurementsMap, error) {
+		calls++
+		return common.MeasurementsMap{"hw.calls": calls}, nil
+	}
+
+	// collected on cycles 1 and 4, cached measurements are reported on 2, 3 and 5
+	assert.Equal(t, []interface{}{1, 1, 1, 2, 2}, run("inventory", collector, 5))
+	assert.Equal(t, 2, calls)
+
+	// the smallest N of the reported measurements applies
+	calls = 0
+	mixed := func(context.Context) (common.MeasurementsMap, error) {
+		calls++
+		return common.MeasurementsMap{"hw.calls": calls, "cpu.load": 1}, nil
+	}
+	assert.Equal(t, []interface{}{1, 1, 2, 2, 3}, run("mixed", mixed, 5))
+
+	// failed runs are not reused
+	calls = 0
+	failing := func(context.Context) (common.MeasurementsMap, error) {
+		calls++
+		return common.MeasurementsMap{"hw.calls": calls}, errors.New("device busy")
+	}
+	assert.Equal(t, []interface{}{1, 2, 3}, run("failing", failing, 3))
+
+	// the longest matching prefix is used
+	assert.Equal(t, 1, r.sampleEveryForKey("hw.sensors.temp"))
+	assert.Equal(t, 3, r.sampleEveryForKey("hw.inventory"))
+	assert.Equal(t, 1, r.sampleEveryForKey("hwinfo"))
+	assert.Equal(t, 1, r.sampleEveryFor(nil))
+}
@@ -4,9 +4,14 @@ import (
 	"bytes"
 	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"reflect"
+	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
@@ -16,18 +21,28 @@ import (
 	"github.com/troian/toml"
 
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/hwinfo"
 	"github.com/cloudradar-monitoring/cagent/pkg/jobmon"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/fs"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/httpcheck"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/jmx"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/mysql"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/processes"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/snmp"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
+	"github.com/cloudradar-monitoring/cagent/pkg/remote"
 )
 
 const (
 	IOModeFile = "file"
 	IOModeHTTP = "http"
+	// IOModeSocket writes the results to the Unix domain socket out_socket
+	IOModeSocket = "socket"
 
 	OperationModeFull      = "full"
 	OperationModeMinimal   = "minimal"
 	OperationModeHeartbeat = "heartbeat"
+	OperationModeCheck     = "check"
 
 	minIntervalValue          = 30.0
 	minHeartbeatIntervalValue = 5.0
@@ -35,11 +50,47 @@ const (
 	minHubRequestTimeout = 1
 	maxHubRequestTimeout = 600
 
+	maxHardwareCommandRetries = 5
+
+	maxMetricPrecision = 10
+
+	minLogMaxSizeMB  = 1
+	maxLogMaxSizeMB  = 1024
+	maxLogMaxBackups = 100
+	maxLogMaxAgeDays = 3650
+
+	// ConfigLocationStdin used as config path makes cagent to read the config from stdin
+	ConfigLocationStdin = "-"
+
+	configURLFetchTimeout = 10 * time.Second
+
 	minSystemUpdatesCheckInterval = 300
 	minSelfUpdatesCheckInterval   = 600
+
+	CPUUtilAverageTypeArithmetic = "arithmetic"
+	CPUUtilAverageTypeEMA        = "ema"
+
+	TimestampFormatRFC3339 = "rfc3339"
+	TimestampFormatUnix    = "unix"
+	TimestampFormatUnixMs  = "unix_ms"
+
+	JSONNestingFlat   = "flat"
+	JSONNestingNested = "nested"
+
+	HubBufferOn429Drop       = "drop"
+	HubBufferOn429DropOldest = "drop_oldest"
+
+	maxHubBufferSize = 1000
 )
 
-var operationModes = []string{OperationModeFull, OperationModeMinimal, OperationModeHeartbeat}
+var operationModes = []string{OperationModeFull, OperationModeMinimal, OperationModeHeartbeat, OperationModeCheck}
+var cpuUtilAverageTypes = []string{CPUUtilAverageTypeArithmetic, CPUUtilAverageTypeEMA}
+var timestampFormats = []string{TimestampFormatRFC3339, TimestampFormatUnix, TimestampFormatUnixMs}
+var jsonNestings = []string{JSONNestingFlat, JSONNestingNested}
+var hubBufferOn429Policies = []string{HubBufferOn429Drop, HubBufferOn429DropOldest}
+
+// metricPrefixRegexp limits metric_prefix to characters which are safe in the keys of all outputs
+var metricPrefixRegexp = regexp.MustCompile(`^[a-zA-Z0-9._-]*$`)
 
 var DefaultCfgPath string
 var defaultLogPath string
@@ -64,56 +115,149 @@ type LogsFilesConfig struct {
 }
 
 type Config struct {
-	OperationMode     string  `toml:"operation_mode" comment:"operation_mode, possible values:\n\"full\": perform all checks unless disabled individually through other config option. Default.\n\"minimal\": perform just the checks for CPU utilization, CPU Load, Memory Usage, and Disk fill levels.\n\"heartbeat\": Just send the heartbeat according to the heartbeat interval.\nApplies only to io_mode = http, ignored on the command line."`
-	Interval          float64 `toml:"interval" comment:"interval to push metrics to the HUB"`
-	HeartbeatInterval float64 `toml:"heartbeat" comment:"send a heartbeat without metrics to the HUB every X seconds"`
-	Sleep             float64 `toml:"sleep" comment:"sleep duration after failed communication with the HUB"`
+	OperationMode     string                  `toml:"operation_mode" comment:"operation_mode, possible values:\n\"full\": perform all checks unless disabled individually through other config option. Default.\n\"minimal\": perform just the checks for CPU utilization, CPU Load, Memory Usage, and Disk fill levels.\n\"heartbeat\": Just send the heartbeat according to the heartbeat interval.\n\"check\": perform all checks once, send the results and exit. Exit code is 2 if any critical threshold is breached, 3 if the results can't be sent.\nApplies only to io_mode = http, ignored on the command line."`
+	Interval          float64                 `toml:"interval" comment:"interval to push metrics to the HUB"`
+	HeartbeatInterval float64                 `toml:"heartbeat" comment:"send a heartbeat without metrics to the HUB every X seconds"`
+	IntervalSchedule  []IntervalScheduleEntry `toml:"interval_schedule,omitempty" comment:"Daily windows of the local clock in which interval and operation_mode are replaced, e.g. to collect less often overnight\nThe windows must not overlap, requires operation_mode \"full\" or \"minimal\". Example:\n[[interval_schedule]]\n  from = '20:00'\n  to = '07:00'\n  interval = 900\n  operation_mode = 'minimal'"`
+	Sleep             float64                 `toml:"sleep" comment:"sleep duration after failed communication with the HUB"`
+
+	CollectionDeadline   float64 `toml:"collection_deadline" comment:"Fraction of the interval after which collectors that are still running are abandoned for the current run\nand their previous values are reported, so metrics are pushed on schedule. The abandoned collectors are cancelled and their late results are discarded.\nBetween 0 and 1, 0 disables it. default 0"`
+	CollectorConcurrency int     `toml:"collector_concurrency" comment:"Maximum number of collectors executed at the same time. Collectors abandoned after collection_deadline don't count.\nLower it to reduce the load spikes caused by the external commands (dmidecode, smartctl, etc.) on small hosts. default is the number of CPUs"`
+
+	MetricSampleEvery map[string]int `toml:"metric_sample_every" comment:"Collect the measurements matching the listed key prefixes only every Nth collection cycle, their previous values are reported in between\nA collector is skipped only if all its measurements match, the smallest N of them applies. Failed collections are retried in the next cycle\nN must be >= 1. Example: metric_sample_every = { proc = 3, services = 10, 'hw.inventory' = 60 }"`
+
+	StartupDelay       float64 `toml:"startup_delay" comment:"Seconds to wait after the start before the first collection and heartbeat, lets the system settle after boot. default 0"`
+	StartupDelayRandom float64 `toml:"startup_delay_random" comment:"Max number of seconds randomly added to startup_delay to spread the load on the Hub when many hosts boot at once\nstartup_delay + startup_delay_random must be lower than the interval. default 0"`
 
 	PidFile   string `toml:"pid" comment:"pid file location"`
 	LogFile   string `toml:"log,omitempty" required:"false" comment:"log file location"`
 	LogSyslog string `toml:"log_syslog" comment:"\"local\" for local unix socket or URL e.g. \"udp://localhost:514\" for remote syslog server"`
 
+	LogMaxSizeMB  int `toml:"log_max_size_MB" comment:"Log file is rotated when it exceeds this size in megabytes. Min: 1, Max: 1024. default 10"`
+	LogMaxBackups int `toml:"log_max_backups" comment:"Number of rotated log files to keep. 0 keeps all of them unless removed by log_max_age_days. Max: 100. default 5"`
+	LogMaxAgeDays int `toml:"log_max_age_days" comment:"Rotated log files older than this number of days are removed. 0 disables the removal by age. Max: 3650. default 30"`
+
 	MinValuableConfig
 
+	OutTimestampFormat string `toml:"out_timestamp_format" comment:"timestamp format used in io_mode=\"file\" and \"socket\", possible values: \"rfc3339\", \"unix\", \"unix_ms\". default \"rfc3339\""`
+	OutTimezone        string `toml:"out_timezone" comment:"IANA time zone name used for rfc3339 timestamps in io_mode=\"file\" and \"socket\", e.g. \"UTC\" or \"Europe/Berlin\"\nLocal time zone of the host is used if empty"`
+
+	OutJSONNesting    string `toml:"out_json_nesting" comment:"Structure of the measurements JSON in io_mode=\"file\" and \"socket\", possible values:\n\"flat\": dotted keys, e.g. {\"cpu.util.idle.1.total\": 95.1}. Default.\n\"nested\": keys are split by dots into nested objects, e.g. {\"cpu\": {\"util\": {\"idle\": {\"1\": {\"total\": 95.1}}}}}\nIf a key is both a value and an object, the value is kept under \"_value\" key of the object"`
+	OutJSONNestingHub bool   `toml:"out_json_nesting_hub" comment:"Apply out_json_nesting to the measurements sent to the Hub as well. default false"`
+
+	OutGzip   bool   `toml:"out_gzip" comment:"Gzip the results written in io_mode=\"file\", always enabled if the output file name ends with .gz\nEvery result is appended as a separate gzip member, the file can be read with gunzip or zcat. default false"`
+	OutSocket string `toml:"out_socket" comment:"Path of the Unix domain socket the results are written to as newline-delimited JSON in io_mode=\"socket\"\nThe connection is reestablished if the consumer restarts, results are dropped while it is unavailable. default \"\""`
+
+	CollectorTimestamps bool `toml:"collector_timestamps" comment:"Report the time every collector measured its values in the 'collected_at' section, keyed by the collector (fs, proc, smartmon etc.)\nCached values, e.g. of the collectors sampled by metric_sample_every, carry the time they were collected at. default false"`
+
+	MockMetrics string `toml:"mock_metrics" comment:"Path to a TOML or JSON (*.json) file with measurements reported instead of running the collectors, for demos and testing the Hub integration\nThe file contains either the measurements, e.g. \"cpu.util.idle.1.total\" = 95.1, or a list of them under the 'cycles' key reported in turns. default \"\""`
+
+	IncludeErrors bool `toml:"include_errors" comment:"Report the errors of the collectors in the current collection as errors.<collector>, e.g. errors.smartmon\nThe credentials in URLs and the directories of absolute paths are removed from the messages. default false"`
+
+	FatalErrors []string `toml:"fatal_errors" comment:"Categories of startup errors which make cagent exit instead of logging them, possible values:\n\"smartctl\": smart_monitoring is enabled but smartctl is missing or not supported\n\"hub_unreachable\": the Hub connection test fails (io_mode=\"http\" only)\n\"windows_perf_counters\": some of windows_perf_counters can't be added\ndefault []"`
+
+	MetricPrefix string `toml:"metric_prefix" comment:"Prepended to the keys of all reported measurements, e.g. \"datacenter1.web01.\" turns cpu.util.idle.1.total into datacenter1.web01.cpu.util.idle.1.total\nAllowed characters: letters, digits, '.', '_' and '-'. default \"\""`
+
+	SuppressZeroRates bool `toml:"suppress_zero_rates" comment:"Omit the rate metrics (*_per_s) which are exactly 0 in the current cycle, e.g. of idle interfaces and disks\nNon-zero rates, gauges and null values are reported as usual. default false"`
+
+	MetricPrecision int `toml:"metric_precision" comment:"Number of decimal places floating point metrics are rounded to. 0 means to report integers. Max: 10. default 2"`
+
+	IncludeMetadata bool `toml:"include_metadata" comment:"Send the unit and kind (gauge or rate) of the metrics in a separate 'meta' section once per run. default false"`
+
+	MaintenanceUntil string `toml:"maintenance_until" comment:"RFC3339 timestamp until which the maintenance mode is active, e.g. \"2026-01-31T22:00:00+01:00\"\nDuring the maintenance the alerting keys (fs fill states, SMART status, stalled fans, time sync, HTTP and DNS checks) are reported as ok\nand module alerts are suppressed, metric values are reported as usual\nCAGENT_MAINTENANCE_UNTIL environment variable takes precedence over this setting"`
+	MaintenanceFile  string `toml:"maintenance_file" comment:"Path to a file which activates the maintenance mode while it exists, e.g. \"/etc/cagent/maintenance\""`
+
 	HubGzip           bool   `toml:"hub_gzip" comment:"enable gzip when sending results to the HUB"`
 	HubRequestTimeout int    `toml:"hub_request_timeout" comment:"time limit in seconds for requests made to Hub.\nThe timeout includes connection time, any redirects, and reading the response body.\nMin: 1, Max: 600. default: 30"`
 	HubProxy          string `toml:"hub_proxy" commented:"true"`
 	HubProxyUser      string `toml:"hub_proxy_user" commented:"true"`
 	HubProxyPassword  string `toml:"hub_proxy_password" commented:"true"`
 
+	HubUserAgent string `toml:"hub_user_agent" comment:"User-Agent header sent with requests to the Hub. Leave empty to use the default 'cagent/<version> (<os>/<arch>)'"`
+
+	HubCredentialsFile string `toml:"hub_credentials_file" comment:"Path to a TOML or JSON (*.json) file containing hub_user, hub_password and/or hub_token\nValues from this file take precedence over the ones set here, hub_token is sent as the Bearer authorization\nThe file is read again once it changes, e.g. rewritten by Vault agent. Keep it readable by the cagent user only"`
+
+	HubSendChangedOnly     bool    `toml:"hub_send_changed_only" comment:"After the first successful send, only the measurements which changed since the last successful send are sent to the Hub.\nMeasurements which disappeared are sent as null. Results written in io_mode=\"file\" are not affected. default false"`
+	HubSkipUnchanged       bool    `toml:"hub_skip_unchanged" comment:"Don't send the measurements to the Hub if they are identical to the last successfully sent ones, send the heartbeat instead.\nResults written in io_mode=\"file\" are not affected. default false"`
+	HubFullRefreshInterval float64 `toml:"hub_full_refresh_interval" comment:"With hub_send_changed_only or hub_skip_unchanged, send all measurements every N seconds. 0 disables the periodic full send. default 3600"`
+
+	HubBufferOn429 string `toml:"hub_buffer_on_429" comment:"What to do with the results the Hub rejected with HTTP 429. No requests are sent to the Hub until Retry-After elapses. Possible values:\n\"drop\": discard them. Default.\n\"drop_oldest\": keep up to hub_buffer_size results and send them once the Hub accepts results again, the oldest are dropped if the buffer is full"`
+	HubBufferSize  int    `toml:"hub_buffer_size" comment:"Max number of results kept with hub_buffer_on_429 = \"drop_oldest\". Max: 1000. default 10"`
+
 	CPULoadDataGather []string `toml:"cpu_load_data_gathering_mode" comment:"default ['avg1']"`
+	CPULoadPerCore    []string `toml:"cpu_load_per_core" comment:"Load averages which are reported divided by the number of logical CPUs as well, as load.avg.<N>.per_core\nMust be a subset of cpu_load_data_gathering_mode, e.g. ['avg15']. default []"`
 	CPUUtilDataGather []string `toml:"cpu_utilisation_gathering_mode" comment:"default ['avg1']"`
-	CPUUtilTypes      []string `toml:"cpu_utilisation_types" comment:"default ['user','system','idle','iowait']"`
+	CPUUtilTypes      []string `toml:"cpu_utilisation_types" comment:"default ['user','system','idle','iowait']. Use ['all'] to report all types supported on the OS"`
+
+	CPUUtilAverageType string  `toml:"cpu_util_average_type" comment:"How CPU utilisation is averaged over the gathering mode period, possible values:\n\"arithmetic\": arithmetic mean of all measurements in the period. Default.\n\"ema\": exponential moving average, reacts faster and doesn't need to keep all measurements"`
+	CPUUtilEMAAlpha    float64 `toml:"cpu_util_ema_alpha" comment:"Smoothing factor between 0 and 1 used for cpu_util_average_type = \"ema\"\nIf 0 it's derived from the gathering mode period. default 0.0"`
+	CPUWarmupSamples   int     `toml:"cpu_warmup_samples" comment:"Minimal number of CPU utilisation samples (taken every 10 seconds) in the gathering mode period to report it.\nUtilisation is reported as null until then. default 2"`
 
 	FSTypeInclude                 []string `toml:"fs_type_include" comment:"default ['ext3','ext4','xfs','jfs','ntfs','btrfs','hfs','apfs','fat32','smbfs','nfs']"`
 	FSPathExclude                 []string `toml:"fs_path_exclude" comment:"Exclude file systems by name, disabled by default"`
+	FSReadOnlyExpected            []string `toml:"fs_read_only_expected" comment:"Mountpoints which are read-only intentionally, glob patterns are supported, e.g. ['/mnt/iso', '/snap/*/*']\nfs.read_only.<mount> reports if the file system is currently mounted read-only. If a file system which doesn't match\nis read-only, e.g. the kernel remounted it after I/O errors, fs.read_only_unexpected.<mount> is true and agent.health is degraded. default []"`
 	FSPathExcludeRecurse          bool     `toml:"fs_path_exclude_recurse" comment:"Having fs_path_exclude_recurse = false the specified path must match a mountpoint or it will be ignored\nHaving fs_path_exclude_recurse = true the specified path can be any folder and all mountpoints underneath will be excluded"`
-	FSMetrics                     []string `toml:"fs_metrics" comment:"default ['free_B', 'free_percent', 'total_B', 'read_B_per_s', 'write_B_per_s', 'read_ops_per_s', 'write_ops_per_s', 'inodes_used_percent']"`
+	FSMetrics                     []string `toml:"fs_metrics" comment:"On Windows the idle time and the queue length of the physical disks are reported as well\nas fs.disk_idle_percent.PhysicalDrive<N> and fs.disk_queue_length.PhysicalDrive<N>, the disks of the volumes as fs.physical_disk.<volume>\ndefault ['free_B', 'free_percent', 'total_B', 'read_B_per_s', 'write_B_per_s', 'read_ops_per_s', 'write_ops_per_s', 'inodes_used_percent']"`
 	FSIdentifyMountpointsByDevice bool     `toml:"fs_identify_mountpoints_by_device" comment:"To avoid monitoring of so-called mount binds mount points are identified by the path and device name.\nMountpoints pointing to the same device are ignored. What appears first in /proc/self/mountinfo is considered as the original.\nApplies only to Linux"`
+	FSFillWarningPercent          float64  `toml:"fs_fill_warning_percent" comment:"Used space in percent at which fill_state of a mountpoint is reported as \"warning\". default 90.0"`
+	FSFillCriticalPercent         float64  `toml:"fs_fill_critical_percent" comment:"Used space in percent at which fill_state of a mountpoint is reported as \"critical\". default 95.0"`
+
+	FSFillThresholds    map[string]fs.FillThresholds `toml:"fs_fill_thresholds" comment:"Override fill thresholds for specific mountpoints. Example:\n[fs_fill_thresholds.\"/var\"]\n  warning_percent = 80.0\n  critical_percent = 90.0"`
+	FSAlwaysIncludeRoot bool                         `toml:"fs_always_include_root" comment:"Measure the root filesystem '/' even if it matches fs_path_exclude. default false"`
+
+	FSStatTimeout float64 `toml:"fs_stat_timeout" comment:"Timeout in seconds to get the usage of network filesystems (nfs, cifs, fuse etc.)\nIf it is exceeded, the mountpoint is reported as not reachable. default 5.0"`
 
 	NetInterfaceExclude             []string `toml:"net_interface_exclude" commented:"true"`
 	NetInterfaceExcludeRegex        []string `toml:"net_interface_exclude_regex" comment:"default [\"^vnet(.*)$\", \"^virbr(.*)$\", \"^vmnet(.*)$\", \"^vEthernet(.*)$\"]. On Windows, also \"Pseudo-Interface\" is added to list"`
 	NetInterfaceExcludeDisconnected bool     `toml:"net_interface_exclude_disconnected" comment:"default true"`
 	NetInterfaceExcludeLoopback     bool     `toml:"net_interface_exclude_loopback" comment:"default true"`
 
-	NetMetrics           []string `toml:"net_metrics" comment:"default ['in_B_per_s','out_B_per_s','total_out_B_per_s','total_in_B_per_s']"`
+	NetMetrics           []string `toml:"net_metrics" comment:"default ['in_B_per_s','out_B_per_s','total_out_B_per_s','total_in_B_per_s','link_up','link_speed_B_per_s','mtu','duplex']\nlink_speed_B_per_s is the negotiated speed of the link reported by the OS\nduplex is 'full', 'half' or 'unknown' if not reported by the OS. It is available on Linux only\nadd 'addresses' to report the IPv4 and IPv6 addresses assigned to the interfaces\nadd 'listening' to report the listening TCP and UDP ports as listening.<proto>.<port> with the names of the owning processes"`
 	NetInterfaceMaxSpeed string   `toml:"net_interface_max_speed" comment:"If the value is not specified, cagent will try to query the maximum speed of the network cards to calculate the bandwidth usage (default)\nDepending on the network card type this is not always reliable.\nSome virtual network cards, for example, report a maximum speed lower than the real speed.\nYou can set a fixed value by using <number of Bytes per second> + <K, M or G as a quantifier>.\nExamples: \"125M\" (equals 1 GigaBit), \"12.5M\" (equals 100 MegaBits), \"12.5G\" (equals 100 GigaBit)"`
 
-	SystemFields []string `toml:"system_fields" comment:"default ['uname','os_kernel','os_family','os_arch','cpu_model','fqdn','memory_total_B']"`
+	SystemFields []string `toml:"system_fields" comment:"default ['uname','os_kernel','os_family','os_arch','cpu_model','fqdn','memory_total_B']\nAdd 'users' to report the number of logged in users and their sessions\nAdd 'os_distro' and 'os_distro_version' to report the distribution name and version (from /etc/os-release on Linux)\nAdd 'kernel_reboot_required' to report if a kernel newer than the running one is installed (dpkg or rpm based Linux only)\nAdd 'entropy' to report the entropy available in the kernel random pool as entropy_avail and entropy_low (Linux only)"`
+
+	EntropyLowThreshold int `toml:"entropy_low_threshold" comment:"Available entropy in bits below which system.entropy_low is reported as true. default 256"`
+
+	WindowsPerfCounters []string `toml:"windows_perf_counters" comment:"Windows performance counters reported as perfcounter.<path>, the path is lowercased and special characters are replaced with '_'\nWildcard instances are reported for every instance, English counter names are used on Windows Vista and newer. Example:\nwindows_perf_counters = ['\\Processor(_Total)\\% Processor Time', '\\LogicalDisk(*)\\Avg. Disk Queue Length']\nApplies only to Windows. default []"`
 
 	VirtualMachinesStat []string `toml:"virtual_machines_stat" comment:"default ['hyper-v'], available options 'hyper-v'"`
 
-	HardwareInventory bool `toml:"hardware_inventory" comment:"default true"`
+	HardwareInventory bool `toml:"hardware_inventory" comment:"Turn on/off the hardware inventory (hw.inventory) collected on the first run. default true"`
+
+	HardwareInventoryTypes []string `toml:"hardware_inventory_types" comment:"Types of hardware inventory to collect, possible values: 'pci','usb','displays','cpu','memory'\n'memory' includes the baseboard info, 'cpu' includes the microcode version and the vulnerabilities mitigation status on Linux. Empty list means all types. default []"`
+
+	DmidecodeSections []string `toml:"dmidecode_sections" comment:"Parts of the dmidecode output reported in the 'memory' hardware inventory, possible values: 'baseboard','memory','bios','chassis','processor'\nEmpty list means all sections. Applies to Linux only. default ['baseboard','memory','bios','chassis','processor']"`
+
+	HardwareCommandRetries int `toml:"hardware_command_retries" comment:"Number of retries if dmidecode or smartctl fail transiently, e.g. the device is busy\nCommands which are not installed or not permitted are not retried. Max: 5. default 1"`
 
 	DiscoverAutostartingServicesOnly bool `toml:"discover_autostarting_services_only" comment:"default true"`
 
+	ServicesTrackRestarts []string `toml:"services_track_restarts" comment:"Services which restarts are detected by the change of their main PID between the collections\nReported as service.<name>.restarts, the number of restarts since cagent started. Systemd and Windows only\nExample: services_track_restarts = ['nginx.service', 'php-fpm.service']. default []"`
+	ServicesOpenFDs       bool     `toml:"services_open_fds" comment:"Check the main processes of the running discovered services for leaking file descriptors, see discover_autostarting_services_only\nReported as service.<name>.open_fds and service.<name>.open_sockets. The values are null if the descriptors can't be read, e.g. due to permissions. Systemd only. default false"`
+	SystemdFailedUnits    bool     `toml:"systemd_failed_units" comment:"Report the number of failed systemd units as systemd.failed_units and their names as systemd.failed.<n>. Systemd only. default true"`
+
 	CPUUtilisationAnalysis CPUUtilisationAnalysisConfig `toml:"cpu_utilisation_analysis"`
 
-	TemperatureMonitoring bool `toml:"temperature_monitoring" comment:"default true"`
+	TemperatureMonitoring bool `toml:"temperature_monitoring" comment:"Report temperature sensors and on Linux the thermal throttling counters of CPU cores\nas cpu.<n>.throttle_count and cpu.<n>.throttled. default true"`
+
+	FanMonitoring       bool    `toml:"fan_monitoring" comment:"Report the fan speeds exposed via hwmon as fan.<chip>.<n>.rpm and fan.<chip>.<n>.stalled\nwhich is true if the fan stands still while a temperature of the same chip is >= fan_stall_temperature. Linux only. default false"`
+	FanStallTemperature float64 `toml:"fan_stall_temperature" comment:"Temperature in °C from which a fan at 0 RPM is reported as stalled. default 60"`
 
 	SoftwareRAIDMonitoring bool `toml:"software_raid_monitoring" comment:"Software raid monitoring\nAuto-detect software raids by reading /proc/mdstat and monitor them\ndefault true"`
 
+	LVMMonitoring bool `toml:"lvm_monitoring" comment:"Monitor free space of LVM volume groups and usage of thin pools\nRequires vgs and lvs binaries. Unless cagent runs as root a sudo rule is required. Example:\ncagent ALL=(root) NOPASSWD: /sbin/vgs, /sbin/lvs\nApplies only to Linux. default false"`
+
+	DiskMonitoring bool `toml:"disk_monitoring" comment:"Report the rotational and discard (TRIM) support, busy time and queue length of the physical disks as disk.<dev>.*\nRead from /sys/block, virtual devices like loop, dm-* and md* are skipped. Applies only to Linux. default true"`
+
+	NUMAMonitoring bool `toml:"numa_monitoring" comment:"Report free and used memory and the numa_miss/numa_foreign counters of every NUMA node as numa.node<n>.*\nRead from /sys/devices/system/node, hosts with a single NUMA node are skipped. Applies only to Linux. default false"`
+
+	NTPServers         []string `toml:"ntp_servers" comment:"NTP servers queried using SNTP to measure the offset of the local clock if neither chronyc nor timedatectl report it\nThe servers are queried every interval, so prefer your own servers over public pools, e.g. ['ntp1.example.com']. default []"`
+	NTPSyncThresholdMs float64  `toml:"ntp_sync_threshold_ms" comment:"Max offset of the local clock in milliseconds at which it's reported as synced. default 100.0"`
+
 	SMARTMonitoring bool            `toml:"smart_monitoring" comment:"Enable S.M.A.R.T monitoring of hard disks\ndefault false"`
+	SMARTHealthOnly bool            `toml:"smart_health_only" comment:"Retrieve only the overall health self-assessment of disks (smartctl -H) instead of all attributes\ndefault false"`
+	SMARTInterval   float64         `toml:"smart_interval" comment:"Refresh S.M.A.R.T data every N seconds, the cached values are reported in between. Raised to interval if lower\ndefault 600"`
 	SMARTCtl        string          `toml:"smartctl" comment:"Path to a smartctl binary (smartctl.exe on windows, path must be escaped) version >= 7\nSee https://docs.cloudradar.io/configuring-hosts/installing-agents/troubleshoot-s.m.a.r.t-monitoring\nsmartctl = \"C:\\\\Program Files\\\\smartmontools\\\\bin\\\\smartctl.exe\"\nsmartctl = \"/usr/local/bin/smartctl\""`
 	Logs            LogsFilesConfig `toml:"logs,omitempty"`
 
@@ -131,6 +275,20 @@ type Config struct {
 
 	DockerMonitoring DockerMonitoringConfig `toml:"docker_monitoring" comment:"Cagent monitors all running docker containers and reports them for further processing to the Hub.\nYou can change the following settings."`
 
+	RemoteTargets []remote.Target `toml:"remote_targets,omitempty" comment:"Hosts which can't run cagent but allow SSH access. Their load average, file systems usage (df)\nand software RAID health (mdstat) are collected over SSH and reported under remote.<name>. Example:\n[[remote_targets]]\n  name = 'nas'\n  host = '192.168.1.10:22'\n  user = 'monitor'\n  key_file = '/etc/cagent/id_ed25519'\n  known_hosts_file = '/etc/cagent/known_hosts'"`
+
+	SNMPTargets []snmp.Target `toml:"snmp_targets,omitempty" comment:"Devices like switches or PDUs which can't run cagent but speak SNMP. The listed OIDs are polled every collection\nand reported as snmp.<name>.<metric>. The metrics of a device which didn't respond within its timeout are reported as null. Example:\n[[snmp_targets]]\n  name = 'pdu1'\n  host = '192.168.1.20'\n  community = 'public'\n  oids = { uptime = '1.3.6.1.2.1.1.3.0', load = '1.3.6.1.4.1.318.1.1.12.2.3.1.1.2.1' }"`
+
+	JMXTargets []jmx.Target `toml:"jmx_targets,omitempty" comment:"JVMs which heap, garbage collection and threads MBeans are read every collection through the Jolokia agent\nand reported as jmx.<name>.<metric>. The metrics of a JVM which didn't respond within its timeout are reported as null. Example:\n[[jmx_targets]]\n  name = 'tomcat'\n  url = 'http://127.0.0.1:8778/jolokia'"`
+
+	HTTPChecks []httpcheck.Check `toml:"http_checks,omitempty" comment:"Local service endpoints probed with a GET request every collection and reported as httpcheck.<name>.up, .status_code,\n.response_time_ms and .state (ok, unexpected_status, redirect, tls_error, timeout or connection_error). Redirects are not followed. Example:\n[[http_checks]]\n  name = 'api'\n  url = 'http://127.0.0.1:8080/health'\n  expect_status = 200\n  timeout = 5.0"`
+
+	ContainersMonitoring ContainersMonitoringConfig `toml:"containers_monitoring" comment:"Report the local container runtime (docker, podman, containerd) and the number of running and total containers.\nCounts are reported for docker and podman only, it requires read access to the runtime socket."`
+
+	CgroupMonitoring CgroupMonitoringConfig `toml:"cgroup_monitoring" comment:"Report CPU and memory usage of the top-level systemd slices (system.slice, user.slice etc.) using cgroup v2. Linux only"`
+
+	DNSCheck DNSCheckConfig `toml:"dns_check" comment:"Resolve the listed hostnames using the system resolver and report dns.<host>.resolved, dns.<host>.latency_ms\nand dns.<host>.status (ok, nxdomain, servfail, timeout or error)"`
+
 	MemMonitoring bool `toml:"mem_monitoring" comment:"\nTurn on or off parts of the monitoring.\nPresets of the operation_mode have precedence.\nWhat's disabled by the operation_mode can't be turned on here.\nBut it can still be turned off.\n\nTurn on/off the monitoring of memory"`
 
 	CPUMonitoring bool `toml:"cpu_monitoring" comment:"Turn on/off any CPU related monitoring including the cpu_utilisation_analysis"`
@@ -139,6 +297,8 @@ type Config struct {
 
 	NetMonitoring bool `toml:"net_monitoring" comment:"Turn on/off any network-related monitoring"`
 
+	SelfMonitoring bool `toml:"self_monitoring" comment:"Turn on/off the monitoring of CPU and memory used by cagent itself"`
+
 	OnHTTP5xxRetries       int     `toml:"on_http_5xx_retries" comment:"Number of retries if server replies with a 5xx code"`
 	OnHTTP5xxRetryInterval float64 `toml:"on_http_5xx_retry_interval" comment:"Interval in seconds between retries to contact server in case of a 5xx code"`
 }
@@ -153,6 +313,7 @@ type CPUUtilisationAnalysisConfig struct {
 	Metric                         string  `toml:"metric" commend:"possible values: 'user','system','idle','iowait'" json:"metric"`
 	GatheringMode                  string  `toml:"gathering_mode" comment:"should be one of values of cpu_utilisation_gathering_mode" json:"gathering_mode"`
 	ReportProcesses                int     `toml:"report_processes" comment:"number of processes to return" json:"report_processes"`
+	TriggerSamples                 int     `toml:"trigger_samples" comment:"number of consecutive measurements (taken every 10 seconds) meeting the threshold required to start the analysis" json:"trigger_samples"`
 	TrailingProcessAnalysisMinutes int     `toml:"trailing_process_analysis_minutes" comment:"how much time analysis will continue to perform after the CPU utilisation returns to the normal value" json:"trailing_process_analysis_minutes"`
 }
 
@@ -170,6 +331,23 @@ type DockerMonitoringConfig struct {
 	Enabled bool `toml:"enabled" comment:"Set 'false' to disable docker monitoring'"`
 }
 
+type CgroupMonitoringConfig struct {
+	Enabled bool     `toml:"enabled" comment:"Set 'true' to enable reporting cgroups usage. default false"`
+	Path    string   `toml:"path" comment:"Mountpoint of the cgroup v2 hierarchy. Leave empty to use '/sys/fs/cgroup'"`
+	Cgroups []string `toml:"cgroups" comment:"Cgroups to report in addition to the top-level slices, relative to the path\nExample: cgroups = ['system.slice/nginx.service']"`
+}
+
+type DNSCheckConfig struct {
+	Hosts    []string `toml:"hosts" comment:"Hostnames to resolve. Empty list disables the check. default []\nExample: hosts = ['example.com', 'db.internal']"`
+	Timeout  float64  `toml:"timeout" comment:"Timeout in seconds of a single lookup, it's reported as timeout if exceeded. default 2.0"`
+	Interval float64  `toml:"interval" comment:"Resolve the hostnames every N seconds, the cached results are reported in between. Must be >= interval\n0 resolves them on every collection. default 0"`
+}
+
+type ContainersMonitoringConfig struct {
+	Enabled bool   `toml:"enabled" comment:"Set 'false' to disable reporting the container runtime"`
+	Socket  string `toml:"socket" comment:"Path to the container runtime socket. Leave empty to detect it automatically\nExample: socket = '/run/podman/podman.sock'"`
+}
+
 func (l *UpdatesMonitoringConfig) Validate() error {
 	if l.FetchTimeout >= l.CheckInterval {
 		return errors.New("fetch_timeout should be less than check_interval")
@@ -246,27 +424,49 @@ func init() {
 func NewConfig() *Config {
 	cfg := &Config{
 		LogFile:                          defaultLogPath,
+		LogMaxSizeMB:                     10,
+		LogMaxBackups:                    5,
+		LogMaxAgeDays:                    30,
 		OperationMode:                    OperationModeFull,
 		Interval:                         90,
 		Sleep:                            0,
+		CollectorConcurrency:             runtime.NumCPU(),
+		OutTimestampFormat:               TimestampFormatRFC3339,
+		OutJSONNesting:                   JSONNestingFlat,
+		MetricPrecision:                  2,
 		HeartbeatInterval:                15,
 		HubGzip:                          true,
 		HubRequestTimeout:                30,
+		HubFullRefreshInterval:           3600,
+		HubBufferOn429:                   HubBufferOn429Drop,
+		FatalErrors:                      []string{},
+		HubBufferSize:                    10,
 		CPULoadDataGather:                []string{"avg1"},
 		CPUUtilTypes:                     []string{"user", "system", "idle", "iowait"},
 		CPUUtilDataGather:                []string{"avg1"},
+		CPUUtilAverageType:               CPUUtilAverageTypeArithmetic,
+		CPUWarmupSamples:                 2,
 		FSTypeInclude:                    []string{"ext3", "ext4", "xfs", "jfs", "ntfs", "btrfs", "hfs", "apfs", "fat32", "smbfs", "nfs"},
 		FSPathExclude:                    []string{},
 		FSPathExcludeRecurse:             false,
 		FSMetrics:                        []string{"free_B", "free_percent", "total_B", "read_B_per_s", "write_B_per_s", "read_ops_per_s", "write_ops_per_s"},
 		FSIdentifyMountpointsByDevice:    true,
-		NetMetrics:                       []string{"in_B_per_s", "out_B_per_s", "total_out_B_per_s", "total_in_B_per_s"},
+		FSFillWarningPercent:             90,
+		FSFillCriticalPercent:            95,
+		FSFillThresholds:                 map[string]fs.FillThresholds{},
+		FSAlwaysIncludeRoot:              false,
+		FSStatTimeout:                    5,
+		NetMetrics:                       []string{"in_B_per_s", "out_B_per_s", "total_out_B_per_s", "total_in_B_per_s", "link_up", "link_speed_B_per_s", "mtu", "duplex"},
 		NetInterfaceExcludeDisconnected:  true,
 		NetInterfaceExclude:              []string{},
 		NetInterfaceExcludeRegex:         []string{"^vnet(.*)$", "^virbr(.*)$", "^vmnet(.*)$", "^vEthernet(.*)$"},
 		NetInterfaceExcludeLoopback:      true,
 		SystemFields:                     []string{"uname", "os_kernel", "os_family", "os_arch", "cpu_model", "fqdn", "memory_total_B"},
+		EntropyLowThreshold:              256,
 		HardwareInventory:                true,
+		HardwareInventoryTypes:           []string{},
+		DmidecodeSections:                append([]string{}, hwinfo.DmidecodeSections...),
+		HardwareCommandRetries:           1,
 		DiscoverAutostartingServicesOnly: true,
 		CPUUtilisationAnalysis: CPUUtilisationAnalysisConfig{
 			Threshold:                      10,
@@ -274,11 +474,18 @@ func NewConfig() *Config {
 			Metric:                         "idle",
 			GatheringMode:                  "avg1",
 			ReportProcesses:                5,
+			TriggerSamples:                 3,
 			TrailingProcessAnalysisMinutes: 5,
 		},
 		SMARTMonitoring:        false,
+		SMARTInterval:          600,
 		TemperatureMonitoring:  true,
+		FanStallTemperature:    60,
 		SoftwareRAIDMonitoring: true,
+		DiskMonitoring:         true,
+		SystemdFailedUnits:     true,
+		NTPServers:             []string{},
+		NTPSyncThresholdMs:     100,
 		Logs: LogsFilesConfig{
 			HubFile: "",
 		},
@@ -300,11 +507,14 @@ func NewConfig() *Config {
 			Enabled:       false,
 			CheckInterval: 21600,
 		},
-		DockerMonitoring: DockerMonitoringConfig{Enabled: true},
-		MemMonitoring:    true,
-		CPUMonitoring:    true,
-		FSMonitoring:     true,
-		NetMonitoring:    true,
+		DockerMonitoring:     DockerMonitoringConfig{Enabled: true},
+		ContainersMonitoring: ContainersMonitoringConfig{Enabled: true},
+		DNSCheck:             DNSCheckConfig{Timeout: 2},
+		MemMonitoring:        true,
+		CPUMonitoring:        true,
+		FSMonitoring:         true,
+		NetMonitoring:        true,
+		SelfMonitoring:       true,
 
 		OnHTTP5xxRetries:       4,
 		OnHTTP5xxRetryInterval: 2.0,
@@ -394,19 +604,25 @@ func TryUpdateConfigFromFile(cfg *Config, configFilePath string) error {
 	if err != nil {
 		return err
 	}
+	defer cfgFile.Close()
 
-	_, err = toml.DecodeReader(cfgFile, cfg)
+	return TryUpdateConfigFromReader(cfg, cfgFile)
+}
+
+// TryUpdateConfigFromReader applies values from TOML config read from r to cfg
+func TryUpdateConfigFromReader(cfg *Config, r io.Reader) error {
+	content, err := ioutil.ReadAll(r)
 	if err != nil {
 		return err
 	}
 
-	_, err = cfgFile.Seek(0, 0)
+	_, err = toml.Decode(string(content), cfg)
 	if err != nil {
 		return err
 	}
 
 	var deprecatedCfg ConfigDeprecated
-	meta, err := toml.DecodeReader(cfgFile, &deprecatedCfg)
+	meta, err := toml.Decode(string(content), &deprecatedCfg)
 	if err != nil {
 		return err
 	}
@@ -416,7 +632,45 @@ func TryUpdateConfigFromFile(cfg *Config, configFilePath string) error {
 	return nil
 }
 
+// TryUpdateConfigFromURL applies values from TOML config fetched from http(s) configURL to cfg.
+// The system proxy or hub_proxy already set in cfg is used
+func TryUpdateConfigFromURL(cfg *Config, configURL string) error {
+	client := newHubClient(cfg, userAgent(cfg))
+	client.Timeout = configURLFetchTimeout
+
+	req, err := http.NewRequest(http.MethodGet, configURL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Add("User-Agent", userAgent(cfg))
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("failed to fetch config from %s: HTTP %d", configURL, resp.StatusCode)
+	}
+
+	return TryUpdateConfigFromReader(cfg, resp.Body)
+}
+
+func isConfigURL(configLocation string) bool {
+	return strings.HasPrefix(configLocation, "http://") || strings.HasPrefix(configLocation, "https://")
+}
+
+// IsConfigFileLocation reports whether configLocation is a path of a local file, i.e. neither ConfigLocationStdin nor http(s) URL
+func IsConfigFileLocation(configLocation string) bool {
+	return configLocation != ConfigLocationStdin && !isConfigURL(configLocation)
+}
+
 func SaveConfigFile(cfg interface{}, configFilePath string) error {
+	if !IsConfigFileLocation(configFilePath) {
+		return fmt.Errorf("config can't be saved to '%s', a path of the config file is required", configFilePath)
+	}
+
 	var f *os.File
 	var err error
 	if f, err = os.OpenFile(configFilePath, os.O_WRONLY|os.O_CREATE, 0666); err != nil {
@@ -442,6 +696,26 @@ func SaveConfigFile(cfg interface{}, configFilePath string) error {
 }
 
 func GenerateDefaultConfigFile(mvc *MinValuableConfig, configFilePath string) error {
+	buff := &bytes.Buffer{}
+	if err := toml.NewEncoder(buff).Encode(mvc); err != nil {
+		return fmt.Errorf("failed to encode сonfig to file")
+	}
+
+	return writeNewConfigFile(configFilePath, buff.Bytes())
+}
+
+// GenerateFullConfigFile writes all settings of cfg with their descriptions, so the config file is self-documenting.
+// Empty fields marked as commented, e.g. hub_password, are written commented out
+func GenerateFullConfigFile(cfg *Config, configFilePath string) error {
+	buff := &bytes.Buffer{}
+	if err := toml.NewEncoder(buff).Encode(cfg); err != nil {
+		return fmt.Errorf("failed to encode сonfig to file")
+	}
+
+	return writeNewConfigFile(configFilePath, commentOutEmptyFields(cfg, buff.Bytes()))
+}
+
+func writeNewConfigFile(configFilePath string, content []byte) error {
 	var err error
 
 	if _, err = os.Stat(configFilePath); os.IsExist(err) {
@@ -471,12 +745,68 @@ func GenerateDefaultConfigFile(mvc *MinValuableConfig, configFilePath string) er
 		return fmt.Errorf("failed to write headline to сonfig file")
 	}
 
-	err = toml.NewEncoder(f).Encode(mvc)
-	if err != nil {
-		return fmt.Errorf("failed to encode сonfig to file")
+	if _, err = f.Write(content); err != nil {
+		return fmt.Errorf("failed to write сonfig file: %s", err.Error())
 	}
 
-	return err
+	return nil
+}
+
+// commentOutEmptyFields comments out the lines of top-level fields tagged with commented:"true" which have empty value
+func commentOutEmptyFields(cfg *Config, encoded []byte) []byte {
+	keys := emptyCommentedFieldKeys(reflect.ValueOf(cfg).Elem())
+	if len(keys) == 0 {
+		return encoded
+	}
+
+	lines := strings.Split(string(encoded), "\n")
+	for i, line := range lines {
+		for _, key := range keys {
+			if strings.HasPrefix(line, key+" = ") {
+				lines[i] = "# " + line
+				break
+			}
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n"))
+}
+
+func emptyCommentedFieldKeys(v reflect.Value) []string {
+	var keys []string
+
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		value := v.Field(i)
+
+		if field.Anonymous && value.Kind() == reflect.Struct {
+			keys = append(keys, emptyCommentedFieldKeys(value)...)
+			continue
+		}
+
+		if commented, _ := strconv.ParseBool(field.Tag.Get("commented")); !commented {
+			continue
+		}
+
+		isEmpty := value.IsZero()
+		if value.Kind() == reflect.Slice || value.Kind() == reflect.Map {
+			isEmpty = value.Len() == 0
+		}
+
+		if key := strings.Split(field.Tag.Get("toml"), ",")[0]; isEmpty && key != "" {
+			keys = append(keys, key)
+		}
+	}
+
+	return keys
+}
+
+// hasEnabledCollectors checks if at least one of the collectors which can be turned off in the config is enabled
+func (cfg *Config) hasEnabledCollectors() bool {
+	return cfg.CPUMonitoring || cfg.MemMonitoring || cfg.FSMonitoring || cfg.NetMonitoring ||
+		cfg.SMARTMonitoring || cfg.SoftwareRAIDMonitoring || cfg.HardwareInventory || cfg.TemperatureMonitoring ||
+		cfg.FanMonitoring
 }
 
 func (cfg *Config) GetParsedNetInterfaceMaxSpeed() (uint64, error) {
@@ -509,56 +839,394 @@ func (cfg *Config) GetParsedNetInterfaceMaxSpeed() (uint64, error) {
 	return 0, fmt.Errorf("unsupported unit: %c", unit)
 }
 
+func (cfg *Config) getOutLocation() (*time.Location, error) {
+	if cfg.OutTimezone == "" {
+		return time.Local, nil
+	}
+
+	return time.LoadLocation(cfg.OutTimezone)
+}
+
+// maintenanceUntil returns the end of the maintenance window, zero time if it is not set
+func (cfg *Config) maintenanceUntil() (time.Time, error) {
+	value := cfg.MaintenanceUntil
+	if val, ok := os.LookupEnv("CAGENT_MAINTENANCE_UNTIL"); ok {
+		value = val
+	}
+
+	if value == "" {
+		return time.Time{}, nil
+	}
+
+	return time.Parse(time.RFC3339, value)
+}
+
+// InMaintenance checks if the maintenance mode is active at the moment now
+func (cfg *Config) InMaintenance(now time.Time) bool {
+	if until, err := cfg.maintenanceUntil(); err == nil && now.Before(until) {
+		return true
+	}
+
+	if cfg.MaintenanceFile != "" {
+		if _, err := os.Stat(cfg.MaintenanceFile); err == nil {
+			return true
+		}
+	}
+
+	return false
+}
+
+// validateOutFile checks that results can be written to out_file: the file is writable
+// or it doesn't exist but can be created together with the missing directories
+func validateOutFile(outFile string) error {
+	if outFile == "" {
+		return fmt.Errorf("out_file must be set in io_mode=\"%s\"", IOModeFile)
+	}
+
+	// stdout and the null device
+	if outFile == "-" || strings.EqualFold(outFile, os.DevNull) {
+		return nil
+	}
+
+	if info, err := os.Stat(outFile); err == nil {
+		if info.IsDir() {
+			return fmt.Errorf("out_file '%s' is a directory", outFile)
+		}
+
+		f, err := os.OpenFile(outFile, os.O_WRONLY|os.O_APPEND, 0)
+		if err != nil {
+			return fmt.Errorf("out_file '%s' is not writable: %s", outFile, err.Error())
+		}
+		return f.Close()
+	}
+
+	// the nearest existing directory must allow to create the missing directories and the file
+	dir := filepath.Dir(outFile)
+	for {
+		info, err := os.Stat(dir)
+		if err == nil {
+			if !info.IsDir() {
+				return fmt.Errorf("out_file '%s' can't be created: '%s' is not a directory", outFile, dir)
+			}
+			break
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return fmt.Errorf("out_file '%s' can't be created: %s", outFile, err.Error())
+		}
+		dir = parent
+	}
+
+	probe, err := ioutil.TempFile(dir, ".cagent-out-file")
+	if err != nil {
+		return fmt.Errorf("out_file '%s' can't be created: directory '%s' is not writable", outFile, dir)
+	}
+	probe.Close()
+
+	return os.Remove(probe.Name())
+}
+
+// FormatOutTimestamp converts t according to out_timestamp_format and out_timezone settings
+func (cfg *Config) FormatOutTimestamp(t time.Time) (interface{}, error) {
+	switch cfg.OutTimestampFormat {
+	case TimestampFormatUnix:
+		return t.Unix(), nil
+	case TimestampFormatUnixMs:
+		return t.UnixNano() / int64(time.Millisecond), nil
+	case TimestampFormatRFC3339:
+		loc, err := cfg.getOutLocation()
+		if err != nil {
+			return nil, err
+		}
+		return t.In(loc).Format(time.RFC3339), nil
+	}
+
+	return nil, fmt.Errorf("unsupported out_timestamp_format: %s", cfg.OutTimestampFormat)
+}
+
 func (cfg *Config) validate() error {
+	if cfg.IOMode == IOModeFile {
+		if cfg.OutFile != "" {
+			// e.g. converts forward slashes to backslashes on Windows
+			cfg.OutFile = filepath.Clean(cfg.OutFile)
+		}
+		if err := validateOutFile(cfg.OutFile); err != nil {
+			return wrapConfigError(ConfigErrorBadOutFile, "out_file", err)
+		}
+	}
+
+	if cfg.IOMode == IOModeSocket && cfg.OutSocket == "" {
+		return newConfigError(ConfigErrorBadOutSocket, "out_socket", "out_socket must be set in io_mode=\"%s\"", IOModeSocket)
+	}
+
 	if cfg.HubProxy != "" {
 		if !strings.HasPrefix(cfg.HubProxy, "http") {
 			cfg.HubProxy = "http://" + cfg.HubProxy
 		}
 
 		if _, err := url.Parse(cfg.HubProxy); err != nil {
-			return fmt.Errorf("failed to parse 'hub_proxy' URL")
+			return newConfigError(ConfigErrorBadHubProxy, "hub_proxy", "failed to parse 'hub_proxy' URL")
 		}
 	}
 
 	if cfg.Interval < minIntervalValue {
-		return fmt.Errorf("interval value must be >= %.1f", minIntervalValue)
+		return newConfigError(ConfigErrorIntervalTooLow, "interval", "interval value must be >= %.1f", minIntervalValue)
+	}
+
+	if cfg.CollectionDeadline < 0 || cfg.CollectionDeadline > 1 {
+		return newConfigError(ConfigErrorCollectionDeadlineOutOfRange, "collection_deadline", "collection_deadline must be between 0 and 1")
+	}
+
+	if cfg.CollectorConcurrency < 1 {
+		return newConfigError(ConfigErrorCollectorConcurrencyTooLow, "collector_concurrency", "collector_concurrency must be >= 1")
+	}
+
+	for prefix, every := range cfg.MetricSampleEvery {
+		if every < 1 {
+			return newConfigError(ConfigErrorMetricSampleEveryTooLow, "metric_sample_every."+prefix, "metric_sample_every value for '%s' must be >= 1", prefix)
+		}
+	}
+
+	if cfg.StartupDelay < 0 || cfg.StartupDelayRandom < 0 {
+		return newConfigError(ConfigErrorStartupDelayNegative, "startup_delay", "startup_delay and startup_delay_random must be >= 0")
+	}
+
+	if cfg.StartupDelay+cfg.StartupDelayRandom >= cfg.Interval {
+		return newConfigError(ConfigErrorStartupDelayTooHigh, "startup_delay", "startup_delay + startup_delay_random must be lower than interval")
 	}
 
 	if cfg.HeartbeatInterval < minHeartbeatIntervalValue {
-		return fmt.Errorf("heartbeat value must be >= %.1f", minHeartbeatIntervalValue)
+		return newConfigError(ConfigErrorHeartbeatTooLow, "heartbeat", "heartbeat value must be >= %.1f", minHeartbeatIntervalValue)
 	}
 
 	if !common.StrInSlice(cfg.OperationMode, operationModes) {
-		return fmt.Errorf("invalid operation_mode supplied. Must be one of %v", operationModes)
+		return newConfigError(ConfigErrorBadOperationMode, "operation_mode", "invalid operation_mode supplied. Must be one of %v", operationModes)
+	}
+
+	if err := cfg.validateIntervalSchedule(); err != nil {
+		return err
 	}
 
 	_, err := cfg.GetParsedNetInterfaceMaxSpeed()
 	if err != nil {
-		return fmt.Errorf("invalid net_interface_max_speed value supplied: %s", err.Error())
+		return newConfigError(ConfigErrorBadNetSpeed, "net_interface_max_speed", "invalid net_interface_max_speed value supplied: %s", err.Error())
+	}
+
+	for _, section := range cfg.DmidecodeSections {
+		if !common.StrInSlice(section, hwinfo.DmidecodeSections) {
+			return newConfigError(ConfigErrorBadDmidecodeSection, "dmidecode_sections", "invalid dmidecode_sections value '%s' supplied. Must be one of %v", section, hwinfo.DmidecodeSections)
+		}
+	}
+
+	for _, t := range cfg.HardwareInventoryTypes {
+		if !common.StrInSlice(t, hwinfo.InventoryTypes) {
+			return newConfigError(ConfigErrorBadHardwareInventoryType, "hardware_inventory_types", "invalid hardware_inventory_types value '%s' supplied. Must be one of %v", t, hwinfo.InventoryTypes)
+		}
+	}
+
+	if cfg.HardwareCommandRetries < 0 || cfg.HardwareCommandRetries > maxHardwareCommandRetries {
+		return newConfigError(ConfigErrorHardwareCommandRetriesRange, "hardware_command_retries", "hardware_command_retries must be between 0 and %d", maxHardwareCommandRetries)
+	}
+
+	// configs written before smart_interval existed don't set it, so it's raised instead of rejecting them
+	if cfg.SMARTMonitoring && cfg.SMARTInterval < cfg.Interval {
+		log.Warnf("smart_interval is less than interval. It was set to %.0f", cfg.Interval)
+		cfg.SMARTInterval = cfg.Interval
+	}
+
+	if _, err = parseCPULoadGatheringModes(cfg.CPULoadDataGather); err != nil {
+		return wrapConfigError(ConfigErrorBadCPULoadGatheringMode, "cpu_load_data_gathering_mode", err)
+	}
+
+	if _, err = parseCPULoadPerCore(cfg.CPULoadPerCore, cfg.CPULoadDataGather); err != nil {
+		return wrapConfigError(ConfigErrorBadCPULoadPerCore, "cpu_load_per_core", err)
+	}
+
+	if cfg.FanStallTemperature <= 0 {
+		return newConfigError(ConfigErrorFanStallTemperatureTooLow, "fan_stall_temperature", "fan_stall_temperature must be > 0")
+	}
+
+	if cfg.EntropyLowThreshold < 0 {
+		return newConfigError(ConfigErrorEntropyLowThresholdNegative, "entropy_low_threshold", "entropy_low_threshold must be >= 0")
+	}
+
+	for _, path := range cfg.WindowsPerfCounters {
+		if err = winperf.ValidateCounterPath(path); err != nil {
+			return newConfigError(ConfigErrorBadWindowsPerfCounter, "windows_perf_counters", "windows_perf_counters: %s", err.Error())
+		}
+	}
+
+	if _, err = parseCPUUtilGatheringModes(cfg.CPUUtilDataGather); err != nil {
+		return wrapConfigError(ConfigErrorBadCPUUtilGatheringMode, "cpu_utilisation_gathering_mode", err)
+	}
+
+	if _, err = expandCPUUtilTypes(cfg.CPUUtilTypes, runtime.GOOS); err != nil {
+		return wrapConfigError(ConfigErrorBadCPUUtilType, "cpu_utilisation_types", err)
+	}
+
+	if !common.StrInSlice(cfg.CPUUtilAverageType, cpuUtilAverageTypes) {
+		return newConfigError(ConfigErrorBadCPUUtilAverageType, "cpu_util_average_type", "invalid cpu_util_average_type supplied. Must be one of %v", cpuUtilAverageTypes)
+	}
+
+	if cfg.CPUUtilEMAAlpha < 0 || cfg.CPUUtilEMAAlpha > 1 {
+		return newConfigError(ConfigErrorCPUUtilEMAAlphaOutOfRange, "cpu_util_ema_alpha", "cpu_util_ema_alpha must be between 0 and 1")
+	}
+
+	if cfg.CPUWarmupSamples < 1 {
+		return newConfigError(ConfigErrorCPUWarmupSamplesTooLow, "cpu_warmup_samples", "cpu_warmup_samples must be >= 1")
+	}
+
+	if !common.StrInSlice(cfg.OutTimestampFormat, timestampFormats) {
+		return newConfigError(ConfigErrorBadOutTimestampFormat, "out_timestamp_format", "invalid out_timestamp_format supplied. Must be one of %v", timestampFormats)
+	}
+
+	if !common.StrInSlice(cfg.OutJSONNesting, jsonNestings) {
+		return newConfigError(ConfigErrorBadOutJSONNesting, "out_json_nesting", "invalid out_json_nesting supplied. Must be one of %v", jsonNestings)
+	}
+
+	if _, err = cfg.getOutLocation(); err != nil {
+		return newConfigError(ConfigErrorBadOutTimezone, "out_timezone", "invalid out_timezone supplied: %s", err.Error())
+	}
+
+	if cfg.CPUUtilisationAnalysis.TriggerSamples < 1 {
+		return newConfigError(ConfigErrorTriggerSamplesTooLow, "cpu_utilisation_analysis.trigger_samples", "cpu_utilisation_analysis.trigger_samples must be >= 1")
+	}
+
+	if cfg.OperationMode != OperationModeHeartbeat && !cfg.hasEnabledCollectors() {
+		log.Warn("all collectors are disabled in the config, only the basic agent status will be reported")
+	}
+
+	if cfg.NTPSyncThresholdMs <= 0 {
+		return newConfigError(ConfigErrorNTPSyncThresholdTooLow, "ntp_sync_threshold_ms", "ntp_sync_threshold_ms must be > 0")
+	}
+
+	if cfg.LogMaxSizeMB < minLogMaxSizeMB || cfg.LogMaxSizeMB > maxLogMaxSizeMB {
+		return newConfigError(ConfigErrorLogMaxSizeOutOfRange, "log_max_size_MB", "log_max_size_MB must be between %d and %d", minLogMaxSizeMB, maxLogMaxSizeMB)
+	}
+
+	if cfg.LogMaxBackups < 0 || cfg.LogMaxBackups > maxLogMaxBackups {
+		return newConfigError(ConfigErrorLogMaxBackupsOutOfRange, "log_max_backups", "log_max_backups must be between 0 and %d", maxLogMaxBackups)
+	}
+
+	if cfg.LogMaxAgeDays < 0 || cfg.LogMaxAgeDays > maxLogMaxAgeDays {
+		return newConfigError(ConfigErrorLogMaxAgeOutOfRange, "log_max_age_days", "log_max_age_days must be between 0 and %d", maxLogMaxAgeDays)
+	}
+
+	for _, category := range cfg.FatalErrors {
+		if !common.StrInSlice(category, FatalErrorCategories) {
+			return newConfigError(ConfigErrorBadFatalErrors, "fatal_errors", "invalid fatal_errors value '%s' supplied. Must be one of %v", category, FatalErrorCategories)
+		}
+	}
+
+	if !metricPrefixRegexp.MatchString(cfg.MetricPrefix) {
+		return newConfigError(ConfigErrorBadMetricPrefix, "metric_prefix", "metric_prefix may contain only letters, digits, '.', '_' and '-'")
+	}
+
+	if cfg.MetricPrecision < 0 || cfg.MetricPrecision > maxMetricPrecision {
+		return newConfigError(ConfigErrorMetricPrecisionOutOfRange, "metric_precision", "metric_precision must be between 0 and %d", maxMetricPrecision)
+	}
+
+	if _, err := cfg.maintenanceUntil(); err != nil {
+		return newConfigError(ConfigErrorBadMaintenanceUntil, "maintenance_until", "maintenance_until must be a RFC3339 timestamp: %s", err.Error())
+	}
+
+	if strings.ContainsAny(cfg.HubUserAgent, "\r\n") {
+		return newConfigError(ConfigErrorBadHubUserAgent, "hub_user_agent", "hub_user_agent must not contain line breaks")
+	}
+
+	if !common.StrInSlice(cfg.HubBufferOn429, hubBufferOn429Policies) {
+		return newConfigError(ConfigErrorBadHubBufferOn429, "hub_buffer_on_429", "invalid hub_buffer_on_429 supplied. Must be one of %v", hubBufferOn429Policies)
+	}
+
+	if cfg.HubBufferSize < 1 || cfg.HubBufferSize > maxHubBufferSize {
+		return newConfigError(ConfigErrorHubBufferSizeOutOfRange, "hub_buffer_size", "hub_buffer_size must be between 1 and %d", maxHubBufferSize)
+	}
+
+	if cfg.HubFullRefreshInterval < 0 {
+		return newConfigError(ConfigErrorHubFullRefreshIntervalNegative, "hub_full_refresh_interval", "hub_full_refresh_interval must be >= 0")
 	}
 
 	if cfg.HubRequestTimeout < minHubRequestTimeout || cfg.HubRequestTimeout > maxHubRequestTimeout {
-		return fmt.Errorf("hub_request_timeout must be between %d and %d", minHubRequestTimeout, maxHubRequestTimeout)
+		return newConfigError(ConfigErrorHubRequestTimeoutOutOfRange, "hub_request_timeout", "hub_request_timeout must be between %d and %d", minHubRequestTimeout, maxHubRequestTimeout)
+	}
+
+	fillThresholds := fs.FillThresholds{WarningPercent: cfg.FSFillWarningPercent, CriticalPercent: cfg.FSFillCriticalPercent}
+	if err = fillThresholds.Validate(); err != nil {
+		return newConfigError(ConfigErrorBadFSFillThresholds, "fs_fill_warning_percent", "invalid fs_fill_warning_percent/fs_fill_critical_percent values supplied: %s", err.Error())
+	}
+
+	if cfg.FSStatTimeout <= 0 {
+		return newConfigError(ConfigErrorFSStatTimeoutTooLow, "fs_stat_timeout", "fs_stat_timeout must be > 0")
+	}
+
+	for i, target := range cfg.RemoteTargets {
+		if err = target.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadRemoteTarget, fmt.Sprintf("remote_targets[%d]", i), "invalid remote_targets[%d] config: %s", i, err.Error())
+		}
+	}
+
+	for i, target := range cfg.SNMPTargets {
+		if err = target.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadSNMPTarget, fmt.Sprintf("snmp_targets[%d]", i), "invalid snmp_targets[%d] config: %s", i, err.Error())
+		}
+	}
+
+	for i, target := range cfg.JMXTargets {
+		if err = target.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadJMXTarget, fmt.Sprintf("jmx_targets[%d]", i), "invalid jmx_targets[%d] config: %s", i, err.Error())
+		}
+	}
+
+	httpCheckNames := make(map[string]bool)
+	for i, check := range cfg.HTTPChecks {
+		if err = check.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadHTTPCheck, fmt.Sprintf("http_checks[%d]", i), "invalid http_checks[%d] config: %s", i, err.Error())
+		}
+		if httpCheckNames[check.Name] {
+			return newConfigError(ConfigErrorBadHTTPCheck, fmt.Sprintf("http_checks[%d]", i), "invalid http_checks[%d] config: duplicate name '%s'", i, check.Name)
+		}
+		httpCheckNames[check.Name] = true
+	}
+
+	for i, host := range cfg.DNSCheck.Hosts {
+		if strings.TrimSpace(host) == "" {
+			return newConfigError(ConfigErrorBadDNSCheck, fmt.Sprintf("dns_check.hosts[%d]", i), "dns_check.hosts[%d] must not be empty", i)
+		}
+	}
+
+	if cfg.DNSCheck.Timeout <= 0 {
+		return newConfigError(ConfigErrorBadDNSCheck, "dns_check.timeout", "dns_check.timeout must be > 0")
+	}
+
+	if cfg.DNSCheck.Interval != 0 && cfg.DNSCheck.Interval < cfg.Interval {
+		return newConfigError(ConfigErrorBadDNSCheck, "dns_check.interval", "dns_check.interval must be 0 or >= interval")
+	}
+
+	for path, thresholds := range cfg.FSFillThresholds {
+		if err = thresholds.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadFSFillThresholds, "fs_fill_thresholds."+path, "invalid [fs_fill_thresholds.\"%s\"] config: %s", path, err.Error())
+		}
 	}
 
 	err = cfg.JobMonitoring.Validate()
 	if err != nil {
-		return fmt.Errorf("invalid [jobmon] config: %s", err.Error())
+		return newConfigError(ConfigErrorBadJobMonitoring, "jobmon", "invalid [jobmon] config: %s", err.Error())
 	}
 
 	err = cfg.SystemUpdatesChecks.Validate()
 	if err != nil {
-		return fmt.Errorf("invalid [system_updates_checks] config: %s", err.Error())
+		return newConfigError(ConfigErrorBadSystemUpdatesChecks, "system_updates_checks", "invalid [system_updates_checks] config: %s", err.Error())
 	}
 
 	err = cfg.MysqlMonitoring.Validate()
 	if err != nil {
-		return fmt.Errorf("invalid [mysql_monitoring] config: %s", err.Error())
+		return newConfigError(ConfigErrorBadMysqlMonitoring, "mysql_monitoring", "invalid [mysql_monitoring] config: %s", err.Error())
 	}
 
 	err = cfg.Updates.Validate()
 	if err != nil {
-		return fmt.Errorf("invalid [updates] config: %s", err.Error())
+		return newConfigError(ConfigErrorBadUpdates, "updates", "invalid [updates] config: %s", err.Error())
 	}
 
 	if cfg.OnHTTP5xxRetries < 0 || cfg.OnHTTP5xxRetries > 5 {
@@ -574,14 +1242,43 @@ func (cfg *Config) validate() error {
 	return nil
 }
 
+// checkHubCredentialsFile makes sure hub_credentials_file exists and can be parsed. Its credentials are read by FileHubCredentialProvider, see Cagent.New
+func (cfg *Config) checkHubCredentialsFile() error {
+	if cfg.HubCredentialsFile == "" {
+		return nil
+	}
+
+	stat, err := os.Stat(cfg.HubCredentialsFile)
+	if err != nil {
+		return err
+	}
+
+	if runtime.GOOS != "windows" && stat.Mode().Perm()&0004 != 0 {
+		log.Warnf("hub_credentials_file '%s' is world-readable. Please restrict its permissions, e.g. chmod 600", cfg.HubCredentialsFile)
+	}
+
+	_, err = readHubCredentialsFile(cfg.HubCredentialsFile)
+	return err
+}
+
 // HandleAllConfigSetup prepares Config for Cagent with parameters specified in file
-// if Config file does not exist default one is created in form of MinValuableConfig
+// if Config file does not exist default one is created in form of MinValuableConfig.
+// ConfigLocationStdin reads the config from stdin, http(s) URL fetches it. Default config is generated only for the local file
 func HandleAllConfigSetup(configFilePath string) (*Config, error) {
 	cfg := NewConfig()
 
-	err := TryUpdateConfigFromFile(cfg, configFilePath)
+	var err error
+	switch {
+	case configFilePath == ConfigLocationStdin:
+		err = TryUpdateConfigFromReader(cfg, os.Stdin)
+	case isConfigURL(configFilePath):
+		err = TryUpdateConfigFromURL(cfg, configFilePath)
+	default:
+		err = TryUpdateConfigFromFile(cfg, configFilePath)
+	}
+
 	// If the Config file does not exist create a default Config at configFilePath
-	if os.IsNotExist(err) {
+	if os.IsNotExist(err) && IsConfigFileLocation(configFilePath) {
 		mvc := NewMinimumConfig()
 		if err = GenerateDefaultConfigFile(mvc, configFilePath); err != nil {
 			return nil, err
@@ -595,6 +1292,10 @@ func HandleAllConfigSetup(configFilePath string) (*Config, error) {
 		return nil, fmt.Errorf("Config load error: %s", err.Error())
 	}
 
+	if err = cfg.checkHubCredentialsFile(); err != nil {
+		return nil, fmt.Errorf("hub_credentials_file load error: %s", err.Error())
+	}
+
 	if err = cfg.validate(); err != nil {
 		return nil, err
 	}
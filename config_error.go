@@ -0,0 +1,91 @@
+package cagent
+
+import "fmt"
+
+// Codes of the ConfigError returned by the config validation
+const (
+	ConfigErrorBadOutFile                     = "bad_out_file"
+	ConfigErrorBadOutSocket                   = "bad_out_socket"
+	ConfigErrorBadHubProxy                    = "bad_hub_proxy"
+	ConfigErrorIntervalTooLow                 = "interval_too_low"
+	ConfigErrorCollectionDeadlineOutOfRange   = "collection_deadline_out_of_range"
+	ConfigErrorCollectorConcurrencyTooLow     = "collector_concurrency_too_low"
+	ConfigErrorMetricSampleEveryTooLow        = "metric_sample_every_too_low"
+	ConfigErrorStartupDelayNegative           = "startup_delay_negative"
+	ConfigErrorStartupDelayTooHigh            = "startup_delay_too_high"
+	ConfigErrorHeartbeatTooLow                = "heartbeat_too_low"
+	ConfigErrorBadOperationMode               = "bad_operation_mode"
+	ConfigErrorBadIntervalSchedule            = "bad_interval_schedule"
+	ConfigErrorBadNetSpeed                    = "bad_net_speed"
+	ConfigErrorBadDmidecodeSection            = "bad_dmidecode_section"
+	ConfigErrorBadHardwareInventoryType       = "bad_hardware_inventory_type"
+	ConfigErrorHardwareCommandRetriesRange    = "hardware_command_retries_out_of_range"
+	ConfigErrorBadCPULoadGatheringMode        = "bad_cpu_load_gathering_mode"
+	ConfigErrorBadCPULoadPerCore              = "bad_cpu_load_per_core"
+	ConfigErrorFanStallTemperatureTooLow      = "fan_stall_temperature_too_low"
+	ConfigErrorEntropyLowThresholdNegative    = "entropy_low_threshold_negative"
+	ConfigErrorBadWindowsPerfCounter          = "bad_windows_perf_counter"
+	ConfigErrorBadCPUUtilGatheringMode        = "bad_cpu_util_gathering_mode"
+	ConfigErrorBadCPUUtilType                 = "bad_cpu_util_type"
+	ConfigErrorBadCPUUtilAverageType          = "bad_cpu_util_average_type"
+	ConfigErrorCPUUtilEMAAlphaOutOfRange      = "cpu_util_ema_alpha_out_of_range"
+	ConfigErrorCPUWarmupSamplesTooLow         = "cpu_warmup_samples_too_low"
+	ConfigErrorBadOutTimestampFormat          = "bad_out_timestamp_format"
+	ConfigErrorBadOutJSONNesting              = "bad_out_json_nesting"
+	ConfigErrorBadOutTimezone                 = "bad_out_timezone"
+	ConfigErrorTriggerSamplesTooLow           = "trigger_samples_too_low"
+	ConfigErrorNTPSyncThresholdTooLow         = "ntp_sync_threshold_too_low"
+	ConfigErrorLogMaxSizeOutOfRange           = "log_max_size_out_of_range"
+	ConfigErrorLogMaxBackupsOutOfRange        = "log_max_backups_out_of_range"
+	ConfigErrorLogMaxAgeOutOfRange            = "log_max_age_out_of_range"
+	ConfigErrorBadFatalErrors                 = "bad_fatal_errors"
+	ConfigErrorBadMetricPrefix                = "bad_metric_prefix"
+	ConfigErrorMetricPrecisionOutOfRange      = "metric_precision_out_of_range"
+	ConfigErrorBadMaintenanceUntil            = "bad_maintenance_until"
+	ConfigErrorBadHubUserAgent                = "bad_hub_user_agent"
+	ConfigErrorBadHubBufferOn429              = "bad_hub_buffer_on_429"
+	ConfigErrorHubBufferSizeOutOfRange        = "hub_buffer_size_out_of_range"
+	ConfigErrorHubFullRefreshIntervalNegative = "hub_full_refresh_interval_negative"
+	ConfigErrorHubRequestTimeoutOutOfRange    = "hub_request_timeout_out_of_range"
+	ConfigErrorBadFSFillThresholds            = "bad_fs_fill_thresholds"
+	ConfigErrorFSStatTimeoutTooLow            = "fs_stat_timeout_too_low"
+	ConfigErrorBadRemoteTarget                = "bad_remote_target"
+	ConfigErrorBadSNMPTarget                  = "bad_snmp_target"
+	ConfigErrorBadJMXTarget                   = "bad_jmx_target"
+	ConfigErrorBadDNSCheck                    = "bad_dns_check"
+	ConfigErrorBadHTTPCheck                   = "bad_http_check"
+	ConfigErrorBadJobMonitoring               = "bad_jobmon"
+	ConfigErrorBadSystemUpdatesChecks         = "bad_system_updates_checks"
+	ConfigErrorBadMysqlMonitoring             = "bad_mysql_monitoring"
+	ConfigErrorBadUpdates                     = "bad_updates"
+)
+
+// ConfigError describes an invalid config value, so the callers can handle it without parsing the message
+type ConfigError struct {
+	// Code is one of ConfigError* constants
+	Code string
+	// Field is the config key of the invalid value, e.g. "interval" or "cpu_utilisation_analysis.trigger_samples"
+	Field   string
+	Message string
+}
+
+func (e *ConfigError) Error() string {
+	return e.Message
+}
+
+func newConfigError(code, field string, format string, args ...interface{}) *ConfigError {
+	return &ConfigError{
+		Code:    code,
+		Field:   field,
+		Message: fmt.Sprintf(format, args...),
+	}
+}
+
+// wrapConfigError keeps the message of the error returned by a parse or validate helper
+func wrapConfigError(code, field string, err error) *ConfigError {
+	return &ConfigError{
+		Code:    code,
+		Field:   field,
+		Message: err.Error(),
+	}
+}
@@ -1,13 +1,28 @@
 package cagent
 
 import (
+	"context"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"path/filepath"
 	"regexp"
+	"runtime"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"github.com/troian/toml"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/httpcheck"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/jmx"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/snmp"
 )
 
 func TestNewMinimumConfig(t *testing.T) {
@@ -66,6 +81,57 @@ fs_metrics = ['a', 'b']
 	assert.Equal(t, []string{"a", "b"}, config.FSMetrics)
 }
 
+func TestTryUpdateConfigFromReader(t *testing.T) {
+	config := NewConfig()
+	err := TryUpdateConfigFromReader(config, strings.NewReader(`
+interval = 120.0
+hub_url = "https://hub.example.com"
+fs_metrics = ['free_B']
+`))
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, config.Interval)
+	assert.Equal(t, "https://hub.example.com", config.HubURL)
+	assert.Equal(t, []string{"free_B"}, config.FSMetrics)
+
+	err = TryUpdateConfigFromReader(NewConfig(), strings.NewReader("interval = "))
+	assert.Error(t, err)
+}
+
+func TestHandleAllConfigSetupFromURL(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/cagent.conf":
+			_, _ = w.Write([]byte("interval = 120.0\nhub_url = \"https://hub.example.com\"\n"))
+		case "/invalid.conf":
+			_, _ = w.Write([]byte("interval = 1.0\n"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	config, err := HandleAllConfigSetup(server.URL + "/cagent.conf")
+	assert.NoError(t, err)
+	assert.Equal(t, 120.0, config.Interval)
+	assert.Equal(t, "https://hub.example.com", config.HubURL)
+
+	// fetched config is validated
+	_, err = HandleAllConfigSetup(server.URL + "/invalid.conf")
+	assert.Error(t, err)
+
+	_, err = HandleAllConfigSetup(server.URL + "/missing.conf")
+	assert.Error(t, err)
+}
+
+func TestSaveConfigFileRequiresFilePath(t *testing.T) {
+	assert.True(t, IsConfigFileLocation("/etc/cagent/cagent.conf"))
+
+	for _, location := range []string{ConfigLocationStdin, "https://example.com/cagent.conf"} {
+		assert.False(t, IsConfigFileLocation(location))
+		assert.Error(t, SaveConfigFile(NewMinimumConfig(), location))
+	}
+}
+
 func TestGenerateDefaultConfigFile(t *testing.T) {
 	mvc := &MinValuableConfig{
 		LogLevel: "debug",
@@ -88,6 +154,30 @@ func TestGenerateDefaultConfigFile(t *testing.T) {
 	}
 }
 
+func TestGenerateFullConfigFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-config")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	configPath := filepath.Join(tmpDir, "cagent.conf")
+	err = GenerateFullConfigFile(NewConfig(), configPath)
+	assert.NoError(t, err)
+
+	content, err := ioutil.ReadFile(configPath)
+	assert.NoError(t, err)
+
+	assert.Contains(t, string(content), "[cpu_utilisation_analysis]")
+	assert.Contains(t, string(content), "# default ['free_B', 'free_percent', 'total_B', 'read_B_per_s', 'write_B_per_s', 'read_ops_per_s', 'write_ops_per_s', 'inodes_used_percent']\nfs_metrics = [")
+	assert.Contains(t, string(content), "\n# hub_password = \"\"\n")
+	assert.Contains(t, string(content), "\n# hub_proxy_password = \"\"\n")
+	assert.Contains(t, string(content), "\nio_mode = \"http\"\n")
+
+	loadedConfig := NewConfig()
+	err = TryUpdateConfigFromFile(loadedConfig, configPath)
+	assert.NoError(t, err)
+	assert.Equal(t, NewConfig(), loadedConfig)
+}
+
 func TestHandleAllConfigSetup(t *testing.T) {
 	t.Run("config-file-does-exist", func(t *testing.T) {
 		const sampleConfig = `
@@ -267,3 +357,500 @@ func TestGetParsedNetworkInterfaceMaxSpeed(t *testing.T) {
 		assert.Equal(t, expected.val, val, "for input %s: %d vs %d", strVal, expected.val, val)
 	}
 }
+
+func TestFormatOutTimestamp(t *testing.T) {
+	instant := time.Date(2020, 3, 15, 10, 30, 0, 123456789, time.UTC)
+
+	var cases = []struct {
+		format   string
+		timezone string
+		expected interface{}
+	}{
+		{TimestampFormatRFC3339, "UTC", "2020-03-15T10:30:00Z"},
+		{TimestampFormatRFC3339, "Europe/Berlin", "2020-03-15T11:30:00+01:00"},
+		{TimestampFormatRFC3339, "America/New_York", "2020-03-15T06:30:00-04:00"},
+		{TimestampFormatUnix, "UTC", int64(1584268200)},
+		{TimestampFormatUnix, "Europe/Berlin", int64(1584268200)},
+		{TimestampFormatUnixMs, "UTC", int64(1584268200123)},
+		{TimestampFormatUnixMs, "Europe/Berlin", int64(1584268200123)},
+	}
+
+	for _, c := range cases {
+		cfg := NewConfig()
+		cfg.OutTimestampFormat = c.format
+		cfg.OutTimezone = c.timezone
+
+		val, err := cfg.FormatOutTimestamp(instant)
+		assert.NoError(t, err)
+		assert.Equal(t, c.expected, val, "for format %s and timezone %s", c.format, c.timezone)
+	}
+}
+
+func TestValidateOutTimestampSettings(t *testing.T) {
+	cfg := NewConfig()
+	cfg.OutTimezone = "Mars/Olympus_Mons"
+	assert.Error(t, cfg.validate())
+
+	cfg = NewConfig()
+	cfg.OutTimestampFormat = "iso"
+	assert.Error(t, cfg.validate())
+
+	cfg = NewConfig()
+	cfg.OutTimezone = "Europe/Berlin"
+	assert.NoError(t, cfg.validate())
+}
+
+func TestValidateCPUUtilTypesAll(t *testing.T) {
+	cfg := NewConfig()
+	cfg.CPUUtilTypes = []string{"all"}
+	assert.NoError(t, cfg.validate())
+
+	cfg.CPUUtilTypes = []string{"all", "user"}
+	assert.Error(t, cfg.validate())
+}
+
+func TestValidateLogRotationSettings(t *testing.T) {
+	cfg := NewConfig()
+	cfg.LogMaxSizeMB = 0
+	assert.EqualError(t, cfg.validate(), "log_max_size_MB must be between 1 and 1024")
+
+	cfg = NewConfig()
+	cfg.LogMaxBackups = -1
+	assert.Error(t, cfg.validate())
+
+	cfg = NewConfig()
+	cfg.LogMaxAgeDays = 5000
+	assert.Error(t, cfg.validate())
+
+	cfg = NewConfig()
+	cfg.LogMaxBackups = 0
+	cfg.LogMaxAgeDays = 0
+	assert.NoError(t, cfg.validate())
+}
+
+func TestValidateCPUWarmupSamples(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, 2, cfg.CPUWarmupSamples)
+
+	cfg.CPUWarmupSamples = 0
+	assert.EqualError(t, cfg.validate(), "cpu_warmup_samples must be >= 1")
+}
+
+func TestValidateCollectorConcurrency(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, runtime.NumCPU(), cfg.CollectorConcurrency)
+
+	cfg.CollectorConcurrency = 0
+	assert.EqualError(t, cfg.validate(), "collector_concurrency must be >= 1")
+}
+
+func TestValidateStartupDelay(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Interval = 60
+	cfg.StartupDelay = 30
+	cfg.StartupDelayRandom = 20
+	assert.NoError(t, cfg.validate())
+
+	cfg.StartupDelayRandom = 30
+	assert.EqualError(t, cfg.validate(), "startup_delay + startup_delay_random must be lower than interval")
+
+	cfg.StartupDelay = -1
+	cfg.StartupDelayRandom = 0
+	assert.EqualError(t, cfg.validate(), "startup_delay and startup_delay_random must be >= 0")
+}
+
+func TestValidateSMARTInterval(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Interval = 900
+	assert.NoError(t, cfg.validate(), "ignored if smart_monitoring is disabled")
+
+	cfg.SMARTMonitoring = true
+	assert.NoError(t, cfg.validate())
+	assert.Equal(t, 900.0, cfg.SMARTInterval, "raised to interval")
+
+	cfg.SMARTInterval = 1200
+	assert.NoError(t, cfg.validate())
+	assert.Equal(t, 1200.0, cfg.SMARTInterval)
+}
+
+func TestHandleAllConfigSetupWithoutSMARTInterval(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "cagent-smart-interval")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+	require.NoError(t, tmpFile.Close())
+
+	// written before smart_interval was added
+	err = ioutil.WriteFile(tmpFile.Name(), []byte("interval = 900.0\nsmart_monitoring = true\nhub_url = \"https://hub.example.com\"\n"), 0600)
+	require.NoError(t, err)
+
+	config, err := HandleAllConfigSetup(tmpFile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, 900.0, config.SMARTInterval)
+
+	err = ioutil.WriteFile(tmpFile.Name(), []byte("interval = 90.0\nsmart_monitoring = true\nhub_url = \"https://hub.example.com\"\n"), 0600)
+	require.NoError(t, err)
+
+	config, err = HandleAllConfigSetup(tmpFile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, 600.0, config.SMARTInterval, "default is kept if it's not lower than interval")
+}
+
+func TestValidateMetricSampleEvery(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MetricSampleEvery = map[string]int{"proc": 3, "smartmon": 1}
+	assert.NoError(t, cfg.validate())
+
+	cfg.MetricSampleEvery["services"] = 0
+	assert.EqualError(t, cfg.validate(), "metric_sample_every value for 'services' must be >= 1")
+
+	tmpFile, err := ioutil.TempFile("", "")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	require.NoError(t, ioutil.WriteFile(tmpFile.Name(), []byte(`metric_sample_every = { proc = 3, "hw.inventory" = 10 }`), 0600))
+	cfg, err = HandleAllConfigSetup(tmpFile.Name())
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"proc": 3, "hw.inventory": 10}, cfg.MetricSampleEvery)
+}
+
+func TestValidateOutFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-out-file")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	cfg := NewConfig()
+	cfg.IOMode = IOModeFile
+
+	t.Run("valid", func(t *testing.T) {
+		cfg.OutFile = filepath.Join(tmpDir, "results", "cagent.out")
+		assert.NoError(t, cfg.validate())
+
+		files, err := ioutil.ReadDir(tmpDir)
+		require.NoError(t, err)
+		assert.Empty(t, files, "validation must not leave files behind")
+
+		existing := filepath.Join(tmpDir, "existing.out")
+		require.NoError(t, ioutil.WriteFile(existing, nil, 0644))
+		cfg.OutFile = existing
+		assert.NoError(t, cfg.validate())
+	})
+
+	t.Run("null-device", func(t *testing.T) {
+		cfg.OutFile = NewMinimumConfig().OutFile
+		if cfg.OutFile == "" {
+			cfg.OutFile = os.DevNull
+		}
+		assert.NoError(t, cfg.validate())
+
+		cfg.OutFile = os.DevNull
+		assert.NoError(t, cfg.validate())
+	})
+
+	t.Run("not-writable", func(t *testing.T) {
+		notDir := filepath.Join(tmpDir, "file")
+		require.NoError(t, ioutil.WriteFile(notDir, nil, 0644))
+		cfg.OutFile = filepath.Join(notDir, "cagent.out")
+		assert.EqualError(t, cfg.validate(), fmt.Sprintf("out_file '%s' can't be created: '%s' is not a directory", cfg.OutFile, notDir))
+
+		cfg.OutFile = tmpDir
+		assert.EqualError(t, cfg.validate(), fmt.Sprintf("out_file '%s' is a directory", tmpDir))
+
+		if runtime.GOOS == "windows" || os.Geteuid() == 0 {
+			t.Skip("directory permissions are not enforced")
+		}
+
+		readOnlyDir := filepath.Join(tmpDir, "readonly")
+		require.NoError(t, os.Mkdir(readOnlyDir, 0555))
+		cfg.OutFile = filepath.Join(readOnlyDir, "cagent.out")
+		assert.EqualError(t, cfg.validate(), fmt.Sprintf("out_file '%s' can't be created: directory '%s' is not writable", cfg.OutFile, readOnlyDir))
+	})
+
+	t.Run("empty", func(t *testing.T) {
+		cfg.OutFile = ""
+		assert.EqualError(t, cfg.validate(), `out_file must be set in io_mode="file"`)
+	})
+}
+
+func TestValidateOutJSONNesting(t *testing.T) {
+	cfg := NewConfig()
+	assert.Equal(t, JSONNestingFlat, cfg.OutJSONNesting)
+	assert.NoError(t, cfg.validate())
+
+	cfg.OutJSONNesting = "tree"
+	assert.EqualError(t, cfg.validate(), "invalid out_json_nesting supplied. Must be one of [flat nested]")
+}
+
+func TestMaintenance(t *testing.T) {
+	now := time.Date(2026, 1, 31, 20, 0, 0, 0, time.UTC)
+
+	cfg := NewConfig()
+	assert.False(t, cfg.InMaintenance(now))
+
+	cfg.MaintenanceUntil = "2026-01-31T22:00:00+01:00"
+	assert.NoError(t, cfg.validate())
+	assert.True(t, cfg.InMaintenance(now))
+	assert.False(t, cfg.InMaintenance(now.Add(time.Hour)))
+
+	cfg.MaintenanceUntil = "tomorrow"
+	err := cfg.validate()
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "maintenance_until must be a RFC3339 timestamp")
+	assert.False(t, cfg.InMaintenance(now))
+
+	os.Setenv("CAGENT_MAINTENANCE_UNTIL", "2026-01-31T21:00:00Z")
+	defer os.Unsetenv("CAGENT_MAINTENANCE_UNTIL")
+	assert.NoError(t, cfg.validate())
+	assert.True(t, cfg.InMaintenance(now))
+	os.Unsetenv("CAGENT_MAINTENANCE_UNTIL")
+
+	dir, err := ioutil.TempDir("", "cagent-maintenance")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	cfg = NewConfig()
+	cfg.MaintenanceFile = filepath.Join(dir, "maintenance")
+	assert.False(t, cfg.InMaintenance(now))
+	assert.NoError(t, ioutil.WriteFile(cfg.MaintenanceFile, nil, 0644))
+	assert.True(t, cfg.InMaintenance(now))
+}
+
+func TestValidateCPUGatheringModes(t *testing.T) {
+	cfg := NewConfig()
+	cfg.CPULoadDataGather = []string{"avg1"}
+	cfg.CPUUtilDataGather = []string{"avg5", "avg15"}
+	assert.NoError(t, cfg.validate())
+
+	cfg = NewConfig()
+	cfg.CPULoadDataGather = []string{"avg5", "avg10"}
+	cfg.CPUUtilDataGather = []string{"avg10"}
+	assert.EqualError(t, cfg.validate(), "invalid cpu_load_data_gathering_mode value 'avg10'. Supported values: avg1, avg5, avg15")
+
+	cfg = NewConfig()
+	cfg.CPULoadDataGather = []string{"avg1"}
+	cfg.CPUUtilDataGather = []string{"avg"}
+	assert.EqualError(t, cfg.validate(), "invalid cpu_utilisation_gathering_mode value 'avg': must be in format avgN")
+}
+
+func TestValidateCPULoadPerCore(t *testing.T) {
+	cfg := NewConfig()
+	cfg.CPULoadDataGather = []string{"avg1", "avg5", "avg15"}
+	cfg.CPULoadPerCore = []string{"avg15"}
+	assert.NoError(t, cfg.validate())
+
+	cfg.CPULoadDataGather = []string{"avg1", "avg5"}
+	assert.EqualError(t, cfg.validate(), "invalid cpu_load_per_core value 'avg15'. Supported values: avg1, avg5")
+
+	cfg.CPULoadDataGather = []string{}
+	assert.EqualError(t, cfg.validate(), "cpu_load_per_core requires cpu_load_data_gathering_mode to be set")
+}
+
+func TestValidateDmidecodeSections(t *testing.T) {
+	cfg := NewConfig()
+	assert.NoError(t, cfg.validate())
+
+	cfg.DmidecodeSections = []string{"baseboard"}
+	assert.NoError(t, cfg.validate())
+
+	cfg.DmidecodeSections = []string{"baseboard", "cpu"}
+	assert.EqualError(t, cfg.validate(), "invalid dmidecode_sections value 'cpu' supplied. Must be one of [baseboard memory bios chassis processor]")
+}
+
+func TestValidateWindowsPerfCounters(t *testing.T) {
+	cfg := NewConfig()
+	cfg.WindowsPerfCounters = []string{`\Processor(_Total)\% Processor Time`, `\LogicalDisk(*)\Avg. Disk Queue Length`}
+	assert.NoError(t, cfg.validate())
+
+	cfg.WindowsPerfCounters = []string{`Processor(_Total)\% Processor Time`}
+	assert.EqualError(t, cfg.validate(), `windows_perf_counters: invalid counter path 'Processor(_Total)\% Processor Time', expected format is \Object(Instance)\Counter or \Object\Counter`)
+}
+
+func TestValidateMetricPrefix(t *testing.T) {
+	cfg := NewConfig()
+	cfg.MetricPrefix = "datacenter-1.web_01."
+	assert.NoError(t, cfg.validate())
+
+	cfg.MetricPrefix = "dc1:web01."
+	assert.EqualError(t, cfg.validate(), "metric_prefix may contain only letters, digits, '.', '_' and '-'")
+}
+
+func TestValidateConfigErrorCodes(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+		code   string
+		field  string
+	}{
+		{"out_socket", func(cfg *Config) { cfg.IOMode = IOModeSocket }, ConfigErrorBadOutSocket, "out_socket"},
+		{"interval", func(cfg *Config) { cfg.Interval = 10 }, ConfigErrorIntervalTooLow, "interval"},
+		{"heartbeat", func(cfg *Config) { cfg.HeartbeatInterval = 1 }, ConfigErrorHeartbeatTooLow, "heartbeat"},
+		{"operation_mode", func(cfg *Config) { cfg.OperationMode = "lazy" }, ConfigErrorBadOperationMode, "operation_mode"},
+		{"interval_schedule", func(cfg *Config) {
+			cfg.IntervalSchedule = []IntervalScheduleEntry{{From: "08:00", To: "18:00"}, {From: "12:00", To: "14:00"}}
+		}, ConfigErrorBadIntervalSchedule, "interval_schedule[1]"},
+		{"net_interface_max_speed", func(cfg *Config) { cfg.NetInterfaceMaxSpeed = "10X" }, ConfigErrorBadNetSpeed, "net_interface_max_speed"},
+		{"collector_concurrency", func(cfg *Config) { cfg.CollectorConcurrency = 0 }, ConfigErrorCollectorConcurrencyTooLow, "collector_concurrency"},
+		{"metric_sample_every", func(cfg *Config) { cfg.MetricSampleEvery = map[string]int{"services": 0} }, ConfigErrorMetricSampleEveryTooLow, "metric_sample_every.services"},
+		{"startup_delay", func(cfg *Config) { cfg.StartupDelay = -1 }, ConfigErrorStartupDelayNegative, "startup_delay"},
+		{"dmidecode_sections", func(cfg *Config) { cfg.DmidecodeSections = []string{"cpu"} }, ConfigErrorBadDmidecodeSection, "dmidecode_sections"},
+		{"cpu_load_data_gathering_mode", func(cfg *Config) { cfg.CPULoadDataGather = []string{"avg10"} }, ConfigErrorBadCPULoadGatheringMode, "cpu_load_data_gathering_mode"},
+		{"cpu_utilisation_gathering_mode", func(cfg *Config) { cfg.CPUUtilDataGather = []string{"avg"} }, ConfigErrorBadCPUUtilGatheringMode, "cpu_utilisation_gathering_mode"},
+		{"cpu_warmup_samples", func(cfg *Config) { cfg.CPUWarmupSamples = 0 }, ConfigErrorCPUWarmupSamplesTooLow, "cpu_warmup_samples"},
+		{"out_json_nesting", func(cfg *Config) { cfg.OutJSONNesting = "deep" }, ConfigErrorBadOutJSONNesting, "out_json_nesting"},
+		{"trigger_samples", func(cfg *Config) { cfg.CPUUtilisationAnalysis.TriggerSamples = 0 }, ConfigErrorTriggerSamplesTooLow, "cpu_utilisation_analysis.trigger_samples"},
+		{"log_max_size_MB", func(cfg *Config) { cfg.LogMaxSizeMB = 0 }, ConfigErrorLogMaxSizeOutOfRange, "log_max_size_MB"},
+		{"fan_stall_temperature", func(cfg *Config) { cfg.FanStallTemperature = 0 }, ConfigErrorFanStallTemperatureTooLow, "fan_stall_temperature"},
+		{"metric_prefix", func(cfg *Config) { cfg.MetricPrefix = "dc1/web01 " }, ConfigErrorBadMetricPrefix, "metric_prefix"},
+		{"hub_user_agent", func(cfg *Config) { cfg.HubUserAgent = "agent\n" }, ConfigErrorBadHubUserAgent, "hub_user_agent"},
+		{"windows_perf_counters", func(cfg *Config) { cfg.WindowsPerfCounters = []string{"Processor"} }, ConfigErrorBadWindowsPerfCounter, "windows_perf_counters"},
+		{"snmp_targets", func(cfg *Config) {
+			cfg.SNMPTargets = []snmp.Target{{Host: "192.168.1.20", OIDs: map[string]string{"uptime": "sysUpTime.0"}}}
+		}, ConfigErrorBadSNMPTarget, "snmp_targets[0]"},
+		{"dns_check.timeout", func(cfg *Config) { cfg.DNSCheck.Timeout = 0 }, ConfigErrorBadDNSCheck, "dns_check.timeout"},
+		{"dns_check.interval", func(cfg *Config) { cfg.DNSCheck.Interval = cfg.Interval / 2 }, ConfigErrorBadDNSCheck, "dns_check.interval"},
+		{"http_checks", func(cfg *Config) { cfg.HTTPChecks = []httpcheck.Check{{URL: "http://127.0.0.1:8080/health"}} }, ConfigErrorBadHTTPCheck, "http_checks[0]"},
+		{"http_checks duplicate", func(cfg *Config) {
+			cfg.HTTPChecks = []httpcheck.Check{{Name: "api", URL: "http://127.0.0.1:8080/health"}, {Name: "api", URL: "http://127.0.0.1:8081/health"}}
+		}, ConfigErrorBadHTTPCheck, "http_checks[1]"},
+		{"jmx_targets", func(cfg *Config) { cfg.JMXTargets = []jmx.Target{{URL: "127.0.0.1:8778/jolokia"}} }, ConfigErrorBadJMXTarget, "jmx_targets[0]"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			tt.modify(cfg)
+
+			err := cfg.validate()
+			require.Error(t, err)
+			configErr, ok := err.(*ConfigError)
+			require.True(t, ok, "expected *ConfigError, got %T", err)
+			assert.Equal(t, tt.code, configErr.Code)
+			assert.Equal(t, tt.field, configErr.Field)
+		})
+	}
+}
+
+func TestFSFillThresholdsConfig(t *testing.T) {
+	const sampleConfig = `
+fs_fill_warning_percent = 80.0
+fs_fill_critical_percent = 90.0
+
+[fs_fill_thresholds."/var"]
+  warning_percent = 95.0
+  critical_percent = 99.0
+`
+
+	tmpFile, err := ioutil.TempFile("", "")
+	assert.Nil(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	err = ioutil.WriteFile(tmpFile.Name(), []byte(sampleConfig), 0600)
+	assert.Nil(t, err)
+
+	config, err := HandleAllConfigSetup(tmpFile.Name())
+	assert.Nil(t, err)
+	assert.Equal(t, 80.0, config.FSFillWarningPercent)
+	assert.Equal(t, 90.0, config.FSFillCriticalPercent)
+	assert.Equal(t, 95.0, config.FSFillThresholds["/var"].WarningPercent)
+	assert.Equal(t, 99.0, config.FSFillThresholds["/var"].CriticalPercent)
+
+	config.FSFillWarningPercent = 91.0
+	assert.Error(t, config.validate())
+}
+
+func TestHubCredentialsFile(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-credentials")
+	assert.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	writeConfig := func(credentialsFile string) string {
+		configPath := filepath.Join(tmpDir, "cagent.conf")
+		err := ioutil.WriteFile(configPath, []byte(`
+hub_url = "https://hub.example.com"
+hub_user = "placeholder-user"
+hub_password = "placeholder-password"
+hub_credentials_file = '`+credentialsFile+`'
+`), 0600)
+		assert.NoError(t, err)
+		return configPath
+	}
+
+	// the credentials are read by the Hub credential provider, the config keeps the placeholders
+	hubCredentials := func(t *testing.T, configPath string) (user, pass, token string) {
+		cfg, err := HandleAllConfigSetup(configPath)
+		require.NoError(t, err)
+
+		ca, err := New(cfg, configPath)
+		require.NoError(t, err)
+		defer ca.Shutdown()
+
+		require.NotNil(t, ca.hubCredentialCache())
+		user, pass, token, err = ca.hubCredentialCache().get(context.Background())
+		require.NoError(t, err)
+		return user, pass, token
+	}
+
+	t.Run("toml", func(t *testing.T) {
+		credentialsPath := filepath.Join(tmpDir, "credentials.toml")
+		err := ioutil.WriteFile(credentialsPath, []byte(`
+hub_user = "real-user"
+hub_password = "real-password"
+hub_token = "real-token"
+`), 0600)
+		assert.NoError(t, err)
+
+		user, pass, token := hubCredentials(t, writeConfig(credentialsPath))
+		assert.Equal(t, "real-user", user)
+		assert.Equal(t, "real-password", pass)
+		assert.Equal(t, "real-token", token)
+	})
+
+	t.Run("json-partial", func(t *testing.T) {
+		credentialsPath := filepath.Join(tmpDir, "credentials.json")
+		err := ioutil.WriteFile(credentialsPath, []byte(`{"hub_password": "real-password"}`), 0600)
+		assert.NoError(t, err)
+
+		user, pass, token := hubCredentials(t, writeConfig(credentialsPath))
+		assert.Equal(t, "placeholder-user", user)
+		assert.Equal(t, "real-password", pass)
+		assert.Empty(t, token)
+	})
+
+	t.Run("malformed-file", func(t *testing.T) {
+		credentialsPath := filepath.Join(tmpDir, "malformed.json")
+		err := ioutil.WriteFile(credentialsPath, []byte(`{"hub_password": `), 0600)
+		assert.NoError(t, err)
+
+		_, err = HandleAllConfigSetup(writeConfig(credentialsPath))
+		assert.Error(t, err)
+	})
+
+	t.Run("missing-file", func(t *testing.T) {
+		_, err := HandleAllConfigSetup(writeConfig(filepath.Join(tmpDir, "missing.toml")))
+		assert.Error(t, err)
+	})
+}
+
+func TestValidateWarnsIfAllCollectorsDisabled(t *testing.T) {
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	cfg := NewConfig()
+	cfg.CPUMonitoring = false
+	cfg.MemMonitoring = false
+	cfg.FSMonitoring = false
+	cfg.NetMonitoring = false
+	cfg.SoftwareRAIDMonitoring = false
+	cfg.HardwareInventory = false
+	cfg.TemperatureMonitoring = false
+	assert.NoError(t, cfg.validate())
+
+	if assert.NotNil(t, hook.LastEntry()) {
+		assert.Equal(t, logrus.WarnLevel, hook.LastEntry().Level)
+		assert.Contains(t, hook.LastEntry().Message, "all collectors are disabled")
+	}
+
+	hook.Reset()
+	cfg.NetMonitoring = true
+	assert.NoError(t, cfg.validate())
+	assert.Nil(t, hook.LastEntry())
+}
@@ -3,7 +3,7 @@ package cagent
 import (
 	"errors"
 	"fmt"
-	"math"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
@@ -20,6 +20,15 @@ import (
 const measureInterval = time.Second * 10
 
 var errMetricsAreNotCollectedYet = errors.New("metrics are not collected yet")
+
+var gatheringModeRegexp = regexp.MustCompile(`^avg(\d+)$`)
+
+// load average is provided by OS only for these periods
+var cpuLoadGatheringModeMinutes = []int{1, 5, 15}
+
+// CPUUtilTypesAll used in cpu_utilisation_types stands for all types supported on the current OS
+const CPUUtilTypesAll = "all"
+
 var utilisationMetricsByOS = map[string][]string{
 	"windows": {"system", "user", "idle", "irq"},
 	"linux":   {"system", "user", "nice", "iowait", "idle", "softirq", "irq"},
@@ -41,6 +50,34 @@ type TimeSeriesAverage struct {
 	TimeSeries         []TimeValue
 	mu                 sync.Mutex
 	_DurationInMinutes []int // do not set directly, use SetDurationsMinutes
+
+	// WarmupSamples is the minimal number of measured intervals in the period to calculate the percentage
+	WarmupSamples int
+}
+
+// ExponentialMovingAverage is an alternative to TimeSeriesAverage that doesn't retain the samples.
+// Each added sample updates the average as: avg = alpha*sample + (1-alpha)*avg
+type ExponentialMovingAverage struct {
+	Alpha  float64
+	Values ValuesMap
+
+	// Samples is the number of samples added
+	Samples int
+}
+
+func (ema *ExponentialMovingAverage) Add(values ValuesMap) {
+	if ema.Values == nil {
+		ema.Values = make(ValuesMap)
+	}
+	ema.Samples++
+
+	for key, val := range values {
+		if prev, exists := ema.Values[key]; exists {
+			ema.Values[key] = ema.Alpha*val + (1-ema.Alpha)*prev
+		} else {
+			ema.Values[key] = val
+		}
+	}
 }
 
 type thresholdNotifier struct {
@@ -49,6 +86,22 @@ type thresholdNotifier struct {
 	Function             func(current, threshold float64) (notify bool)
 	GatheringModeMinutes int // supported values: 1, 5, 15
 	Chan                 chan float64
+
+	// TriggerSamples is the number of consecutive samples the condition must hold before notifying
+	TriggerSamples     int
+	consecutiveSamples int
+}
+
+// observe registers the current value and reports if the notification should be sent.
+// Condition must be met for TriggerSamples consecutive samples, so values hovering around the threshold don't trigger it
+func (tn *thresholdNotifier) observe(val float64) bool {
+	if val < 0 || !tn.Function(val, tn.Percentage) {
+		tn.consecutiveSamples = 0
+		return false
+	}
+
+	tn.consecutiveSamples++
+	return tn.consecutiveSamples >= tn.TriggerSamples
 }
 
 type CPUWatcher struct {
@@ -56,9 +109,20 @@ type CPUWatcher struct {
 	LoadAvg5  bool
 	LoadAvg15 bool
 
+	// LoadPerCore lists the load average periods in minutes which are reported divided by the number of logical CPUs as well
+	LoadPerCore []int
+
 	UtilAvg   TimeSeriesAverage
 	UtilTypes []string
 
+	// UtilAverageType selects how utilisation is averaged: CPUUtilAverageTypeArithmetic or CPUUtilAverageTypeEMA
+	UtilAverageType string
+	UtilEMA         map[int]*ExponentialMovingAverage
+	lastUtilTimes   *TimeValue
+
+	// WarmupSamples is the minimal number of samples to report the utilisation of a period, it is nil until then
+	WarmupSamples int
+
 	ThresholdNotifiers []thresholdNotifier
 }
 
@@ -78,6 +142,90 @@ func init() {
 	}
 }
 
+// parseCPULoadGatheringModes parses cpu_load_data_gathering_mode values into load average periods in minutes
+func parseCPULoadGatheringModes(modes []string) ([]int, error) {
+	return parseGatheringModes("cpu_load_data_gathering_mode", modes, cpuLoadGatheringModeMinutes)
+}
+
+// parseCPULoadPerCore parses cpu_load_per_core values into load average periods in minutes.
+// Only the periods enabled by cpu_load_data_gathering_mode are accepted
+func parseCPULoadPerCore(perCore []string, loadModes []string) ([]int, error) {
+	if len(perCore) == 0 {
+		return nil, nil
+	}
+
+	loadWindows, _ := parseCPULoadGatheringModes(loadModes)
+	if len(loadWindows) == 0 {
+		return nil, fmt.Errorf("cpu_load_per_core requires cpu_load_data_gathering_mode to be set")
+	}
+
+	return parseGatheringModes("cpu_load_per_core", perCore, loadWindows)
+}
+
+// parseCPUUtilGatheringModes parses cpu_utilisation_gathering_mode values into utilisation averaging windows in minutes
+func parseCPUUtilGatheringModes(modes []string) ([]int, error) {
+	return parseGatheringModes("cpu_utilisation_gathering_mode", modes, nil)
+}
+
+// expandCPUUtilTypes replaces the special 'all' value of cpu_utilisation_types with every type supported on osName
+func expandCPUUtilTypes(types []string, osName string) ([]string, error) {
+	if !common.StrInSlice(CPUUtilTypesAll, types) {
+		return types, nil
+	}
+
+	if len(types) > 1 {
+		return nil, fmt.Errorf("invalid cpu_utilisation_types value: '%s' can't be combined with other types", CPUUtilTypesAll)
+	}
+
+	return append([]string{}, utilisationMetricsByOS[osName]...), nil
+}
+
+// parseGatheringModes parses 'avgN' values into the list of unique periods in minutes.
+// If supportedMinutes is not empty only the listed periods are accepted.
+// Invalid values are skipped and reported in the returned error
+func parseGatheringModes(option string, modes []string, supportedMinutes []int) ([]int, error) {
+	var result []int
+	var errs common.ErrorCollector
+
+	for _, mode := range modes {
+		match := gatheringModeRegexp.FindStringSubmatch(mode)
+		if match == nil {
+			errs.Add(fmt.Errorf("invalid %s value '%s': must be in format avgN", option, mode))
+			continue
+		}
+
+		v, err := strconv.Atoi(match[1])
+		if err != nil || v <= 0 {
+			errs.Add(fmt.Errorf("invalid %s value '%s': period must be a positive number of minutes", option, mode))
+			continue
+		}
+
+		if len(supportedMinutes) > 0 && !intInSlice(v, supportedMinutes) {
+			var supported []string
+			for _, m := range supportedMinutes {
+				supported = append(supported, fmt.Sprintf("avg%d", m))
+			}
+			errs.Add(fmt.Errorf("invalid %s value '%s'. Supported values: %s", option, mode, strings.Join(supported, ", ")))
+			continue
+		}
+
+		if !intInSlice(v, result) {
+			result = append(result, v)
+		}
+	}
+
+	return result, errs.Combine()
+}
+
+func intInSlice(v int, list []int) bool {
+	for _, item := range list {
+		if item == v {
+			return true
+		}
+	}
+	return false
+}
+
 func minutes(mins int) time.Duration {
 	return time.Duration(time.Minute * time.Duration(mins))
 }
@@ -124,11 +272,6 @@ func (tsa *TimeSeriesAverage) Average() map[int]ValuesMap {
 	return sum
 }
 
-func roundUpWithPrecision(p float64, precision int) float64 {
-	k := math.Pow10(precision)
-	return float64(int64(p*k+0.5)) / k
-}
-
 func (tsa *TimeSeriesAverage) Percentage() (map[int]ValuesMap, error) {
 	sum := make(map[int]ValuesMap)
 
@@ -172,24 +315,60 @@ func (tsa *TimeSeriesAverage) Percentage() (map[int]ValuesMap, error) {
 				continue
 			}
 
+			if len(tsa.TimeSeries)-1-keyInt < tsa.WarmupSamples {
+				log.Debugf("cpu.util metrics for %d min avg calculation are warming up", d)
+				sum[d][key] = -1
+				continue
+			}
+
 			secondsSpentOnThisTypeOfLoad := lastVal - tsa.TimeSeries[keyInt].Values[key]
 			secondsBetweenFirstAndLastMeasurementInTheRange := last.Time.Sub(tsa.TimeSeries[keyInt].Time).Seconds()
 
 			// divide CPU times with seconds to found the percentage
-			sum[d][key] = roundUpWithPrecision((secondsSpentOnThisTypeOfLoad/secondsBetweenFirstAndLastMeasurementInTheRange)*100, 2)
+			percentage := clampedUtilPercentage(key, secondsSpentOnThisTypeOfLoad, secondsBetweenFirstAndLastMeasurementInTheRange)
+			if percentage == -1 {
+				sum[d][key] = -1
+				continue
+			}
+			sum[d][key] = common.RoundToPrecision(percentage, 2)
 		}
 	}
 
 	return sum, nil
 }
 
+// clampedUtilPercentage converts CPU time spent in the period of elapsedSeconds into utilisation percentage clamped to [0, 100].
+// Clock adjustments or a core going offline can produce values out of range.
+// -1 is returned if the CPU time counters were reset or the clock stepped back, as the value is unknown then
+func clampedUtilPercentage(key string, cpuSeconds, elapsedSeconds float64) float64 {
+	if cpuSeconds < 0 || elapsedSeconds <= 0 {
+		log.Debugf("[CPU] %s: skipping sample, CPU time delta %.2fs for %.2fs elapsed", key, cpuSeconds, elapsedSeconds)
+		return -1
+	}
+
+	percentage := cpuSeconds / elapsedSeconds * 100
+	if percentage > 100 {
+		log.Debugf("[CPU] %s: clamping utilisation %.2f%% to 100%%", key, percentage)
+		return 100
+	}
+
+	return percentage
+}
+
+// emaAlphaForDuration returns the smoothing factor equivalent to the arithmetic mean window of given duration
+func emaAlphaForDuration(mins int) float64 {
+	samples := float64(minutes(mins) / measureInterval)
+	return 2 / (samples + 1)
+}
+
 func (ca *Cagent) CPUWatcher() *CPUWatcher {
 	if ca.cpuWatcher != nil {
 		return ca.cpuWatcher
 	}
 
-	cw := CPUWatcher{}
+	cw := CPUWatcher{UtilAverageType: ca.Config.CPUUtilAverageType, WarmupSamples: ca.Config.CPUWarmupSamples}
 	cw.UtilAvg.mu.Lock()
+	cw.UtilAvg.WarmupSamples = ca.Config.CPUWarmupSamples
 
 	if len(ca.Config.CPULoadDataGather) > 0 {
 		_, err := load.Avg()
@@ -197,38 +376,40 @@ func (ca *Cagent) CPUWatcher() *CPUWatcher {
 		if err != nil && err.Error() == "not implemented yet" {
 			log.Errorf("[CPU] load_avg metric unavailable on %s", runtime.GOOS)
 		} else {
-			for _, d := range ca.Config.CPULoadDataGather {
-				if strings.HasPrefix(d, "avg") {
-					v, _ := strconv.Atoi(d[3:])
-
-					switch v {
-					case 1:
-						cw.LoadAvg1 = true
-					case 5:
-						cw.LoadAvg5 = true
-					case 15:
-						cw.LoadAvg15 = true
-					default:
-						log.Errorf("[CPU] wrong cpu_load_data_gathering_mode. Supported values: avg1, avg5, avg15")
-					}
+			loadWindows, err := parseCPULoadGatheringModes(ca.Config.CPULoadDataGather)
+			if err != nil {
+				log.Errorf("[CPU] %s", err.Error())
+			}
+
+			for _, v := range loadWindows {
+				switch v {
+				case 1:
+					cw.LoadAvg1 = true
+				case 5:
+					cw.LoadAvg5 = true
+				case 15:
+					cw.LoadAvg15 = true
 				}
 			}
-		}
-	}
 
-	durations := []int{}
-	for _, d := range ca.Config.CPUUtilDataGather {
-		if strings.HasPrefix(d, "avg") {
-			v, err := strconv.Atoi(d[3:])
+			cw.LoadPerCore, err = parseCPULoadPerCore(ca.Config.CPULoadPerCore, ca.Config.CPULoadDataGather)
 			if err != nil {
-				log.Errorf("[CPU] failed to parse cpu_load_data_gathering_mode '%s': %s", d, err.Error())
-				continue
+				log.Errorf("[CPU] %s", err.Error())
 			}
-			durations = append(durations, v)
 		}
 	}
 
-	for _, t := range ca.Config.CPUUtilTypes {
+	durations, err := parseCPUUtilGatheringModes(ca.Config.CPUUtilDataGather)
+	if err != nil {
+		log.Errorf("[CPU] %s", err.Error())
+	}
+
+	utilTypes, err := expandCPUUtilTypes(ca.Config.CPUUtilTypes, runtime.GOOS)
+	if err != nil {
+		log.Errorf("[CPU] %s", err.Error())
+	}
+
+	for _, t := range utilTypes {
 		found := false
 
 		for _, metric := range utilisationMetricsByOS[runtime.GOOS] {
@@ -246,6 +427,16 @@ func (ca *Cagent) CPUWatcher() *CPUWatcher {
 	}
 
 	cw.UtilAvg.SetDurationsMinutes(durations...)
+	if cw.UtilAverageType == CPUUtilAverageTypeEMA {
+		cw.UtilEMA = make(map[int]*ExponentialMovingAverage)
+		for _, d := range durations {
+			alpha := ca.Config.CPUUtilEMAAlpha
+			if alpha == 0 {
+				alpha = emaAlphaForDuration(d)
+			}
+			cw.UtilEMA[d] = &ExponentialMovingAverage{Alpha: alpha}
+		}
+	}
 	cw.UtilAvg.mu.Unlock()
 	ca.cpuWatcher = &cw
 
@@ -307,19 +498,24 @@ func (cw *CPUWatcher) Once() error {
 		}
 	}
 
-	cw.UtilAvg.Add(time.Now(), values)
+	if cw.UtilAverageType == CPUUtilAverageTypeEMA {
+		cw.addEMASample(TimeValue{time.Now(), values})
+	} else {
+		cw.UtilAvg.Add(time.Now(), values)
+	}
 	cw.UtilAvg.mu.Unlock()
 	if cw.ThresholdNotifiers != nil {
-		avg, _ := cw.UtilAvg.Percentage()
+		avg, _ := cw.utilPercentage()
 
-		for _, tm := range cw.ThresholdNotifiers {
+		for i := range cw.ThresholdNotifiers {
+			tm := &cw.ThresholdNotifiers[i]
 			var values ValuesMap
 			var exists bool
 			if values, exists = avg[tm.GatheringModeMinutes]; !exists {
 				continue
 			}
 
-			if val, exists := values[tm.Metric+".%d.total"]; exists && val >= 0 && tm.Function(val, tm.Percentage) {
+			if val, exists := values[tm.Metric+".%d.total"]; exists && tm.observe(val) {
 				tm.Chan <- val
 			}
 		}
@@ -327,6 +523,62 @@ func (cw *CPUWatcher) Once() error {
 	return nil
 }
 
+// addEMASample converts accumulated CPU times into utilisation percentage since the previous sample
+// and folds it into the moving averages. Must be called with UtilAvg.mu locked
+func (cw *CPUWatcher) addEMASample(sample TimeValue) {
+	last := cw.lastUtilTimes
+	cw.lastUtilTimes = &sample
+	if last == nil {
+		return
+	}
+
+	seconds := sample.Time.Sub(last.Time).Seconds()
+	if seconds <= 0 {
+		log.Debugf("[CPU] skipping sample, clock stepped back by %.2fs", -seconds)
+		return
+	}
+
+	percentages := make(ValuesMap)
+	for key, val := range sample.Values {
+		if lastVal, exists := last.Values[key]; exists {
+			if percentage := clampedUtilPercentage(key, val-lastVal, seconds); percentage != -1 {
+				percentages[key] = percentage
+			}
+		}
+	}
+
+	for _, ema := range cw.UtilEMA {
+		ema.Add(percentages)
+	}
+}
+
+func (cw *CPUWatcher) utilPercentage() (map[int]ValuesMap, error) {
+	if cw.UtilAverageType != CPUUtilAverageTypeEMA {
+		return cw.UtilAvg.Percentage()
+	}
+
+	cw.UtilAvg.mu.Lock()
+	defer cw.UtilAvg.mu.Unlock()
+
+	result := make(map[int]ValuesMap)
+	for d, ema := range cw.UtilEMA {
+		if ema.Values == nil {
+			return nil, errMetricsAreNotCollectedYet
+		}
+
+		result[d] = make(ValuesMap)
+		for key, val := range ema.Values {
+			if ema.Samples < cw.WarmupSamples {
+				result[d][key] = -1
+				continue
+			}
+			result[d][key] = common.RoundToPrecision(val, 2)
+		}
+	}
+
+	return result, nil
+}
+
 func (cw *CPUWatcher) Run() {
 	for {
 		start := time.Now()
@@ -346,7 +598,7 @@ func (cw *CPUWatcher) Run() {
 
 func (cw *CPUWatcher) Results() (common.MeasurementsMap, error) {
 	var errs []string
-	util, err := cw.UtilAvg.Percentage()
+	util, err := cw.utilPercentage()
 	if err != nil {
 		log.Errorf("[CPU] Failed to calculate utilisation metrics: " + err.Error())
 		errs = append(errs, err.Error())
@@ -368,17 +620,7 @@ func (cw *CPUWatcher) Results() (common.MeasurementsMap, error) {
 			log.Error("[CPU] Failed to read load_avg: ", err.Error())
 			errs = append(errs, err.Error())
 		} else {
-			if cw.LoadAvg1 {
-				results["load.avg.1"] = loadAvg.Load1
-			}
-
-			if cw.LoadAvg5 {
-				results["load.avg.5"] = loadAvg.Load5
-			}
-
-			if cw.LoadAvg15 {
-				results["load.avg.15"] = loadAvg.Load15
-			}
+			results.AddWithPrefix("", cw.loadAvgResults(loadAvg, runtime.NumCPU()))
 		}
 	}
 
@@ -390,7 +632,33 @@ func (cw *CPUWatcher) Results() (common.MeasurementsMap, error) {
 
 }
 
-func (cw *CPUWatcher) AddThresholdNotifier(percentage float64, metric string, operator string, gatheringMode string, ch chan float64) error {
+// loadAvgResults returns the enabled load averages and their per core variants for LoadPerCore periods
+func (cw *CPUWatcher) loadAvgResults(loadAvg *load.AvgStat, cores int) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	for _, avg := range []struct {
+		minutes int
+		enabled bool
+		value   float64
+	}{
+		{1, cw.LoadAvg1, loadAvg.Load1},
+		{5, cw.LoadAvg5, loadAvg.Load5},
+		{15, cw.LoadAvg15, loadAvg.Load15},
+	} {
+		if !avg.enabled {
+			continue
+		}
+
+		key := fmt.Sprintf("load.avg.%d", avg.minutes)
+		results[key] = avg.value
+		if cores > 0 && intInSlice(avg.minutes, cw.LoadPerCore) {
+			results[key+".per_core"] = avg.value / float64(cores)
+		}
+	}
+
+	return results
+}
+
+func (cw *CPUWatcher) AddThresholdNotifier(percentage float64, metric string, operator string, gatheringMode string, triggerSamples int, ch chan float64) error {
 
 	if ch == nil {
 		return fmt.Errorf("ch should be non-nil chan")
@@ -400,7 +668,11 @@ func (cw *CPUWatcher) AddThresholdNotifier(percentage float64, metric string, op
 		return fmt.Errorf("percentage should be more >0 and <=100")
 	}
 
-	tn := thresholdNotifier{Percentage: percentage, Chan: ch}
+	if triggerSamples < 1 {
+		return fmt.Errorf("trigger samples should be >= 1")
+	}
+
+	tn := thresholdNotifier{Percentage: percentage, Chan: ch, TriggerSamples: triggerSamples}
 
 	tn.Percentage = percentage
 
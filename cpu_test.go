@@ -0,0 +1,221 @@
+package cagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/load"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestExponentialMovingAverage(t *testing.T) {
+	ema := ExponentialMovingAverage{Alpha: 0.5}
+
+	ema.Add(ValuesMap{"idle": 10})
+	assert.Equal(t, 10.0, ema.Values["idle"])
+
+	ema.Add(ValuesMap{"idle": 20})
+	assert.Equal(t, 15.0, ema.Values["idle"])
+
+	ema.Add(ValuesMap{"idle": 30})
+	assert.Equal(t, 22.5, ema.Values["idle"])
+
+	ema.Add(ValuesMap{"idle": 0})
+	assert.Equal(t, 11.25, ema.Values["idle"])
+}
+
+func TestCPUWatcherEMAPercentage(t *testing.T) {
+	cw := CPUWatcher{
+		UtilAverageType: CPUUtilAverageTypeEMA,
+		UtilEMA:         map[int]*ExponentialMovingAverage{1: {Alpha: 0.5}},
+	}
+
+	_, err := cw.utilPercentage()
+	assert.Equal(t, errMetricsAreNotCollectedYet, err)
+
+	start := time.Now()
+	// CPU times are accumulated seconds
+	cw.addEMASample(TimeValue{start, ValuesMap{"idle.%d.total": 100}})
+	cw.addEMASample(TimeValue{start.Add(10 * time.Second), ValuesMap{"idle.%d.total": 108}})
+	cw.addEMASample(TimeValue{start.Add(20 * time.Second), ValuesMap{"idle.%d.total": 114}})
+
+	util, err := cw.utilPercentage()
+	assert.NoError(t, err)
+	// 80% and 60% samples
+	assert.Equal(t, 70.0, util[1]["idle.%d.total"])
+}
+
+func TestExpandCPUUtilTypes(t *testing.T) {
+	types, err := expandCPUUtilTypes([]string{"all"}, "linux")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"system", "user", "nice", "iowait", "idle", "softirq", "irq"}, types)
+
+	types, err = expandCPUUtilTypes([]string{"user", "idle"}, "linux")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"user", "idle"}, types)
+
+	_, err = expandCPUUtilTypes([]string{"all", "user"}, "linux")
+	assert.EqualError(t, err, "invalid cpu_utilisation_types value: 'all' can't be combined with other types")
+}
+
+func TestParseCPUGatheringModes(t *testing.T) {
+	loadWindows, err := parseCPULoadGatheringModes([]string{"avg1"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, loadWindows)
+
+	utilWindows, err := parseCPUUtilGatheringModes([]string{"avg5", "avg15", "avg5"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{5, 15}, utilWindows)
+
+	// utilisation is averaged by cagent itself, so windows not supported for load average are allowed
+	utilWindows, err = parseCPUUtilGatheringModes([]string{"avg30"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{30}, utilWindows)
+
+	loadWindows, err = parseCPULoadGatheringModes([]string{"avg1", "avg30"})
+	assert.EqualError(t, err, "invalid cpu_load_data_gathering_mode value 'avg30'. Supported values: avg1, avg5, avg15")
+	assert.Equal(t, []int{1}, loadWindows)
+
+	_, err = parseCPUUtilGatheringModes([]string{"5min"})
+	assert.EqualError(t, err, "invalid cpu_utilisation_gathering_mode value '5min': must be in format avgN")
+
+	_, err = parseCPUUtilGatheringModes([]string{"avg0"})
+	assert.EqualError(t, err, "invalid cpu_utilisation_gathering_mode value 'avg0': period must be a positive number of minutes")
+}
+
+func TestThresholdNotifierHysteresis(t *testing.T) {
+	// idle utilisation hovering around the threshold of 10%
+	series := []float64{5, 15, 5, 5, 15, 5, 15, 9, 8, 7, 15, 5}
+
+	countTriggers := func(triggerSamples int) int {
+		cw := CPUWatcher{}
+		cw.UtilAvg.SetDurationsMinutes(1)
+		err := cw.AddThresholdNotifier(10, "idle", "lt", "avg1", triggerSamples, make(chan float64))
+		assert.NoError(t, err)
+
+		triggers := 0
+		for _, val := range series {
+			if cw.ThresholdNotifiers[0].observe(val) {
+				triggers++
+			}
+		}
+		return triggers
+	}
+
+	// every sample below the threshold triggers the analysis without hysteresis
+	assert.Equal(t, 8, countTriggers(1))
+	// only the run of 3 consecutive samples below the threshold triggers it
+	assert.Equal(t, 1, countTriggers(3))
+
+	err := (&CPUWatcher{}).AddThresholdNotifier(10, "idle", "lt", "avg1", 0, make(chan float64))
+	assert.Error(t, err)
+}
+
+func TestTimeSeriesAveragePercentageClockStepBack(t *testing.T) {
+	cw := CPUWatcher{}
+	tsa := &cw.UtilAvg
+	tsa.SetDurationsMinutes(1)
+
+	now := time.Now()
+	for i := 0; i < 6; i++ {
+		tsa.Add(now.Add(time.Duration(i-6)*measureInterval), ValuesMap{"idle.%d.total": float64(100 + i*5)})
+	}
+	// clock stepped back by NTP before the last sample
+	tsa.Add(now.Add(-2*time.Minute), ValuesMap{"idle.%d.total": 130})
+
+	util, err := tsa.Percentage()
+	assert.NoError(t, err)
+	assert.Equal(t, -1.0, util[1]["idle.%d.total"])
+
+	results, _ := cw.Results()
+	assert.Contains(t, results, "util.idle.1.total")
+	assert.Nil(t, results["util.idle.1.total"])
+}
+
+func TestClampedUtilPercentage(t *testing.T) {
+	assert.Equal(t, 50.0, clampedUtilPercentage("idle.%d.total", 5, 10))
+	assert.Equal(t, 100.0, clampedUtilPercentage("idle.%d.total", 12, 10))
+	assert.Equal(t, 0.0, clampedUtilPercentage("idle.%d.total", 0, 10))
+	// counters reset
+	assert.Equal(t, -1.0, clampedUtilPercentage("idle.%d.total", -3, 10))
+	assert.Equal(t, -1.0, clampedUtilPercentage("idle.%d.total", 5, 0))
+}
+
+func TestCPUWatcherEMAPercentageCounterReset(t *testing.T) {
+	cw := CPUWatcher{
+		UtilAverageType: CPUUtilAverageTypeEMA,
+		UtilEMA:         map[int]*ExponentialMovingAverage{1: {Alpha: 0.5}},
+	}
+
+	start := time.Now()
+	cw.addEMASample(TimeValue{start, ValuesMap{"idle.%d.total": 100}})
+	cw.addEMASample(TimeValue{start.Add(10 * time.Second), ValuesMap{"idle.%d.total": 108}})
+	// counters reset and the core reported more time than elapsed
+	cw.addEMASample(TimeValue{start.Add(20 * time.Second), ValuesMap{"idle.%d.total": 2}})
+	cw.addEMASample(TimeValue{start.Add(30 * time.Second), ValuesMap{"idle.%d.total": 20}})
+
+	util, err := cw.utilPercentage()
+	assert.NoError(t, err)
+	assert.Equal(t, 90.0, util[1]["idle.%d.total"])
+}
+
+func TestCPUWatcherEMAWarmup(t *testing.T) {
+	cw := CPUWatcher{
+		UtilAverageType: CPUUtilAverageTypeEMA,
+		UtilEMA:         map[int]*ExponentialMovingAverage{1: {Alpha: 0.5}},
+		WarmupSamples:   2,
+	}
+
+	start := time.Now()
+	cw.addEMASample(TimeValue{start, ValuesMap{"idle.%d.total": 100}})
+	cw.addEMASample(TimeValue{start.Add(10 * time.Second), ValuesMap{"idle.%d.total": 108}})
+
+	results, err := cw.Results()
+	assert.NoError(t, err)
+	assert.Contains(t, results, "util.idle.1.total")
+	assert.Nil(t, results["util.idle.1.total"])
+
+	cw.addEMASample(TimeValue{start.Add(20 * time.Second), ValuesMap{"idle.%d.total": 114}})
+
+	results, err = cw.Results()
+	assert.NoError(t, err)
+	assert.Equal(t, 70.0, results["util.idle.1.total"])
+}
+
+func TestTimeSeriesAveragePercentageWarmup(t *testing.T) {
+	cw := CPUWatcher{}
+	tsa := &cw.UtilAvg
+	tsa.SetDurationsMinutes(1)
+	tsa.WarmupSamples = 2
+
+	now := time.Now()
+	// samples older than 1 minute, e.g. collected before the CPU queries timed out
+	for i := 0; i < 5; i++ {
+		tsa.Add(now.Add(time.Duration(-110+i*10)*time.Second), ValuesMap{"idle.%d.total": float64(100 + i*5)})
+	}
+	tsa.Add(now.Add(-20*time.Second), ValuesMap{"idle.%d.total": 130})
+	tsa.Add(now.Add(-10*time.Second), ValuesMap{"idle.%d.total": 135})
+
+	results, _ := cw.Results()
+	assert.Contains(t, results, "util.idle.1.total")
+	assert.Nil(t, results["util.idle.1.total"])
+
+	tsa.Add(now, ValuesMap{"idle.%d.total": 143})
+
+	results, _ = cw.Results()
+	assert.Equal(t, 65.0, results["util.idle.1.total"])
+}
+
+func TestCPUWatcherLoadAvgPerCore(t *testing.T) {
+	cw := CPUWatcher{LoadAvg1: true, LoadAvg5: true, LoadAvg15: true, LoadPerCore: []int{15}}
+	results := cw.loadAvgResults(&load.AvgStat{Load1: 6, Load5: 4, Load15: 2}, 4)
+
+	assert.Equal(t, common.MeasurementsMap{
+		"load.avg.1":           6.0,
+		"load.avg.5":           4.0,
+		"load.avg.15":          2.0,
+		"load.avg.15.per_core": 0.5,
+	}, results)
+}
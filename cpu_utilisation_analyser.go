@@ -41,7 +41,7 @@ func (ca *Cagent) CPUUtilisationAnalyser() *CPUUtilisationAnalyser {
 	cuan.top = top.New()
 
 	thresholdChan := make(chan float64)
-	err := ca.cpuWatcher.AddThresholdNotifier(cfg.Threshold, cfg.Metric, cfg.Function, cfg.GatheringMode, thresholdChan)
+	err := ca.cpuWatcher.AddThresholdNotifier(cfg.Threshold, cfg.Metric, cfg.Function, cfg.GatheringMode, cfg.TriggerSamples, thresholdChan)
 	if err != nil {
 		log.Error("[CPU_ANALYSIS] addThresholdNotifier error", err.Error())
 		return ca.cpuUtilisationAnalyser
@@ -0,0 +1,46 @@
+package cagent
+
+import (
+	"context"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// Categories of the startup errors which abort the startup if listed in fatal_errors
+const (
+	// FatalErrorSMARTCtl is reported if smart_monitoring is enabled but smartctl is missing or not supported
+	FatalErrorSMARTCtl = "smartctl"
+	// FatalErrorHubUnreachable is reported if the Hub connection test fails at startup
+	FatalErrorHubUnreachable = "hub_unreachable"
+	// FatalErrorWindowsPerfCounters is reported if some of windows_perf_counters can't be added
+	FatalErrorWindowsPerfCounters = "windows_perf_counters"
+)
+
+var FatalErrorCategories = []string{FatalErrorSMARTCtl, FatalErrorHubUnreachable, FatalErrorWindowsPerfCounters}
+
+// handleStartupError returns the error if its category is listed in fatal_errors, so the startup is aborted.
+// Otherwise the error is logged and nil is returned
+func (ca *Cagent) handleStartupError(category string, err error) error {
+	if err == nil {
+		return nil
+	}
+
+	if common.StrInSlice(category, ca.Config.FatalErrors) {
+		return errors.Wrapf(err, "%s error is fatal according to fatal_errors", category)
+	}
+
+	logrus.Error(err.Error())
+	return nil
+}
+
+// checkHubReachable tests the Hub connection at startup. The test is done only if hub_unreachable is listed in fatal_errors
+func (ca *Cagent) checkHubReachable() error {
+	if !common.StrInSlice(FatalErrorHubUnreachable, ca.Config.FatalErrors) || ca.Config.IOMode != IOModeHTTP {
+		return nil
+	}
+
+	return ca.handleStartupError(FatalErrorHubUnreachable, ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password"))
+}
@@ -0,0 +1,39 @@
+package cagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewFatalErrors(t *testing.T) {
+	cfg := NewConfig()
+	cfg.OperationMode = OperationModeMinimal
+	cfg.SMARTMonitoring = true
+	cfg.SMARTCtl = "/not-existing/smartctl"
+
+	// smartctl is not fatal by default
+	ca, err := New(cfg, "")
+	require.NoError(t, err)
+	ca.Shutdown()
+
+	cfg.FatalErrors = []string{FatalErrorWindowsPerfCounters}
+	ca, err = New(cfg, "")
+	require.NoError(t, err, "unlisted errors are logged only")
+	ca.Shutdown()
+
+	cfg.FatalErrors = []string{FatalErrorSMARTCtl}
+	_, err = New(cfg, "")
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "smartctl error is fatal according to fatal_errors")
+}
+
+func TestValidateFatalErrors(t *testing.T) {
+	cfg := NewConfig()
+	cfg.FatalErrors = []string{FatalErrorSMARTCtl, FatalErrorHubUnreachable}
+	assert.NoError(t, cfg.validate())
+
+	cfg.FatalErrors = []string{"smart"}
+	assert.EqualError(t, cfg.validate(), "invalid fatal_errors value 'smart' supplied. Must be one of [smartctl hub_unreachable windows_perf_counters]")
+}
@@ -1,7 +1,9 @@
 package cagent
 
 import (
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/fs"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/lvm"
 )
 
 func (ca *Cagent) GetFileSystemWatcher() *fs.FileSystemWatcher {
@@ -12,8 +14,24 @@ func (ca *Cagent) GetFileSystemWatcher() *fs.FileSystemWatcher {
 			PathExcludeRecurse:          ca.Config.FSPathExcludeRecurse,
 			Metrics:                     ca.Config.FSMetrics,
 			IdentifyMountpointsByDevice: ca.Config.FSIdentifyMountpointsByDevice,
+			FillThresholds: fs.FillThresholds{
+				WarningPercent:  ca.Config.FSFillWarningPercent,
+				CriticalPercent: ca.Config.FSFillCriticalPercent,
+			},
+			FillThresholdsPerPath: ca.Config.FSFillThresholds,
+			AlwaysIncludeRoot:     ca.Config.FSAlwaysIncludeRoot,
+			StatTimeout:           secToDuration(ca.Config.FSStatTimeout),
+			ReadOnlyExpected:      ca.Config.FSReadOnlyExpected,
 		})
 	}
 
 	return ca.fsWatcher
 }
+
+func (ca *Cagent) LVMResults() (common.MeasurementsMap, error) {
+	if ca.lvm == nil {
+		ca.lvm = lvm.New(common.Invoke{StdoutOnly: true})
+	}
+
+	return ca.lvm.GetMeasurements()
+}
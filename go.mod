@@ -11,6 +11,7 @@ require (
 	github.com/gentlemanautomaton/winguid v0.0.0-20190307223039-3f364f74ee74 // indirect
 	github.com/go-ole/go-ole v1.2.4
 	github.com/go-sql-driver/mysql v1.5.0
+	github.com/gosnmp/gosnmp v1.32.0
 	github.com/jaypipes/ghw v0.7.0
 	github.com/kardianos/service v1.0.1-0.20190622144052-5da1f538b7fe
 	github.com/konsorten/go-windows-terminal-sequences v1.0.2 // indirect
@@ -23,11 +24,13 @@ require (
 	github.com/shirou/gopsutil v2.18.13-0.20190131151121-071446942108+incompatible
 	github.com/shirou/w32 v0.0.0-20160930032740-bb4de0191aa4
 	github.com/sirupsen/logrus v1.5.0
-	github.com/stretchr/testify v1.2.2
+	github.com/stretchr/testify v1.7.0
 	github.com/troian/toml v0.4.2
 	github.com/vcraescu/go-xrandr v0.0.0-20190102070802-135ba5f1bc04
-	golang.org/x/sys v0.0.0-20191024073052-e66fe6eb8e0c
+	golang.org/x/crypto v0.0.0-20201221181555-eec23a3978ad
+	golang.org/x/sys v0.0.0-20191026070338-33540a1f6037
 	gopkg.in/Knetic/govaluate.v3 v3.0.0 // indirect
+	gopkg.in/natefinch/lumberjack.v2 v2.2.1
 	gopkg.in/toast.v1 v1.0.0-20180812000517-0a84660828b2
 	howett.net/plist v0.0.0-20201203080718-1454fab16a06
 )
@@ -1,11 +1,14 @@
 package cagent
 
 import (
+	"compress/gzip"
 	"context"
+	"crypto/sha256"
 	"encoding/json"
 	"net/http"
 	"os"
 	"runtime"
+	"strings"
 	"time"
 
 	"github.com/cloudradar-monitoring/selfupdate"
@@ -16,12 +19,23 @@ import (
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
 	"github.com/cloudradar-monitoring/cagent/pkg/hwinfo"
 	"github.com/cloudradar-monitoring/cagent/pkg/jobmon"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/blockdev"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/cgroups"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/containers"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/dns"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/docker"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/edac"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/httpcheck"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/jmx"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/networking"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/ntp"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/numa"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/processes"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/sensors"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/services"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/snmp"
 	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/updates"
+	"github.com/cloudradar-monitoring/cagent/pkg/remote"
 )
 
 type Cleaner interface {
@@ -44,6 +58,13 @@ var (
 	ErrHubUnauthorized = errors.New("Hub replied with a 401 error code")
 )
 
+// exit codes of the check operation mode, follow the Nagios plugins convention
+const (
+	CheckResultOK       = 0
+	CheckResultCritical = 2
+	CheckResultUnknown  = 3
+)
+
 func (c *cleanupCommand) AddStep(f func() error) {
 	c.steps = append(c.steps, f)
 }
@@ -64,26 +85,46 @@ func (ca *Cagent) Run(outputFile *os.File, interrupt chan struct{}) {
 		}
 	}()
 
+	if !ca.waitForStart(interrupt) {
+		return
+	}
+
 	retries := 0
-	retryIn := secToDuration(ca.Config.Interval)
+	var interval float64
+	var retryIn time.Duration
 	var firstRetry time.Time
 	var measurements common.MeasurementsMap
 	var cleaner Cleaner
+	var paused bool
 
 	for {
+		var err error
 		if retries == 0 {
-			log.Debug("Run: collectMeasurements")
-			measurements, cleaner = ca.collectMeasurements(ca.Config.OperationMode == OperationModeFull)
+			// interval_schedule is evaluated at the beginning of every cycle
+			now := time.Now()
+			interval = ca.Config.EffectiveInterval(now)
+			retryIn = secToDuration(interval)
+
+			operationMode := ca.Config.EffectiveOperationMode(now)
+			paused = operationMode == OperationModeHeartbeat
+			if paused {
+				log.Debug("Run: only the heartbeat is sent according to interval_schedule")
+			} else {
+				log.Debug("Run: collectMeasurements")
+				measurements, cleaner = ca.collectMeasurements(operationMode == OperationModeFull)
+			}
 		}
-		err := ca.reportMeasurements(measurements, outputFile)
-		if err == nil {
-			err = cleaner.Cleanup()
+		if !paused {
+			err = ca.reportMeasurements(measurements, outputFile)
+			if err == nil {
+				err = cleaner.Cleanup()
+			}
 		}
 
 		if err != nil {
 			if err == ErrHubTooManyRequests {
-				// for error code 429, wait 10 seconds and try again
-				retryIn = 10 * time.Second
+				// for error code 429, wait as long as the Hub requested and try again
+				retryIn = ca.hubRetryIn()
 				log.Infof("Run: HTTP 429, too many requests, retrying in %v", retryIn)
 			} else if err == ErrHubUnauthorized {
 				// increase sleep time by 30 seconds until it is 1 hour
@@ -102,11 +143,11 @@ func (ca *Cagent) Run(outputFile *os.File, interrupt chan struct{}) {
 				retries++
 				if retries > ca.Config.OnHTTP5xxRetries {
 					retries = 0
-					retryIn = secToDuration(ca.Config.Interval) - time.Since(firstRetry)
+					retryIn = secToDuration(interval) - time.Since(firstRetry)
 					if retryIn < 0 {
 						retryIn = 0
 					}
-					log.Errorf("Run: hub connection error, next run in %v s (out of %v s)", retryIn, secToDuration(ca.Config.Interval))
+					log.Errorf("Run: hub connection error, next run in %v s (out of %v s)", retryIn, secToDuration(interval))
 				} else {
 					log.Infof("Run: hub connection error %d/%d, retrying in %v s", retries, ca.Config.OnHTTP5xxRetries, ca.Config.OnHTTP5xxRetryInterval)
 				}
@@ -133,143 +174,418 @@ func (ca *Cagent) RunOnce(outputFile *os.File, fullMode bool) error {
 	return err
 }
 
+// RunCheck performs all checks once, reports the results and returns the code to exit with.
+// Reporting error results in CheckResultUnknown unless any critical threshold is breached
+func (ca *Cagent) RunCheck(outputFile *os.File) (int, error) {
+	measurements, cleaner := ca.collectMeasurements(true)
+	err := ca.reportMeasurements(measurements, outputFile)
+	if err == nil {
+		err = cleaner.Cleanup()
+	}
+
+	code := checkResultCode(measurements)
+	if err != nil && code == CheckResultOK {
+		code = CheckResultUnknown
+	}
+
+	return code, err
+}
+
+func checkResultCode(measurements common.MeasurementsMap) int {
+	reasons := criticalHealthReasons(measurements)
+	if len(reasons) > 0 {
+		log.Errorf("critical thresholds breached: %s", strings.Join(reasons, "; "))
+		return CheckResultCritical
+	}
+
+	return CheckResultOK
+}
+
 func (ca *Cagent) collectMeasurements(fullMode bool) (common.MeasurementsMap, Cleaner) {
+	if ca.mockMetrics != nil {
+		return ca.mockMetrics.Next(), &cleanupCommand{}
+	}
+
 	var errCollector = common.ErrorCollector{}
 	var cleanupCommand = &cleanupCommand{}
 	var measurements = make(common.MeasurementsMap)
 	var cfg = ca.Config
 
+	// collectors which may stall on external commands or hardware are run till the collection deadline
+	var deadline time.Time
+	if cfg.CollectionDeadline > 0 {
+		now := time.Now()
+		deadline = now.Add(secToDuration(cfg.EffectiveInterval(now) * cfg.CollectionDeadline))
+	}
+	// merge reports the keys which were already emitted by another collector, their values are overwritten.
+	// The same collision is reported only once, it would repeat every cycle otherwise
+	merge := func(name string, res common.MeasurementsMap, prefix string) {
+		if conflicts := measurements.Merge(res, prefix); len(conflicts) > 0 {
+			common.LogOncef(log.WarnLevel, "%d measurements of collector '%s' overwrite the ones of other collectors: %s", len(conflicts), name, strings.Join(conflicts, ", "))
+		}
+	}
+	// collectorErrors are reported as errors.<collector> with include_errors
+	collectorErrors := make(map[string][]string)
+	addError := func(name string, err error) {
+		errCollector.Add(err)
+		if err != nil {
+			collectorErrors[name] = append(collectorErrors[name], err.Error())
+		}
+	}
+	collectedAt := make(map[string]time.Time)
+	collect := func(name string, f collectorFunc) {
+		res, err := ca.runCollector(name, deadline, f)
+		addError(name, err)
+		merge(name, res, "")
+		if at := ca.collectors.CollectedAt(name); !at.IsZero() {
+			collectedAt[name] = at
+		}
+	}
+
 	if ca.Config.CPUMonitoring {
+		startedAt := time.Now()
 		cpum, err := ca.CPUWatcher().Results()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("cpu.", cpum)
+		ca.recordSelfTest("cpu", cpum, err, time.Since(startedAt))
+		addError("cpu", err)
+		merge("cpu", cpum, "cpu.")
 	}
 
 	if ca.Config.FSMonitoring {
-		fsResults, err := ca.GetFileSystemWatcher().Results()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("fs.", fsResults)
+		collect("fs", func(ctx context.Context) (common.MeasurementsMap, error) {
+			fsResults, err := ca.GetFileSystemWatcher().Results()
+			return common.MeasurementsMap{}.AddWithPrefix("fs.", fsResults), err
+		})
 	}
 
 	var memStat *mem.VirtualMemoryStat
 	if ca.Config.MemMonitoring {
 		var mem common.MeasurementsMap
 		var err error
+		startedAt := time.Now()
 		mem, memStat, err = ca.MemResults()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("mem.", mem)
+		ca.recordSelfTest("mem", mem, err, time.Since(startedAt))
+		addError("mem", err)
+		merge("mem", mem, "mem.")
 	}
 
 	if ca.Config.CPUMonitoring {
 		cpuUtilisationAnalysisResult, cpuUtilisationAnalysisIsActive, err := ca.CPUUtilisationAnalyser().Results()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("cpu_utilisation_analysis.", cpuUtilisationAnalysisResult)
+		addError("cpu_utilisation_analysis", err)
+		merge("cpu_utilisation_analysis", cpuUtilisationAnalysisResult, "cpu_utilisation_analysis.")
 		if cpuUtilisationAnalysisIsActive {
-			measurements = measurements.AddWithPrefix(
-				"cpu_utilisation_analysis.",
-				common.MeasurementsMap{"settings": cfg.CPUUtilisationAnalysis},
-			)
+			merge("cpu_utilisation_analysis", common.MeasurementsMap{"settings": cfg.CPUUtilisationAnalysis}, "cpu_utilisation_analysis.")
 		}
 	}
 
 	if fullMode {
-		info, err := ca.HostInfoResults()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("system.", info)
+		collect("system", func(ctx context.Context) (common.MeasurementsMap, error) {
+			errs := common.ErrorCollector{}
+			res := common.MeasurementsMap{}
 
-		ipResults, err := networking.IPAddresses()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("system.", ipResults)
+			info, err := ca.HostInfoResults()
+			errs.Add(err)
+			res = res.AddWithPrefix("system.", info)
+
+			ipResults, err := networking.IPAddresses()
+			errs.Add(err)
+			res = res.AddWithPrefix("system.", ipResults)
+
+			return res, errs.Combine()
+		})
+
+		if ca.Config.FSMonitoring && ca.Config.LVMMonitoring {
+			collect("lvm", func(ctx context.Context) (common.MeasurementsMap, error) {
+				lvmResults, err := ca.LVMResults()
+				return common.MeasurementsMap{}.AddWithPrefix("lvm.", lvmResults), err
+			})
+		}
 
 		if ca.Config.NetMonitoring {
-			netResults, err := ca.GetNetworkWatcher().Results()
-			errCollector.Add(err)
-			measurements = measurements.AddWithPrefix("net.", netResults)
+			collect("net", func(ctx context.Context) (common.MeasurementsMap, error) {
+				netResults, err := ca.GetNetworkWatcher().Results()
+				return common.MeasurementsMap{}.AddWithPrefix("net.", netResults), err
+			})
 		}
 
-		proc, processList, err := processes.GetMeasurements(memStat, &ca.Config.ProcessMonitoring)
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("proc.", proc)
+		collect("proc", func(ctx context.Context) (common.MeasurementsMap, error) {
+			errs := common.ErrorCollector{}
+			res := common.MeasurementsMap{}
 
-		ports, err := ca.PortsResult(processList)
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("listeningports.", ports)
+			proc, processList, err := processes.GetMeasurements(memStat, &ca.Config.ProcessMonitoring)
+			errs.Add(err)
+			res = res.AddWithPrefix("proc.", proc)
+
+			ports, err := ca.PortsResult(processList)
+			errs.Add(err)
+			res = res.AddWithPrefix("listeningports.", ports)
+
+			return res, errs.Combine()
+		})
 
 		if ca.Config.MemMonitoring {
 			swap, err := ca.SwapResults()
-			errCollector.Add(err)
-			measurements = measurements.AddWithPrefix("swap.", swap)
+			addError("swap", err)
+			merge("swap", swap, "swap.")
+
+			collect("edac", func(ctx context.Context) (common.MeasurementsMap, error) {
+				edacResults, err := edac.GetMeasurements()
+				return common.MeasurementsMap{}.AddWithPrefix("edac.", edacResults), err
+			})
+
+			if cfg.NUMAMonitoring {
+				collect("numa", func(ctx context.Context) (common.MeasurementsMap, error) {
+					numaResults, err := numa.GetMeasurements()
+					return common.MeasurementsMap{}.AddWithPrefix("numa.", numaResults), err
+				})
+			}
 		}
 
-		ca.getVMStatMeasurements(func(name string, meas common.MeasurementsMap, err error) {
-			if err == nil {
-				measurements = measurements.AddWithPrefix("virt."+name+".", meas)
-			}
-			errCollector.Add(err)
-		})
+		collect("virt", func(ctx context.Context) (common.MeasurementsMap, error) {
+			errs := common.ErrorCollector{}
+			res := common.MeasurementsMap{}
+			ca.getVMStatMeasurements(func(name string, meas common.MeasurementsMap, err error) {
+				if err == nil {
+					res = res.AddWithPrefix("virt."+name+".", meas)
+				}
+				errs.Add(err)
+			})
 
-		ca.hwInventory.Do(func() {
-			hwInfo, err := hwinfo.Inventory()
-			errCollector.Add(err)
-			if hwInfo != nil {
-				measurements = measurements.AddInnerWithPrefix("hw.inventory", hwInfo)
-			}
+			return res, errs.Combine()
 		})
 
+		if cfg.DiskMonitoring {
+			collect("disk", func(ctx context.Context) (common.MeasurementsMap, error) {
+				if ca.blockdevWatcher == nil {
+					ca.blockdevWatcher = blockdev.NewWatcher()
+				}
+				diskResults, err := ca.blockdevWatcher.Results()
+				return common.MeasurementsMap{}.AddWithPrefix("disk.", diskResults), err
+			})
+		}
+
+		if cfg.HardwareInventory {
+			collect("hw.inventory", func(ctx context.Context) (common.MeasurementsMap, error) {
+				var res common.MeasurementsMap
+				var err error
+				ca.hwInventory.Do(func() {
+					var hwInfo map[string]interface{}
+					hwInfo, err = hwinfo.Inventory(cfg.HardwareInventoryTypes, cfg.DmidecodeSections, ca.hardwareCommandInvoker())
+					if hwInfo != nil {
+						res = common.MeasurementsMap{}.AddInnerWithPrefix("hw.inventory", hwInfo)
+					}
+				})
+
+				return res, err
+			})
+		}
+
 		if cfg.SystemUpdatesChecks.Enabled && cfg.SystemUpdatesChecks.CheckInterval > 0 {
-			watcher := updates.GetWatcher(cfg.SystemUpdatesChecks.FetchTimeout, cfg.SystemUpdatesChecks.CheckInterval)
-			u, err := watcher.GetSystemUpdatesInfo()
-			if err != updates.ErrorDisabledOnHost {
-				errCollector.Add(err)
+			collect("updates", func(ctx context.Context) (common.MeasurementsMap, error) {
+				if ca.updatesWatcher == nil {
+					ca.updatesWatcher = updates.GetWatcher(cfg.SystemUpdatesChecks.FetchTimeout, cfg.SystemUpdatesChecks.CheckInterval)
+				}
+				u, err := ca.updatesWatcher.GetSystemUpdatesInfo()
+				if err == updates.ErrorDisabledOnHost {
+					return nil, nil
+				}
+
 				var prefix string
 				if runtime.GOOS == "windows" {
 					prefix = "windows_update."
 				} else {
 					prefix = "linux_update."
 				}
-				measurements = measurements.AddWithPrefix(prefix, u)
+				return common.MeasurementsMap{}.AddWithPrefix(prefix, u), err
+			})
+		}
+
+		collect("services", func(ctx context.Context) (common.MeasurementsMap, error) {
+			errs := common.ErrorCollector{}
+			res := common.MeasurementsMap{}
+
+			servicesList, err := services.ListServices(cfg.DiscoverAutostartingServicesOnly)
+			if err != services.ErrorNotImplementedForOS {
+				errs.Add(err)
 			}
+			res = res.AddWithPrefix("services.", servicesList)
+
+			if len(cfg.ServicesTrackRestarts) > 0 {
+				pids, err := services.ServicePIDs(cfg.ServicesTrackRestarts)
+				if err != services.ErrorNotImplementedForOS {
+					errs.Add(err)
+				}
+				if ca.serviceRestarts == nil {
+					ca.serviceRestarts = services.NewRestartTracker()
+				}
+				res = res.AddWithPrefix("service.", ca.serviceRestarts.Update(pids))
+			}
+
+			if cfg.ServicesOpenFDs {
+				if names := services.RunningSystemdServices(servicesList); len(names) > 0 {
+					pids, err := services.ServicePIDs(names)
+					if err == nil {
+						var openFDs common.MeasurementsMap
+						openFDs, err = services.OpenFDs(pids)
+						res = res.AddWithPrefix("service.", openFDs)
+					}
+					if err != services.ErrorNotImplementedForOS {
+						errs.Add(err)
+					}
+				}
+			}
+
+			return res, errs.Combine()
+		})
+
+		if cfg.CgroupMonitoring.Enabled {
+			collect("cgroup", func(ctx context.Context) (common.MeasurementsMap, error) {
+				if ca.cgroupWatcher == nil {
+					ca.cgroupWatcher = cgroups.NewWatcher(cfg.CgroupMonitoring.Path, cfg.CgroupMonitoring.Cgroups)
+				}
+				cgroupResults, err := ca.cgroupWatcher.Results()
+				return common.MeasurementsMap{}.AddWithPrefix("cgroup.", cgroupResults), err
+			})
 		}
 
-		servicesList, err := services.ListServices(cfg.DiscoverAutostartingServicesOnly)
-		if err != services.ErrorNotImplementedForOS {
-			errCollector.Add(err)
+		if len(cfg.DNSCheck.Hosts) > 0 {
+			collect("dns", func(ctx context.Context) (common.MeasurementsMap, error) {
+				if ca.dnsChecker == nil {
+					ca.dnsChecker = dns.NewChecker(cfg.DNSCheck.Hosts, secToDuration(cfg.DNSCheck.Timeout), secToDuration(cfg.DNSCheck.Interval))
+				}
+				return common.MeasurementsMap{}.AddWithPrefix("dns.", ca.dnsChecker.Results(ctx)), nil
+			})
+		}
+
+		if cfg.SystemdFailedUnits {
+			collect("systemd", func(ctx context.Context) (common.MeasurementsMap, error) {
+				failedUnits, err := services.FailedSystemdUnits()
+				if err == services.ErrorNotImplementedForOS {
+					err = nil
+				}
+				return common.MeasurementsMap{}.AddWithPrefix("systemd.", failedUnits), err
+			})
 		}
-		measurements = measurements.AddWithPrefix("services.", servicesList)
 
 		if cfg.DockerMonitoring.Enabled {
-			containersList, err := docker.ListContainers()
-			if err != docker.ErrorNotImplementedForOS && err != docker.ErrorDockerNotAvailable {
-				errCollector.Add(err)
-			}
-			measurements = measurements.AddWithPrefix("docker.", containersList)
+			collect("docker", func(ctx context.Context) (common.MeasurementsMap, error) {
+				containersList, err := docker.ListContainers()
+				if err == docker.ErrorNotImplementedForOS || err == docker.ErrorDockerNotAvailable {
+					err = nil
+				}
+				return common.MeasurementsMap{}.AddWithPrefix("docker.", containersList), err
+			})
+		}
+
+		if cfg.ContainersMonitoring.Enabled {
+			collect("containers", func(ctx context.Context) (common.MeasurementsMap, error) {
+				return common.MeasurementsMap{}.AddWithPrefix("containers.", containers.GetMeasurements(cfg.ContainersMonitoring.Socket)), nil
+			})
 		}
 
 		if cfg.TemperatureMonitoring {
-			temperatures, err := sensors.ReadTemperatureSensors()
-			errCollector.Add(err)
-			measurements = measurements.AddWithPrefix("temperatures.", common.MeasurementsMap{"list": temperatures})
+			collect("temperatures", func(ctx context.Context) (common.MeasurementsMap, error) {
+				temperatures, err := sensors.ReadTemperatureSensors()
+				return common.MeasurementsMap{"temperatures.list": temperatures}, err
+			})
+
+			collect("throttle", func(ctx context.Context) (common.MeasurementsMap, error) {
+				if ca.throttleWatcher == nil {
+					ca.throttleWatcher = sensors.NewThrottleWatcher()
+				}
+				throttleResults, err := ca.throttleWatcher.Results()
+				return common.MeasurementsMap{}.AddWithPrefix("cpu.", throttleResults), err
+			})
 		}
 
-		moduleReports, err := ca.collectModulesMeasurements()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("", common.MeasurementsMap{"modules": moduleReports})
+		if cfg.FanMonitoring {
+			collect("fan", func(ctx context.Context) (common.MeasurementsMap, error) {
+				fanResults, err := sensors.ReadFanSpeeds(cfg.FanStallTemperature)
+				return common.MeasurementsMap{}.AddWithPrefix("fan.", fanResults), err
+			})
+		}
 
-		smartMeas := ca.getSMARTMeasurements()
-		if len(smartMeas) > 0 {
-			measurements = measurements.AddInnerWithPrefix("smartmon", smartMeas)
+		if ca.perfCounters != nil {
+			collect("perfcounter", func(ctx context.Context) (common.MeasurementsMap, error) {
+				perfResults, err := ca.perfCounters.GetMeasurements()
+				return common.MeasurementsMap{}.AddWithPrefix("perfcounter.", perfResults), err
+			})
+		}
+
+		collect("time", func(ctx context.Context) (common.MeasurementsMap, error) {
+			syncThreshold := time.Duration(cfg.NTPSyncThresholdMs * float64(time.Millisecond))
+			return common.MeasurementsMap{}.AddWithPrefix("time.", ntp.GetMeasurements(cfg.NTPServers, syncThreshold)), nil
+		})
+
+		collect("modules", func(ctx context.Context) (common.MeasurementsMap, error) {
+			moduleReports, err := ca.collectModulesMeasurements()
+			return common.MeasurementsMap{"modules": moduleReports}, err
+		})
+
+		collect("smartmon", func(ctx context.Context) (common.MeasurementsMap, error) {
+			smartMeas := ca.getSMARTMeasurements()
+			if len(smartMeas) == 0 {
+				return nil, nil
+			}
+			return common.MeasurementsMap{}.AddInnerWithPrefix("smartmon", smartMeas), nil
+		})
+
+		if len(cfg.RemoteTargets) > 0 {
+			collect("remote", func(ctx context.Context) (common.MeasurementsMap, error) {
+				hosts, err := remote.CollectTargets(cfg.RemoteTargets)
+				return common.MeasurementsMap{}.AddInnerWithPrefix("remote", hosts), err
+			})
+		}
+
+		if len(cfg.SNMPTargets) > 0 {
+			collect("snmp", func(ctx context.Context) (common.MeasurementsMap, error) {
+				devices, err := snmp.CollectTargets(ctx, cfg.SNMPTargets)
+				return common.MeasurementsMap{}.AddWithPrefix("snmp.", devices), err
+			})
+		}
+
+		if len(cfg.HTTPChecks) > 0 {
+			collect("httpcheck", func(ctx context.Context) (common.MeasurementsMap, error) {
+				return common.MeasurementsMap{}.AddWithPrefix("httpcheck.", httpcheck.RunChecks(ctx, cfg.HTTPChecks)), nil
+			})
+		}
+
+		if len(cfg.JMXTargets) > 0 {
+			collect("jmx", func(ctx context.Context) (common.MeasurementsMap, error) {
+				jvms, err := jmx.CollectTargets(ctx, cfg.JMXTargets)
+				return common.MeasurementsMap{}.AddWithPrefix("jmx.", jvms), err
+			})
 		}
 
 		spool := jobmon.NewSpoolManager(cfg.JobMonitoring.SpoolDirPath, log.StandardLogger())
 		ids, jobs, err := spool.GetFinishedJobs()
-		errCollector.Add(err)
-		measurements = measurements.AddWithPrefix("", common.MeasurementsMap{"jobmon": jobs})
+		addError("jobmon", err)
+		merge("jobmon", common.MeasurementsMap{"jobmon": jobs}, "")
 		cleanupCommand.AddStep(func() error {
 			return spool.RemoveJobs(ids)
 		})
 	}
 
-	measurements["operation_mode"] = cfg.OperationMode
+	if cfg.SelfMonitoring {
+		collect("self", func(ctx context.Context) (common.MeasurementsMap, error) {
+			selfResults, err := ca.SelfResults()
+			return common.MeasurementsMap{}.AddWithPrefix("self.", selfResults), err
+		})
+	}
+
+	measurements["operation_mode"] = cfg.EffectiveOperationMode(time.Now())
+
+	maintenance := cfg.InMaintenance(time.Now())
+	measurements["agent.maintenance"] = maintenance
+	measurements["agent.config_hash"] = ca.configHash
+	if maintenance {
+		suppressAlerts(measurements)
+	}
+
+	measurements = measurements.AddWithPrefix("", agentHealthMeasurements(measurements, errCollector.Combine()))
+
+	if cfg.IncludeErrors {
+		measurements = measurements.AddWithPrefix("errors.", collectorErrorMeasurements(collectorErrors))
+	}
 
 	if errCollector.HasErrors() {
 		measurements["message"] = errCollector.Combine()
@@ -278,19 +594,62 @@ func (ca *Cagent) collectMeasurements(fullMode bool) (common.MeasurementsMap, Cl
 		measurements["cagent.success"] = 1
 	}
 
-	return measurements, cleanupCommand
+	ca.collectedAt = collectedAt
+
+	return measurements.Round(cfg.MetricPrecision), cleanupCommand
 }
 
 func (ca *Cagent) reportMeasurements(measurements common.MeasurementsMap, outputFile *os.File) error {
+	measurements = ca.applyTransforms(measurements)
+	unsuppressed := measurements
+	if ca.Config.SuppressZeroRates {
+		measurements = measurements.WithoutZeroRates()
+	}
+	var meta map[string]common.MetricMetadata
+	if ca.Config.IncludeMetadata && !ca.metadataSent {
+		// the metrics are recognized by their keys without metric_prefix
+		meta = prefixMetadata(measurements.Metadata(), ca.Config.MetricPrefix)
+	}
+	measurements = prefixMeasurements(measurements, ca.Config.MetricPrefix)
+
+	now := time.Now()
 	result := &Result{
-		Timestamp:    time.Now().Unix(),
+		Timestamp:    now.Unix(),
 		Measurements: measurements,
+		Meta:         meta,
 	}
-	if outputFile != nil {
-		err := json.NewEncoder(outputFile).Encode(result)
+	if ca.Config.CollectorTimestamps && len(ca.collectedAt) > 0 {
+		result.CollectedAt = make(map[string]interface{}, len(ca.collectedAt))
+		for name, at := range ca.collectedAt {
+			result.CollectedAt[name] = at.Unix()
+		}
+	}
+
+	if outputFile != nil || ca.socketOutput != nil {
+		timestamp, err := ca.Config.FormatOutTimestamp(now)
+		if err != nil {
+			return errors.Wrap(err, "failed to format measurement result timestamp")
+		}
+		result.Timestamp = timestamp
+		for name := range result.CollectedAt {
+			result.CollectedAt[name], err = ca.Config.FormatOutTimestamp(ca.collectedAt[name])
+			if err != nil {
+				return errors.Wrap(err, "failed to format collection timestamp")
+			}
+		}
+		if ca.Config.OutJSONNesting == JSONNestingNested {
+			result.Measurements = nestMeasurements(measurements)
+		}
+
+		if outputFile != nil {
+			err = ca.writeResultToFile(outputFile, result)
+		} else {
+			err = ca.socketOutput.Write(result)
+		}
 		if err != nil {
-			return errors.Wrap(err, "failed to JSON encode measurement result")
+			return err
 		}
+		ca.metadataSent = ca.metadataSent || result.Meta != nil
 		return nil
 	}
 
@@ -298,25 +657,143 @@ func (ca *Cagent) reportMeasurements(measurements common.MeasurementsMap, output
 		ca.prettyPrintMeasurementsToFile(measurements, ca.Config.Logs.HubFile)
 	}
 
+	if ca.Config.HubSendChangedOnly && ca.hubLastSent != nil && !ca.hubFullRefreshDue(now) {
+		result.Measurements = measurements.ChangedSince(ca.hubLastSent)
+		result.ChangedOnly = true
+		for key, value := range result.Measurements {
+			// a rate which dropped to zero was suppressed, it isn't gone
+			if value == nil && ca.Config.SuppressZeroRates {
+				if zero, ok := unsuppressed[strings.TrimPrefix(key, ca.Config.MetricPrefix)]; ok && zero != nil {
+					result.Measurements[key] = zero
+				}
+			}
+		}
+	}
+
+	var digest [sha256.Size]byte
+	if ca.Config.HubSkipUnchanged {
+		var err error
+		digest, err = measurementsDigest(measurements)
+		if err == nil && digest == ca.hubLastSentDigest && result.Meta == nil && !ca.hubFullRefreshDue(now) {
+			log.Debug("Measurements didn't change since the last send to the Hub, sending the heartbeat instead")
+			return ca.sendHeartbeat()
+		}
+	}
+
+	if ca.Config.OutJSONNesting == JSONNestingNested && ca.Config.OutJSONNestingHub {
+		result.Measurements = nestMeasurements(result.Measurements)
+	}
+
 	ctx, cancelFn := context.WithTimeout(context.Background(), 5*time.Minute)
 	defer cancelFn()
 
-	err := ca.PostResultToHub(ctx, result)
+	err := ca.postBufferedResults(ctx)
+	if err == nil {
+		err = ca.PostResultToHub(ctx, result)
+	}
+	if err == ErrHubTooManyRequests || len(ca.hubBuffer) > 0 {
+		// the result is sent after the buffered ones to keep the order
+		ca.bufferHubResult(result)
+	}
 	if err != nil {
 		if err == ErrHubTooManyRequests || err == ErrHubServerError || err == ErrHubUnauthorized {
 			return err
 		}
 		err = errors.Wrap(err, "failed to POST measurement result to Hub")
+	} else {
+		ca.metadataSent = ca.metadataSent || result.Meta != nil
+		if ca.Config.HubSendChangedOnly {
+			ca.hubLastSent = measurements
+		}
+		if ca.Config.HubSkipUnchanged {
+			ca.hubLastSentDigest = digest
+		}
+		if !result.ChangedOnly {
+			ca.hubLastFullSentAt = now
+		}
 	}
 
 	return err
 }
 
+// measurementsDigest returns the SHA-256 of JSON encoded measurements, the keys are encoded in the sorted order
+func measurementsDigest(measurements common.MeasurementsMap) ([sha256.Size]byte, error) {
+	encoded, err := json.Marshal(measurements)
+	if err != nil {
+		return [sha256.Size]byte{}, err
+	}
+
+	return sha256.Sum256(encoded), nil
+}
+
+// hubFullRefreshDue returns true if all measurements need to be sent according to hub_full_refresh_interval
+func (ca *Cagent) hubFullRefreshDue(now time.Time) bool {
+	if ca.Config.HubFullRefreshInterval <= 0 {
+		return false
+	}
+
+	return now.Sub(ca.hubLastFullSentAt) >= secToDuration(ca.Config.HubFullRefreshInterval)
+}
+
+// writeResultToFile appends JSON encoded result to the output file.
+// With out_gzip or *.gz output file every result is written as a separate gzip member,
+// so the file stays a valid gzip stream between the writes
+func (ca *Cagent) writeResultToFile(outputFile *os.File, result *Result) error {
+	if !ca.Config.OutGzip && !strings.HasSuffix(outputFile.Name(), ".gz") {
+		err := json.NewEncoder(outputFile).Encode(result)
+		return errors.Wrap(err, "failed to JSON encode measurement result")
+	}
+
+	gzipped := gzip.NewWriter(outputFile)
+	if err := json.NewEncoder(gzipped).Encode(result); err != nil {
+		return errors.Wrap(err, "failed to JSON encode measurement result")
+	}
+
+	return errors.Wrap(gzipped.Close(), "failed to finalize gzipped measurement result")
+}
+
+// prefixMeasurements prepends metric_prefix to all measurement keys
+func prefixMeasurements(measurements common.MeasurementsMap, prefix string) common.MeasurementsMap {
+	if prefix == "" {
+		return measurements
+	}
+
+	return common.MeasurementsMap{}.AddWithPrefix(prefix, measurements)
+}
+
+// prefixMetadata prepends metric_prefix to the metric keys of meta
+func prefixMetadata(meta map[string]common.MetricMetadata, prefix string) map[string]common.MetricMetadata {
+	if prefix == "" {
+		return meta
+	}
+
+	prefixed := make(map[string]common.MetricMetadata, len(meta))
+	for key, metadata := range meta {
+		prefixed[prefix+key] = metadata
+	}
+
+	return prefixed
+}
+
+// nestMeasurements expands dotted keys into nested objects according to out_json_nesting = "nested"
+func nestMeasurements(measurements common.MeasurementsMap) common.MeasurementsMap {
+	nested, err := measurements.Nest()
+	if err != nil {
+		log.Warnf("out_json_nesting: %s. Their values are reported as %s", err.Error(), common.NestedLeafKey)
+	}
+
+	return nested
+}
+
 func (ca *Cagent) RunHeartbeat(interrupt chan struct{}) {
 	if ca.Config.Updates.Enabled {
 		ca.selfUpdater = selfupdate.StartChecking()
 	}
 
+	if !ca.waitForStart(interrupt) {
+		return
+	}
+
 	var firstRetry time.Time
 	retries := 0
 	retryIn := secToDuration(ca.Config.HeartbeatInterval)
@@ -325,8 +802,8 @@ func (ca *Cagent) RunHeartbeat(interrupt chan struct{}) {
 		err := ca.sendHeartbeat()
 		if err != nil {
 			if err == ErrHubTooManyRequests {
-				// for error code 429, wait 10 seconds and try again
-				retryIn = 10 * time.Second
+				// for error code 429, wait as long as the Hub requested and try again
+				retryIn = ca.hubRetryIn()
 				log.Infof("RunHeartbeat: HTTP 429, too many requests, retrying in %v", retryIn)
 			} else if err == ErrHubUnauthorized {
 				// increase sleep time by 30 seconds until it is 1 hour
@@ -383,16 +860,22 @@ func (ca *Cagent) sendHeartbeat() error {
 		return errors.WithStack(err)
 	}
 	req.Header.Add("User-Agent", ca.userAgent())
-	if len(ca.Config.HubUser) > 0 {
-		req.SetBasicAuth(ca.Config.HubUser, ca.Config.HubPassword)
+	if err = ca.setHubAuth(ctx, req); err != nil {
+		return err
 	}
+	if ca.hubPausedFor() > 0 {
+		return ErrHubTooManyRequests
+	}
+
 	req = req.WithContext(ctx)
 	resp, err := ca.hubClient.Do(req)
 	if resp != nil {
 		if resp.StatusCode == http.StatusTooManyRequests {
+			ca.pauseHubSends(resp)
 			return ErrHubTooManyRequests
 		}
 		if resp.StatusCode == http.StatusUnauthorized {
+			ca.invalidateHubCredentials()
 			return ErrHubUnauthorized
 		}
 		if resp.StatusCode >= 500 && resp.StatusCode <= 599 {
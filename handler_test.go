@@ -1,11 +1,24 @@
 package cagent
 
 import (
+	"compress/gzip"
+	"encoding/json"
+	"fmt"
 	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
 	"os"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/fs"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/httpcheck"
+	"github.com/cloudradar-monitoring/cagent/pkg/remote"
 )
 
 func helperCreateCagent(t *testing.T) *Cagent {
@@ -37,3 +50,490 @@ func TestCagentCollectMeasurements(t *testing.T) {
 	}
 	assert.Equal(t, 1, m["cagent.success"], "msg %s", errorMsg)
 }
+
+func TestCagentCollectMeasurementsDisabledCollectors(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	// the updates watcher is process-wide and stopped by the Cagent of TestCagentCollectMeasurements
+	ca.Config.SystemUpdatesChecks.Enabled = false
+	ca.Config.NetMonitoring = false
+	ca.Config.HardwareInventory = false
+
+	m, _ := ca.collectMeasurements(true)
+	for key := range m {
+		assert.False(t, strings.HasPrefix(key, "net."), "unexpected net metric %s", key)
+		assert.False(t, strings.HasPrefix(key, "hw.inventory"), "unexpected hw.inventory metric %s", key)
+	}
+}
+
+func TestCagentRunCheckCriticalThreshold(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.OperationMode = OperationModeCheck
+	ca.Config.HardwareInventory = false
+	// any used file system breaches the critical threshold
+	ca.Config.FSFillWarningPercent = 0
+	ca.Config.FSFillCriticalPercent = 0.0001
+
+	output, err := ioutil.TempFile("", "cagent-check")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	code, err := ca.RunCheck(output)
+	assert.NoError(t, err)
+	assert.Equal(t, CheckResultCritical, code)
+
+	info, err := output.Stat()
+	assert.NoError(t, err)
+	assert.NotZero(t, info.Size())
+}
+
+func TestCheckResultCode(t *testing.T) {
+	assert.Equal(t, CheckResultOK, checkResultCode(common.MeasurementsMap{
+		"fs.fill_state./": fs.FillStateWarning,
+	}))
+	assert.Equal(t, CheckResultCritical, checkResultCode(common.MeasurementsMap{
+		"fs.fill_state./":     fs.FillStateOK,
+		"fs.fill_state./data": fs.FillStateCritical,
+	}))
+}
+
+func TestCagentReportMeasurementsMetadataOnce(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.IncludeMetadata = true
+
+	output, err := ioutil.TempFile("", "cagent-meta")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	measurements := common.MeasurementsMap{"mem.total_B": 1024}
+	assert.NoError(t, ca.reportMeasurements(measurements, output))
+	assert.NoError(t, ca.reportMeasurements(measurements, output))
+
+	data, err := ioutil.ReadFile(output.Name())
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	assert.Len(t, lines, 2)
+	assert.Contains(t, lines[0], `"meta":{"mem.total_B":{"unit":"B","kind":"gauge"}}`)
+	assert.NotContains(t, lines[1], `"meta"`)
+}
+
+func TestCagentReportMeasurementsMetadataWithMetricPrefix(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.IncludeMetadata = true
+	ca.Config.MetricPrefix = "datacenter1.web01."
+
+	output, err := ioutil.TempFile("", "cagent-meta-prefix")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "cpu.util.idle.1.total": 95.1}, output))
+
+	var result Result
+	data, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, map[string]common.MetricMetadata{
+		"datacenter1.web01.mem.total_B":           {Unit: "B", Kind: common.MetricKindGauge},
+		"datacenter1.web01.cpu.util.idle.1.total": {Unit: "%", Kind: common.MetricKindGauge},
+	}, result.Meta)
+}
+
+func TestCagentCollectMeasurementsMaintenance(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.SystemUpdatesChecks.Enabled = false
+	ca.Config.HardwareInventory = false
+	ca.Config.MaintenanceUntil = time.Now().Add(time.Hour).Format(time.RFC3339)
+	// any used file system breaches the critical threshold
+	ca.Config.FSFillWarningPercent = 0
+	ca.Config.FSFillCriticalPercent = 0.0001
+	ca.Config.FSMetrics = []string{"used_percent"}
+
+	m, _ := ca.collectMeasurements(true)
+	assert.Equal(t, true, m["agent.maintenance"])
+	assert.Equal(t, CheckResultOK, checkResultCode(m))
+
+	fillStates := 0
+	for key, value := range m {
+		if !strings.HasPrefix(key, "fs.fill_state.") {
+			continue
+		}
+		fillStates++
+		assert.Equal(t, fs.FillStateOK, value, key)
+
+		// values are reported as usual
+		assert.IsType(t, float64(0), m["fs.used_percent."+strings.TrimPrefix(key, "fs.fill_state.")], key)
+	}
+	assert.NotZero(t, fillStates)
+}
+
+func TestCagentReportMeasurementsNested(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.OutJSONNesting = JSONNestingNested
+
+	output, err := ioutil.TempFile("", "cagent-nested")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "cagent.success": 1}, output))
+
+	data, err := ioutil.ReadFile(output.Name())
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"measurements":{"cagent":{"success":1},"mem":{"total_B":1024}}`)
+}
+
+func TestCagentReportMeasurementsMetricPrefix(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.MetricPrefix = "datacenter1.web01."
+
+	output, err := ioutil.TempFile("", "cagent-prefix")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "cpu.util.idle.1.total": 95.1, "cagent.success": 1}, output))
+
+	var result Result
+	data, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &result))
+	require.Len(t, result.Measurements, 3)
+	for key := range result.Measurements {
+		assert.True(t, strings.HasPrefix(key, "datacenter1.web01."), key)
+	}
+	assert.EqualValues(t, 1024, result.Measurements["datacenter1.web01.mem.total_B"])
+}
+
+func TestCagentReportMeasurementsSuppressZeroRates(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.SuppressZeroRates = true
+
+	output, err := ioutil.TempFile("", "cagent-zero-rates")
+	assert.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{
+		"net.in_B_per_s.eth0":  float64(0),
+		"net.out_B_per_s.eth0": float64(0),
+		"net.in_B_per_s.eth1":  float64(2048),
+		"net.out_B_per_s.eth1": float64(512),
+		"mem.free_B":           uint64(0),
+	}, output))
+
+	var result Result
+	data, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, common.MeasurementsMap{
+		"net.in_B_per_s.eth1":  float64(2048),
+		"net.out_B_per_s.eth1": float64(512),
+		"mem.free_B":           float64(0),
+	}, result.Measurements)
+}
+
+func TestCagentReportMeasurementsChangedOnly(t *testing.T) {
+	var received []Result
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result Result
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&result))
+		received = append(received, result)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.HubURL = server.URL
+	ca.Config.HubGzip = false
+	ca.Config.HubSendChangedOnly = true
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "net.link_speed": nil}, nil))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "net.link_speed": nil}, nil))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048, "net.link_speed": 1000}, nil))
+
+	// full refresh
+	ca.hubLastFullSentAt = time.Now().Add(-secToDuration(ca.Config.HubFullRefreshInterval))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048, "net.link_speed": 1000}, nil))
+
+	require.Len(t, received, 4)
+	assert.False(t, received[0].ChangedOnly)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(1024), "net.link_speed": nil}, received[0].Measurements)
+	assert.True(t, received[1].ChangedOnly)
+	assert.Empty(t, received[1].Measurements)
+	assert.True(t, received[2].ChangedOnly)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(2048), "net.link_speed": float64(1000)}, received[2].Measurements)
+	assert.False(t, received[3].ChangedOnly)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(2048), "net.link_speed": float64(1000)}, received[3].Measurements)
+}
+
+func TestCagentReportMeasurementsChangedOnlyZeroRate(t *testing.T) {
+	var received []Result
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result Result
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&result))
+		received = append(received, result)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.HubURL = server.URL
+	ca.Config.HubGzip = false
+	ca.Config.HubSendChangedOnly = true
+	ca.Config.SuppressZeroRates = true
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"net.in_B_per_s.eth0": float64(2048), "mem.total_B": 1024}, nil))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"net.in_B_per_s.eth0": float64(0), "mem.total_B": 1024}, nil))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"net.in_B_per_s.eth0": float64(0), "mem.total_B": 1024}, nil))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024}, nil))
+
+	require.Len(t, received, 4)
+	assert.Equal(t, common.MeasurementsMap{"net.in_B_per_s.eth0": float64(2048), "mem.total_B": float64(1024)}, received[0].Measurements)
+	assert.Equal(t, common.MeasurementsMap{"net.in_B_per_s.eth0": float64(0)}, received[1].Measurements)
+	assert.Empty(t, received[2].Measurements)
+	assert.Empty(t, received[3].Measurements)
+}
+
+func TestCagentRunStartupDelay(t *testing.T) {
+	output, err := ioutil.TempFile("", "cagent-startup")
+	require.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	cfg := NewConfig()
+	cfg.OperationMode = OperationModeMinimal
+	cfg.StartupDelay = 0.3
+	cfg.StartupDelayRandom = 0.1
+
+	start := time.Now()
+	ca, err := New(cfg, "")
+	require.NoError(t, err)
+	defer ca.Shutdown()
+
+	interrupt := make(chan struct{})
+	defer close(interrupt)
+	go ca.Run(output, interrupt)
+
+	for {
+		data, err := ioutil.ReadFile(output.Name())
+		require.NoError(t, err)
+		if len(data) > 0 {
+			break
+		}
+		require.True(t, time.Since(start) < 10*time.Second, "first collection didn't happen")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	assert.True(t, time.Since(start) >= 300*time.Millisecond, "first collection happened before startup_delay")
+}
+
+func TestCagentReportMeasurementsGzip(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	for _, tc := range []struct {
+		name    string
+		pattern string
+		outGzip bool
+	}{
+		{"out_gzip", "cagent-out", true},
+		{"gz-suffix", "cagent-out-*.gz", false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ca.Config.OutGzip = tc.outGzip
+
+			output, err := ioutil.TempFile("", tc.pattern)
+			require.NoError(t, err)
+			defer os.Remove(output.Name())
+			defer output.Close()
+
+			require.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024}, output))
+			require.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048}, output))
+
+			f, err := os.Open(output.Name())
+			require.NoError(t, err)
+			defer f.Close()
+
+			r, err := gzip.NewReader(f)
+			require.NoError(t, err)
+			data, err := ioutil.ReadAll(r)
+			require.NoError(t, err)
+
+			lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+			require.Len(t, lines, 2)
+			for i, expected := range []float64{1024, 2048} {
+				var result Result
+				require.NoError(t, json.Unmarshal([]byte(lines[i]), &result))
+				assert.Equal(t, expected, result.Measurements["mem.total_B"])
+			}
+		})
+	}
+}
+
+func TestCagentCollectMeasurementsConfigHash(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	m, _ := ca.collectMeasurements(false)
+	hash := m["agent.config_hash"]
+	assert.Equal(t, ConfigHash(ca.Config), hash)
+
+	// changed at runtime after HTTP 401 responses of the hub, not by a config edit
+	ca.Config.Sleep += 30
+	ca.Config.Interval++
+	m, _ = ca.collectMeasurements(false)
+	assert.Equal(t, hash, m["agent.config_hash"], "hash of the loaded config must be reported")
+}
+
+func TestCagentCollectMeasurementsHungCollector(t *testing.T) {
+	release := make(chan struct{})
+	hung := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer hung.Close()
+	defer close(release)
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.SystemUpdatesChecks.Enabled = false
+	ca.Config.Interval = 1
+	ca.Config.CollectionDeadline = 0.5
+	ca.Config.HTTPChecks = []httpcheck.Check{{Name: "hung", URL: hung.URL, Timeout: 30}}
+	ca.collectors = newCollectorRunner(1, nil)
+
+	m, _ := ca.collectMeasurements(true)
+	assert.Contains(t, fmt.Sprint(m["message"]), "collector httpcheck abandoned")
+	fsCollectedAt := ca.collectors.CollectedAt("fs")
+	require.False(t, fsCollectedAt.IsZero())
+
+	// the hung collector doesn't keep the only slot, the other collectors run in the next cycle
+	startedAt := time.Now()
+	m, _ = ca.collectMeasurements(true)
+	assert.True(t, time.Since(startedAt) < time.Second, "collection cycle must finish on time")
+	assert.True(t, ca.collectors.CollectedAt("fs").After(fsCollectedAt), "fs collector must run in the next cycle")
+	assert.NotContains(t, fmt.Sprint(m["message"]), "collector fs")
+}
+
+func TestCagentReportMeasurementsCollectorTimestamps(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.CollectorTimestamps = true
+	ca.Config.OutTimestampFormat = TimestampFormatUnixMs
+	ca.collectors = newCollectorRunner(1, map[string]int{"self": 3})
+
+	_, _ = ca.collectMeasurements(false)
+	selfCollectedAt := ca.collectors.CollectedAt("self")
+	require.False(t, selfCollectedAt.IsZero())
+
+	time.Sleep(20 * time.Millisecond)
+	measurements, _ := ca.collectMeasurements(false)
+
+	output, err := ioutil.TempFile("", "cagent-collected-at")
+	require.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	require.NoError(t, ca.reportMeasurements(measurements, output))
+
+	data, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+	var result Result
+	require.NoError(t, json.Unmarshal(data, &result))
+
+	// self collector is cached and carries the time of the first collection
+	assert.Equal(t, float64(selfCollectedAt.UnixNano()/int64(time.Millisecond)), result.CollectedAt["self"])
+	require.Contains(t, result.CollectedAt, "fs")
+	assert.True(t, result.CollectedAt["fs"].(float64) > result.CollectedAt["self"].(float64))
+}
+
+func TestCagentReportMeasurementsSkipUnchanged(t *testing.T) {
+	var posted []Result
+	var heartbeats int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			heartbeats++
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		var result Result
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&result))
+		posted = append(posted, result)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.HubURL = server.URL
+	ca.Config.HubGzip = false
+	ca.Config.HubSkipUnchanged = true
+
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024, "system.uname": "Linux"}, nil))
+	require.Len(t, posted, 1)
+	assert.Equal(t, 0, heartbeats)
+
+	// unchanged
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"system.uname": "Linux", "mem.total_B": 1024}, nil))
+	assert.Len(t, posted, 1)
+	assert.Equal(t, 1, heartbeats)
+
+	// changed
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048, "system.uname": "Linux"}, nil))
+	require.Len(t, posted, 2)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(2048), "system.uname": "Linux"}, posted[1].Measurements)
+	assert.Equal(t, 1, heartbeats)
+
+	// full refresh
+	ca.hubLastFullSentAt = time.Now().Add(-secToDuration(ca.Config.HubFullRefreshInterval))
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048, "system.uname": "Linux"}, nil))
+	assert.Len(t, posted, 3)
+	assert.Equal(t, 1, heartbeats)
+}
+
+func TestCagentCollectMeasurementsIncludeErrors(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.SystemUpdatesChecks.Enabled = false
+	ca.Config.IncludeErrors = true
+	ca.Config.RemoteTargets = []remote.Target{{
+		Name:           "nas",
+		Host:           "127.0.0.1:1",
+		User:           "monitor",
+		KeyFile:        "/home/monitor/.ssh/id_not_existing",
+		KnownHostsFile: "/home/monitor/.ssh/known_hosts",
+	}}
+
+	measurements, _ := ca.collectMeasurements(true)
+	assert.Contains(t, measurements["errors.remote"], "remote nas: failed to read key_file: open .../id_not_existing: ")
+
+	ca.Config.IncludeErrors = false
+	measurements, _ = ca.collectMeasurements(true)
+	assert.NotContains(t, measurements, "errors.remote")
+}
@@ -0,0 +1,240 @@
+package cagent
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/dns"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/fs"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/httpcheck"
+)
+
+const (
+	agentHealthOK       = 0
+	agentHealthDegraded = 1
+	agentHealthError    = 2
+)
+
+const smartStatusFailed = "FAILED"
+
+// agentHealthMeasurements rolls up the results of all collectors into the single health state:
+// error if any collector failed, degraded if any file system is critically filled or unexpectedly read-only, any module (e.g. RAID) raised an alert,
+// any disk failed the SMART self-assessment or any SMART attribute is failing now
+func agentHealthMeasurements(measurements common.MeasurementsMap, collectorsErr error) common.MeasurementsMap {
+	health := agentHealthOK
+	var reasons []string
+
+	if collectorsErr != nil {
+		health = agentHealthError
+		reasons = append(reasons, "collector errors occurred")
+	}
+
+	degradedReasons := criticalHealthReasons(measurements)
+	if len(degradedReasons) > 0 {
+		if health == agentHealthOK {
+			health = agentHealthDegraded
+		}
+		reasons = append(reasons, degradedReasons...)
+	}
+
+	reason := "ok"
+	if len(reasons) > 0 {
+		reason = strings.Join(reasons, "; ")
+	}
+
+	return common.MeasurementsMap{
+		"agent.health":        health,
+		"agent.health_reason": reason,
+	}
+}
+
+// criticalHealthReasons lists the breached critical thresholds: critically filled or unexpectedly read-only file systems,
+// alerts raised by the modules, disks failed the SMART self-assessment and SMART attributes failing now
+// Thresholds are not evaluated during the maintenance
+func criticalHealthReasons(measurements common.MeasurementsMap) []string {
+	if maintenance, _ := measurements["agent.maintenance"].(bool); maintenance {
+		return nil
+	}
+
+	reasons := fsHealthReasons(measurements)
+	reasons = append(reasons, modulesHealthReasons(measurements)...)
+	reasons = append(reasons, smartHealthReasons(measurements)...)
+	return reasons
+}
+
+func fsHealthReasons(measurements common.MeasurementsMap) []string {
+	const (
+		fillStatePrefix          = "fs.fill_state."
+		readOnlyUnexpectedPrefix = "fs.read_only_unexpected."
+	)
+
+	var criticalMounts, readOnlyMounts []string
+	for key, value := range measurements {
+		switch {
+		case strings.HasPrefix(key, fillStatePrefix):
+			if state, ok := value.(string); ok && state == fs.FillStateCritical {
+				criticalMounts = append(criticalMounts, strings.TrimPrefix(key, fillStatePrefix))
+			}
+		case strings.HasPrefix(key, readOnlyUnexpectedPrefix):
+			if readOnly, ok := value.(bool); ok && readOnly {
+				readOnlyMounts = append(readOnlyMounts, strings.TrimPrefix(key, readOnlyUnexpectedPrefix))
+			}
+		}
+	}
+
+	var reasons []string
+	if len(criticalMounts) > 0 {
+		sort.Strings(criticalMounts)
+		reasons = append(reasons, fmt.Sprintf("file system fill critical: %s", strings.Join(criticalMounts, ", ")))
+	}
+	if len(readOnlyMounts) > 0 {
+		sort.Strings(readOnlyMounts)
+		reasons = append(reasons, fmt.Sprintf("file system unexpectedly read-only: %s", strings.Join(readOnlyMounts, ", ")))
+	}
+
+	return reasons
+}
+
+func modulesHealthReasons(measurements common.MeasurementsMap) []string {
+	reports, ok := measurements["modules"].([]*monitoring.ModuleReport)
+	if !ok {
+		return nil
+	}
+
+	var reasons []string
+	for _, report := range reports {
+		if report == nil || len(report.Alerts) == 0 {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("%s: %s", report.Name, report.Alerts[0]))
+	}
+
+	return reasons
+}
+
+func smartHealthReasons(measurements common.MeasurementsMap) []string {
+	disks, ok := measurements["smartmon"].(common.MeasurementsMap)
+	if !ok {
+		return nil
+	}
+
+	const failingNowSuffix = ".failing_now"
+
+	var failedDisks, failingAttributes []string
+	for disk, info := range disks {
+		diskInfo, ok := info.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		if status, ok := diskInfo["smart_status"].(string); ok && status == smartStatusFailed {
+			failedDisks = append(failedDisks, disk)
+		}
+		for key, value := range diskInfo {
+			if failing, ok := value.(bool); ok && failing && strings.HasSuffix(key, failingNowSuffix) {
+				failingAttributes = append(failingAttributes, disk+" "+strings.TrimSuffix(key, failingNowSuffix))
+			}
+		}
+	}
+
+	var reasons []string
+	if len(failedDisks) > 0 {
+		sort.Strings(failedDisks)
+		reasons = append(reasons, fmt.Sprintf("SMART status failed: %s", strings.Join(failedDisks, ", ")))
+	}
+	if len(failingAttributes) > 0 {
+		sort.Strings(failingAttributes)
+		reasons = append(reasons, fmt.Sprintf("SMART attribute failing: %s", strings.Join(failingAttributes, ", ")))
+	}
+
+	return reasons
+}
+
+// maintenanceOverrides are the values the alerting keys matching prefix and suffix are reported with during the maintenance
+var maintenanceOverrides = []struct {
+	prefix, suffix string
+	value          interface{}
+}{
+	{"fs.fill_state.", "", fs.FillStateOK},
+	{"fs.read_only_unexpected.", "", false},
+	{"fan.", ".stalled", false},
+	{"time.synced", "", true},
+	{"system.entropy_low", "", false},
+	{"httpcheck.", ".state", httpcheck.StateOK},
+	{"httpcheck.", ".up", true},
+	{"dns.", ".status", dns.StatusOK},
+	{"dns.", ".resolved", true},
+}
+
+// suppressAlerts is applied during the maintenance: the alerting keys are reported as ok (see maintenanceOverrides),
+// disks as passed the SMART checks and alerts and warnings of the modules are dropped. Metric values are kept as is.
+// Keys which are null because they couldn't be determined are kept null
+func suppressAlerts(measurements common.MeasurementsMap) {
+	for key, value := range measurements {
+		if value == nil {
+			continue
+		}
+		for _, override := range maintenanceOverrides {
+			if strings.HasPrefix(key, override.prefix) && strings.HasSuffix(key, override.suffix) {
+				measurements[key] = override.value
+				break
+			}
+		}
+	}
+
+	suppressSMARTAlerts(measurements)
+	suppressModuleAlerts(measurements)
+
+	// modules of the remote hosts
+	hosts, _ := measurements["remote"].(common.MeasurementsMap)
+	for _, host := range hosts {
+		if hostMeasurements, ok := host.(common.MeasurementsMap); ok {
+			suppressModuleAlerts(hostMeasurements)
+		}
+	}
+}
+
+func suppressModuleAlerts(measurements common.MeasurementsMap) {
+	reports, _ := measurements["modules"].([]*monitoring.ModuleReport)
+	for _, report := range reports {
+		if report == nil {
+			continue
+		}
+		report.Alerts = make([]monitoring.Alert, 0)
+		report.Warnings = make([]monitoring.Warning, 0)
+	}
+}
+
+// suppressSMARTAlerts reports the failed disks as passed and no attribute as failing now.
+// The disk maps are copied since the SMART results are reused between the collections
+func suppressSMARTAlerts(measurements common.MeasurementsMap) {
+	disks, ok := measurements["smartmon"].(common.MeasurementsMap)
+	if !ok {
+		return
+	}
+
+	suppressed := make(common.MeasurementsMap, len(disks))
+	for disk, info := range disks {
+		diskInfo, ok := info.(map[string]interface{})
+		if !ok {
+			suppressed[disk] = info
+			continue
+		}
+
+		copied := make(map[string]interface{}, len(diskInfo))
+		for key, value := range diskInfo {
+			copied[key] = value
+			if strings.HasSuffix(key, ".failing_now") && value != nil {
+				copied[key] = false
+			}
+		}
+		if copied["smart_status"] == smartStatusFailed {
+			copied["smart_status"] = "PASSED"
+			copied["health"] = 1
+		}
+		suppressed[disk] = copied
+	}
+	measurements["smartmon"] = suppressed
+}
@@ -0,0 +1,149 @@
+package cagent
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring"
+)
+
+func TestAgentHealthMeasurements(t *testing.T) {
+	healthyMeasurements := func() common.MeasurementsMap {
+		return common.MeasurementsMap{
+			"fs.fill_state./":     "ok",
+			"fs.fill_state./home": "warning",
+			"modules":             []*monitoring.ModuleReport{},
+			"smartmon": common.MeasurementsMap{
+				"/dev/sda": map[string]interface{}{"smart_status": "PASSED"},
+			},
+		}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthOK,
+			"agent.health_reason": "ok",
+		}, agentHealthMeasurements(healthyMeasurements(), nil))
+	})
+
+	t.Run("degraded-raid", func(t *testing.T) {
+		report := monitoring.NewReport("software raid health according to /proc/mdstat", time.Now(), "")
+		report.AddAlert("Raid md1 degraded. Devices failing: sde1.")
+
+		m := healthyMeasurements()
+		m["modules"] = []*monitoring.ModuleReport{&report}
+
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthDegraded,
+			"agent.health_reason": "software raid health according to /proc/mdstat: Raid md1 degraded. Devices failing: sde1.",
+		}, agentHealthMeasurements(m, nil))
+	})
+
+	t.Run("degraded-fs-and-smart", func(t *testing.T) {
+		m := healthyMeasurements()
+		m["fs.fill_state./"] = "critical"
+		m["smartmon"] = common.MeasurementsMap{
+			"/dev/sda": map[string]interface{}{"smart_status": "FAILED"},
+			"/dev/sdb": map[string]interface{}{"smart_status": "PASSED"},
+		}
+
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthDegraded,
+			"agent.health_reason": "file system fill critical: /; SMART status failed: /dev/sda",
+		}, agentHealthMeasurements(m, nil))
+	})
+
+	t.Run("degraded-smart-attribute", func(t *testing.T) {
+		m := healthyMeasurements()
+		m["smartmon"] = common.MeasurementsMap{
+			"/dev/sda": map[string]interface{}{
+				"smart_status":                       "PASSED",
+				"reallocated_sector_ct.failing_now":  true,
+				"reallocated_sector_ct.failed_ever":  true,
+				"spin_retry_count.failing_now":       false,
+				"spin_retry_count.failed_ever":       true,
+				"raw_read_error_rate.failing_now":    false,
+				"raw_read_error_rate.threshold":      51,
+				"current_pending_sector.failing_now": true,
+			},
+		}
+
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthDegraded,
+			"agent.health_reason": "SMART attribute failing: /dev/sda current_pending_sector, /dev/sda reallocated_sector_ct",
+		}, agentHealthMeasurements(m, nil))
+	})
+
+	t.Run("degraded-read-only", func(t *testing.T) {
+		m := healthyMeasurements()
+		m["fs.read_only./"] = false
+		m["fs.read_only_unexpected./"] = false
+		m["fs.read_only./data"] = true
+		m["fs.read_only_unexpected./data"] = true
+		m["fs.read_only./mnt/iso"] = true
+		m["fs.read_only_unexpected./mnt/iso"] = false
+
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthDegraded,
+			"agent.health_reason": "file system unexpectedly read-only: /data",
+		}, agentHealthMeasurements(m, nil))
+	})
+
+	t.Run("collector-error", func(t *testing.T) {
+		m := healthyMeasurements()
+		m["fs.fill_state./"] = "critical"
+
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthError,
+			"agent.health_reason": "collector errors occurred; file system fill critical: /",
+		}, agentHealthMeasurements(m, errors.New("failed to read mounts")))
+	})
+
+	t.Run("maintenance", func(t *testing.T) {
+		report := monitoring.NewReport("software raid health according to /proc/mdstat", time.Now(), "")
+		report.AddAlert("Raid md1 degraded. Devices failing: sde1.")
+
+		m := healthyMeasurements()
+		m["agent.maintenance"] = true
+		m["fs.fill_state./"] = "critical"
+		m["fs.used_percent./"] = 97.5
+		m["fs.read_only_unexpected./data"] = true
+		m["modules"] = []*monitoring.ModuleReport{&report}
+		m["fan.coretemp.1.stalled"] = true
+		m["time.synced"] = false
+		m["system.entropy_low"] = true
+		m["httpcheck.web.state"] = "timeout"
+		m["httpcheck.web.up"] = false
+		m["httpcheck.web.response_time_ms"] = 5000.0
+		m["dns.example_com.status"] = "servfail"
+		m["dns.example_com.resolved"] = nil
+		disk := map[string]interface{}{"smart_status": "FAILED", "health": 0, "5.failing_now": true, "5.raw_value": 12}
+		m["smartmon"] = common.MeasurementsMap{"/dev/sda": disk}
+
+		suppressAlerts(m)
+		assert.Equal(t, false, m["fan.coretemp.1.stalled"])
+		assert.Equal(t, true, m["time.synced"])
+		assert.Equal(t, false, m["system.entropy_low"])
+		assert.Equal(t, "ok", m["httpcheck.web.state"])
+		assert.Equal(t, true, m["httpcheck.web.up"])
+		assert.Equal(t, 5000.0, m["httpcheck.web.response_time_ms"])
+		assert.Equal(t, "ok", m["dns.example_com.status"])
+		assert.Nil(t, m["dns.example_com.resolved"])
+		assert.Equal(t, common.MeasurementsMap{
+			"/dev/sda": map[string]interface{}{"smart_status": "PASSED", "health": 1, "5.failing_now": false, "5.raw_value": 12},
+		}, m["smartmon"])
+		assert.Equal(t, "FAILED", disk["smart_status"], "cached SMART results are not modified")
+		assert.Equal(t, "ok", m["fs.fill_state./"])
+		assert.Equal(t, 97.5, m["fs.used_percent./"])
+		assert.Equal(t, false, m["fs.read_only_unexpected./data"])
+		assert.Empty(t, report.Alerts)
+		assert.Equal(t, common.MeasurementsMap{
+			"agent.health":        agentHealthOK,
+			"agent.health_reason": "ok",
+		}, agentHealthMeasurements(m, nil))
+	})
+}
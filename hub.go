@@ -21,49 +21,55 @@ import (
 
 func (ca *Cagent) initHubClientOnce() {
 	ca.hubClientOnce.Do(func() {
-		// copy the default transport struct
-		transport := *(http.DefaultTransport.(*http.Transport))
-		transport.ResponseHeaderTimeout = 15 * time.Second
+		ca.hubClient = newHubClient(ca.Config, ca.userAgent())
+	})
+}
 
-		rootCAs, err := common.CustomRootCertPool()
-		if err != nil {
-			if err != common.ErrorCustomRootCertPoolNotImplementedForOS {
-				logrus.Errorf("failed to add root certs: %s", err.Error())
-			}
-		} else if rootCAs != nil {
-			transport.TLSClientConfig = &tls.Config{
-				RootCAs: rootCAs,
-			}
+// newHubClient creates HTTP client for requests to the Hub using the custom root certificates and proxy settings of the config
+func newHubClient(cfg *Config, userAgent string) *http.Client {
+	// copy the default transport settings
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.ResponseHeaderTimeout = 15 * time.Second
+
+	rootCAs, err := common.CustomRootCertPool()
+	if err != nil {
+		if err != common.ErrorCustomRootCertPoolNotImplementedForOS {
+			logrus.Errorf("failed to add root certs: %s", err.Error())
+		}
+	} else if rootCAs != nil {
+		transport.TLSClientConfig = &tls.Config{
+			RootCAs: rootCAs,
 		}
+	}
 
-		transport.Proxy = proxydetect.GetProxyForRequest
-		proxydetect.UserAgent = ca.userAgent()
+	transport.Proxy = proxydetect.GetProxyForRequest
+	proxydetect.UserAgent = userAgent
 
-		if len(ca.Config.HubProxy) > 0 {
-			// in case we have proxy set in the config
-			// it will override the proxy from the system
-			if !strings.HasPrefix(ca.Config.HubProxy, "http://") {
-				ca.Config.HubProxy = "http://" + ca.Config.HubProxy
+	if len(cfg.HubProxy) > 0 {
+		// in case we have proxy set in the config
+		// it will override the proxy from the system
+		if !strings.HasPrefix(cfg.HubProxy, "http://") {
+			cfg.HubProxy = "http://" + cfg.HubProxy
+		}
+		proxyURL, err := url.Parse(cfg.HubProxy)
+		if err != nil {
+			logrus.WithFields(logrus.Fields{
+				"url": cfg.HubProxy,
+			}).Warningln("failed to parse hub_proxy URL")
+		} else {
+			if len(cfg.HubProxyUser) > 0 {
+				proxyURL.User = url.UserPassword(cfg.HubProxyUser, cfg.HubProxyPassword)
 			}
-			proxyURL, err := url.Parse(ca.Config.HubProxy)
-			if err != nil {
-				logrus.WithFields(logrus.Fields{
-					"url": ca.Config.HubProxy,
-				}).Warningln("failed to parse hub_proxy URL")
-			} else {
-				if len(ca.Config.HubProxyUser) > 0 {
-					proxyURL.User = url.UserPassword(ca.Config.HubProxyUser, ca.Config.HubProxyPassword)
-				}
-				transport.Proxy = func(_ *http.Request) (*url.URL, error) {
-					return proxyURL, nil
-				}
+			transport.Proxy = func(_ *http.Request) (*url.URL, error) {
+				return proxyURL, nil
 			}
 		}
-		ca.hubClient = &http.Client{
-			Timeout:   time.Duration(ca.Config.HubRequestTimeout) * time.Second,
-			Transport: &transport,
-		}
-	})
+	}
+
+	return &http.Client{
+		Timeout:   time.Duration(cfg.HubRequestTimeout) * time.Second,
+		Transport: transport,
+	}
 }
 
 // validateHubURL performs Hub URL validation, that reference field name as in source config.
@@ -83,6 +89,8 @@ func (ca *Cagent) validateHubURL(fieldHubURL string) error {
 // CheckHubCredentials performs credentials check for a Hub config, returning errors that reference
 // field names as in source config. Since config may be filled from file or UI, the field names can be different.
 // Consider also localization of UI, we want to decouple credential checking logic from their actual view in UI.
+// The request goes through the configured proxy and root certificates and is limited by hub_request_timeout.
+// Returned error is *HubConnectionError, its Kind tells whether DNS, TLS, authorization etc. failed
 //
 // Examples:
 // * for TOML: CheckHubCredentials(ctx, "hub_url", "hub_user", "hub_password")
@@ -91,21 +99,22 @@ func (ca *Cagent) CheckHubCredentials(ctx context.Context, fieldHubURL, fieldHub
 	ca.initHubClientOnce()
 	err := ca.validateHubURL(fieldHubURL)
 	if err != nil {
-		return err
+		return &HubConnectionError{Kind: HubConnectionErrorConfig, Err: err}
 	}
 
 	req, _ := http.NewRequest("HEAD", ca.Config.HubURL, nil)
 	req.Header.Add("User-Agent", ca.userAgent())
-	if len(ca.Config.HubUser) > 0 {
-		req.SetBasicAuth(ca.Config.HubUser, ca.Config.HubPassword)
-	}
 
 	ctx, cancelFn := context.WithTimeout(ctx, time.Minute)
+	if err = ca.setHubAuth(ctx, req); err != nil {
+		cancelFn()
+		return &HubConnectionError{Kind: HubConnectionErrorAuth, Err: err}
+	}
 	req = req.WithContext(ctx)
 	resp, err := ca.hubClient.Do(req)
 	cancelFn()
-	if err = ca.checkClientError(resp, err, fieldHubUser, fieldHubPassword); err != nil {
-		return errors.WithStack(err)
+	if clientErr := ca.checkClientError(resp, err, fieldHubUser, fieldHubPassword); clientErr != nil {
+		return &HubConnectionError{Kind: classifyHubConnectionError(resp, err), Err: errors.WithStack(clientErr)}
 	}
 
 	return nil
@@ -128,7 +137,9 @@ func (ca *Cagent) checkClientError(resp *http.Response, err error, fieldHubUser,
 
 	_ = resp.Body.Close()
 	if resp.StatusCode == http.StatusUnauthorized {
-		if len(ca.Config.HubUser) == 0 {
+		if ca.hubCredentialCache() != nil {
+			ca.invalidateHubCredentials()
+		} else if len(ca.Config.HubUser) == 0 {
 			return newEmptyFieldError(fieldHubUser)
 		} else if len(ca.Config.HubPassword) == 0 {
 			return newEmptyFieldError(fieldHubPassword)
@@ -186,17 +197,24 @@ func (ca *Cagent) PostResultToHub(ctx context.Context, result *Result) error {
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Add("User-Agent", ca.userAgent())
-	if len(ca.Config.HubUser) > 0 {
-		req.SetBasicAuth(ca.Config.HubUser, ca.Config.HubPassword)
+	if err = ca.setHubAuth(ctx, req); err != nil {
+		return err
 	}
+	if ca.hubPausedFor() > 0 {
+		// the Hub asked to wait with HTTP 429 and Retry-After
+		return ErrHubTooManyRequests
+	}
+
 	req = req.WithContext(ctx)
 	resp, err := ca.hubClient.Do(req)
 
 	if resp != nil {
 		if resp.StatusCode == http.StatusTooManyRequests {
+			ca.pauseHubSends(resp)
 			return ErrHubTooManyRequests
 		}
 		if resp.StatusCode == http.StatusUnauthorized {
+			ca.invalidateHubCredentials()
 			return ErrHubUnauthorized
 		}
 		if resp.StatusCode >= 500 && resp.StatusCode <= 599 {
@@ -204,8 +222,24 @@ func (ca *Cagent) PostResultToHub(ctx context.Context, result *Result) error {
 		}
 	}
 	if err = ca.checkClientError(resp, err, "hub_user", "hub_password"); err != nil {
+		if resp != nil && resp.StatusCode >= 400 && resp.StatusCode <= 499 {
+			return &hubRejectedError{err: errors.WithStack(err)}
+		}
 		return errors.WithStack(err)
 	}
 
 	return nil
 }
+
+// hubRejectedError is returned if the Hub rejected the result with HTTP 4xx other than 401 and 429, so sending it again won't help
+type hubRejectedError struct {
+	err error
+}
+
+func (e *hubRejectedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *hubRejectedError) Cause() error {
+	return e.err
+}
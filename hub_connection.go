@@ -0,0 +1,77 @@
+package cagent
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net"
+	"net/http"
+
+	"github.com/pkg/errors"
+)
+
+// HubConnectionErrorKind categorizes the reason why the Hub connection test failed
+type HubConnectionErrorKind string
+
+const (
+	HubConnectionErrorConfig     HubConnectionErrorKind = "config"
+	HubConnectionErrorDNS        HubConnectionErrorKind = "dns"
+	HubConnectionErrorTLS        HubConnectionErrorKind = "tls"
+	HubConnectionErrorAuth       HubConnectionErrorKind = "auth"
+	HubConnectionErrorTimeout    HubConnectionErrorKind = "timeout"
+	HubConnectionErrorConnection HubConnectionErrorKind = "connection"
+	HubConnectionErrorResponse   HubConnectionErrorKind = "response"
+)
+
+// HubConnectionError is returned by CheckHubCredentials
+type HubConnectionError struct {
+	Kind HubConnectionErrorKind
+	Err  error
+}
+
+func (e *HubConnectionError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *HubConnectionError) Cause() error {
+	return e.Err
+}
+
+func (e *HubConnectionError) Unwrap() error {
+	return e.Err
+}
+
+// classifyHubConnectionError returns the kind of the failed Hub request by the request error or the response status
+func classifyHubConnectionError(resp *http.Response, err error) HubConnectionErrorKind {
+	switch {
+	case err != nil:
+		return classifyHubRequestError(err)
+	case resp.StatusCode == http.StatusUnauthorized || resp.StatusCode == http.StatusForbidden:
+		return HubConnectionErrorAuth
+	default:
+		return HubConnectionErrorResponse
+	}
+}
+
+func classifyHubRequestError(err error) HubConnectionErrorKind {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		return HubConnectionErrorDNS
+	}
+
+	var netErr net.Error
+	if errors.Is(err, context.DeadlineExceeded) || (errors.As(err, &netErr) && netErr.Timeout()) {
+		return HubConnectionErrorTimeout
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var hostnameErr x509.HostnameError
+	var certInvalidErr x509.CertificateInvalidError
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &unknownAuthorityErr) || errors.As(err, &hostnameErr) ||
+		errors.As(err, &certInvalidErr) || errors.As(err, &recordHeaderErr) {
+		return HubConnectionErrorTLS
+	}
+
+	return HubConnectionErrorConnection
+}
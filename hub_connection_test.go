@@ -0,0 +1,135 @@
+package cagent
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckHubCredentialsErrorKind(t *testing.T) {
+	newHubServer := func(handler http.HandlerFunc) (*httptest.Server, *Cagent) {
+		server := httptest.NewServer(handler)
+
+		cfg := NewConfig()
+		cfg.HubURL = server.URL
+		cfg.HubUser = "user"
+		cfg.HubPassword = "secret"
+		cfg.HubRequestTimeout = 1
+
+		return server, &Cagent{Config: cfg}
+	}
+
+	t.Run("ok", func(t *testing.T) {
+		server, ca := newHubServer(func(w http.ResponseWriter, r *http.Request) {
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "user" || password != "secret" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		defer server.Close()
+
+		assert.NoError(t, ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password"))
+	})
+
+	t.Run("credential-provider", func(t *testing.T) {
+		server, ca := newHubServer(func(w http.ResponseWriter, r *http.Request) {
+			if _, password, _ := r.BasicAuth(); password != "secret-1" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.WriteHeader(http.StatusOK)
+		})
+		defer server.Close()
+
+		ca.SetHubCredentialProvider(&rotatingHubCredentialProvider{}, time.Minute)
+		assert.NoError(t, ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password"))
+	})
+
+	t.Run("unauthorized", func(t *testing.T) {
+		server, ca := newHubServer(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusUnauthorized)
+		})
+		defer server.Close()
+
+		err := ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password")
+		require.IsType(t, &HubConnectionError{}, err)
+		assert.Equal(t, HubConnectionErrorAuth, err.(*HubConnectionError).Kind)
+	})
+
+	t.Run("timeout", func(t *testing.T) {
+		release := make(chan struct{})
+		server, ca := newHubServer(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-time.After(5 * time.Second):
+			}
+		})
+		defer server.Close()
+		defer close(release)
+
+		started := time.Now()
+		err := ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password")
+		require.IsType(t, &HubConnectionError{}, err)
+		assert.Equal(t, HubConnectionErrorTimeout, err.(*HubConnectionError).Kind)
+		assert.True(t, time.Since(started) < 3*time.Second, "hub_request_timeout is not respected")
+	})
+
+	t.Run("untrusted-certificate", func(t *testing.T) {
+		server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+		defer server.Close()
+
+		cfg := NewConfig()
+		cfg.HubURL = server.URL
+
+		ca := &Cagent{Config: cfg}
+		err := ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password")
+		require.IsType(t, &HubConnectionError{}, err)
+		assert.Equal(t, HubConnectionErrorTLS, err.(*HubConnectionError).Kind)
+	})
+
+	t.Run("invalid-url", func(t *testing.T) {
+		cfg := NewConfig()
+		cfg.HubURL = "ftp://hub.example.com"
+
+		ca := &Cagent{Config: cfg}
+		err := ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password")
+		require.IsType(t, &HubConnectionError{}, err)
+		assert.Equal(t, HubConnectionErrorConfig, err.(*HubConnectionError).Kind)
+	})
+}
+
+func TestHubUserAgent(t *testing.T) {
+	var receivedUserAgent string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedUserAgent = r.Header.Get("User-Agent")
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := NewConfig()
+	cfg.HubURL = server.URL
+
+	ca := &Cagent{Config: cfg}
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	assert.Regexp(t, `^cagent/\S+ \(`+runtime.GOOS+`/`+runtime.GOARCH+`\)$`, receivedUserAgent)
+
+	cfg = NewConfig()
+	cfg.HubURL = server.URL
+	cfg.HubUserAgent = "cagent-fleet-eu/1.0"
+
+	ca = &Cagent{Config: cfg}
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	assert.Equal(t, "cagent-fleet-eu/1.0", receivedUserAgent)
+
+	receivedUserAgent = ""
+	require.NoError(t, ca.CheckHubCredentials(context.Background(), "hub_url", "hub_user", "hub_password"))
+	assert.Equal(t, "cagent-fleet-eu/1.0", receivedUserAgent)
+}
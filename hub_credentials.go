@@ -0,0 +1,230 @@
+package cagent
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/troian/toml"
+)
+
+// HubCredentialProvider supplies the credentials of the Hub requests instead of hub_user and hub_password, e.g. short-lived ones from Vault.
+// token is sent as the Bearer authorization and has precedence over user and pass. Set it with Cagent.SetHubCredentialProvider
+type HubCredentialProvider interface {
+	GetCredentials(ctx context.Context) (user, pass, token string, err error)
+}
+
+// hubCredentialCache holds the credentials returned by the provider for ttl
+type hubCredentialCache struct {
+	provider HubCredentialProvider
+	ttl      time.Duration
+
+	mu          sync.Mutex
+	user        string
+	pass        string
+	token       string
+	fetched     bool
+	fetchedAt   time.Time
+	invalidated bool
+}
+
+// SetHubCredentialProvider makes the Hub requests use the credentials of provider instead of hub_user and hub_password.
+// The credentials are requested again once ttl elapsed or the Hub rejected them with HTTP 401. Zero ttl requests them before each send.
+// nil provider restores the config credentials
+func (ca *Cagent) SetHubCredentialProvider(provider HubCredentialProvider, ttl time.Duration) {
+	ca.hubCredentialsLock.Lock()
+	defer ca.hubCredentialsLock.Unlock()
+
+	if provider == nil {
+		ca.hubCredentials = nil
+		return
+	}
+
+	ca.hubCredentials = &hubCredentialCache{provider: provider, ttl: ttl}
+}
+
+func (ca *Cagent) hubCredentialCache() *hubCredentialCache {
+	ca.hubCredentialsLock.Lock()
+	defer ca.hubCredentialsLock.Unlock()
+
+	return ca.hubCredentials
+}
+
+// setHubAuth adds the authorization of the Hub request, either from the credential provider or from the config
+func (ca *Cagent) setHubAuth(ctx context.Context, req *http.Request) error {
+	cache := ca.hubCredentialCache()
+	if cache == nil {
+		if len(ca.Config.HubUser) > 0 {
+			req.SetBasicAuth(ca.Config.HubUser, ca.Config.HubPassword)
+		}
+		return nil
+	}
+
+	user, pass, token, err := cache.get(ctx)
+	if err != nil {
+		return err
+	}
+
+	switch {
+	case token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case user != "":
+		req.SetBasicAuth(user, pass)
+	}
+
+	return nil
+}
+
+// invalidateHubCredentials makes the next request fetch the credentials from the provider again, e.g. after HTTP 401
+func (ca *Cagent) invalidateHubCredentials() {
+	if cache := ca.hubCredentialCache(); cache != nil {
+		cache.mu.Lock()
+		cache.invalidated = true
+		cache.mu.Unlock()
+	}
+}
+
+// get returns the cached credentials or requests them from the provider if they expired.
+// If the provider fails, the previous credentials are used till the Hub rejects them
+func (c *hubCredentialCache) get(ctx context.Context) (user, pass, token string, err error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.fetched && !c.invalidated && time.Since(c.fetchedAt) < c.ttl {
+		return c.user, c.pass, c.token, nil
+	}
+
+	user, pass, token, err = c.provider.GetCredentials(ctx)
+	if err != nil {
+		if c.fetched && !c.invalidated {
+			log.WithError(err).Warn("failed to refresh the Hub credentials, using the previous ones")
+			return c.user, c.pass, c.token, nil
+		}
+		return "", "", "", errors.Wrap(err, "failed to get the Hub credentials")
+	}
+
+	c.user, c.pass, c.token = user, pass, token
+	c.fetched = true
+	c.fetchedAt = time.Now()
+	c.invalidated = false
+
+	return user, pass, token, nil
+}
+
+// hubCredentials is the format of hub_credentials_file and of the output of ExecHubCredentialProvider.
+// Fields absent in it don't override the config credentials
+type hubCredentials struct {
+	HubUser     *string `toml:"hub_user" json:"hub_user"`
+	HubPassword *string `toml:"hub_password" json:"hub_password"`
+	HubToken    *string `toml:"hub_token" json:"hub_token"`
+}
+
+// merge returns the credentials with the absent fields taken from user and pass
+func (c hubCredentials) merge(user, pass string) (string, string, string) {
+	var token string
+	if c.HubUser != nil {
+		user = *c.HubUser
+	}
+	if c.HubPassword != nil {
+		pass = *c.HubPassword
+	}
+	if c.HubToken != nil {
+		token = *c.HubToken
+	}
+
+	return user, pass, token
+}
+
+// readHubCredentialsFile reads the credentials from the TOML or JSON file, the format is chosen by the extension
+func readHubCredentialsFile(path string) (hubCredentials, error) {
+	var creds hubCredentials
+	if !strings.EqualFold(filepath.Ext(path), ".json") {
+		_, err := toml.DecodeFile(path, &creds)
+		return creds, err
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return creds, err
+	}
+
+	return creds, json.Unmarshal(data, &creds)
+}
+
+// FileHubCredentialProvider reads the credentials from the TOML or JSON (*.json) file with hub_user, hub_password and hub_token,
+// e.g. hub_credentials_file rewritten by Vault agent. The file is read again once its modification time changes
+type FileHubCredentialProvider struct {
+	path string
+	// user and pass are used if the file doesn't set them
+	user string
+	pass string
+
+	mu      sync.Mutex
+	modTime time.Time
+	creds   hubCredentials
+}
+
+func NewFileHubCredentialProvider(path string) *FileHubCredentialProvider {
+	return &FileHubCredentialProvider{path: path}
+}
+
+func (p *FileHubCredentialProvider) GetCredentials(ctx context.Context) (user, pass, token string, err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stat, err := os.Stat(p.path)
+	if err != nil {
+		return "", "", "", err
+	}
+
+	if !stat.ModTime().Equal(p.modTime) {
+		creds, err := readHubCredentialsFile(p.path)
+		if err != nil {
+			return "", "", "", errors.Wrapf(err, "failed to parse the Hub credentials file '%s'", p.path)
+		}
+
+		p.creds = creds
+		p.modTime = stat.ModTime()
+	}
+
+	user, pass, token = p.creds.merge(p.user, p.pass)
+	return user, pass, token, nil
+}
+
+// ExecHubCredentialProvider runs the command which prints the credentials as JSON {"hub_user": "", "hub_password": "", "hub_token": ""}
+type ExecHubCredentialProvider struct {
+	name string
+	args []string
+}
+
+func NewExecHubCredentialProvider(name string, args ...string) *ExecHubCredentialProvider {
+	return &ExecHubCredentialProvider{name: name, args: args}
+}
+
+func (p *ExecHubCredentialProvider) GetCredentials(ctx context.Context) (user, pass, token string, err error) {
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, p.name, p.args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err = cmd.Run(); err != nil {
+		return "", "", "", errors.Wrapf(err, "credentials command '%s' failed: %s", p.name, bytes.TrimSpace(stderr.Bytes()))
+	}
+
+	var creds hubCredentials
+	if err = json.Unmarshal(stdout.Bytes(), &creds); err != nil {
+		return "", "", "", errors.Wrapf(err, "failed to parse the output of the credentials command '%s'", p.name)
+	}
+
+	user, pass, token = creds.merge("", "")
+	return user, pass, token, nil
+}
@@ -0,0 +1,106 @@
+package cagent
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// rotatingHubCredentialProvider issues a new password on every call
+type rotatingHubCredentialProvider struct {
+	calls int
+}
+
+func (p *rotatingHubCredentialProvider) GetCredentials(ctx context.Context) (user, pass, token string, err error) {
+	p.calls++
+	return "agent", fmt.Sprintf("secret-%d", p.calls), "", nil
+}
+
+func TestHubCredentialProvider(t *testing.T) {
+	var receivedUser, receivedPass string
+	validPass := ""
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		receivedUser, receivedPass, _ = r.BasicAuth()
+		if validPass != "" && receivedPass != validPass {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := NewConfig()
+	cfg.HubURL = server.URL
+	cfg.HubUser = "static"
+	cfg.HubPassword = "static-secret"
+
+	ca := &Cagent{Config: cfg}
+	provider := &rotatingHubCredentialProvider{}
+	ca.SetHubCredentialProvider(provider, 0)
+
+	for i := 1; i <= 3; i++ {
+		require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+		assert.Equal(t, "agent", receivedUser)
+		assert.Equal(t, fmt.Sprintf("secret-%d", i), receivedPass)
+	}
+
+	// the cached credentials are used till the TTL elapses
+	ca.SetHubCredentialProvider(provider, time.Hour)
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	assert.Equal(t, "secret-4", receivedPass)
+
+	// or till the Hub rejects them
+	validPass = "secret-5"
+	assert.Equal(t, ErrHubUnauthorized, ca.PostResultToHub(context.Background(), &Result{}))
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	assert.Equal(t, "secret-5", receivedPass)
+
+	ca.SetHubCredentialProvider(nil, 0)
+	validPass = ""
+	require.NoError(t, ca.PostResultToHub(context.Background(), &Result{}))
+	assert.Equal(t, "static", receivedUser)
+	assert.Equal(t, "static-secret", receivedPass)
+}
+
+func TestFileHubCredentialProvider(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cagent-creds")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "hub.json")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"hub_token": "token-1"}`), 0600))
+
+	provider := NewFileHubCredentialProvider(path)
+	_, _, token, err := provider.GetCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-1", token)
+
+	require.NoError(t, ioutil.WriteFile(path, []byte(`{"hub_token": "token-2"}`), 0600))
+	// make sure the modification time differs on file systems with the coarse timestamps
+	require.NoError(t, os.Chtimes(path, time.Now(), time.Now().Add(time.Minute)))
+
+	_, _, token, err = provider.GetCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "token-2", token)
+
+	// the fields absent in the file are taken from the config
+	path = filepath.Join(dir, "hub.toml")
+	require.NoError(t, ioutil.WriteFile(path, []byte(`hub_password = "rotated"`), 0600))
+	provider = NewFileHubCredentialProvider(path)
+	provider.user, provider.pass = "static", "static-secret"
+	user, pass, token, err := provider.GetCredentials(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "static", user)
+	assert.Equal(t, "rotated", pass)
+	assert.Empty(t, token)
+}
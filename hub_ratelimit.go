@@ -0,0 +1,108 @@
+package cagent
+
+import (
+	"context"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	// defaultHubRetryAfter is used if the Hub replies with HTTP 429 without a valid Retry-After header
+	defaultHubRetryAfter = 10 * time.Second
+	// maxHubRetryAfter limits the pause requested by the Hub
+	maxHubRetryAfter = time.Hour
+)
+
+// parseRetryAfter parses the Retry-After header value which is either the number of seconds or an HTTP-date
+func parseRetryAfter(value string, now time.Time) (time.Duration, bool) {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return 0, false
+	}
+
+	var retryAfter time.Duration
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		retryAfter = time.Duration(seconds) * time.Second
+	} else if at, err := http.ParseTime(value); err == nil {
+		retryAfter = at.Sub(now)
+	} else {
+		return 0, false
+	}
+
+	if retryAfter < 0 {
+		retryAfter = 0
+	} else if retryAfter > maxHubRetryAfter {
+		retryAfter = maxHubRetryAfter
+	}
+
+	return retryAfter, true
+}
+
+// pauseHubSends stops sending to the Hub for the time requested by the Retry-After header of HTTP 429 response
+func (ca *Cagent) pauseHubSends(resp *http.Response) {
+	now := time.Now()
+	retryAfter, ok := parseRetryAfter(resp.Header.Get("Retry-After"), now)
+	if !ok {
+		retryAfter = defaultHubRetryAfter
+	}
+
+	ca.hubPauseLock.Lock()
+	defer ca.hubPauseLock.Unlock()
+	ca.hubPausedUntil = now.Add(retryAfter)
+}
+
+// hubPausedFor returns the time left till the sends to the Hub are allowed again
+func (ca *Cagent) hubPausedFor() time.Duration {
+	ca.hubPauseLock.Lock()
+	defer ca.hubPauseLock.Unlock()
+
+	left := time.Until(ca.hubPausedUntil)
+	if left < 0 {
+		return 0
+	}
+
+	return left
+}
+
+// hubRetryIn returns the time to wait before the next send after HTTP 429
+func (ca *Cagent) hubRetryIn() time.Duration {
+	if left := ca.hubPausedFor(); left > 0 {
+		return left
+	}
+
+	return defaultHubRetryAfter
+}
+
+// bufferHubResult keeps the result rejected with HTTP 429 to send it later according to hub_buffer_on_429.
+// The oldest results are dropped if the buffer is full
+func (ca *Cagent) bufferHubResult(result *Result) {
+	if ca.Config.HubBufferOn429 != HubBufferOn429DropOldest {
+		return
+	}
+
+	ca.hubBuffer = append(ca.hubBuffer, result)
+	if dropped := len(ca.hubBuffer) - ca.Config.HubBufferSize; dropped > 0 {
+		log.Warnf("hub buffer is full, dropping %d oldest result(s)", dropped)
+		ca.hubBuffer = ca.hubBuffer[dropped:]
+	}
+}
+
+// postBufferedResults sends the results buffered because of HTTP 429, oldest first.
+// The result which failed to be sent is kept to be retried later, only the results rejected by the Hub with HTTP 4xx are dropped
+func (ca *Cagent) postBufferedResults(ctx context.Context) error {
+	for len(ca.hubBuffer) > 0 {
+		if err := ca.PostResultToHub(ctx, ca.hubBuffer[0]); err != nil {
+			if _, rejected := err.(*hubRejectedError); !rejected {
+				return err
+			}
+			log.WithError(err).Warn("dropping the buffered result rejected by the Hub")
+		}
+		ca.hubBuffer = ca.hubBuffer[1:]
+	}
+
+	return nil
+}
@@ -0,0 +1,186 @@
+package cagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestParseRetryAfter(t *testing.T) {
+	now := time.Date(2026, 10, 15, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		value    string
+		expected time.Duration
+		ok       bool
+	}{
+		{"120", 2 * time.Minute, true},
+		{" 0 ", 0, true},
+		{"Thu, 15 Oct 2026 12:00:30 GMT", 30 * time.Second, true},
+		{"Thu, 15 Oct 2026 11:00:00 GMT", 0, true},
+		{"86400", maxHubRetryAfter, true},
+		{"", 0, false},
+		{"soon", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			retryAfter, ok := parseRetryAfter(tt.value, now)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.expected, retryAfter)
+		})
+	}
+}
+
+func TestCagentRunHonorsRetryAfter(t *testing.T) {
+	var lock sync.Mutex
+	var requestTimes []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lock.Lock()
+		defer lock.Unlock()
+
+		requestTimes = append(requestTimes, time.Now())
+		if len(requestTimes) == 1 {
+			w.Header().Set("Retry-After", "2")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	cfg := NewConfig()
+	cfg.OperationMode = OperationModeMinimal
+	cfg.HubURL = server.URL
+
+	ca, err := New(cfg, "")
+	require.NoError(t, err)
+	defer ca.Shutdown()
+
+	interrupt := make(chan struct{})
+	defer close(interrupt)
+	go ca.Run(nil, interrupt)
+
+	start := time.Now()
+	for {
+		lock.Lock()
+		sent := len(requestTimes)
+		lock.Unlock()
+		if sent >= 2 {
+			break
+		}
+		require.True(t, time.Since(start) < 15*time.Second, "results were not retried")
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	lock.Lock()
+	defer lock.Unlock()
+	waited := requestTimes[1].Sub(requestTimes[0])
+	assert.True(t, waited >= 2*time.Second, "retried after %v", waited)
+	assert.True(t, waited < defaultHubRetryAfter, "retried after %v", waited)
+}
+
+func TestCagentReportMeasurementsBufferOn429(t *testing.T) {
+	var received []Result
+	rateLimited := true
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if rateLimited {
+			w.Header().Set("Retry-After", "0")
+			w.WriteHeader(http.StatusTooManyRequests)
+			return
+		}
+
+		var result Result
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &result))
+		received = append(received, result)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.HubURL = server.URL
+	ca.Config.HubGzip = false
+	ca.Config.HubBufferOn429 = HubBufferOn429DropOldest
+	ca.Config.HubBufferSize = 2
+
+	for i := 1; i <= 3; i++ {
+		assert.Equal(t, ErrHubTooManyRequests, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": i}, nil))
+	}
+	assert.Len(t, ca.hubBuffer, 2, "the oldest result is dropped")
+
+	// sends are paused till Retry-After elapses
+	ca.hubPausedUntil = time.Now().Add(time.Hour)
+	rateLimited = false
+	assert.Equal(t, ErrHubTooManyRequests, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 4}, nil))
+	assert.Empty(t, received)
+
+	ca.hubPausedUntil = time.Time{}
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 5}, nil))
+	require.Len(t, received, 3)
+	assert.Equal(t, float64(3), received[0].Measurements["mem.total_B"])
+	assert.Equal(t, float64(4), received[1].Measurements["mem.total_B"])
+	assert.Equal(t, float64(5), received[2].Measurements["mem.total_B"])
+	assert.Empty(t, ca.hubBuffer)
+}
+
+func TestCagentPostBufferedResultsKeepsFailedResult(t *testing.T) {
+	var received []Result
+	status := http.StatusServiceUnavailable
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result Result
+		body, err := ioutil.ReadAll(r.Body)
+		assert.NoError(t, err)
+		assert.NoError(t, json.Unmarshal(body, &result))
+
+		// the result 1 is malformed for the Hub
+		if status == http.StatusNoContent && result.Measurements["mem.total_B"] == float64(1) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+
+		w.WriteHeader(status)
+		if status == http.StatusNoContent {
+			received = append(received, result)
+		}
+	}))
+	defer server.Close()
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.Config.HubURL = server.URL
+	ca.Config.HubGzip = false
+	ca.Config.HubBufferOn429 = HubBufferOn429DropOldest
+	ca.Config.HubBufferSize = 5
+	ca.hubBuffer = []*Result{
+		{Measurements: common.MeasurementsMap{"mem.total_B": 1}},
+		{Measurements: common.MeasurementsMap{"mem.total_B": 2}},
+	}
+
+	// 5xx keeps the buffered results and the new one is buffered after them
+	assert.Equal(t, ErrHubServerError, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 3}, nil))
+	assert.Len(t, ca.hubBuffer, 3)
+	assert.Empty(t, received)
+
+	// 4xx drops the rejected result only
+	status = http.StatusNoContent
+	assert.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 4}, nil))
+	require.Len(t, received, 3)
+	assert.Equal(t, float64(2), received[0].Measurements["mem.total_B"])
+	assert.Equal(t, float64(3), received[1].Measurements["mem.total_B"])
+	assert.Equal(t, float64(4), received[2].Measurements["mem.total_B"])
+	assert.Empty(t, ca.hubBuffer)
+}
@@ -0,0 +1,139 @@
+package cagent
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const minutesPerDay = 24 * 60
+
+// scheduleOperationModes can be used inside the interval_schedule windows. "heartbeat" pauses the collection of the measurements
+var scheduleOperationModes = []string{OperationModeFull, OperationModeMinimal, OperationModeHeartbeat}
+
+// IntervalScheduleEntry overrides interval and operation_mode in a daily time window of the local clock
+type IntervalScheduleEntry struct {
+	From          string  `toml:"from" comment:"Start of the window in the local time, HH:MM"`
+	To            string  `toml:"to" comment:"End of the window in the local time, HH:MM, not included. The window crosses midnight if it is before from"`
+	Interval      float64 `toml:"interval" comment:"interval used inside the window, interval is used if 0"`
+	OperationMode string  `toml:"operation_mode" comment:"\"full\", \"minimal\" or \"heartbeat\" to send just the heartbeat inside the window, operation_mode is used if empty"`
+}
+
+// parseTimeOfDay returns the minutes since midnight of the HH:MM value
+func parseTimeOfDay(value string) (int, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' is not in HH:MM format", value)
+	}
+
+	return t.Hour()*60 + t.Minute(), nil
+}
+
+// minuteRanges returns the [start, end) minutes of the day covered by the window, the window crossing midnight is split in two
+func (e IntervalScheduleEntry) minuteRanges() ([][2]int, error) {
+	from, err := parseTimeOfDay(e.From)
+	if err != nil {
+		return nil, fmt.Errorf("from: %s", err)
+	}
+
+	to, err := parseTimeOfDay(e.To)
+	if err != nil {
+		return nil, fmt.Errorf("to: %s", err)
+	}
+
+	if from == to {
+		return nil, fmt.Errorf("from and to must differ")
+	}
+
+	if from < to {
+		return [][2]int{{from, to}}, nil
+	}
+
+	return [][2]int{{from, minutesPerDay}, {0, to}}, nil
+}
+
+func (e IntervalScheduleEntry) Validate() error {
+	if _, err := e.minuteRanges(); err != nil {
+		return err
+	}
+
+	if e.Interval != 0 && e.Interval < minIntervalValue {
+		return fmt.Errorf("interval value must be >= %.1f", minIntervalValue)
+	}
+
+	if e.OperationMode != "" && !common.StrInSlice(e.OperationMode, scheduleOperationModes) {
+		return fmt.Errorf("invalid operation_mode supplied. Must be one of %v", scheduleOperationModes)
+	}
+
+	return nil
+}
+
+// validateIntervalSchedule checks the entries and that their windows don't overlap
+func (cfg *Config) validateIntervalSchedule() error {
+	if len(cfg.IntervalSchedule) == 0 {
+		return nil
+	}
+
+	if cfg.OperationMode != OperationModeFull && cfg.OperationMode != OperationModeMinimal {
+		return newConfigError(ConfigErrorBadIntervalSchedule, "interval_schedule", "interval_schedule requires operation_mode \"%s\" or \"%s\"", OperationModeFull, OperationModeMinimal)
+	}
+
+	var ranges [][2]int
+	var owners []int
+	for i, entry := range cfg.IntervalSchedule {
+		if err := entry.Validate(); err != nil {
+			return newConfigError(ConfigErrorBadIntervalSchedule, fmt.Sprintf("interval_schedule[%d]", i), "invalid interval_schedule[%d] config: %s", i, err.Error())
+		}
+
+		entryRanges, _ := entry.minuteRanges()
+		for _, r := range entryRanges {
+			for j, other := range ranges {
+				if r[0] < other[1] && other[0] < r[1] {
+					return newConfigError(ConfigErrorBadIntervalSchedule, fmt.Sprintf("interval_schedule[%d]", i), "interval_schedule[%d] overlaps with interval_schedule[%d]", i, owners[j])
+				}
+			}
+			ranges = append(ranges, r)
+			owners = append(owners, i)
+		}
+	}
+
+	return nil
+}
+
+// activeIntervalSchedule returns the interval_schedule entry which window includes the moment now, nil if there is none
+func (cfg *Config) activeIntervalSchedule(now time.Time) *IntervalScheduleEntry {
+	minute := now.Hour()*60 + now.Minute()
+	for i := range cfg.IntervalSchedule {
+		ranges, err := cfg.IntervalSchedule[i].minuteRanges()
+		if err != nil {
+			continue
+		}
+
+		for _, r := range ranges {
+			if minute >= r[0] && minute < r[1] {
+				return &cfg.IntervalSchedule[i]
+			}
+		}
+	}
+
+	return nil
+}
+
+// EffectiveInterval returns the interval at the moment now taking interval_schedule into account
+func (cfg *Config) EffectiveInterval(now time.Time) float64 {
+	if entry := cfg.activeIntervalSchedule(now); entry != nil && entry.Interval > 0 {
+		return entry.Interval
+	}
+
+	return cfg.Interval
+}
+
+// EffectiveOperationMode returns the operation mode at the moment now taking interval_schedule into account
+func (cfg *Config) EffectiveOperationMode(now time.Time) string {
+	if entry := cfg.activeIntervalSchedule(now); entry != nil && entry.OperationMode != "" {
+		return entry.OperationMode
+	}
+
+	return cfg.OperationMode
+}
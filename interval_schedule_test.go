@@ -0,0 +1,72 @@
+package cagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEffectiveInterval(t *testing.T) {
+	cfg := NewConfig()
+	cfg.Interval = 60
+	cfg.IntervalSchedule = []IntervalScheduleEntry{
+		{From: "20:00", To: "07:00", Interval: 900, OperationMode: OperationModeMinimal},
+		{From: "12:00", To: "13:00", OperationMode: OperationModeHeartbeat},
+	}
+	require.NoError(t, cfg.validate())
+
+	at := func(hour, min int) time.Time {
+		return time.Date(2020, 3, 10, hour, min, 0, 0, time.Local)
+	}
+
+	assert.Equal(t, float64(60), cfg.EffectiveInterval(at(19, 59)))
+	assert.Equal(t, OperationModeFull, cfg.EffectiveOperationMode(at(19, 59)))
+
+	assert.Equal(t, float64(900), cfg.EffectiveInterval(at(20, 0)))
+	assert.Equal(t, float64(900), cfg.EffectiveInterval(at(23, 59)))
+	assert.Equal(t, float64(900), cfg.EffectiveInterval(at(3, 30)))
+	assert.Equal(t, OperationModeMinimal, cfg.EffectiveOperationMode(at(3, 30)))
+	assert.Equal(t, float64(60), cfg.EffectiveInterval(at(7, 0)))
+
+	assert.Equal(t, float64(60), cfg.EffectiveInterval(at(12, 30)))
+	assert.Equal(t, OperationModeHeartbeat, cfg.EffectiveOperationMode(at(12, 30)))
+	assert.Equal(t, OperationModeFull, cfg.EffectiveOperationMode(at(13, 0)))
+}
+
+func TestValidateIntervalSchedule(t *testing.T) {
+	tests := []struct {
+		name     string
+		schedule []IntervalScheduleEntry
+		valid    bool
+	}{
+		{"adjacent", []IntervalScheduleEntry{{From: "22:00", To: "06:00", Interval: 300}, {From: "06:00", To: "08:00", Interval: 120}}, true},
+		{"overlap", []IntervalScheduleEntry{{From: "08:00", To: "18:00", Interval: 300}, {From: "17:00", To: "19:00", Interval: 120}}, false},
+		{"overlap across midnight", []IntervalScheduleEntry{{From: "22:00", To: "06:00", Interval: 300}, {From: "05:00", To: "07:00", Interval: 120}}, false},
+		{"malformed from", []IntervalScheduleEntry{{From: "8am", To: "18:00", Interval: 300}}, false},
+		{"malformed to", []IntervalScheduleEntry{{From: "08:00", To: "24:00", Interval: 300}}, false},
+		{"empty window", []IntervalScheduleEntry{{From: "08:00", To: "08:00", Interval: 300}}, false},
+		{"interval too low", []IntervalScheduleEntry{{From: "08:00", To: "18:00", Interval: 5}}, false},
+		{"check mode", []IntervalScheduleEntry{{From: "08:00", To: "18:00", OperationMode: OperationModeCheck}}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := NewConfig()
+			cfg.IntervalSchedule = tt.schedule
+
+			err := cfg.validate()
+			if tt.valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+
+	cfg := NewConfig()
+	cfg.OperationMode = OperationModeHeartbeat
+	cfg.IntervalSchedule = []IntervalScheduleEntry{{From: "08:00", To: "18:00", OperationMode: OperationModeFull}}
+	assert.Error(t, cfg.validate())
+}
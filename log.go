@@ -2,10 +2,12 @@ package cagent
 
 import (
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 )
 
 type LogLevel string
@@ -41,13 +43,18 @@ func (lvl LogLevel) LogrusLevel() logrus.Level {
 }
 
 type logrusFileHook struct {
-	file      *os.File
-	flag      int
-	chmod     os.FileMode
+	writer    io.Writer
 	formatter *logrus.TextFormatter
 }
 
-func addLogFileHook(file string, flag int, chmod os.FileMode) error {
+// logRotation defines when the log file is rotated and how many old files are kept
+type logRotation struct {
+	maxSizeMB  int
+	maxBackups int
+	maxAgeDays int
+}
+
+func addLogFileHook(file string, flag int, chmod os.FileMode, rotation logRotation) error {
 
 	dir := filepath.Dir(file)
 	err := os.MkdirAll(dir, 0755)
@@ -56,18 +63,35 @@ func addLogFileHook(file string, flag int, chmod os.FileMode) error {
 	}
 
 	plainFormatter := &logrus.TextFormatter{FullTimestamp: true, DisableColors: true}
-	logFile, err := os.OpenFile(file, flag, chmod)
+	writer, err := newRotatingLogWriter(file, flag, chmod, rotation)
 	if err != nil {
-		return fmt.Errorf("Unable to write log file: %s", err.Error())
+		return err
 	}
 
-	hook := &logrusFileHook{logFile, flag, chmod, plainFormatter}
+	hook := &logrusFileHook{writer, plainFormatter}
 
 	logrus.AddHook(hook)
 
 	return nil
 }
 
+func newRotatingLogWriter(file string, flag int, chmod os.FileMode, rotation logRotation) (io.Writer, error) {
+	// create the file in advance to set its permissions, rotated files inherit them
+	logFile, err := os.OpenFile(file, flag, chmod)
+	if err != nil {
+		return nil, fmt.Errorf("Unable to write log file: %s", err.Error())
+	}
+	logFile.Close()
+
+	return &lumberjack.Logger{
+		Filename:   file,
+		MaxSize:    rotation.maxSizeMB,
+		MaxBackups: rotation.maxBackups,
+		MaxAge:     rotation.maxAgeDays,
+		LocalTime:  true,
+	}, nil
+}
+
 // Fire event
 func (hook *logrusFileHook) Fire(entry *logrus.Entry) error {
 	plainformat, err := hook.formatter.Format(entry)
@@ -77,7 +101,7 @@ func (hook *logrusFileHook) Fire(entry *logrus.Entry) error {
 	}
 
 	line := string(plainformat)
-	_, err = hook.file.WriteString(line)
+	_, err = io.WriteString(hook.writer, line)
 	if err != nil {
 		_, _ = fmt.Fprintf(os.Stderr, "unable to write file on filehook(entry.String) %v", err)
 		return err
@@ -111,7 +135,12 @@ func (ca *Cagent) configureLogger() {
 	ca.SetLogLevel(ca.Config.LogLevel)
 
 	if ca.Config.LogFile != "" {
-		err := addLogFileHook(ca.Config.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+		rotation := logRotation{
+			maxSizeMB:  ca.Config.LogMaxSizeMB,
+			maxBackups: ca.Config.LogMaxBackups,
+			maxAgeDays: ca.Config.LogMaxAgeDays,
+		}
+		err := addLogFileHook(ca.Config.LogFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644, rotation)
 		if err != nil {
 			logrus.Error("Can't write logs to file: ", err.Error())
 		}
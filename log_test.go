@@ -0,0 +1,57 @@
+package cagent
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotatingLogWriter(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cagent-log")
+	require.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	logFile := filepath.Join(dir, "cagent.log")
+	w, err := newRotatingLogWriter(logFile, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644, logRotation{maxSizeMB: 1, maxBackups: 2})
+	require.NoError(t, err)
+
+	// each chunk fills more than a half of the limit, so every write after the first one rotates the file
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	backups := func() []string {
+		files, err := filepath.Glob(filepath.Join(dir, "cagent-*.log"))
+		require.NoError(t, err)
+		return files
+	}
+
+	_, err = w.Write(chunk)
+	require.NoError(t, err)
+	assert.Empty(t, backups())
+
+	_, err = w.Write(chunk)
+	require.NoError(t, err)
+	assert.Len(t, backups(), 1)
+
+	for i := 0; i < 3; i++ {
+		// backup names have millisecond precision
+		time.Sleep(5 * time.Millisecond)
+		_, err = w.Write(chunk)
+		require.NoError(t, err)
+	}
+
+	// old backups are removed in background
+	deadline := time.Now().Add(5 * time.Second)
+	for len(backups()) > 2 && time.Now().Before(deadline) {
+		time.Sleep(50 * time.Millisecond)
+	}
+	assert.Len(t, backups(), 2)
+
+	info, err := os.Stat(logFile)
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
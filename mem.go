@@ -12,6 +12,8 @@ import (
 	log "github.com/sirupsen/logrus"
 
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/hugepages"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/slab"
 )
 
 const memGetTimeout = time.Second * 10
@@ -70,5 +72,17 @@ func (ca *Cagent) MemResults() (common.MeasurementsMap, *mem.VirtualMemoryStat,
 		results["available_percent"] = floatToIntPercentRoundUP(float64(memStat.Available) / float64(memStat.Total))
 	}
 
+	hugepagesResults, err := hugepages.GetMeasurements()
+	if err != nil {
+		log.WithError(err).Debug("[MEM] Failed to read hugepages usage")
+	}
+	results = results.AddWithPrefix("", hugepagesResults)
+
+	slabResults, err := slab.GetMeasurements()
+	if err != nil {
+		log.WithError(err).Debug("[MEM] Failed to read slab usage")
+	}
+	results = results.AddWithPrefix("", slabResults)
+
 	return results, memStat, nil
 }
@@ -0,0 +1,62 @@
+package cagent
+
+import (
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// MetricDescriptor describes a measurement key cagent can report, see ListMetricDescriptors
+type MetricDescriptor = common.MetricDescriptor
+
+// ListMetricDescriptors returns the key patterns of every metric this build can report, sorted by key.
+// The placeholders in angle brackets are substituted with the instance names, e.g. <iface> with the network interface
+func ListMetricDescriptors() []MetricDescriptor {
+	return common.MetricDescriptors()
+}
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "operation_mode", ConfigOption: "operation_mode"},
+		common.MetricDescriptor{Key: "agent.maintenance", Unit: "bool", ConfigOption: "maintenance_until"},
+		common.MetricDescriptor{Key: "agent.config_hash"},
+
+		common.MetricDescriptor{Key: "cpu.util.<type>.<avg>.total", Unit: "%", ConfigOption: "cpu_utilisation_types"},
+		common.MetricDescriptor{Key: "cpu.util.<type>.<avg>.<cpu>", Unit: "%", ConfigOption: "cpu_utilisation_types"},
+		common.MetricDescriptor{Key: "cpu.load.avg.<avg>", ConfigOption: "cpu_load_data_gathering_mode"},
+		common.MetricDescriptor{Key: "cpu.load.avg.<avg>.per_core", ConfigOption: "cpu_load_per_core"},
+
+		common.MetricDescriptor{Key: "mem.total_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.used_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.used_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.free_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.free_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.shared_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.shared_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.cached_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.cached_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.buff_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.buff_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.available_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.available_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+
+		common.MetricDescriptor{Key: "swap.total_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "swap.used_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "swap.free_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "swap.in_per_s", Unit: "pages/s", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "swap.out_per_s", Unit: "pages/s", ConfigOption: "mem_monitoring"},
+
+		common.MetricDescriptor{Key: "system.entropy_avail", Unit: "bits", ConfigOption: "system_fields"},
+		common.MetricDescriptor{Key: "system.entropy_low", Unit: "bool", ConfigOption: "entropy_low_threshold"},
+		common.MetricDescriptor{Key: "system.users_count", ConfigOption: "system_fields"},
+		common.MetricDescriptor{Key: "system.sessions_count", ConfigOption: "system_fields"},
+
+		common.MetricDescriptor{Key: "errors.<collector>", ConfigOption: "include_errors"},
+
+		common.MetricDescriptor{Key: "temperatures.list", ConfigOption: "temperature_monitoring"},
+		common.MetricDescriptor{Key: "modules"},
+
+		common.MetricDescriptor{Key: "self.cpu_percent", Unit: "%", ConfigOption: "self_monitoring"},
+		common.MetricDescriptor{Key: "self.mem_rss_B", Unit: "B", ConfigOption: "self_monitoring"},
+		common.MetricDescriptor{Key: "self.goroutines", ConfigOption: "self_monitoring"},
+		common.MetricDescriptor{Key: "self.open_fds", ConfigOption: "self_monitoring"},
+	)
+}
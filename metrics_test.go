@@ -0,0 +1,28 @@
+package cagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestListMetricDescriptors(t *testing.T) {
+	descriptors := ListMetricDescriptors()
+
+	byKey := map[string]MetricDescriptor{}
+	for i, d := range descriptors {
+		byKey[d.Key] = d
+		if i > 0 {
+			assert.True(t, descriptors[i-1].Key < d.Key, "descriptors must be sorted by key")
+		}
+	}
+
+	for _, expected := range []MetricDescriptor{
+		{Key: "cpu.util.<type>.<avg>.total", Unit: "%", ConfigOption: "cpu_utilisation_types"},
+		{Key: "cpu.load.avg.<avg>", Unit: "", ConfigOption: "cpu_load_data_gathering_mode"},
+		{Key: "fs.free_B.<mount>", Unit: "B", ConfigOption: "fs_metrics"},
+		{Key: "fs.free_percent.<mount>", Unit: "%", ConfigOption: "fs_metrics"},
+	} {
+		assert.Equal(t, expected, byKey[expected.Key])
+	}
+}
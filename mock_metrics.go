@@ -0,0 +1,86 @@
+package cagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+	"github.com/troian/toml"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// mockMetricsCyclesKey holds the list of measurements reported in turns, see mock_metrics
+const mockMetricsCyclesKey = "cycles"
+
+// mockMetrics supplies the measurements loaded from the mock_metrics file instead of running the collectors
+type mockMetrics struct {
+	cycles []common.MeasurementsMap
+	next   int
+	mu     sync.Mutex
+}
+
+// loadMockMetrics reads TOML or JSON (*.json) file. It contains either the measurements as the top-level keys
+// or the list of measurements under the "cycles" key which are reported one per collection in turns.
+// A file mixing both is rejected
+func loadMockMetrics(path string) (*mockMetrics, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.Wrap(err, "failed to read mock_metrics file")
+	}
+
+	var content map[string]interface{}
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &content)
+	} else {
+		err = toml.Unmarshal(data, &content)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed to parse mock_metrics file '%s'", path)
+	}
+
+	cyclesValue, hasCycles := content[mockMetricsCyclesKey]
+	if !hasCycles {
+		return &mockMetrics{cycles: []common.MeasurementsMap{content}}, nil
+	}
+	if len(content) > 1 {
+		return nil, errors.Errorf("mock_metrics file '%s': measurements must be listed under cycles, not next to it", path)
+	}
+
+	m := &mockMetrics{}
+	switch cycles := cyclesValue.(type) {
+	case []map[string]interface{}:
+		for _, cycle := range cycles {
+			m.cycles = append(m.cycles, cycle)
+		}
+	case []interface{}:
+		for i, cycle := range cycles {
+			measurements, ok := cycle.(map[string]interface{})
+			if !ok {
+				return nil, errors.Errorf("mock_metrics file '%s': cycles[%d] must be an object", path, i)
+			}
+			m.cycles = append(m.cycles, measurements)
+		}
+	default:
+		return nil, errors.Errorf("mock_metrics file '%s': cycles must be a list of objects", path)
+	}
+
+	if len(m.cycles) == 0 {
+		return nil, errors.Errorf("mock_metrics file '%s': cycles must not be empty", path)
+	}
+
+	return m, nil
+}
+
+// Next returns a copy of the measurements of the next cycle
+func (m *mockMetrics) Next() common.MeasurementsMap {
+	m.mu.Lock()
+	cycle := m.cycles[m.next]
+	m.next = (m.next + 1) % len(m.cycles)
+	m.mu.Unlock()
+
+	return common.MeasurementsMap{}.AddWithPrefix("", cycle)
+}
@@ -0,0 +1,113 @@
+package cagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestLoadMockMetrics(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-mock")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+
+	tomlPath := filepath.Join(tmpDir, "mock.toml")
+	require.NoError(t, ioutil.WriteFile(tomlPath, []byte(`
+"cpu.util.idle.1.total" = 95.1
+"mem.total_B" = 1024
+"system.uname" = "Linux demo"
+`), 0600))
+
+	m, err := loadMockMetrics(tomlPath)
+	require.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"cpu.util.idle.1.total": 95.1, "mem.total_B": int64(1024), "system.uname": "Linux demo"}, m.Next())
+	assert.Equal(t, common.MeasurementsMap{"cpu.util.idle.1.total": 95.1, "mem.total_B": int64(1024), "system.uname": "Linux demo"}, m.Next())
+
+	jsonPath := filepath.Join(tmpDir, "mock.json")
+	require.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{"cycles": [{"mem.free_B": 10}, {"mem.free_B": 20}]}`), 0600))
+
+	m, err = loadMockMetrics(jsonPath)
+	require.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"mem.free_B": float64(10)}, m.Next())
+	assert.Equal(t, common.MeasurementsMap{"mem.free_B": float64(20)}, m.Next())
+	assert.Equal(t, common.MeasurementsMap{"mem.free_B": float64(10)}, m.Next())
+
+	require.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{"cycles": []}`), 0600))
+	_, err = loadMockMetrics(jsonPath)
+	assert.Error(t, err)
+
+	require.NoError(t, ioutil.WriteFile(jsonPath, []byte(`{"cycles": [{"mem.free_B": 10}], "mem.total_B": 1024}`), 0600))
+	_, err = loadMockMetrics(jsonPath)
+	assert.Error(t, err)
+
+	_, err = loadMockMetrics(filepath.Join(tmpDir, "not-existing.toml"))
+	assert.Error(t, err)
+}
+
+func TestCagentRunMockMetrics(t *testing.T) {
+	var lock sync.Mutex
+	var received []Result
+	var receivedAt []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var result Result
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&result))
+
+		lock.Lock()
+		defer lock.Unlock()
+		received = append(received, result)
+		receivedAt = append(receivedAt, time.Now())
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	mockFile, err := ioutil.TempFile("", "cagent-mock-*.json")
+	require.NoError(t, err)
+	defer os.Remove(mockFile.Name())
+	_, err = mockFile.WriteString(`{"cycles": [{"mem.total_B": 1024, "cpu.util.idle.1.total": 95.1}, {"mem.total_B": 2048, "cpu.util.idle.1.total": 12.5}]}`)
+	require.NoError(t, err)
+	require.NoError(t, mockFile.Close())
+
+	cfg := NewConfig()
+	cfg.HubURL = server.URL
+	cfg.HubGzip = false
+	cfg.MockMetrics = mockFile.Name()
+
+	ca, err := New(cfg, "")
+	require.NoError(t, err)
+	defer ca.Shutdown()
+	// shorter than the allowed minimum to keep the test fast
+	ca.Config.Interval = 0.3
+
+	interrupt := make(chan struct{})
+	go ca.Run(nil, interrupt)
+
+	start := time.Now()
+	for {
+		lock.Lock()
+		count := len(received)
+		lock.Unlock()
+		if count >= 2 {
+			break
+		}
+		require.True(t, time.Since(start) < 10*time.Second, "mock metrics were not sent")
+		time.Sleep(10 * time.Millisecond)
+	}
+	close(interrupt)
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(1024), "cpu.util.idle.1.total": 95.1}, received[0].Measurements)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(2048), "cpu.util.idle.1.total": 12.5}, received[1].Measurements)
+	assert.True(t, receivedAt[1].Sub(receivedAt[0]) >= 300*time.Millisecond, "sent before the interval passed")
+}
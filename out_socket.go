@@ -0,0 +1,109 @@
+package cagent
+
+import (
+	"encoding/json"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+const (
+	socketOutputDialTimeout  = 5 * time.Second
+	socketOutputWriteTimeout = 5 * time.Second
+	socketOutputMinBackoff   = time.Second
+	socketOutputMaxBackoff   = time.Minute
+)
+
+// socketOutput writes the results as newline-delimited JSON to the Unix domain socket in io_mode="socket".
+// The connection is reestablished if the consumer restarts. While the consumer is unavailable,
+// the results are dropped and the connection attempts are backed off, so the collection is never blocked
+type socketOutput struct {
+	path string
+
+	mu      sync.Mutex
+	conn    net.Conn
+	backoff time.Duration
+	retryAt time.Time
+}
+
+func newSocketOutput(path string) *socketOutput {
+	return &socketOutput{path: path}
+}
+
+// Write sends the result. If the established connection is broken, it is redialed once right away
+func (s *socketOutput) Write(result *Result) error {
+	data, err := json.Marshal(result)
+	if err != nil {
+		return errors.Wrap(err, "failed to JSON encode measurement result")
+	}
+	data = append(data, '\n')
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	redial := s.conn != nil
+	if err = s.write(data); err == nil || !redial {
+		return err
+	}
+
+	return s.write(data)
+}
+
+func (s *socketOutput) write(data []byte) error {
+	if s.conn == nil {
+		if err := s.connect(); err != nil {
+			return err
+		}
+	}
+
+	err := s.conn.SetWriteDeadline(time.Now().Add(socketOutputWriteTimeout))
+	if err == nil {
+		_, err = s.conn.Write(data)
+	}
+	if err != nil {
+		s.conn.Close()
+		s.conn = nil
+		return errors.Wrapf(err, "failed to write measurement result to out_socket '%s'", s.path)
+	}
+
+	return nil
+}
+
+func (s *socketOutput) connect() error {
+	now := time.Now()
+	if now.Before(s.retryAt) {
+		return errors.Errorf("out_socket '%s' is unavailable, next connection attempt in %v", s.path, s.retryAt.Sub(now).Round(time.Second))
+	}
+
+	conn, err := net.DialTimeout("unix", s.path, socketOutputDialTimeout)
+	if err != nil {
+		s.backoff *= 2
+		if s.backoff < socketOutputMinBackoff {
+			s.backoff = socketOutputMinBackoff
+		} else if s.backoff > socketOutputMaxBackoff {
+			s.backoff = socketOutputMaxBackoff
+		}
+		s.retryAt = now.Add(s.backoff)
+		return errors.Wrapf(err, "failed to connect to out_socket '%s'", s.path)
+	}
+
+	s.conn = conn
+	s.backoff = 0
+	s.retryAt = time.Time{}
+	return nil
+}
+
+func (s *socketOutput) Close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.conn == nil {
+		return nil
+	}
+
+	err := s.conn.Close()
+	s.conn = nil
+	return err
+}
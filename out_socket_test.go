@@ -0,0 +1,121 @@
+package cagent
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// listenSocketConsumer accepts a single connection and sends the received lines to the returned channel.
+// The returned func stops the consumer closing the connection
+func listenSocketConsumer(t *testing.T, path string) (func(), chan string) {
+	listener, err := net.Listen("unix", path)
+	require.NoError(t, err)
+
+	lines := make(chan string, 10)
+	accepted := make(chan net.Conn, 1)
+	go func() {
+		defer close(lines)
+		conn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+		accepted <- conn
+
+		scanner := bufio.NewScanner(conn)
+		for scanner.Scan() {
+			lines <- scanner.Text()
+		}
+	}()
+
+	stop := func() {
+		listener.Close()
+		select {
+		case conn := <-accepted:
+			conn.Close()
+		default:
+		}
+	}
+
+	return stop, lines
+}
+
+func receiveSocketResult(t *testing.T, lines chan string) Result {
+	select {
+	case line := <-lines:
+		var result Result
+		require.NoError(t, json.Unmarshal([]byte(line), &result))
+		return result
+	case <-time.After(5 * time.Second):
+		require.Fail(t, "no result received on the socket")
+	}
+
+	return Result{}
+}
+
+func TestCagentReportMeasurementsSocket(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-socket")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	socketPath := filepath.Join(tmpDir, "out.sock")
+
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+	ca.Config.IOMode = IOModeSocket
+	ca.Config.OutSocket = socketPath
+	ca.Config.OutTimestampFormat = TimestampFormatUnix
+	ca.socketOutput = newSocketOutput(socketPath)
+
+	stopConsumer, lines := listenSocketConsumer(t, socketPath)
+	require.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1024}, nil))
+	result := receiveSocketResult(t, lines)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(1024)}, result.Measurements)
+
+	// the consumer restarts
+	stopConsumer()
+	for range lines {
+	}
+	stopConsumer, lines = listenSocketConsumer(t, socketPath)
+	defer stopConsumer()
+
+	require.NoError(t, ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 2048}, nil))
+	result = receiveSocketResult(t, lines)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(2048)}, result.Measurements)
+}
+
+func TestSocketOutputBackoff(t *testing.T) {
+	tmpDir, err := ioutil.TempDir("", "cagent-socket")
+	require.NoError(t, err)
+	defer os.RemoveAll(tmpDir)
+	socketPath := filepath.Join(tmpDir, "out.sock")
+
+	out := newSocketOutput(socketPath)
+	defer out.Close()
+
+	// no consumer
+	assert.Error(t, out.Write(&Result{}))
+	assert.Equal(t, socketOutputMinBackoff, out.backoff)
+	retryAt := out.retryAt
+
+	// no connection attempts till the backoff elapses
+	stopConsumer, lines := listenSocketConsumer(t, socketPath)
+	defer stopConsumer()
+	assert.Error(t, out.Write(&Result{}))
+	assert.Equal(t, retryAt, out.retryAt)
+
+	out.retryAt = time.Now()
+	require.NoError(t, out.Write(&Result{Measurements: common.MeasurementsMap{"mem.total_B": 1024}}))
+	result := receiveSocketResult(t, lines)
+	assert.Equal(t, common.MeasurementsMap{"mem.total_B": float64(1024)}, result.Measurements)
+	assert.Equal(t, time.Duration(0), out.backoff)
+}
@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
 	"time"
 
@@ -24,26 +25,58 @@ type Invoker interface {
 	CommandWithContext(context.Context, string, ...string) ([]byte, error)
 }
 
-type Invoke struct{}
+// Invoke returns the standard and the error output of the command combined.
+// With StdoutOnly the standard output is returned alone and the error output is attached to the returned error, see CommandError
+type Invoke struct {
+	StdoutOnly bool
+}
 
 var _ Invoker = (*Invoke)(nil)
 
 func (i Invoke) CommandWithContext(ctx context.Context, name string, arg ...string) ([]byte, error) {
 	cmd := exec.CommandContext(ctx, name, arg...)
 
-	var buf bytes.Buffer
+	var buf, stderr bytes.Buffer
 	cmd.Stdout = &buf
 	cmd.Stderr = &buf
+	if i.StdoutOnly {
+		cmd.Stderr = &stderr
+	}
 
-	if err := cmd.Start(); err != nil {
-		return buf.Bytes(), err
+	err := cmd.Start()
+	if err == nil {
+		err = cmd.Wait()
 	}
 
-	if err := cmd.Wait(); err != nil {
-		return buf.Bytes(), err
+	if err != nil && i.StdoutOnly {
+		err = &CommandError{Err: err, Stderr: stderr.Bytes()}
 	}
 
-	return buf.Bytes(), nil
+	return buf.Bytes(), err
+}
+
+// CommandError is returned by Invoke with StdoutOnly if the command failed
+type CommandError struct {
+	Err    error
+	Stderr []byte
+}
+
+func (e *CommandError) Error() string {
+	return e.Err.Error()
+}
+
+func (e *CommandError) Unwrap() error {
+	return e.Err
+}
+
+// CommandErrorOutput returns the error output of the failed command attached to err by Invoke with StdoutOnly
+func CommandErrorOutput(err error) []byte {
+	var cmdErr *CommandError
+	if errors.As(err, &cmdErr) {
+		return cmdErr.Stderr
+	}
+
+	return nil
 }
 
 // RunCommandWithContext convenience wrapper to CommandWithContext
@@ -80,7 +113,13 @@ func MergeStringMaps(mapA, mapB map[string]interface{}) map[string]interface{} {
 }
 
 func RoundToTwoDecimalPlaces(v float64) float64 {
-	return math.Round(v*100) / 100
+	return RoundToPrecision(v, 2)
+}
+
+// RoundToPrecision rounds v half away from zero to the specified number of decimal places
+func RoundToPrecision(v float64, precision int) float64 {
+	k := math.Pow10(precision)
+	return math.Round(v*k) / k
 }
 
 func FloatToIntRoundUP(f float64) int {
@@ -115,6 +154,10 @@ func HostSys(combineWith ...string) string {
 	return GetEnv("HOST_SYS", "/sys", combineWith...)
 }
 
+func HostDev(combineWith ...string) string {
+	return GetEnv("HOST_DEV", "/dev", combineWith...)
+}
+
 // ReadLines reads contents from a file and splits them by new lines.
 // A convenience wrapper to ReadLinesOffsetN(filename, 0, -1).
 // from github.com/shriou/gopsutil/internal/common.go
@@ -152,6 +195,40 @@ func ReadLinesOffsetN(filename string, offset uint, n int) ([]string, error) {
 	return ret, nil
 }
 
+// ReadMeminfo parses the file in the format of /proc/meminfo into the values by field name.
+// The values in kB are converted to bytes, the lines which can't be parsed are skipped
+func ReadMeminfo(filePath string) (map[string]uint64, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if len(fields) > 2 && fields[2] == "kB" {
+			value *= 1024
+		}
+
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return values, nil
+}
+
 // StrInSlice returns true if search string found in slice
 func StrInSlice(search string, slice []string) bool {
 	for _, str := range slice {
@@ -0,0 +1,57 @@
+// +build !windows
+
+package common
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestInvokeStdoutOnly(t *testing.T) {
+	script := "echo data; echo 'sudo: unable to resolve host' >&2"
+
+	output, err := Invoke{}.CommandWithContext(context.Background(), "/bin/sh", "-c", script)
+	assert.NoError(t, err)
+	assert.Equal(t, "data\nsudo: unable to resolve host\n", string(output))
+
+	output, err = Invoke{StdoutOnly: true}.CommandWithContext(context.Background(), "/bin/sh", "-c", script)
+	assert.NoError(t, err)
+	assert.Equal(t, "data\n", string(output))
+
+	output, err = Invoke{StdoutOnly: true}.CommandWithContext(context.Background(), "/bin/sh", "-c", "echo partial; echo 'Permission denied' >&2; exit 1")
+	assert.Error(t, err)
+	assert.Equal(t, "partial\n", string(output))
+	assert.Equal(t, "Permission denied\n", string(CommandErrorOutput(err)))
+
+	var exitErr *exec.ExitError
+	assert.True(t, errors.As(err, &exitErr))
+	assert.Equal(t, 1, exitErr.ExitCode())
+	assert.False(t, IsTransientCommandError(output, err), "error output is checked for permanent failures")
+}
+
+func TestReadMeminfo(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "meminfo")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString("MemTotal:       16303892 kB\nHugePages_Total:       8\nmalformed line\nSlab:             not-a-number kB\n")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	values, err := ReadMeminfo(tmpFile.Name())
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint64{
+		"MemTotal":        16303892 * 1024,
+		"HugePages_Total": 8,
+	}, values)
+
+	_, err = ReadMeminfo(tmpFile.Name() + ".not-existing")
+	assert.Error(t, err)
+}
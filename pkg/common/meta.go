@@ -0,0 +1,108 @@
+package common
+
+import "strings"
+
+const (
+	MetricKindGauge = "gauge"
+	MetricKindRate  = "rate"
+)
+
+// MetricMetadata describes the unit and the kind of a metric
+type MetricMetadata struct {
+	Unit string `json:"unit"`
+	Kind string `json:"kind"`
+}
+
+// metricSuffixes are checked in order, so longer suffixes must precede the shorter ones ending the same way
+var metricSuffixes = []struct {
+	suffix string
+	unit   string
+	kind   string
+}{
+	{"_B_per_s", "B/s", MetricKindRate},
+	{"_ops_per_s", "ops/s", MetricKindRate},
+	{"_per_s", "1/s", MetricKindRate},
+	{"_B", "B", MetricKindGauge},
+	{"_KB", "KB", MetricKindGauge},
+	{"_MB", "MB", MetricKindGauge},
+	{"_percent", "%", MetricKindGauge},
+	{"_ms", "ms", MetricKindGauge},
+	{"_sec", "s", MetricKindGauge},
+	{"_s", "s", MetricKindGauge},
+}
+
+// MetricMeta derives the unit and the kind of the metric from the suffix of its name, e.g. 'fs.free_B./home' is a gauge in bytes.
+// Metric name is the first dot-separated part of the key having a known suffix, the rest is treated as an instance (mount point, interface etc).
+// CPU utilisation metrics are reported in percent and other keys ending with '.total' are counts of items.
+// Empty strings are returned if the metric is unknown
+func MetricMeta(key string) (unit, kind string) {
+	if strings.HasPrefix(key, "cpu.util.") {
+		return "%", MetricKindGauge
+	}
+
+	for _, part := range strings.Split(key, ".") {
+		for _, s := range metricSuffixes {
+			if strings.HasSuffix(part, s.suffix) && len(part) > len(s.suffix) {
+				return s.unit, s.kind
+			}
+		}
+	}
+
+	if strings.HasSuffix(key, ".total") {
+		return "count", MetricKindGauge
+	}
+
+	return "", ""
+}
+
+// Metadata returns the metadata of the known metrics in mm. Nested maps are not inspected
+func (mm MeasurementsMap) Metadata() map[string]MetricMetadata {
+	result := make(map[string]MetricMetadata)
+	for key := range mm {
+		unit, kind := MetricMeta(key)
+		if kind == "" {
+			continue
+		}
+		result[key] = MetricMetadata{Unit: unit, Kind: kind}
+	}
+
+	return result
+}
+
+// WithoutZeroRates returns a copy of mm without the rate metrics equal to 0, see MetricMeta. Nested maps are not inspected
+func (mm MeasurementsMap) WithoutZeroRates() MeasurementsMap {
+	result := make(MeasurementsMap, len(mm))
+	for key, value := range mm {
+		if isZeroNumber(value) {
+			if _, kind := MetricMeta(key); kind == MetricKindRate {
+				continue
+			}
+		}
+		result[key] = value
+	}
+
+	return result
+}
+
+func isZeroNumber(v interface{}) bool {
+	switch value := v.(type) {
+	case float64:
+		return value == 0
+	case float32:
+		return value == 0
+	case int:
+		return value == 0
+	case int32:
+		return value == 0
+	case int64:
+		return value == 0
+	case uint:
+		return value == 0
+	case uint32:
+		return value == 0
+	case uint64:
+		return value == 0
+	default:
+		return false
+	}
+}
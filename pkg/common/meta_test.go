@@ -0,0 +1,74 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMetricMeta(t *testing.T) {
+	tests := []struct {
+		key  string
+		unit string
+		kind string
+	}{
+		{"mem.total_B", "B", MetricKindGauge},
+		{"fs.free_B./mnt/data_percent", "B", MetricKindGauge},
+		{"fs.used_percent./", "%", MetricKindGauge},
+		{"net.in_B_per_s.eth0", "B/s", MetricKindRate},
+		{"disk.read_ops_per_s.sda", "ops/s", MetricKindRate},
+		{"net.errors_per_s.eth0", "1/s", MetricKindRate},
+		{"system.uptime_s", "s", MetricKindGauge},
+		{"system.ntp_offset_ms", "ms", MetricKindGauge},
+		{"cpu.util.idle.1.total", "%", MetricKindGauge},
+		{"containers.total", "count", MetricKindGauge},
+		{"system.users_count", "", ""},
+		{"system.uname", "", ""},
+		{"_B", "", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			unit, kind := MetricMeta(tt.key)
+			assert.Equal(t, tt.unit, unit)
+			assert.Equal(t, tt.kind, kind)
+		})
+	}
+}
+
+func TestMeasurementsMapMetadata(t *testing.T) {
+	measurements := MeasurementsMap{
+		"mem.total_B":  uint64(1024),
+		"system.uname": "Linux",
+	}
+
+	assert.Equal(t, map[string]MetricMetadata{
+		"mem.total_B": {Unit: "B", Kind: MetricKindGauge},
+	}, measurements.Metadata())
+}
+
+func TestMeasurementsMapWithoutZeroRates(t *testing.T) {
+	measurements := MeasurementsMap{
+		// idle interface
+		"net.in_B_per_s.eth0":  float64(0),
+		"net.out_B_per_s.eth0": 0,
+		// busy interface
+		"net.in_B_per_s.eth1":     float64(1250.5),
+		"net.out_B_per_s.eth1":    uint64(320),
+		"net.errors_per_s.eth1":   float64(0),
+		"net.dropped_per_s.eth1":  nil,
+		"fs.free_B./":             uint64(0),
+		"fs.read_B_per_s./":       float64(0),
+		"fs.write_ops_per_s./":    float64(3),
+		"cpu.util.iowait.1.total": float64(0),
+	}
+
+	assert.Equal(t, MeasurementsMap{
+		"net.in_B_per_s.eth1":     float64(1250.5),
+		"net.out_B_per_s.eth1":    uint64(320),
+		"net.dropped_per_s.eth1":  nil,
+		"fs.free_B./":             uint64(0),
+		"fs.write_ops_per_s./":    float64(3),
+		"cpu.util.iowait.1.total": float64(0),
+	}, measurements.WithoutZeroRates())
+}
@@ -0,0 +1,113 @@
+package common
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// MetricDescriptor describes a measurement key the collectors can report.
+// Key is the full key pattern with placeholders in angle brackets, e.g. "net.in_B_per_s.<iface>"
+type MetricDescriptor struct {
+	Key  string
+	Unit string
+	// ConfigOption is the config option which enables the metric, empty if it's always reported
+	ConfigOption string
+}
+
+var metricRegistry = struct {
+	mu          sync.Mutex
+	descriptors map[string]MetricDescriptor
+	patterns    map[string]*regexp.Regexp
+}{descriptors: make(map[string]MetricDescriptor), patterns: make(map[string]*regexp.Regexp)}
+
+var metricPlaceholderRegexp = regexp.MustCompile(`<[^>]+>`)
+
+// metricKeyPattern returns the regexp matching the keys of the key pattern, a placeholder matches any non-empty part of the key
+func metricKeyPattern(key string) *regexp.Regexp {
+	parts := metricPlaceholderRegexp.Split(key, -1)
+	for i := range parts {
+		parts[i] = regexp.QuoteMeta(parts[i])
+	}
+
+	return regexp.MustCompile("^" + strings.Join(parts, ".+") + "$")
+}
+
+// RegisterMetrics adds the metrics the collector reports to the registry. The descriptor of an already registered key is replaced
+func RegisterMetrics(descriptors ...MetricDescriptor) {
+	metricRegistry.mu.Lock()
+	defer metricRegistry.mu.Unlock()
+
+	for _, d := range descriptors {
+		metricRegistry.descriptors[d.Key] = d
+		metricRegistry.patterns[d.Key] = metricKeyPattern(d.Key)
+	}
+}
+
+// MetricDescriptors returns the registered metrics sorted by key
+func MetricDescriptors() []MetricDescriptor {
+	metricRegistry.mu.Lock()
+	defer metricRegistry.mu.Unlock()
+
+	res := make([]MetricDescriptor, 0, len(metricRegistry.descriptors))
+	for _, d := range metricRegistry.descriptors {
+		res = append(res, d)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		return res[i].Key < res[j].Key
+	})
+
+	return res
+}
+
+// IsMetricRegistered reports whether key matches the key pattern of a registered metric
+func IsMetricRegistered(key string) bool {
+	metricRegistry.mu.Lock()
+	defer metricRegistry.mu.Unlock()
+
+	if _, exists := metricRegistry.descriptors[key]; exists {
+		return true
+	}
+
+	for _, pattern := range metricRegistry.patterns {
+		if pattern.MatchString(key) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// UnregisteredMetrics returns the sorted keys of mm which don't match any registered metric.
+// The keys of nested maps are joined with a dot, e.g. "hw.inventory" containing "bios.vendor" is checked as "hw.inventory.bios.vendor"
+func UnregisteredMetrics(mm MeasurementsMap) []string {
+	var unregistered []string
+	walkMetricKeys("", mm, func(key string) {
+		if !IsMetricRegistered(key) {
+			unregistered = append(unregistered, key)
+		}
+	})
+	sort.Strings(unregistered)
+
+	return unregistered
+}
+
+// walkMetricKeys calls fn with the keys of m, the nested maps are walked unless they are registered metrics themselves, e.g. "modules"
+func walkMetricKeys(prefix string, m map[string]interface{}, fn func(key string)) {
+	for k, v := range m {
+		key := prefix + k
+
+		nested, isMap := v.(map[string]interface{})
+		if mm, ok := v.(MeasurementsMap); ok {
+			nested, isMap = mm, true
+		}
+
+		if isMap && !IsMetricRegistered(key) {
+			walkMetricKeys(key+".", nested, fn)
+			continue
+		}
+
+		fn(key)
+	}
+}
@@ -0,0 +1,26 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnregisteredMetrics(t *testing.T) {
+	RegisterMetrics(
+		MetricDescriptor{Key: "test.free_B.<mount>", Unit: "B"},
+		MetricDescriptor{Key: "test.inventory.ram.<n>.size_B", Unit: "B"},
+		MetricDescriptor{Key: "test.modules"},
+	)
+
+	assert.True(t, IsMetricRegistered("test.free_B./var/lib"))
+	assert.False(t, IsMetricRegistered("test.free_B."))
+	assert.False(t, IsMetricRegistered("test.free_Bytes./"))
+
+	assert.Equal(t, []string{"test.inventory.ram.0.type", "test.unknown"}, UnregisteredMetrics(MeasurementsMap{
+		"test.free_B./":  1,
+		"test.unknown":   1,
+		"test.modules":   []interface{}{map[string]interface{}{"name": "raid"}},
+		"test.inventory": map[string]interface{}{"ram.0.size_B": 1, "ram.0.type": "DDR4"},
+	}))
+}
@@ -0,0 +1,75 @@
+package common
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// NestedLeafKey holds the value of a key which is a prefix of other keys, e.g. "a.b" having "a.b.c" as well
+const NestedLeafKey = "_value"
+
+// nestedBranch distinguishes objects created by Nest from the map values of measurements
+type nestedBranch map[string]interface{}
+
+// Nest expands dotted keys into nested objects, e.g. "cpu.util.idle" becomes {"cpu": {"util": {"idle": ...}}}.
+// Map values of the measurements are not expanded.
+// If a key is both a value and an object, the value is kept under NestedLeafKey of the object and the collision is reported in the error
+func (mm MeasurementsMap) Nest() (MeasurementsMap, error) {
+	keys := make([]string, 0, len(mm))
+	for key := range mm {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	root := nestedBranch{}
+	var collisions []string
+	for _, key := range keys {
+		parts := strings.Split(key, ".")
+
+		node := root
+		for i, part := range parts[:len(parts)-1] {
+			child, exists := node[part]
+			if branch, isBranch := child.(nestedBranch); isBranch {
+				node = branch
+				continue
+			}
+
+			branch := nestedBranch{}
+			if exists {
+				collisions = append(collisions, strings.Join(parts[:i+1], "."))
+				branch[NestedLeafKey] = child
+			}
+			node[part] = branch
+			node = branch
+		}
+
+		last := parts[len(parts)-1]
+		if branch, isBranch := node[last].(nestedBranch); isBranch {
+			collisions = append(collisions, key)
+			branch[NestedLeafKey] = mm[key]
+			continue
+		}
+		node[last] = mm[key]
+	}
+
+	result := MeasurementsMap(root.toMap())
+	if len(collisions) > 0 {
+		return result, fmt.Errorf("keys having both a value and nested keys: %s", strings.Join(collisions, ", "))
+	}
+
+	return result, nil
+}
+
+func (b nestedBranch) toMap() map[string]interface{} {
+	result := make(map[string]interface{}, len(b))
+	for key, value := range b {
+		if branch, isBranch := value.(nestedBranch); isBranch {
+			result[key] = branch.toMap()
+			continue
+		}
+		result[key] = value
+	}
+
+	return result
+}
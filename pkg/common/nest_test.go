@@ -0,0 +1,72 @@
+package common
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasurementsMapNest(t *testing.T) {
+	measurements := MeasurementsMap{
+		"cpu.util.idle.1.total": 97.5,
+		"cpu.util.user.1.total": 2.5,
+		"cpu.load.avg.1":        0.1,
+		"fs.free_B./":           uint64(1024),
+		"net.link_speed":        nil,
+		"operation_mode":        "full",
+		"smartmon": MeasurementsMap{
+			"/dev/sda": map[string]interface{}{"smart_status": "PASSED"},
+		},
+	}
+
+	nested, err := measurements.Nest()
+	assert.NoError(t, err)
+	assert.Equal(t, MeasurementsMap{
+		"cpu": map[string]interface{}{
+			"util": map[string]interface{}{
+				"idle": map[string]interface{}{"1": map[string]interface{}{"total": 97.5}},
+				"user": map[string]interface{}{"1": map[string]interface{}{"total": 2.5}},
+			},
+			"load": map[string]interface{}{
+				"avg": map[string]interface{}{"1": 0.1},
+			},
+		},
+		"fs": map[string]interface{}{
+			"free_B": map[string]interface{}{"/": uint64(1024)},
+		},
+		"net": map[string]interface{}{
+			"link_speed": nil,
+		},
+		"operation_mode": "full",
+		"smartmon": MeasurementsMap{
+			"/dev/sda": map[string]interface{}{"smart_status": "PASSED"},
+		},
+	}, nested)
+}
+
+func TestMeasurementsMapNestCollision(t *testing.T) {
+	measurements := MeasurementsMap{
+		"net.addr.1":        "10.0.0.1/8",
+		"net.addr.1.family": "ipv4",
+		"system.uname":      "Linux",
+		"system.uname.arch": "amd64",
+		"a.b":               nil,
+		"a.b.c":             1,
+	}
+
+	nested, err := measurements.Nest()
+	assert.EqualError(t, err, "keys having both a value and nested keys: a.b, net.addr.1, system.uname")
+	assert.Equal(t, MeasurementsMap{
+		"a": map[string]interface{}{
+			"b": map[string]interface{}{NestedLeafKey: nil, "c": 1},
+		},
+		"net": map[string]interface{}{
+			"addr": map[string]interface{}{
+				"1": map[string]interface{}{NestedLeafKey: "10.0.0.1/8", "family": "ipv4"},
+			},
+		},
+		"system": map[string]interface{}{
+			"uname": map[string]interface{}{NestedLeafKey: "Linux", "arch": "amd64"},
+		},
+	}, nested)
+}
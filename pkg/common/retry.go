@@ -0,0 +1,81 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os/exec"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// exit codes used by shells when command can't be executed or is not found
+const (
+	exitCodeCannotExecute = 126
+	exitCodeNotFound      = 127
+)
+
+var permanentCommandErrorMessages = [][]byte{
+	[]byte("permission denied"),
+	[]byte("operation not permitted"),
+	[]byte("command not found"),
+	[]byte("a password is required"),
+}
+
+// RetryInvoker wraps Invoker and re-runs commands which failed with a transient error
+type RetryInvoker struct {
+	Invoker Invoker
+	// Retries is the number of additional attempts after the first failed one
+	Retries int
+	Delay   time.Duration
+	// IsRetryable decides if the failed command should be retried. IsTransientCommandError is used if nil
+	IsRetryable func(output []byte, err error) bool
+}
+
+var _ Invoker = (*RetryInvoker)(nil)
+
+func (r *RetryInvoker) CommandWithContext(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	isRetryable := r.IsRetryable
+	if isRetryable == nil {
+		isRetryable = IsTransientCommandError
+	}
+
+	for attempt := 0; ; attempt++ {
+		output, err := r.Invoker.CommandWithContext(ctx, name, arg...)
+		if err == nil || attempt >= r.Retries || ctx.Err() != nil || !isRetryable(output, err) {
+			return output, err
+		}
+
+		logrus.WithError(err).Debugf("command '%s' failed, retrying in %s (attempt %d of %d)", name, r.Delay, attempt+1, r.Retries)
+
+		select {
+		case <-ctx.Done():
+			return output, err
+		case <-time.After(r.Delay):
+		}
+	}
+}
+
+// IsTransientCommandError checks if the command failed for the reason which may disappear on the next run, e.g. device is busy.
+// Commands that are not installed or lack permissions are not considered transient
+func IsTransientCommandError(output []byte, err error) bool {
+	var exitErr *exec.ExitError
+	if !errors.As(err, &exitErr) {
+		// command couldn't be started at all or was killed by context
+		return false
+	}
+
+	if code := exitErr.ExitCode(); code == exitCodeCannotExecute || code == exitCodeNotFound || code < 0 {
+		return false
+	}
+
+	lowerOutput := bytes.ToLower(bytes.Join([][]byte{output, CommandErrorOutput(err)}, []byte("\n")))
+	for _, msg := range permanentCommandErrorMessages {
+		if bytes.Contains(lowerOutput, msg) {
+			return false
+		}
+	}
+
+	return true
+}
@@ -3,6 +3,8 @@ package common
 import (
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"time"
 
@@ -32,6 +34,74 @@ func (mm MeasurementsMap) AddInnerWithPrefix(prefix string, m MeasurementsMap) M
 	return mm
 }
 
+// Merge adds the measurements of other to mm with the keys prefixed by prefix, the values of existing keys are overwritten.
+// Returns the sorted list of the overwritten keys, so collisions between collectors can be reported
+func (mm MeasurementsMap) Merge(other MeasurementsMap, prefix string) []string {
+	var conflicts []string
+	for k, v := range other {
+		if _, exists := mm[prefix+k]; exists {
+			conflicts = append(conflicts, prefix+k)
+		}
+		mm[prefix+k] = v
+	}
+
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// Round returns measurements with all float values rounded to the specified number of decimal places.
+// Nested measurement maps are rounded as well, other values are kept as is
+func (mm MeasurementsMap) Round(precision int) MeasurementsMap {
+	return MeasurementsMap(roundMap(mm, precision))
+}
+
+func roundMap(m map[string]interface{}, precision int) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	result := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		result[k] = roundValue(v, precision)
+	}
+	return result
+}
+
+func roundValue(v interface{}, precision int) interface{} {
+	switch value := v.(type) {
+	case float64:
+		return RoundToPrecision(value, precision)
+	case float32:
+		return float32(RoundToPrecision(float64(value), precision))
+	case MeasurementsMap:
+		return MeasurementsMap(roundMap(value, precision))
+	case map[string]interface{}:
+		return roundMap(value, precision)
+	default:
+		return v
+	}
+}
+
+// ChangedSince returns measurements which values differ from prev. Keys missing in mm are treated as nil values,
+// so the keys which disappeared since prev are returned with nil value
+func (mm MeasurementsMap) ChangedSince(prev MeasurementsMap) MeasurementsMap {
+	changed := MeasurementsMap{}
+	for k, v := range mm {
+		prevValue, exists := prev[k]
+		if !exists || !reflect.DeepEqual(v, prevValue) {
+			changed[k] = v
+		}
+	}
+
+	for k, prevValue := range prev {
+		if _, exists := mm[k]; !exists && prevValue != nil {
+			changed[k] = nil
+		}
+	}
+
+	return changed
+}
+
 // Timestamp type allows marshaling time.Time struct as Unix timestamp value
 type Timestamp time.Time
 
@@ -0,0 +1,88 @@
+package common
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMeasurementsMapRound(t *testing.T) {
+	measurements := MeasurementsMap{
+		"cpu.util.idle.1.total": 97.123456,
+		"mem.used_percent":      float32(42.55555),
+		"fs.free_B./":           uint64(1024),
+		"net.link_speed":        nil,
+		"system.uname":          "Linux",
+		"smartmon": MeasurementsMap{
+			"/dev/sda": map[string]interface{}{"temperature_C": 36.66666},
+		},
+	}
+
+	rounded := measurements.Round(2)
+	assert.Equal(t, 97.12, rounded["cpu.util.idle.1.total"])
+	assert.Equal(t, float32(42.56), rounded["mem.used_percent"])
+	assert.Equal(t, uint64(1024), rounded["fs.free_B./"])
+	assert.Nil(t, rounded["net.link_speed"])
+	assert.Equal(t, "Linux", rounded["system.uname"])
+	assert.Equal(t, 36.67, rounded["smartmon"].(MeasurementsMap)["/dev/sda"].(map[string]interface{})["temperature_C"])
+
+	// original measurements are not modified
+	assert.Equal(t, 97.123456, measurements["cpu.util.idle.1.total"])
+
+	rounded = measurements.Round(0)
+	assert.Equal(t, 97.0, rounded["cpu.util.idle.1.total"])
+	assert.Equal(t, float32(43), rounded["mem.used_percent"])
+
+	b, err := json.Marshal(MeasurementsMap{
+		"cpu.util.idle.1.total": rounded["cpu.util.idle.1.total"],
+		"mem.used_percent":      rounded["mem.used_percent"],
+	})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"cpu.util.idle.1.total":97,"mem.used_percent":43}`, string(b))
+}
+
+func TestMeasurementsMapChangedSince(t *testing.T) {
+	prev := MeasurementsMap{
+		"cpu.load.avg.1":  0.5,
+		"fs.free_B./":     uint64(1024),
+		"net.link_speed":  nil,
+		"mem.total_B":     nil,
+		"hw.inventory":    MeasurementsMap{"cpu.cores": 4},
+		"services.nginx":  "running",
+		"temperatures.os": nil,
+	}
+	current := MeasurementsMap{
+		"cpu.load.avg.1": 0.5,
+		"fs.free_B./":    uint64(512),
+		"net.link_speed": 1000,
+		"mem.total_B":    nil,
+		"hw.inventory":   MeasurementsMap{"cpu.cores": 4},
+		"swap.total_B":   0,
+	}
+
+	assert.Equal(t, MeasurementsMap{
+		"fs.free_B./":    uint64(512),
+		"net.link_speed": 1000,
+		"swap.total_B":   0,
+		"services.nginx": nil,
+	}, current.ChangedSince(prev))
+
+	assert.Equal(t, MeasurementsMap{}, current.ChangedSince(current))
+	assert.Equal(t, current, current.ChangedSince(nil))
+}
+
+func TestMeasurementsMapMerge(t *testing.T) {
+	mm := MeasurementsMap{"temp.disk.sda": 35, "cpu.load.avg.1": 0.5}
+
+	conflicts := mm.Merge(MeasurementsMap{"disk.sdb": 40, "disk.sda": 36}, "temp.")
+	assert.Equal(t, []string{"temp.disk.sda"}, conflicts)
+	assert.Equal(t, MeasurementsMap{
+		"temp.disk.sda":  36,
+		"temp.disk.sdb":  40,
+		"cpu.load.avg.1": 0.5,
+	}, mm)
+
+	assert.Empty(t, mm.Merge(MeasurementsMap{"sda": 36}, "smart."))
+	assert.Empty(t, mm.Merge(nil, ""))
+}
@@ -3,14 +3,80 @@ package hwinfo
 import (
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const (
+	InventoryTypePCI      = "pci"
+	InventoryTypeUSB      = "usb"
+	InventoryTypeDisplays = "displays"
+	InventoryTypeCPU      = "cpu"
+	InventoryTypeMemory   = "memory"
 )
 
+var InventoryTypes = []string{InventoryTypePCI, InventoryTypeUSB, InventoryTypeDisplays, InventoryTypeCPU, InventoryTypeMemory}
+
+const (
+	DmidecodeSectionBaseboard = "baseboard"
+	DmidecodeSectionMemory    = "memory"
+	DmidecodeSectionBIOS      = "bios"
+	DmidecodeSectionChassis   = "chassis"
+	DmidecodeSectionProcessor = "processor"
+)
+
+// DmidecodeSections are the parts of the dmidecode output reported in the memory inventory
+var DmidecodeSections = []string{DmidecodeSectionBaseboard, DmidecodeSectionMemory, DmidecodeSectionBIOS, DmidecodeSectionChassis, DmidecodeSectionProcessor}
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "hw.inventory.pci.list", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.usb.list", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.displays.list", ConfigOption: "hardware_inventory"},
+
+		common.MetricDescriptor{Key: "hw.inventory.cpu.<cpu>.manufacturer", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.<cpu>.manufacturing_info", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.<cpu>.description", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.<cpu>.core_count", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.<cpu>.thread_count", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.microcode_version", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.cpu.vuln.<vuln>", ConfigOption: "hardware_inventory"},
+
+		common.MetricDescriptor{Key: "hw.inventory.baseboard.manufacturer", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.baseboard.model", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.baseboard.serial_number", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.ram.number_of_modules", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.ram.<module>.size_B", Unit: "B", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.ram.<module>.type", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.bios.vendor", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.bios.version", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.bios.release_date", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.chassis.manufacturer", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.chassis.type", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.chassis.serial_number", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.processor.<socket>.socket", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.processor.<socket>.version", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.processor.<socket>.max_speed", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.processor.<socket>.core_count", ConfigOption: "hardware_inventory"},
+		common.MetricDescriptor{Key: "hw.inventory.processor.<socket>.thread_count", ConfigOption: "hardware_inventory"},
+	)
+}
+
 type pciDeviceInfo struct {
 	Address     string `json:"address"`
 	DeviceType  string `json:"device_type,omitempty"`
 	VendorName  string `json:"vendor_name,omitempty"`
 	ProductName string `json:"product_name"`
 	Description string `json:"description,omitempty"`
+	// Driver is the kernel driver bound to the device, empty if none is bound or the platform doesn't report it
+	Driver string `json:"driver,omitempty"`
+	// PCIe link state, e.g. "8.0 GT/s PCIe" and "16". Reported on Linux only
+	LinkSpeed    string `json:"link_speed,omitempty"`
+	MaxLinkSpeed string `json:"max_link_speed,omitempty"`
+	LinkWidth    string `json:"link_width,omitempty"`
+	MaxLinkWidth string `json:"max_link_width,omitempty"`
+	// LinkDegraded is true if the link runs at lower speed or width than supported, nil if unknown
+	LinkDegraded *bool `json:"link_degraded,omitempty"`
 }
 
 type usbDeviceInfo struct {
@@ -26,10 +92,79 @@ type monitorInfo struct {
 	VendorName  string `json:"vendor_name,omitempty"`
 	Size        string `json:"size,omitempty"`
 	Resolution  string `json:"resolution,omitempty"`
+	Pixels      string `json:"pixels,omitempty"`
+	RefreshRate string `json:"refresh_rate,omitempty"`
+	PixelDepth  string `json:"pixel_depth,omitempty"`
+	IsMain      bool   `json:"is_main,omitempty"`
+}
+
+// inventoryCollectors holds platform-specific functions retrieving each type of hardware inventory
+type inventoryCollectors struct {
+	listPCIDevices func() ([]*pciDeviceInfo, error)
+	listUSBDevices func() ([]*usbDeviceInfo, error)
+	listDisplays   func() ([]*monitorInfo, error)
+	listCPUs       func() (map[string]interface{}, error)
+	// listMemory also reports the baseboard info as both are retrieved at once on some platforms
+	listMemory func() (map[string]interface{}, error)
+}
+
+// collect runs only collectors of requested inventory types. Empty list means all types
+func (c *inventoryCollectors) collect(types []string) (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	errorCollector := common.ErrorCollector{}
+
+	isEnabled := func(t string) bool {
+		return len(types) == 0 || common.StrInSlice(t, types)
+	}
+
+	if isEnabled(InventoryTypePCI) {
+		pciDevices, err := c.listPCIDevices()
+		errorCollector.Add(err)
+		if len(pciDevices) > 0 {
+			res["pci.list"] = pciDevices
+		}
+	}
+
+	if isEnabled(InventoryTypeUSB) {
+		usbDevices, err := c.listUSBDevices()
+		errorCollector.Add(err)
+		if len(usbDevices) > 0 {
+			res["usb.list"] = usbDevices
+		}
+	}
+
+	if isEnabled(InventoryTypeDisplays) {
+		displays, err := c.listDisplays()
+		errorCollector.Add(err)
+		if len(displays) > 0 {
+			res["displays.list"] = displays
+		}
+	}
+
+	if isEnabled(InventoryTypeCPU) {
+		cpus, err := c.listCPUs()
+		errorCollector.Add(err)
+		if len(cpus) > 0 {
+			res = common.MergeStringMaps(res, cpus)
+		}
+	}
+
+	if isEnabled(InventoryTypeMemory) {
+		memory, err := c.listMemory()
+		errorCollector.Add(err)
+		if len(memory) > 0 {
+			res = common.MergeStringMaps(res, memory)
+		}
+	}
+
+	return res, errorCollector.Combine()
 }
 
-func Inventory() (map[string]interface{}, error) {
-	hw, err := fetchInventory()
+// Inventory retrieves hardware inventory of given types. See InventoryTypes for possible values, empty list means all types
+// dmidecodeSections limits the parts of the dmidecode output reported, see DmidecodeSections. Empty list means all sections
+// invoker is used to run external tools like dmidecode
+func Inventory(types []string, dmidecodeSections []string, invoker common.Invoker) (map[string]interface{}, error) {
+	hw, err := fetchInventory(types, dmidecodeSections, invoker)
 	if err != nil {
 		err = errors.Wrap(err, "[HWINFO]")
 		log.Error(err)
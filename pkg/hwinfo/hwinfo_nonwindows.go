@@ -3,10 +3,9 @@
 package hwinfo
 
 import (
-	"bufio"
 	"bytes"
+	"context"
 	"fmt"
-	"io/ioutil"
 	"os/exec"
 	"strings"
 
@@ -26,103 +25,139 @@ func isDmidecodeAvailable() bool {
 	return true
 }
 
-func fetchInventory() (map[string]interface{}, error) {
-	res := make(map[string]interface{})
-	errorCollector := common.ErrorCollector{}
-
-	pciDevices, err := listPCIDevices()
-	errorCollector.Add(err)
-	if len(pciDevices) > 0 {
-		res["pci.list"] = pciDevices
-	}
-
-	usbDevices, err := listUSBDevices()
-	errorCollector.Add(err)
-	if len(usbDevices) > 0 {
-		res["usb.list"] = usbDevices
-	}
-
-	displays, err := listDisplays()
-	errorCollector.Add(err)
-	if len(displays) > 0 {
-		res["displays.list"] = displays
-	}
-
-	cpus, err := listCPUs()
-	errorCollector.Add(err)
-	if cpus != nil {
-		res = common.MergeStringMaps(res, cpus)
+func fetchInventory(types []string, dmidecodeSections []string, invoker common.Invoker) (map[string]interface{}, error) {
+	collectors := &inventoryCollectors{
+		listPCIDevices: listPCIDevices,
+		listUSBDevices: listUSBDevices,
+		listDisplays:   listDisplays,
+		listCPUs:       listCPUs,
+		listMemory: func() (map[string]interface{}, error) {
+			return retrieveInfoUsingDmiDecode(dmidecodeSections, invoker)
+		},
 	}
 
-	dmiDecodeResults, err := retrieveInfoUsingDmiDecode()
-	errorCollector.Add(err)
-	if len(dmiDecodeResults) > 0 {
-		res = common.MergeStringMaps(res, dmiDecodeResults)
-	}
-
-	return res, errorCollector.Combine()
+	return collectors.collect(types)
 }
 
-func retrieveInfoUsingDmiDecode() (map[string]interface{}, error) {
+func retrieveInfoUsingDmiDecode(sections []string, invoker common.Invoker) (map[string]interface{}, error) {
 	if !isDmidecodeAvailable() {
 		common.LogOncef(log.InfoLevel, "[HWINFO] dmidecode is not present. Skipping retrieval of baseboard, CPU and RAM info...")
 		return nil, nil
 	}
 
-	cmd := exec.Command("/bin/sh", "-c", dmidecodeCommand())
-
-	stdoutBuffer := bytes.Buffer{}
-	cmd.Stdout = bufio.NewWriter(&stdoutBuffer)
+	output, err := runDmidecode(invoker)
+	if err != nil || output == nil {
+		return nil, err
+	}
 
-	stderrBuffer := bytes.Buffer{}
-	cmd.Stderr = bufio.NewWriter(&stderrBuffer)
+	return parseDmidecodeOutput(output, sections)
+}
 
-	if err := cmd.Run(); err != nil {
-		stderrBytes, _ := ioutil.ReadAll(bufio.NewReader(&stderrBuffer))
-		stderr := string(stderrBytes)
-		if strings.Contains(stderr, "/dev/mem: Operation not permitted") {
-			log.Infof("[HWINFO] there was an error while executing '%s': %s\nProbably 'CONFIG_STRICT_DEVMEM' kernel configuration option is enabled. Please refer to kernel configuration manual.", dmidecodeCommand(), stderr)
+// runDmidecode returns nil output without error if dmidecode is not permitted to read DMI table
+// invoker is expected to return the standard output only, see common.Invoke.StdoutOnly
+func runDmidecode(invoker common.Invoker) ([]byte, error) {
+	output, err := invoker.CommandWithContext(context.Background(), "/bin/sh", "-c", dmidecodeCommand())
+	if err != nil {
+		errOutput := string(output) + string(common.CommandErrorOutput(err))
+		if strings.Contains(errOutput, "/dev/mem: Operation not permitted") {
+			log.Infof("[HWINFO] there was an error while executing '%s': %s\nProbably 'CONFIG_STRICT_DEVMEM' kernel configuration option is enabled. Please refer to kernel configuration manual.", dmidecodeCommand(), errOutput)
 			return nil, nil
 		}
 		return nil, errors.Wrap(err, "execute dmidecode")
 	}
 
-	dmi, err := dmidecode.Unmarshal(bufio.NewReader(&stdoutBuffer))
+	return output, nil
+}
+
+// parseDmidecodeOutput reports the requested sections of the dmidecode output, empty list means all sections
+func parseDmidecodeOutput(output []byte, sections []string) (map[string]interface{}, error) {
+	dmi, err := dmidecode.Unmarshal(bytes.NewReader(output))
 	if err != nil {
 		return nil, errors.Wrap(err, "unmarshal dmi")
 	}
 
+	isEnabled := func(section string) bool {
+		return len(sections) == 0 || common.StrInSlice(section, sections)
+	}
+
 	res := make(map[string]interface{})
 
 	// all below requests are based on parsed data returned by dmidecode.Unmarshal
 	// refer to doc dmidecode.Get to get description of function behavior
-	var reqSys []dmidecode.ReqBaseBoard
-	if err = dmi.Get(&reqSys); err == nil {
-		res["baseboard.manufacturer"] = reqSys[0].Manufacturer
-		res["baseboard.model"] = reqSys[0].Version
-		res["baseboard.serial_number"] = reqSys[0].SerialNumber
-	} else if err != dmidecode.ErrNotFound {
-		log.WithError(err).Info("[HWINFO] failed fetching baseboard info")
+	if isEnabled(DmidecodeSectionBaseboard) {
+		var reqSys []dmidecode.ReqBaseBoard
+		if err = dmi.Get(&reqSys); err == nil {
+			res["baseboard.manufacturer"] = reqSys[0].Manufacturer
+			res["baseboard.model"] = reqSys[0].Version
+			res["baseboard.serial_number"] = reqSys[0].SerialNumber
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching baseboard info")
+		}
 	}
 
-	var reqMem []dmidecode.ReqPhysicalMemoryArray
-	if err = dmi.Get(&reqMem); err == nil {
-		res["ram.number_of_modules"] = reqMem[0].NumberOfDevices
-	} else if err != dmidecode.ErrNotFound {
-		log.WithError(err).Info("[HWINFO] failed fetching memory array info")
+	if isEnabled(DmidecodeSectionMemory) {
+		var reqMem []dmidecode.ReqPhysicalMemoryArray
+		if err = dmi.Get(&reqMem); err == nil {
+			res["ram.number_of_modules"] = reqMem[0].NumberOfDevices
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching memory array info")
+		}
+
+		var reqMemDevs []dmidecode.ReqMemoryDevice
+		if err = dmi.Get(&reqMemDevs); err == nil {
+			for i := range reqMemDevs {
+				if reqMemDevs[i].Size == -1 {
+					continue
+				}
+				res[fmt.Sprintf("ram.%d.size_B", i)] = reqMemDevs[i].Size
+				res[fmt.Sprintf("ram.%d.type", i)] = reqMemDevs[i].Type
+			}
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching memory device info")
+		}
+	}
+
+	if isEnabled(DmidecodeSectionBIOS) {
+		var reqBIOS []dmidecode.ReqBiosInfo
+		if err = dmi.Get(&reqBIOS); err == nil {
+			res["bios.vendor"] = reqBIOS[0].Vendor
+			res["bios.version"] = reqBIOS[0].Version
+			if !reqBIOS[0].ReleaseDate.IsZero() {
+				res["bios.release_date"] = reqBIOS[0].ReleaseDate.Format("2006-01-02")
+			}
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching BIOS info")
+		}
+	}
+
+	if isEnabled(DmidecodeSectionChassis) {
+		var reqChassis []dmidecode.ReqChassis
+		if err = dmi.Get(&reqChassis); err == nil {
+			res["chassis.manufacturer"] = reqChassis[0].Manufacturer
+			res["chassis.type"] = reqChassis[0].Type
+			res["chassis.serial_number"] = reqChassis[0].SerialNumber
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching chassis info")
+		}
 	}
 
-	var reqMemDevs []dmidecode.ReqMemoryDevice
-	if err = dmi.Get(&reqMemDevs); err == nil {
-		for i := range reqMemDevs {
-			if reqMemDevs[i].Size == -1 {
-				continue
+	if isEnabled(DmidecodeSectionProcessor) {
+		var reqProcessors []dmidecode.ReqProcessor
+		if err = dmi.Get(&reqProcessors); err == nil {
+			for i := range reqProcessors {
+				// empty sockets
+				if strings.HasPrefix(reqProcessors[i].Status, "Unpopulated") {
+					continue
+				}
+				res[fmt.Sprintf("processor.%d.socket", i)] = reqProcessors[i].SocketDesignation
+				res[fmt.Sprintf("processor.%d.version", i)] = reqProcessors[i].Version
+				res[fmt.Sprintf("processor.%d.max_speed", i)] = reqProcessors[i].MaxSpeed
+				res[fmt.Sprintf("processor.%d.core_count", i)] = reqProcessors[i].CoreCount
+				res[fmt.Sprintf("processor.%d.thread_count", i)] = reqProcessors[i].ThreadCount
 			}
-			res[fmt.Sprintf("ram.%d.size_B", i)] = reqMemDevs[i].Size
-			res[fmt.Sprintf("ram.%d.type", i)] = reqMemDevs[i].Type
+		} else if err != dmidecode.ErrNotFound {
+			log.WithError(err).Info("[HWINFO] failed fetching processor info")
 		}
-	} else if err != dmidecode.ErrNotFound {
-		log.WithError(err).Info("[HWINFO] failed fetching memory device info")
 	}
 
 	return res, nil
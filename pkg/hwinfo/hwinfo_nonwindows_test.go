@@ -0,0 +1,123 @@
+// +build !windows
+
+package hwinfo
+
+import (
+	"context"
+	"io/ioutil"
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+type failingInvoker struct {
+	failures    int
+	failOutput  string
+	failErr     error
+	output      []byte
+	invocations int
+}
+
+func (f *failingInvoker) CommandWithContext(_ context.Context, _ string, _ ...string) ([]byte, error) {
+	f.invocations++
+	if f.invocations <= f.failures {
+		return []byte(f.failOutput), f.failErr
+	}
+
+	return f.output, nil
+}
+
+func helperExitError(t *testing.T, code string) error {
+	err := exec.Command("/bin/sh", "-c", "exit "+code).Run()
+	_, ok := err.(*exec.ExitError)
+	assert.True(t, ok)
+
+	return err
+}
+
+func TestRunDmidecodeRetriesTransientFailure(t *testing.T) {
+	output, err := ioutil.ReadFile("testdata/dmidecode.txt")
+	assert.NoError(t, err)
+
+	invoker := &failingInvoker{
+		failures:   1,
+		failOutput: "/dev/mem: Device or resource busy",
+		failErr:    helperExitError(t, "1"),
+		output:     output,
+	}
+
+	dmidecodeOutput, err := runDmidecode(&common.RetryInvoker{Invoker: invoker, Retries: 1})
+	assert.NoError(t, err)
+	assert.Equal(t, 2, invoker.invocations)
+
+	res, err := parseDmidecodeOutput(dmidecodeOutput, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "Supermicro", res["baseboard.manufacturer"])
+	assert.Equal(t, "ZM148S012345", res["baseboard.serial_number"])
+	assert.Equal(t, 2, res["ram.number_of_modules"])
+	assert.EqualValues(t, 8192*1024*1024, res["ram.0.size_B"])
+	assert.Equal(t, "DDR3", res["ram.0.type"])
+	assert.NotContains(t, res, "ram.1.size_B")
+}
+
+func TestRunDmidecodeDoesNotRetryPermanentFailure(t *testing.T) {
+	invoker := &failingInvoker{
+		failures:   1,
+		failOutput: "/dev/mem: Operation not permitted",
+		failErr:    helperExitError(t, "1"),
+	}
+
+	dmidecodeOutput, err := runDmidecode(&common.RetryInvoker{Invoker: invoker, Retries: 3})
+	assert.NoError(t, err)
+	assert.Nil(t, dmidecodeOutput)
+	assert.Equal(t, 1, invoker.invocations)
+
+	invoker = &failingInvoker{
+		failures: 1,
+		failErr:  helperExitError(t, "127"),
+	}
+	_, err = runDmidecode(&common.RetryInvoker{Invoker: invoker, Retries: 3})
+	assert.Error(t, err)
+	assert.Equal(t, 1, invoker.invocations)
+}
+
+func TestRunDmidecodeSeparateErrorOutput(t *testing.T) {
+	// stdout only invoker attaches the error output to the error
+	invoker := &failingInvoker{
+		failures: 1,
+		failErr:  &common.CommandError{Err: helperExitError(t, "1"), Stderr: []byte("/dev/mem: Operation not permitted")},
+	}
+
+	dmidecodeOutput, err := runDmidecode(&common.RetryInvoker{Invoker: invoker, Retries: 3})
+	assert.NoError(t, err)
+	assert.Nil(t, dmidecodeOutput)
+	assert.Equal(t, 1, invoker.invocations, "permanent failure must not be retried")
+}
+
+func TestParseDmidecodeOutputSections(t *testing.T) {
+	output, err := ioutil.ReadFile("testdata/dmidecode.txt")
+	assert.NoError(t, err)
+
+	res, err := parseDmidecodeOutput(output, nil)
+	assert.NoError(t, err)
+	assert.Equal(t, "American Megatrends Inc.", res["bios.vendor"])
+	assert.Equal(t, "2019-11-22", res["bios.release_date"])
+	assert.Equal(t, "Main Server Chassis", res["chassis.type"])
+	assert.Equal(t, "C8150LE44A12345", res["chassis.serial_number"])
+	assert.Equal(t, "CPU1", res["processor.0.socket"])
+	assert.Equal(t, "3800 MHz", res["processor.0.max_speed"])
+	assert.Equal(t, 8, res["processor.0.thread_count"])
+	assert.NotContains(t, res, "processor.1.socket", "empty sockets are skipped")
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddInnerWithPrefix("hw.inventory", res)))
+
+	res, err = parseDmidecodeOutput(output, []string{DmidecodeSectionBaseboard})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"baseboard.manufacturer":  "Supermicro",
+		"baseboard.model":         "1.01",
+		"baseboard.serial_number": "ZM148S012345",
+	}, res)
+}
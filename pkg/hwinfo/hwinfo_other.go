@@ -10,6 +10,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"strings"
@@ -134,9 +135,72 @@ func listPCIDevices() ([]*pciDeviceInfo, error) {
 			Description: description,
 		})
 	}
+
+	fillPCISysfsInfo(result, sysPCIDevicesPath())
+
 	return result, nil
 }
 
+func sysPCIDevicesPath() string {
+	return common.GetEnv("HOST_SYS", "/sys", "bus/pci/devices")
+}
+
+// fillPCISysfsInfo sets the driver and the link state of the devices from the sysfs PCI devices dir
+func fillPCISysfsInfo(devices []*pciDeviceInfo, devicesPath string) {
+	for _, device := range devices {
+		devicePath := filepath.Join(devicesPath, device.Address)
+
+		// no driver is bound if the link is missing
+		if target, err := os.Readlink(filepath.Join(devicePath, "driver")); err == nil {
+			device.Driver = filepath.Base(target)
+		}
+
+		device.LinkSpeed = readPCISysfsValue(devicePath, "current_link_speed")
+		device.MaxLinkSpeed = readPCISysfsValue(devicePath, "max_link_speed")
+		device.LinkWidth = readPCISysfsValue(devicePath, "current_link_width")
+		device.MaxLinkWidth = readPCISysfsValue(devicePath, "max_link_width")
+
+		speed, speedOK := parsePCILinkValue(device.LinkSpeed)
+		maxSpeed, maxSpeedOK := parsePCILinkValue(device.MaxLinkSpeed)
+		width, widthOK := parsePCILinkValue(device.LinkWidth)
+		maxWidth, maxWidthOK := parsePCILinkValue(device.MaxLinkWidth)
+		if speedOK && maxSpeedOK && widthOK && maxWidthOK {
+			degraded := speed < maxSpeed || width < maxWidth
+			device.LinkDegraded = &degraded
+		}
+	}
+}
+
+// readPCISysfsValue returns the attribute of the device, empty if it doesn't exist or is unknown, e.g. for legacy PCI devices
+func readPCISysfsValue(devicePath string, attribute string) string {
+	buf, err := ioutil.ReadFile(filepath.Join(devicePath, attribute))
+	if err != nil {
+		return ""
+	}
+
+	value := strings.TrimSpace(string(buf))
+	if strings.HasPrefix(value, "Unknown") || value == "0" {
+		return ""
+	}
+
+	return value
+}
+
+// parsePCILinkValue parses the number of the link speed, e.g. "8.0 GT/s PCIe", or width, e.g. "16"
+func parsePCILinkValue(value string) (float64, bool) {
+	fields := strings.Fields(value)
+	if len(fields) == 0 {
+		return 0, false
+	}
+
+	number, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return 0, false
+	}
+
+	return number, true
+}
+
 func listUSBDevices() ([]*usbDeviceInfo, error) {
 	results := make([]*usbDeviceInfo, 0)
 	reg := regexp.MustCompile(`[^:]+`)
@@ -226,7 +290,13 @@ func listDisplays() ([]*monitorInfo, error) {
 //   - siblings:    amount of threads per CPU in the socket
 // e.g. on HT CPU with 2 cores amount of siblings will be 4
 func listCPUs() (map[string]interface{}, error) {
-	lines, _ := common.ReadLines(common.GetEnv("HOST_PROC", "/proc", "cpuinfo"))
+	return readCPUs(common.GetEnv("HOST_PROC", "/proc", "cpuinfo"), common.GetEnv("HOST_SYS", "/sys", "devices/system/cpu/vulnerabilities"))
+}
+
+// readCPUs reports the CPUs found in the cpuinfo file along with the microcode version
+// and the mitigation status of the CPU vulnerabilities listed in the vulnerabilities dir
+func readCPUs(cpuinfoPath, vulnerabilitiesPath string) (map[string]interface{}, error) {
+	lines, _ := common.ReadLines(cpuinfoPath)
 
 	var cpus []cpuStat
 	var processorName string
@@ -298,7 +368,37 @@ func listCPUs() (map[string]interface{}, error) {
 
 	tryFinalizeCurrentCPU()
 
-	return encodeCPUs(cpus), nil
+	res := encodeCPUs(cpus)
+	for name, status := range readCPUVulnerabilities(vulnerabilitiesPath) {
+		res["cpu.vuln."+name] = status
+	}
+
+	return res, nil
+}
+
+// readCPUVulnerabilities returns the content of the files in /sys/devices/system/cpu/vulnerabilities by the file names,
+// e.g. "spectre_v2": "Mitigation: Full generic retpoline". The dir is missing on the older kernels
+func readCPUVulnerabilities(dirPath string) map[string]string {
+	files, err := ioutil.ReadDir(dirPath)
+	if err != nil {
+		return nil
+	}
+
+	res := make(map[string]string)
+	for _, file := range files {
+		if file.IsDir() {
+			continue
+		}
+
+		content, err := ioutil.ReadFile(filepath.Join(dirPath, file.Name()))
+		if err != nil {
+			log.WithError(err).Debugf("[HWINFO] failed to read CPU vulnerability status '%s'", file.Name())
+			continue
+		}
+		res[file.Name()] = strings.TrimSpace(string(content))
+	}
+
+	return res
 }
 
 func (c *cpuStat) finalize() {
@@ -415,6 +515,11 @@ func encodeCPUs(cpus []cpuStat) map[string]interface{} {
 	encodedCpus := make(map[string]interface{})
 
 	for _, cpu := range cpus {
+		// all the CPUs are expected to run the same microcode
+		if _, found := encodedCpus["cpu.microcode_version"]; !found && cpu.Microcode != "" {
+			encodedCpus["cpu.microcode_version"] = cpu.Microcode
+		}
+
 		if _, found := sorted[cpu.PhysicalID]; !found {
 			sorted[cpu.PhysicalID] = true
 
@@ -0,0 +1,88 @@
+// +build !darwin,!windows
+
+package hwinfo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestFillPCISysfsInfo(t *testing.T) {
+	sysDir, err := ioutil.TempDir("", "cagent-sys")
+	require.NoError(t, err)
+	defer os.RemoveAll(sysDir)
+
+	// mimic /sys/bus/pci: the driver links of the devices point to the bus drivers dir
+	devicesPath := filepath.Join(sysDir, "bus", "pci", "devices")
+	for _, driver := range []string{"e1000e", "nouveau"} {
+		require.NoError(t, os.MkdirAll(filepath.Join(sysDir, "bus", "pci", "drivers", driver), 0755))
+	}
+	for address, driver := range map[string]string{"0000:00:1f.6": "e1000e", "0000:01:00.0": "nouveau", "0000:00:1f.4": ""} {
+		deviceDir := filepath.Join(devicesPath, address)
+		require.NoError(t, os.MkdirAll(deviceDir, 0755))
+		if driver != "" {
+			require.NoError(t, os.Symlink(filepath.Join("..", "..", "drivers", driver), filepath.Join(deviceDir, "driver")))
+		}
+	}
+
+	devices := []*pciDeviceInfo{
+		{Address: "0000:00:1f.6", ProductName: "Ethernet Connection I219-LM"},
+		{Address: "0000:01:00.0", ProductName: "GK208B [GeForce GT 710]"},
+		{Address: "0000:00:1f.4", ProductName: "SMBus"},
+		{Address: "0000:02:00.0", ProductName: "missing in sysfs"},
+	}
+	// the GPU negotiated x8 Gen1 instead of x16 Gen3, the NIC runs at its max
+	writeLinkState := func(address, speed, maxSpeed, width, maxWidth string) {
+		for attribute, value := range map[string]string{"current_link_speed": speed, "max_link_speed": maxSpeed, "current_link_width": width, "max_link_width": maxWidth} {
+			require.NoError(t, ioutil.WriteFile(filepath.Join(devicesPath, address, attribute), []byte(value+"\n"), 0644))
+		}
+	}
+	writeLinkState("0000:01:00.0", "2.5 GT/s PCIe", "8.0 GT/s PCIe", "8", "16")
+	writeLinkState("0000:00:1f.6", "2.5 GT/s PCIe", "2.5 GT/s PCIe", "1", "1")
+	writeLinkState("0000:00:1f.4", "Unknown", "Unknown", "0", "0")
+
+	fillPCISysfsInfo(devices, devicesPath)
+
+	assert.Equal(t, "e1000e", devices[0].Driver)
+	assert.Equal(t, "nouveau", devices[1].Driver)
+	assert.Equal(t, "", devices[2].Driver, "no driver bound")
+	assert.Equal(t, "", devices[3].Driver)
+
+	notDegraded, degraded := false, true
+	assert.Equal(t, &notDegraded, devices[0].LinkDegraded)
+	assert.Equal(t, "2.5 GT/s PCIe", devices[1].LinkSpeed)
+	assert.Equal(t, "8.0 GT/s PCIe", devices[1].MaxLinkSpeed)
+	assert.Equal(t, "8", devices[1].LinkWidth)
+	assert.Equal(t, "16", devices[1].MaxLinkWidth)
+	assert.Equal(t, &degraded, devices[1].LinkDegraded)
+	assert.Equal(t, "", devices[2].LinkSpeed, "unknown link state of legacy device")
+	assert.Nil(t, devices[2].LinkDegraded)
+	assert.Nil(t, devices[3].LinkDegraded)
+}
+
+func TestReadCPUs(t *testing.T) {
+	cpus, err := readCPUs(filepath.Join("testdata", "cpuinfo"), filepath.Join("testdata", "vulnerabilities"))
+	require.NoError(t, err)
+
+	assert.Equal(t, "0xde", cpus["cpu.microcode_version"])
+	assert.Equal(t, "Intel(R) Core(TM) i7-7700 CPU @ 3.60GHz", cpus["cpu.0.description"])
+	assert.Equal(t, "Mitigation: PTI", cpus["cpu.vuln.meltdown"])
+	assert.Equal(t, "Mitigation: usercopy/swapgs barriers and __user pointer sanitization", cpus["cpu.vuln.spectre_v1"])
+	assert.Equal(t, "Mitigation: Full generic retpoline, IBPB: conditional, IBRS_FW, STIBP: conditional, RSB filling", cpus["cpu.vuln.spectre_v2"])
+	assert.Equal(t, "Not affected", cpus["cpu.vuln.itlb_multihit"])
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddInnerWithPrefix("hw.inventory", cpus)))
+
+	// older kernels don't report the vulnerabilities
+	cpus, err = readCPUs(filepath.Join("testdata", "cpuinfo"), filepath.Join("testdata", "not-existing"))
+	require.NoError(t, err)
+	for key := range cpus {
+		assert.NotContains(t, key, "cpu.vuln.")
+	}
+}
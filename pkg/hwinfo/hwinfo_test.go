@@ -0,0 +1,48 @@
+package hwinfo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func helperCreateRecordingCollectors(invoked *[]string) *inventoryCollectors {
+	return &inventoryCollectors{
+		listPCIDevices: func() ([]*pciDeviceInfo, error) {
+			*invoked = append(*invoked, InventoryTypePCI)
+			return nil, nil
+		},
+		listUSBDevices: func() ([]*usbDeviceInfo, error) {
+			*invoked = append(*invoked, InventoryTypeUSB)
+			return []*usbDeviceInfo{{DeviceID: "0x8406"}}, nil
+		},
+		listDisplays: func() ([]*monitorInfo, error) {
+			*invoked = append(*invoked, InventoryTypeDisplays)
+			return nil, nil
+		},
+		listCPUs: func() (map[string]interface{}, error) {
+			*invoked = append(*invoked, InventoryTypeCPU)
+			return nil, nil
+		},
+		listMemory: func() (map[string]interface{}, error) {
+			*invoked = append(*invoked, InventoryTypeMemory)
+			return nil, nil
+		},
+	}
+}
+
+func TestInventoryCollectOnlyRequestedTypes(t *testing.T) {
+	var invoked []string
+	res, err := helperCreateRecordingCollectors(&invoked).collect([]string{InventoryTypeUSB})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{InventoryTypeUSB}, invoked)
+	assert.Len(t, res, 1)
+	assert.Contains(t, res, "usb.list")
+}
+
+func TestInventoryCollectAllTypesByDefault(t *testing.T) {
+	var invoked []string
+	_, err := helperCreateRecordingCollectors(&invoked).collect(nil)
+	assert.NoError(t, err)
+	assert.Equal(t, InventoryTypes, invoked)
+}
@@ -18,47 +18,17 @@ import (
 
 const wmiQueryTimeout = time.Second * 10
 
-func fetchInventory() (map[string]interface{}, error) {
-	res := make(map[string]interface{})
-
-	errorCollector := common.ErrorCollector{}
-	pciDevices, err := listPCIDevices()
-	errorCollector.Add(err)
-	if len(pciDevices) > 0 {
-		res["pci.list"] = pciDevices
-	}
-
-	usbDevices, err := listUSBDevices()
-	errorCollector.Add(err)
-	if len(usbDevices) > 0 {
-		res["usb.list"] = usbDevices
-	}
-
-	displays, err := listDisplays()
-	errorCollector.Add(err)
-	if len(displays) > 0 {
-		res["displays.list"] = displays
-	}
-
-	cpuInfo, err := getCPUInfo()
-	errorCollector.Add(err)
-	if len(cpuInfo) > 0 {
-		res = common.MergeStringMaps(res, cpuInfo)
-	}
-
-	baseboardInfo, err := getBaseboardInfo()
-	errorCollector.Add(err)
-	if len(baseboardInfo) > 0 {
-		res = common.MergeStringMaps(res, baseboardInfo)
-	}
-
-	ramInfo, err := getRAMInfo()
-	errorCollector.Add(err)
-	if len(ramInfo) > 0 {
-		res = common.MergeStringMaps(res, ramInfo)
+// fetchInventory uses WMI, dmidecode sections are not applicable
+func fetchInventory(types []string, _ []string, _ common.Invoker) (map[string]interface{}, error) {
+	collectors := &inventoryCollectors{
+		listPCIDevices: listPCIDevices,
+		listUSBDevices: listUSBDevices,
+		listDisplays:   listDisplays,
+		listCPUs:       getCPUInfo,
+		listMemory:     getMemoryInfo,
 	}
 
-	return res, errorCollector.Combine()
+	return collectors.collect(types)
 }
 
 func listPCIDevices() ([]*pciDeviceInfo, error) {
@@ -256,6 +226,25 @@ func getBaseboardInfo() (map[string]interface{}, error) {
 	return res, nil
 }
 
+func getMemoryInfo() (map[string]interface{}, error) {
+	res := make(map[string]interface{})
+	errorCollector := common.ErrorCollector{}
+
+	baseboardInfo, err := getBaseboardInfo()
+	errorCollector.Add(err)
+	if len(baseboardInfo) > 0 {
+		res = common.MergeStringMaps(res, baseboardInfo)
+	}
+
+	ramInfo, err := getRAMInfo()
+	errorCollector.Add(err)
+	if len(ramInfo) > 0 {
+		res = common.MergeStringMaps(res, ramInfo)
+	}
+
+	return res, errorCollector.Combine()
+}
+
 func getRAMInfo() (map[string]interface{}, error) {
 	var ram []win32_PhysicalMemory
 	query := wmi.CreateQuery(&ram, "")
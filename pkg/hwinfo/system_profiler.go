@@ -5,6 +5,7 @@ package hwinfo
 import (
 	"fmt"
 	"io"
+	"regexp"
 	"strings"
 
 	"github.com/pkg/errors"
@@ -48,10 +49,16 @@ type spDisplayDataTypeEntry struct {
 	ConnectionType  string `plist:"spdisplays_connection_type"`
 	DisplayType     string `plist:"spdisplays_display_type"`
 	VendorID        string `plist:"_spdisplays_display-vendor-id"`
+	Pixels          string `plist:"_spdisplays_pixels"`
+	Depth           string `plist:"spdisplays_depth"`
+	Main            string `plist:"spdisplays_main"`
 }
 
 const spDisplaysPrefix = "spdisplays_"
 
+// resolution might contain the refresh rate, e.g. "1440 x 900 @ 60.00Hz"
+var spDisplayResolutionRegexp = regexp.MustCompile(`^(.+?)\s*@\s*([\d.]+\s*Hz)$`)
+
 type spGraphicsCardDataTypeEntry struct {
 	Displays []spDisplayDataTypeEntry `plist:"spdisplays_ndrvs"`
 }
@@ -143,6 +150,12 @@ func parseOutputToListOfDisplays(r io.ReadSeeker) ([]*monitorInfo, error) {
 				resolution = display.ResolutionExtra
 			}
 
+			var refreshRate string
+			if m := spDisplayResolutionRegexp.FindStringSubmatch(resolution); m != nil {
+				resolution = m[1]
+				refreshRate = strings.ReplaceAll(m[2], " ", "")
+			}
+
 			displayType := strings.TrimPrefix(display.DisplayType, spDisplaysPrefix)
 			connectionType := strings.TrimPrefix(display.ConnectionType, spDisplaysPrefix)
 			description := fmt.Sprintf("Display Type: %s, Connection Type: %s", displayType, connectionType)
@@ -152,6 +165,10 @@ func parseOutputToListOfDisplays(r io.ReadSeeker) ([]*monitorInfo, error) {
 				VendorName:  display.VendorID,
 				Size:        "",
 				Resolution:  resolution,
+				Pixels:      display.Pixels,
+				RefreshRate: refreshRate,
+				PixelDepth:  display.Depth,
+				IsMain:      display.Main == spDisplaysPrefix+"yes",
 			}
 			result = append(result, monitorInfo)
 		}
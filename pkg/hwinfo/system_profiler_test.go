@@ -144,6 +144,43 @@ func TestParseOutputToListOfDisplays(t *testing.T) {
 			VendorName:  "610",
 			Size:        "",
 			Resolution:  "2560 x 1440",
+			Pixels:      "2560 x 1440",
+			PixelDepth:  "CGSThirtyBitColor",
+			IsMain:      true,
+		},
+	}
+
+	assert.EqualValues(t, expectedDisplayList, displayList)
+}
+
+func TestParseOutputToListOfDisplaysRetinaAndExternal(t *testing.T) {
+	xml := helperLoadSystemProfilerXML(t, "displays_retina.xml")
+
+	displayList, err := parseOutputToListOfDisplays(bytes.NewReader(xml))
+	assert.NoError(t, err)
+
+	expectedDisplayList := []*monitorInfo{
+		{
+			ID:          "Color LCD",
+			Description: "Display Type: built-in_retinaLCD, Connection Type: internal",
+			VendorName:  "610",
+			Size:        "",
+			Resolution:  "1440 x 900",
+			Pixels:      "2560 x 1600",
+			RefreshRate: "60.00Hz",
+			PixelDepth:  "CGSThirtytwoBitColor",
+			IsMain:      true,
+		},
+		{
+			ID:          "DELL U2719D",
+			Description: "Display Type: LCD, Connection Type: displayport_dongletype_dp",
+			VendorName:  "10ac",
+			Size:        "",
+			Resolution:  "2560 x 1440",
+			Pixels:      "2560 x 1440",
+			RefreshRate: "59.95Hz",
+			PixelDepth:  "CGSThirtytwoBitColor",
+			IsMain:      false,
 		},
 	}
 
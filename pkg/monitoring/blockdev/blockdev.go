@@ -0,0 +1,72 @@
+package blockdev
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "blockdev")
+
+// readBlockDevices reports whether the disks are rotational (HDD) and support discard (TRIM)
+// as <dev>.rotational and <dev>.discard_supported, read from /sys/block/<dev>/queue
+func readBlockDevices(blockRoot string, devices []string) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	for _, name := range devices {
+		queuePath := filepath.Join(blockRoot, name, "queue")
+		if rotational, ok := readSysfsUint(filepath.Join(queuePath, "rotational")); ok {
+			results[name+".rotational"] = rotational == 1
+		}
+		if discardMaxBytes, ok := readSysfsUint(filepath.Join(queuePath, "discard_max_bytes")); ok {
+			results[name+".discard_supported"] = discardMaxBytes > 0
+		}
+	}
+
+	return results
+}
+
+// listPhysicalDevices returns the names of the block devices in blockRoot, nil if it doesn't exist
+func listPhysicalDevices(blockRoot string) ([]string, error) {
+	devices, err := ioutil.ReadDir(blockRoot)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	names := []string{}
+	for _, device := range devices {
+		// virtual devices like loop, dm-* or md* have no backing device
+		if _, err := os.Stat(filepath.Join(blockRoot, device.Name(), "device")); err != nil {
+			continue
+		}
+		names = append(names, device.Name())
+	}
+
+	return names, nil
+}
+
+func readSysfsUint(filePath string) (uint64, bool) {
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Debugf("could not read file: %s", filePath)
+		}
+		return 0, false
+	}
+
+	value, err := strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 64)
+	if err != nil {
+		log.WithError(err).Debugf("could not parse %s", filePath)
+		return 0, false
+	}
+
+	return value, true
+}
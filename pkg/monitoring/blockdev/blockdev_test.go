@@ -0,0 +1,54 @@
+package blockdev
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadBlockDevices(t *testing.T) {
+	blockRoot := filepath.Join("testdata", "block")
+	devices, err := listPhysicalDevices(blockRoot)
+	assert.NoError(t, err)
+	// loop0 is skipped as a virtual device
+	assert.ElementsMatch(t, []string{"sda", "nvme0n1"}, devices)
+
+	results := readBlockDevices(blockRoot, devices)
+	assert.Equal(t, common.MeasurementsMap{
+		"sda.rotational":            true,
+		"sda.discard_supported":     false,
+		"nvme0n1.rotational":        false,
+		"nvme0n1.discard_supported": true,
+	}, results)
+}
+
+func TestReadBlockDevicesNotExisting(t *testing.T) {
+	devices, err := listPhysicalDevices(filepath.Join("testdata", "not-existing"))
+	assert.NoError(t, err)
+	assert.Nil(t, devices)
+}
+
+func TestCalcBusyPercent(t *testing.T) {
+	prev := &disk.IOCountersStat{Name: "sda", IoTime: 120000, WeightedIO: 300000}
+	curr := &disk.IOCountersStat{Name: "sda", IoTime: 135000, WeightedIO: 345000}
+
+	// 15s of I/O in flight within 60s
+	assert.Equal(t, float64(25), calcBusyPercent(prev, curr, 60*time.Second))
+	assert.Equal(t, float64(0.75), calcQueueLength(prev, curr, 60*time.Second))
+
+	// io_time is accounted in jiffies and may run ahead of the wall clock
+	assert.Equal(t, float64(100), calcBusyPercent(prev, curr, 10*time.Second))
+
+	// first collection
+	assert.Nil(t, calcBusyPercent(nil, curr, 60*time.Second))
+	assert.Nil(t, calcQueueLength(nil, curr, 60*time.Second))
+	// device disappeared
+	assert.Nil(t, calcBusyPercent(prev, nil, 60*time.Second))
+	// counters were reset
+	assert.Nil(t, calcBusyPercent(curr, prev, 60*time.Second))
+}
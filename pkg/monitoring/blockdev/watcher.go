@@ -0,0 +1,104 @@
+package blockdev
+
+import (
+	"runtime"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// Watcher keeps the I/O counters of the disks between the collections
+type Watcher struct {
+	prevCounters map[string]disk.IOCountersStat
+	prevAt       time.Time
+}
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "disk.<dev>.rotational", Unit: "bool", ConfigOption: "disk_monitoring"},
+		common.MetricDescriptor{Key: "disk.<dev>.discard_supported", Unit: "bool", ConfigOption: "disk_monitoring"},
+		common.MetricDescriptor{Key: "disk.<dev>.busy_percent", Unit: "%", ConfigOption: "disk_monitoring"},
+		common.MetricDescriptor{Key: "disk.<dev>.queue_length", ConfigOption: "disk_monitoring"},
+	)
+}
+
+func NewWatcher() *Watcher {
+	return &Watcher{}
+}
+
+// Results reports <dev>.rotational and <dev>.discard_supported read from /sys/block/<dev>/queue
+// along with the utilisation of the disks since the previous call, the same as %util and aqu-sz of iostat:
+// <dev>.busy_percent is the share of the time the disk had I/O in flight and <dev>.queue_length is the average number of requests in flight.
+// The latter two are nil in the first collection or if the I/O counters are not available. Returns nil on other OSes than Linux
+func (w *Watcher) Results() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	blockRoot := common.HostSys("block")
+	devices, err := listPhysicalDevices(blockRoot)
+	if err != nil || devices == nil {
+		return nil, err
+	}
+
+	results := readBlockDevices(blockRoot, devices)
+	if len(devices) == 0 {
+		return results, nil
+	}
+
+	now := time.Now()
+	counters, err := disk.IOCounters(devices...)
+	if err != nil {
+		log.WithError(err).Debug("failed to read the I/O counters of the disks")
+	}
+
+	elapsed := now.Sub(w.prevAt)
+	for _, name := range devices {
+		var prev, curr *disk.IOCountersStat
+		if c, ok := w.prevCounters[name]; ok {
+			prev = &c
+		}
+		if c, ok := counters[name]; ok {
+			curr = &c
+		}
+
+		results[name+".busy_percent"] = calcBusyPercent(prev, curr, elapsed)
+		results[name+".queue_length"] = calcQueueLength(prev, curr, elapsed)
+	}
+
+	w.prevCounters = counters
+	w.prevAt = now
+
+	return results, nil
+}
+
+// calcBusyPercent returns the share of the elapsed time the disk had I/O in flight by the delta of io_time (ms), up to 100.
+// Returns nil if any of the counters is missing or io_time decreased
+func calcBusyPercent(prev, curr *disk.IOCountersStat, elapsed time.Duration) interface{} {
+	if prev == nil || curr == nil || elapsed <= 0 || curr.IoTime < prev.IoTime {
+		return nil
+	}
+
+	busy := float64(curr.IoTime-prev.IoTime) / durationToMs(elapsed) * 100
+	if busy > 100 {
+		busy = 100
+	}
+
+	return busy
+}
+
+// calcQueueLength returns the average number of requests in flight by the delta of weighted_io_time (ms).
+// Returns nil if any of the counters is missing or weighted_io_time decreased
+func calcQueueLength(prev, curr *disk.IOCountersStat, elapsed time.Duration) interface{} {
+	if prev == nil || curr == nil || elapsed <= 0 || curr.WeightedIO < prev.WeightedIO {
+		return nil
+	}
+
+	return float64(curr.WeightedIO-prev.WeightedIO) / durationToMs(elapsed)
+}
+
+func durationToMs(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,168 @@
+package cgroups
+
+import (
+	"bufio"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "cgroups")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "cgroup.cpu_usage_s.<cgroup>", Unit: "s", ConfigOption: "cgroup_monitoring.enabled"},
+		common.MetricDescriptor{Key: "cgroup.cpu_percent.<cgroup>", Unit: "%", ConfigOption: "cgroup_monitoring.enabled"},
+		common.MetricDescriptor{Key: "cgroup.mem_B.<cgroup>", Unit: "B", ConfigOption: "cgroup_monitoring.enabled"},
+	)
+}
+
+// Watcher reports CPU and memory usage of cgroup v2 top-level slices and additionally configured cgroups
+type Watcher struct {
+	basePath string
+	cgroups  []string
+
+	prevUsage   map[string]float64
+	prevUsageAt time.Time
+}
+
+// NewWatcher creates watcher for cgroup v2 hierarchy mounted at basePath.
+// cgroups are paths relative to basePath, e.g. 'system.slice/nginx.service'
+func NewWatcher(basePath string, cgroups []string) *Watcher {
+	if basePath == "" {
+		basePath = common.HostSys("fs/cgroup")
+	}
+
+	return &Watcher{
+		basePath:  basePath,
+		cgroups:   cgroups,
+		prevUsage: make(map[string]float64),
+	}
+}
+
+// Results returns cpu_usage_s (CPU time consumed in total), cpu_percent (since the previous call) and mem_B per cgroup:
+// cpu_usage_s.system.slice = 1234.5, cpu_percent.system.slice = 12.3, mem_B.system.slice = 1048576
+// Nothing is reported if cgroup v2 is not available
+func (w *Watcher) Results() (common.MeasurementsMap, error) {
+	if _, err := os.Stat(filepath.Join(w.basePath, "cgroup.controllers")); err != nil {
+		log.WithError(err).Debugf("cgroup v2 is not mounted at %s", w.basePath)
+		return nil, nil
+	}
+
+	names, err := w.listCgroups()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	elapsed := now.Sub(w.prevUsageAt).Seconds()
+	usage := make(map[string]float64, len(names))
+
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+	for _, name := range names {
+		dir := filepath.Join(w.basePath, name)
+
+		cpuUsage, err := readCPUUsage(filepath.Join(dir, "cpu.stat"))
+		if err != nil {
+			errs.Add(err)
+			results["cpu_usage_s."+name] = nil
+			results["cpu_percent."+name] = nil
+		} else {
+			usage[name] = cpuUsage
+			results["cpu_usage_s."+name] = cpuUsage
+			results["cpu_percent."+name] = nil
+			if prev, exists := w.prevUsage[name]; exists && cpuUsage >= prev && elapsed > 0 {
+				results["cpu_percent."+name] = common.RoundToTwoDecimalPlaces((cpuUsage - prev) / elapsed * 100)
+			}
+		}
+
+		mem, err := readUint(filepath.Join(dir, "memory.current"))
+		if err != nil {
+			errs.Add(err)
+			results["mem_B."+name] = nil
+		} else {
+			results["mem_B."+name] = mem
+		}
+	}
+
+	w.prevUsage = usage
+	w.prevUsageAt = now
+
+	return results, errs.Combine()
+}
+
+// listCgroups returns top-level slices followed by the configured cgroups
+func (w *Watcher) listCgroups() ([]string, error) {
+	files, err := ioutil.ReadDir(w.basePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var names []string
+	for _, file := range files {
+		if file.IsDir() && strings.HasSuffix(file.Name(), ".slice") {
+			names = append(names, file.Name())
+		}
+	}
+	sort.Strings(names)
+
+	for _, cgroup := range w.cgroups {
+		name := strings.Trim(filepath.ToSlash(filepath.Clean(cgroup)), "/")
+		if !common.StrInSlice(name, names) {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+// readCPUUsage returns CPU time in seconds from cpu.stat file:
+// usage_usec 1234567
+// user_usec 1000000
+// system_usec 234567
+func readCPUUsage(path string) (float64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "usage_usec" {
+			continue
+		}
+
+		usec, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("unexpected usage_usec value in %s: %s", path, fields[1])
+		}
+		return float64(usec) / 1e6, nil
+	}
+
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+
+	return 0, fmt.Errorf("usage_usec not found in %s", path)
+}
+
+func readUint(path string) (uint64, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	return strconv.ParseUint(strings.TrimSpace(string(data)), 10, 64)
+}
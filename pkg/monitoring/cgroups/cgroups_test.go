@@ -0,0 +1,54 @@
+package cgroups
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestWatcherResults(t *testing.T) {
+	w := NewWatcher("testdata/cgroup", []string{"/system.slice/nginx.service/"})
+
+	results, err := w.Results()
+	require.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"cpu_usage_s.system.slice":               120.5,
+		"cpu_percent.system.slice":               nil,
+		"mem_B.system.slice":                     uint64(536870912),
+		"cpu_usage_s.user.slice":                 30.0,
+		"cpu_percent.user.slice":                 nil,
+		"mem_B.user.slice":                       uint64(104857600),
+		"cpu_usage_s.system.slice/nginx.service": 2.5,
+		"cpu_percent.system.slice/nginx.service": nil,
+		"mem_B.system.slice/nginx.service":       uint64(20971520),
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("cgroup.", results)))
+
+	// CPU usage percent is derived from the usage since the previous call
+	w.prevUsage["system.slice"] = 119.5
+	w.prevUsage["user.slice"] = 30
+	w.prevUsageAt = time.Now().Add(-10 * time.Second)
+
+	results, err = w.Results()
+	require.NoError(t, err)
+	assert.InDelta(t, 10.0, results["cpu_percent.system.slice"], 0.1)
+	assert.Equal(t, 0.0, results["cpu_percent.user.slice"])
+	assert.Equal(t, 0.0, results["cpu_percent.system.slice/nginx.service"])
+}
+
+func TestWatcherResultsWithoutCgroupV2(t *testing.T) {
+	results, err := NewWatcher("testdata/not-existing", nil).Results()
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
+
+func TestWatcherResultsMissingCgroup(t *testing.T) {
+	results, err := NewWatcher("testdata/cgroup", []string{"system.slice/missing.service"}).Results()
+	assert.Error(t, err)
+	assert.Nil(t, results["mem_B.system.slice/missing.service"])
+	assert.Equal(t, 120.5, results["cpu_usage_s.system.slice"])
+}
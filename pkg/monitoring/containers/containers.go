@@ -0,0 +1,162 @@
+package containers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"runtime"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "containers")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "containers.runtime", ConfigOption: "containers_monitoring.enabled"},
+		common.MetricDescriptor{Key: "containers.running", ConfigOption: "containers_monitoring.enabled"},
+		common.MetricDescriptor{Key: "containers.total", ConfigOption: "containers_monitoring.enabled"},
+	)
+}
+
+const (
+	RuntimeDocker     = "docker"
+	RuntimeContainerd = "containerd"
+	RuntimePodman     = "podman"
+	RuntimeNone       = "none"
+
+	apiRequestTimeout = 10 * time.Second
+)
+
+type runtimeSocket struct {
+	runtime string
+	path    string
+}
+
+// defaultSockets are probed in order if no socket path is configured
+var defaultSockets = []runtimeSocket{
+	{RuntimeDocker, "/var/run/docker.sock"},
+	{RuntimePodman, "/run/podman/podman.sock"},
+	{RuntimeContainerd, "/run/containerd/containerd.sock"},
+}
+
+// dockerContainer holds the fields of /containers/json response we are interested in
+type dockerContainer struct {
+	ID    string `json:"Id"`
+	State string `json:"State"`
+}
+
+// GetMeasurements detects the local container runtime and counts its containers.
+// socketPath overrides the auto-detection, the runtime is then guessed by the path.
+// Docker and Podman are queried through the Docker-compatible API.
+// Counts are reported as nil if the runtime doesn't provide such API or the socket is not accessible
+func GetMeasurements(socketPath string) common.MeasurementsMap {
+	results := common.MeasurementsMap{
+		"runtime": RuntimeNone,
+		"running": nil,
+		"total":   nil,
+	}
+
+	socket := findSocket(socketPath)
+	if socket == nil {
+		return results
+	}
+
+	results["runtime"] = socket.runtime
+	if socket.runtime == RuntimeContainerd {
+		// containerd exposes gRPC API only
+		return results
+	}
+
+	running, total, err := countDockerContainers(socket.path)
+	if err != nil {
+		log.WithError(err).Debugf("failed to list %s containers using %s", socket.runtime, socket.path)
+		return results
+	}
+
+	results["running"] = running
+	results["total"] = total
+
+	return results
+}
+
+func findSocket(socketPath string) *runtimeSocket {
+	if socketPath != "" {
+		if !socketExists(socketPath) {
+			log.Debugf("container runtime socket %s not found", socketPath)
+			return nil
+		}
+		return &runtimeSocket{runtime: runtimeBySocketPath(socketPath), path: socketPath}
+	}
+
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+
+	for i := range defaultSockets {
+		if socketExists(defaultSockets[i].path) {
+			return &defaultSockets[i]
+		}
+	}
+
+	return nil
+}
+
+func socketExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+func runtimeBySocketPath(path string) string {
+	switch {
+	case strings.Contains(path, RuntimePodman):
+		return RuntimePodman
+	case strings.Contains(path, RuntimeContainerd):
+		return RuntimeContainerd
+	default:
+		return RuntimeDocker
+	}
+}
+
+func countDockerContainers(socketPath string) (running, total int, err error) {
+	client := &http.Client{
+		Timeout: apiRequestTimeout,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socketPath)
+			},
+		},
+	}
+
+	// host is ignored as we always dial the socket
+	resp, err := client.Get("http://localhost/containers/json?all=1")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return 0, 0, fmt.Errorf("got unexpected response status %s", resp.Status)
+	}
+
+	var list []dockerContainer
+	if err = json.NewDecoder(resp.Body).Decode(&list); err != nil {
+		return 0, 0, err
+	}
+
+	for _, c := range list {
+		if c.State == "running" {
+			running++
+		}
+	}
+
+	return running, len(list), nil
+}
@@ -0,0 +1,79 @@
+// +build !windows
+
+package containers
+
+import (
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const containersJSON = `[
+  {"Id": "8dfafdbc3a40", "Names": ["/web"], "Image": "nginx", "State": "running", "Status": "Up 2 hours"},
+  {"Id": "9cdc2e1bf1a5", "Names": ["/db"], "Image": "postgres", "State": "running", "Status": "Up 2 hours"},
+  {"Id": "4fa6e0f0c678", "Names": ["/job"], "Image": "busybox", "State": "exited", "Status": "Exited (0) 5 minutes ago"},
+  {"Id": "1e2f3a4b5c6d", "Names": ["/paused"], "Image": "redis", "State": "paused", "Status": "Up 1 hour (Paused)"},
+  {"Id": "7a8b9c0d1e2f", "Names": ["/new"], "Image": "alpine", "State": "created", "Status": "Created"}
+]`
+
+func startMockDockerAPI(t *testing.T, socketPath string) func() {
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/containers/json", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("all") != "1" {
+			http.Error(w, "expected all=1", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(containersJSON))
+	})
+
+	srv := &http.Server{Handler: mux}
+	go func() { _ = srv.Serve(l) }()
+
+	return func() { srv.Close() }
+}
+
+func TestGetMeasurementsDocker(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cagent-containers")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	socketPath := filepath.Join(dir, "docker.sock")
+	stop := startMockDockerAPI(t, socketPath)
+	defer stop()
+
+	results := GetMeasurements(socketPath)
+	assert.Equal(t, RuntimeDocker, results["runtime"])
+	assert.Equal(t, 2, results["running"])
+	assert.Equal(t, 5, results["total"])
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("containers.", results)))
+}
+
+func TestGetMeasurementsNoSocket(t *testing.T) {
+	dir, err := ioutil.TempDir("", "cagent-containers")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	results := GetMeasurements(filepath.Join(dir, "docker.sock"))
+	assert.Equal(t, RuntimeNone, results["runtime"])
+	assert.Nil(t, results["running"])
+	assert.Nil(t, results["total"])
+}
+
+func TestRuntimeBySocketPath(t *testing.T) {
+	assert.Equal(t, RuntimeDocker, runtimeBySocketPath("/var/run/docker.sock"))
+	assert.Equal(t, RuntimePodman, runtimeBySocketPath("/run/user/1000/podman/podman.sock"))
+	assert.Equal(t, RuntimeContainerd, runtimeBySocketPath("/run/containerd/containerd.sock"))
+}
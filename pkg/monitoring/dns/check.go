@@ -0,0 +1,155 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "dns")
+
+// Values of <host>.status
+const (
+	StatusOK       = "ok"
+	StatusNXDomain = "nxdomain"
+	StatusServFail = "servfail"
+	StatusTimeout  = "timeout"
+	StatusError    = "error"
+)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "dns.<host>.resolved", ConfigOption: "dns_check.hosts"},
+		common.MetricDescriptor{Key: "dns.<host>.latency_ms", Unit: "ms", ConfigOption: "dns_check.hosts"},
+		common.MetricDescriptor{Key: "dns.<host>.status", ConfigOption: "dns_check.hosts"},
+	)
+}
+
+// Resolver is implemented by *net.Resolver
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+// Checker resolves the hostnames not more often than every interval, the cached results are reported meanwhile
+type Checker struct {
+	resolver Resolver
+	hosts    []string
+	timeout  time.Duration
+	interval time.Duration
+
+	checkedAt time.Time
+	results   common.MeasurementsMap
+}
+
+// NewChecker returns the Checker which uses the system resolver.
+// Zero interval resolves the hostnames on every call of Results
+func NewChecker(hosts []string, timeout, interval time.Duration) *Checker {
+	return &Checker{
+		resolver: net.DefaultResolver,
+		hosts:    hosts,
+		timeout:  timeout,
+		interval: interval,
+	}
+}
+
+// Results reports for every hostname whether it was resolved as <host>.resolved, the time the resolver took to answer as <host>.latency_ms
+// and <host>.status, one of ok, nxdomain, servfail, timeout or error. latency_ms is nil if the lookup timed out.
+// Hostnames are resolved in parallel, each lookup is abandoned after the timeout
+func (c *Checker) Results(ctx context.Context) common.MeasurementsMap {
+	now := time.Now()
+	if c.results != nil && c.interval > 0 && now.After(c.checkedAt) && now.Sub(c.checkedAt) < c.interval {
+		return c.results
+	}
+
+	c.results = c.check(ctx)
+	c.checkedAt = now
+
+	return c.results
+}
+
+func (c *Checker) check(ctx context.Context) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, host := range c.hosts {
+		wg.Add(1)
+		go func(host string) {
+			defer wg.Done()
+
+			status, latency := c.lookup(ctx, host)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[host+".resolved"] = status == StatusOK
+			results[host+".status"] = status
+			if status == StatusTimeout {
+				results[host+".latency_ms"] = nil
+			} else {
+				results[host+".latency_ms"] = float64(latency) / float64(time.Millisecond)
+			}
+		}(host)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// lookup waits for the resolver not longer than the timeout even if it ignores the cancellation of the context
+func (c *Checker) lookup(ctx context.Context, host string) (string, time.Duration) {
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+
+	done := make(chan error, 1)
+	startedAt := time.Now()
+	go func() {
+		_, err := c.resolver.LookupHost(ctx, host)
+		done <- err
+	}()
+
+	var err error
+	select {
+	case err = <-done:
+	case <-ctx.Done():
+		err = ctx.Err()
+	}
+	latency := time.Since(startedAt)
+
+	status := lookupStatus(err)
+	if err != nil {
+		log.WithError(err).Debugf("failed to resolve %s", host)
+	}
+
+	return status, latency
+}
+
+func lookupStatus(err error) string {
+	if err == nil {
+		return StatusOK
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return StatusTimeout
+	}
+
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return StatusNXDomain
+		case dnsErr.IsTimeout:
+			return StatusTimeout
+		case dnsErr.IsTemporary:
+			// the resolver returns SERVFAIL as "server misbehaving"
+			return StatusServFail
+		}
+	}
+
+	return StatusError
+}
@@ -0,0 +1,93 @@
+package dns
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type mockResolver struct {
+	lookups int32
+}
+
+func (r *mockResolver) LookupHost(ctx context.Context, host string) ([]string, error) {
+	atomic.AddInt32(&r.lookups, 1)
+
+	switch host {
+	case "example.com":
+		return []string{"93.184.216.34"}, nil
+	case "missing.example.com":
+		return nil, &net.DNSError{Err: "no such host", Name: host, IsNotFound: true}
+	case "broken.example.com":
+		return nil, &net.DNSError{Err: "server misbehaving", Name: host, IsTemporary: true}
+	case "slow.example.com":
+		// ignores the context on purpose
+		time.Sleep(2 * time.Second)
+		return []string{"10.0.0.1"}, nil
+	}
+
+	return nil, errors.New("unexpected host")
+}
+
+func TestCheckerResults(t *testing.T) {
+	resolver := &mockResolver{}
+	checker := &Checker{
+		resolver: resolver,
+		hosts:    []string{"example.com", "slow.example.com", "missing.example.com", "broken.example.com"},
+		timeout:  200 * time.Millisecond,
+	}
+
+	startedAt := time.Now()
+	results := checker.Results(context.Background())
+	assert.True(t, time.Since(startedAt) < time.Second, "dead resolver must not stall the collection")
+
+	assert.Equal(t, true, results["example.com.resolved"])
+	assert.Equal(t, StatusOK, results["example.com.status"])
+	assert.IsType(t, float64(0), results["example.com.latency_ms"])
+
+	assert.Equal(t, false, results["slow.example.com.resolved"])
+	assert.Equal(t, StatusTimeout, results["slow.example.com.status"])
+	assert.Contains(t, results, "slow.example.com.latency_ms")
+	assert.Nil(t, results["slow.example.com.latency_ms"])
+
+	assert.Equal(t, false, results["missing.example.com.resolved"])
+	assert.Equal(t, StatusNXDomain, results["missing.example.com.status"])
+	assert.IsType(t, float64(0), results["missing.example.com.latency_ms"])
+
+	assert.Equal(t, false, results["broken.example.com.resolved"])
+	assert.Equal(t, StatusServFail, results["broken.example.com.status"])
+}
+
+func TestCheckerInterval(t *testing.T) {
+	resolver := &mockResolver{}
+	checker := &Checker{
+		resolver: resolver,
+		hosts:    []string{"example.com"},
+		timeout:  time.Second,
+		interval: time.Hour,
+	}
+
+	first := checker.Results(context.Background())
+	second := checker.Results(context.Background())
+	assert.Equal(t, first, second)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&resolver.lookups))
+
+	// every call without interval
+	checker.interval = 0
+	checker.Results(context.Background())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&resolver.lookups))
+}
+
+func TestLookupStatus(t *testing.T) {
+	assert.Equal(t, StatusOK, lookupStatus(nil))
+	assert.Equal(t, StatusTimeout, lookupStatus(context.DeadlineExceeded))
+	assert.Equal(t, StatusTimeout, lookupStatus(&net.DNSError{Err: "i/o timeout", IsTimeout: true}))
+	assert.Equal(t, StatusNXDomain, lookupStatus(&net.DNSError{Err: "no such host", IsNotFound: true}))
+	assert.Equal(t, StatusServFail, lookupStatus(&net.DNSError{Err: "server misbehaving", IsTemporary: true}))
+	assert.Equal(t, StatusError, lookupStatus(errors.New("connection refused")))
+}
@@ -0,0 +1,106 @@
+package edac
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "edac")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "edac.<mc>.ce_count", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "edac.<mc>.ue_count", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "edac.<mc>.<dimm>.ce_count", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "edac.<mc>.<dimm>.ue_count", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "edac.<mc>.<dimm>.label", ConfigOption: "mem_monitoring"},
+	)
+}
+
+// GetMeasurements reads memory error counters exposed by the EDAC subsystem:
+// https://www.kernel.org/doc/html/latest/admin-guide/ras.html#edac-sys-fs-interface
+// Returns nil if EDAC is not available on the host.
+func GetMeasurements() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	return readCounters(common.HostSys("/devices/system/edac/mc"))
+}
+
+func readCounters(mcRoot string) (common.MeasurementsMap, error) {
+	controllers, err := filepath.Glob(filepath.Join(mcRoot, "mc[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(controllers) == 0 {
+		return nil, nil
+	}
+
+	results := common.MeasurementsMap{}
+	for _, mcPath := range controllers {
+		mcName := filepath.Base(mcPath)
+		readCounterInto(results, mcName+".ce_count", filepath.Join(mcPath, "ce_count"))
+		readCounterInto(results, mcName+".ue_count", filepath.Join(mcPath, "ue_count"))
+
+		// newer kernels expose per-DIMM counters, older ones use chip-select rows
+		dimms, _ := filepath.Glob(filepath.Join(mcPath, "dimm[0-9]*"))
+		for _, dimmPath := range dimms {
+			prefix := mcName + "." + filepath.Base(dimmPath)
+			readCounterInto(results, prefix+".ce_count", filepath.Join(dimmPath, "dimm_ce_count"))
+			readCounterInto(results, prefix+".ue_count", filepath.Join(dimmPath, "dimm_ue_count"))
+			if label := readString(filepath.Join(dimmPath, "dimm_label")); label != "" {
+				results[prefix+".label"] = label
+			}
+		}
+
+		if len(dimms) > 0 {
+			continue
+		}
+
+		csrows, _ := filepath.Glob(filepath.Join(mcPath, "csrow[0-9]*"))
+		for _, csrowPath := range csrows {
+			prefix := mcName + "." + filepath.Base(csrowPath)
+			readCounterInto(results, prefix+".ce_count", filepath.Join(csrowPath, "ce_count"))
+			readCounterInto(results, prefix+".ue_count", filepath.Join(csrowPath, "ue_count"))
+		}
+	}
+
+	return results, nil
+}
+
+func readCounterInto(results common.MeasurementsMap, key string, filePath string) {
+	value := readString(filePath)
+	if value == "" {
+		return
+	}
+
+	count, err := strconv.ParseUint(value, 10, 64)
+	if err != nil {
+		log.WithError(err).Debugf("could not parse counter from file: %s", filePath)
+		return
+	}
+
+	results[key] = count
+}
+
+func readString(filePath string) string {
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Debugf("could not read file: %s", filePath)
+		}
+		return ""
+	}
+
+	return strings.TrimSpace(string(buf))
+}
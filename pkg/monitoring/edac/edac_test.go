@@ -0,0 +1,36 @@
+package edac
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadCounters(t *testing.T) {
+	results, err := readCounters(filepath.Join("testdata", "mc"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, common.MeasurementsMap{
+		"mc0.ce_count":        uint64(3),
+		"mc0.ue_count":        uint64(0),
+		"mc0.dimm0.ce_count":  uint64(3),
+		"mc0.dimm0.ue_count":  uint64(0),
+		"mc0.dimm0.label":     "CPU_SrcID#0_Ha#0_Chan#0_DIMM#0",
+		"mc0.dimm1.ce_count":  uint64(0),
+		"mc0.dimm1.ue_count":  uint64(0),
+		"mc1.ce_count":        uint64(1),
+		"mc1.ue_count":        uint64(2),
+		"mc1.csrow0.ce_count": uint64(1),
+		"mc1.csrow0.ue_count": uint64(2),
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("edac.", results)))
+}
+
+func TestReadCountersNotAvailable(t *testing.T) {
+	results, err := readCounters(filepath.Join("testdata", "not-existing"))
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
@@ -49,3 +49,8 @@ func getPartitionIOCounters(deviceName string) (*disk.IOCountersStat, error) {
 	ret := result[name]
 	return &ret, nil
 }
+
+// getPhysicalDiskMeasurements is implemented on Windows only, the disks are reported by the volumes there
+func (fw *FileSystemWatcher) getPhysicalDiskMeasurements() (common.MeasurementsMap, error) {
+	return nil, nil
+}
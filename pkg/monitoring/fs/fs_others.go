@@ -7,6 +7,8 @@ import (
 	"path/filepath"
 
 	"github.com/shirou/gopsutil/disk"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
 func getPartitions(onlyUniqueDevices bool) ([]disk.PartitionStat, error) {
@@ -27,3 +29,8 @@ func getPartitionIOCounters(deviceName string) (*disk.IOCountersStat, error) {
 	ret := result[name]
 	return &ret, nil
 }
+
+// getPhysicalDiskMeasurements is implemented on Windows only, the disks are reported by the volumes there
+func (fw *FileSystemWatcher) getPhysicalDiskMeasurements() (common.MeasurementsMap, error) {
+	return nil, nil
+}
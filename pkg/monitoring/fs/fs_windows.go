@@ -13,6 +13,8 @@ import (
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/registry"
 
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
 	"github.com/cloudradar-monitoring/cagent/pkg/winapi"
 )
 
@@ -160,3 +162,31 @@ func enablePerformanceCounters() error {
 	}
 	return nil
 }
+
+// getPhysicalDiskMeasurements reads idle time and queue length of the physical disks from the PhysicalDisk performance counters.
+// The counters are sampled first time on the first call, so the values are available starting from the second one
+func (fw *FileSystemWatcher) getPhysicalDiskMeasurements() (common.MeasurementsMap, error) {
+	if fw.physicalDiskQuery == nil {
+		query, err := winperf.NewQuery()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, counter := range []string{physicalDiskIdleCounter, physicalDiskQueueCounter} {
+			if err := query.AddCounter(counter); err != nil {
+				return nil, errors.Wrapf(err, "failed to add counter '%s'", counter)
+			}
+		}
+
+		fw.physicalDiskQuery = query
+		_, err = query.Collect()
+		return nil, err
+	}
+
+	values, err := fw.physicalDiskQuery.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	return physicalDiskMeasurements(values), nil
+}
@@ -0,0 +1,37 @@
+package fs
+
+import (
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// filesystem labels and UUIDs change rarely, so they are not read on every check
+const filesystemIDsCacheTTL = time.Hour
+
+// filesystemID identifies the filesystem of a partition. Fields are empty if not available
+type filesystemID struct {
+	Label string
+	UUID  string
+}
+
+// getFilesystemID returns the cached label and UUID of the partition.
+// The cache is refreshed when it expires or a new mountpoint appears
+func (fw *FileSystemWatcher) getFilesystemID(partition disk.PartitionStat, partitions []disk.PartitionStat) filesystemID {
+	id, cached := fw.filesystemIDs[partition.Mountpoint]
+	if cached && time.Since(fw.filesystemIDsReadAt) < filesystemIDsCacheTTL {
+		return id
+	}
+
+	fw.filesystemIDs = readFilesystemIDs(partitions)
+	fw.filesystemIDsReadAt = time.Now()
+
+	return fw.filesystemIDs[partition.Mountpoint]
+}
+
+func (fw *FileSystemWatcher) fillFilesystemIDMetrics(results common.MeasurementsMap, mountName string, id filesystemID) {
+	results["label."+mountName] = id.Label
+	results["uuid."+mountName] = id.UUID
+}
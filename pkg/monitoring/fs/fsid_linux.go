@@ -0,0 +1,94 @@
+// +build linux
+
+package fs
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func readFilesystemIDs(partitions []disk.PartitionStat) map[string]filesystemID {
+	return readFilesystemIDsFromDir(common.HostDev("disk"), partitions)
+}
+
+// readFilesystemIDsFromDir resolves the labels and UUIDs of the partitions devices using by-label and by-uuid symlinks
+// maintained by udev in /dev/disk
+func readFilesystemIDsFromDir(diskDir string, partitions []disk.PartitionStat) map[string]filesystemID {
+	labels := readDiskLinks(filepath.Join(diskDir, "by-label"))
+	uuids := readDiskLinks(filepath.Join(diskDir, "by-uuid"))
+
+	result := make(map[string]filesystemID, len(partitions))
+	for _, partition := range partitions {
+		device := filepath.Clean(partition.Device)
+		// e.g. /dev/mapper/vg-root links to /dev/dm-0
+		if resolved, err := filepath.EvalSymlinks(device); err == nil {
+			device = resolved
+		}
+
+		result[partition.Mountpoint] = filesystemID{
+			Label: unescapeUdevName(labels[device]),
+			UUID:  uuids[device],
+		}
+	}
+
+	return result
+}
+
+// readDiskLinks returns the names of symlinks in dir by the device path they point to
+func readDiskLinks(dir string) map[string]string {
+	result := make(map[string]string)
+
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			logrus.WithError(err).Debugf("[FS] failed to read %s", dir)
+		}
+		return result
+	}
+
+	for _, file := range files {
+		if file.Mode()&os.ModeSymlink == 0 {
+			continue
+		}
+
+		target, err := os.Readlink(filepath.Join(dir, file.Name()))
+		if err != nil {
+			continue
+		}
+		if !filepath.IsAbs(target) {
+			target = filepath.Join(dir, target)
+		}
+		result[filepath.Clean(target)] = file.Name()
+	}
+
+	return result
+}
+
+// unescapeUdevName decodes the characters escaped by udev in /dev/disk/by-label names, e.g. 'My\x20Disk'
+func unescapeUdevName(name string) string {
+	if !strings.Contains(name, `\x`) {
+		return name
+	}
+
+	var sb strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] == '\\' && i+3 < len(name) && name[i+1] == 'x' {
+			if b, err := strconv.ParseUint(name[i+2:i+4], 16, 8); err == nil {
+				sb.WriteByte(byte(b))
+				i += 3
+				continue
+			}
+		}
+		sb.WriteByte(name[i])
+	}
+
+	return sb.String()
+}
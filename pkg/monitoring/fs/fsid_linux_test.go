@@ -0,0 +1,35 @@
+// +build linux
+
+package fs
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadFilesystemIDsFromDir(t *testing.T) {
+	partitions := []disk.PartitionStat{
+		{Device: "testdata/dev/sda1", Mountpoint: "/"},
+		{Device: "testdata/dev/mapper/vg-root", Mountpoint: "/home"},
+		{Device: "tmpfs", Mountpoint: "/tmp"},
+	}
+
+	assert.Equal(t, map[string]filesystemID{
+		"/":     {Label: "data", UUID: "1b2c3d4e-5f60-4718-9a0b-1c2d3e4f5a6b"},
+		"/home": {UUID: "7e6d5c4b-3a29-4817-8695-a4b3c2d1e0f9"},
+		"/tmp":  {},
+	}, readFilesystemIDsFromDir("testdata/dev/disk", partitions))
+
+	// missing /dev/disk is not an error
+	assert.Equal(t, map[string]filesystemID{
+		"/": {},
+	}, readFilesystemIDsFromDir("testdata/not-existing", partitions[:1]))
+}
+
+func TestUnescapeUdevName(t *testing.T) {
+	assert.Equal(t, "data", unescapeUdevName("data"))
+	assert.Equal(t, "My Disk/1", unescapeUdevName(`My\x20Disk\x2f1`))
+	assert.Equal(t, `broken\x2`, unescapeUdevName(`broken\x2`))
+}
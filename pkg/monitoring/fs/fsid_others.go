@@ -0,0 +1,17 @@
+// +build !windows,!linux
+
+package fs
+
+import (
+	"github.com/shirou/gopsutil/disk"
+)
+
+// readFilesystemIDs returns empty IDs as reading them is not supported on this OS
+func readFilesystemIDs(partitions []disk.PartitionStat) map[string]filesystemID {
+	result := make(map[string]filesystemID, len(partitions))
+	for _, partition := range partitions {
+		result[partition.Mountpoint] = filesystemID{}
+	}
+
+	return result
+}
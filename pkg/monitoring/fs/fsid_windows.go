@@ -0,0 +1,53 @@
+// +build windows
+
+package fs
+
+import (
+	"strings"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sys/windows"
+)
+
+// readFilesystemIDs uses the volume label and the GUID of the volume, e.g. '\\?\Volume{26a21bda-a627-11d7-9931-806e6f6e6963}\'
+func readFilesystemIDs(partitions []disk.PartitionStat) map[string]filesystemID {
+	result := make(map[string]filesystemID, len(partitions))
+	for _, partition := range partitions {
+		result[partition.Mountpoint] = readVolumeID(partition.Mountpoint)
+	}
+
+	return result
+}
+
+func readVolumeID(mountpoint string) filesystemID {
+	var id filesystemID
+
+	rootPath, err := windows.UTF16PtrFromString(strings.TrimSuffix(mountpoint, `\`) + `\`)
+	if err != nil {
+		return id
+	}
+
+	label := make([]uint16, windows.MAX_PATH+1)
+	err = windows.GetVolumeInformation(rootPath, &label[0], uint32(len(label)), nil, nil, nil, nil, 0)
+	if err != nil {
+		logrus.WithError(err).Debugf("[FS] failed to get volume label of %s", mountpoint)
+	} else {
+		id.Label = windows.UTF16ToString(label)
+	}
+
+	volumeName := make([]uint16, windows.MAX_PATH+1)
+	err = windows.GetVolumeNameForVolumeMountPoint(rootPath, &volumeName[0], uint32(len(volumeName)))
+	if err != nil {
+		// e.g. network drives
+		logrus.WithError(err).Debugf("[FS] failed to get volume name of %s", mountpoint)
+		return id
+	}
+
+	name := windows.UTF16ToString(volumeName)
+	if start, end := strings.Index(name, "{"), strings.Index(name, "}"); start >= 0 && end > start {
+		id.UUID = name[start+1 : end]
+	}
+
+	return id
+}
@@ -0,0 +1,48 @@
+package fs
+
+import (
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
+)
+
+const (
+	physicalDiskIdleCounter  = `\PhysicalDisk(*)\% Idle Time`
+	physicalDiskQueueCounter = `\PhysicalDisk(*)\Current Disk Queue Length`
+)
+
+// parsePhysicalDiskInstance parses the instance names of the Windows PhysicalDisk counters like "0 C: D:"
+// into the disk name, e.g. PhysicalDrive0, and the drive letters of its volumes
+func parsePhysicalDiskInstance(instance string) (string, []string) {
+	fields := strings.Fields(instance)
+	if len(fields) == 0 || fields[0] == "_Total" {
+		return "", nil
+	}
+
+	return "PhysicalDrive" + fields[0], fields[1:]
+}
+
+// physicalDiskMeasurements reports idle time and queue length of the physical disks
+// as disk_idle_percent.<disk> and disk_queue_length.<disk>, and the disk of every volume as physical_disk.<volume>
+func physicalDiskMeasurements(values map[string][]winperf.Value) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	for counter, metric := range map[string]string{
+		physicalDiskIdleCounter:  "disk_idle_percent",
+		physicalDiskQueueCounter: "disk_queue_length",
+	} {
+		for _, v := range values[counter] {
+			disk, volumes := parsePhysicalDiskInstance(v.Instance)
+			if disk == "" {
+				continue
+			}
+
+			results[metric+"."+disk] = common.RoundToTwoDecimalPlaces(v.Value)
+			for _, volume := range volumes {
+				results["physical_disk."+volume] = disk
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,39 @@
+package fs
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
+)
+
+func TestPhysicalDiskMeasurements(t *testing.T) {
+	values := map[string][]winperf.Value{
+		physicalDiskIdleCounter: {
+			{Instance: "0 C: D:", Value: 97.123},
+			{Instance: "1 E:", Value: 12.5},
+			{Instance: "2", Value: 100},
+			{Instance: "_Total", Value: 69.87},
+		},
+		physicalDiskQueueCounter: {
+			{Instance: "0 C: D:", Value: 0},
+			{Instance: "1 E:", Value: 3},
+			{Instance: "2", Value: 0},
+			{Instance: "_Total", Value: 3},
+		},
+	}
+
+	assert.Equal(t, common.MeasurementsMap{
+		"disk_idle_percent.PhysicalDrive0": 97.12,
+		"disk_idle_percent.PhysicalDrive1": 12.5,
+		"disk_idle_percent.PhysicalDrive2": float64(100),
+		"disk_queue_length.PhysicalDrive0": float64(0),
+		"disk_queue_length.PhysicalDrive1": float64(3),
+		"disk_queue_length.PhysicalDrive2": float64(0),
+		"physical_disk.C:":                 "PhysicalDrive0",
+		"physical_disk.D:":                 "PhysicalDrive0",
+		"physical_disk.E:":                 "PhysicalDrive1",
+	}, physicalDiskMeasurements(values))
+}
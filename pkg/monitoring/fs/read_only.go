@@ -0,0 +1,38 @@
+package fs
+
+import (
+	"path/filepath"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// fillReadOnlyMetrics reports whether the file system is currently mounted read-only by its live mount options as read_only.<mount>.
+// read_only_unexpected.<mount> is true if the mountpoint doesn't match ReadOnlyExpected,
+// i.e. the kernel likely remounted it read-only after I/O errors
+func (fw *FileSystemWatcher) fillReadOnlyMetrics(results common.MeasurementsMap, mountName string, opts string) {
+	readOnly := isReadOnlyMount(opts)
+	results["read_only."+mountName] = readOnly
+	results["read_only_unexpected."+mountName] = readOnly && !fw.isReadOnlyExpected(mountName)
+}
+
+func (fw *FileSystemWatcher) isReadOnlyExpected(mountpoint string) bool {
+	for _, glob := range fw.config.ReadOnlyExpected {
+		if matched, _ := filepath.Match(glob, mountpoint); matched {
+			return true
+		}
+	}
+
+	return false
+}
+
+// isReadOnlyMount checks the mount options like "ro,relatime"
+func isReadOnlyMount(opts string) bool {
+	for _, opt := range strings.Split(opts, ",") {
+		if strings.TrimSpace(opt) == "ro" {
+			return true
+		}
+	}
+
+	return false
+}
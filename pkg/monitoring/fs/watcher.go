@@ -1,14 +1,24 @@
 package fs
 
 import (
+	"errors"
+	"fmt"
 	"path/filepath"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/shirou/gopsutil/disk"
 	"github.com/sirupsen/logrus"
 
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/winperf"
+)
+
+const (
+	FillStateOK       = "ok"
+	FillStateWarning  = "warning"
+	FillStateCritical = "critical"
 )
 
 type FileSystemWatcherConfig struct {
@@ -17,23 +27,120 @@ type FileSystemWatcherConfig struct {
 	PathExcludeRecurse          bool
 	Metrics                     []string
 	IdentifyMountpointsByDevice bool
+	FillThresholds              FillThresholds
+	FillThresholdsPerPath       map[string]FillThresholds
+	AlwaysIncludeRoot           bool
+	StatTimeout                 time.Duration
+	// ReadOnlyExpected are the globs of the mountpoints which are read-only intentionally
+	ReadOnlyExpected []string
+}
+
+const rootMountpoint = "/"
+
+var errStatTimeout = errors.New("stat timed out")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "fs.free_B.<mount>", Unit: "B", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.free_percent.<mount>", Unit: "%", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.used_percent.<mount>", Unit: "%", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.total_B.<mount>", Unit: "B", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.inodes_total.<mount>", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.inodes_free.<mount>", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.inodes_used.<mount>", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.inodes_used_percent.<mount>", Unit: "%", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.fill_state.<mount>", ConfigOption: "fs_fill_thresholds"},
+		common.MetricDescriptor{Key: "fs.read_only.<mount>", Unit: "bool"},
+		common.MetricDescriptor{Key: "fs.read_only_unexpected.<mount>", Unit: "bool", ConfigOption: "fs_read_only_expected"},
+		common.MetricDescriptor{Key: "fs.read_B_per_s.<mount>", Unit: "B/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.write_B_per_s.<mount>", Unit: "B/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.read_ops_per_s.<mount>", Unit: "ops/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.write_ops_per_s.<mount>", Unit: "ops/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.total_read_B_per_s", Unit: "B/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.total_write_B_per_s", Unit: "B/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.total_read_ops_per_s", Unit: "ops/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.total_write_ops_per_s", Unit: "ops/s", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.disk_idle_percent.<disk>", Unit: "%", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.disk_queue_length.<disk>", ConfigOption: "fs_metrics"},
+		common.MetricDescriptor{Key: "fs.physical_disk.<volume>", ConfigOption: "fs_metrics"},
+	)
+}
+
+// networkFSTypes may hang on stat if the remote side is not available
+var networkFSTypes = []string{"nfs", "nfs4", "cifs", "smbfs", "smb3", "afpfs", "webdav", "fuse"}
+
+func isNetworkFilesystem(fsType string) bool {
+	fsType = strings.ToLower(fsType)
+	// also matches fuse subtypes like fuse.sshfs
+	if strings.HasPrefix(fsType, "fuse.") {
+		return true
+	}
+
+	return common.StrInSlice(fsType, networkFSTypes)
+}
+
+// FillThresholds defines used space percentages at which a filesystem is considered to be in warning or critical state
+type FillThresholds struct {
+	WarningPercent  float64 `toml:"warning_percent" comment:"fill level in percent of used space to report the warning state"`
+	CriticalPercent float64 `toml:"critical_percent" comment:"fill level in percent of used space to report the critical state"`
+}
+
+func (t FillThresholds) Validate() error {
+	if t.WarningPercent < 0 || t.WarningPercent > 100 || t.CriticalPercent < 0 || t.CriticalPercent > 100 {
+		return errors.New("thresholds must be between 0 and 100")
+	}
+
+	if t.WarningPercent >= t.CriticalPercent {
+		return errors.New("warning threshold must be less than critical threshold")
+	}
+
+	return nil
+}
+
+func (t FillThresholds) getFillState(usedPercent float64) string {
+	switch {
+	case usedPercent >= t.CriticalPercent:
+		return FillStateCritical
+	case usedPercent >= t.WarningPercent:
+		return FillStateWarning
+	default:
+		return FillStateOK
+	}
 }
 
 type FileSystemWatcher struct {
 	AllowedTypes      map[string]struct{}
 	ExcludePath       map[string]struct{}
-	ExcludedPathCache map[string]bool
+	ExcludedPathCache map[string]string
 	config            *FileSystemWatcherConfig
 	prevIOCounters    map[string]*ioCountersMeasurement
+
+	getUsage func(mountpoint string) (*disk.UsageStat, error)
+
+	// mountpoints with stat calls which are still running after the timeout
+	pendingStats     map[string]struct{}
+	pendingStatsLock sync.Mutex
+
+	filesystemIDs       map[string]filesystemID
+	filesystemIDsReadAt time.Time
+
+	// physicalDiskQuery reads the PhysicalDisk performance counters on Windows, opened on the first use
+	physicalDiskQuery winperf.Query
 }
 
 func NewWatcher(config FileSystemWatcherConfig) *FileSystemWatcher {
 	fsWatcher := &FileSystemWatcher{
 		AllowedTypes:      map[string]struct{}{},
 		ExcludePath:       make(map[string]struct{}),
-		ExcludedPathCache: map[string]bool{},
+		ExcludedPathCache: map[string]string{},
 		config:            &config,
 		prevIOCounters:    make(map[string]*ioCountersMeasurement),
+		getUsage:          getFsPartitionUsageInfo,
+		pendingStats:      make(map[string]struct{}),
+	}
+
+	if fsWatcher.config.StatTimeout <= 0 {
+		fsWatcher.config.StatTimeout = fsInfoRequestTimeout
 	}
 
 	for _, t := range config.TypeInclude {
@@ -44,9 +151,65 @@ func NewWatcher(config FileSystemWatcherConfig) *FileSystemWatcher {
 		fsWatcher.ExcludePath[t] = struct{}{}
 	}
 
+	if rule := fsWatcher.getExcludingRule(rootMountpoint); rule != "" {
+		if config.AlwaysIncludeRoot {
+			logrus.Infof("[FS] root filesystem is excluded by %s, but will be measured as fs_always_include_root is set", rule)
+		} else {
+			logrus.Warnf("[FS] root filesystem is excluded by %s, its space and inodes usage will not be monitored. Set fs_always_include_root = true to measure it regardless", rule)
+		}
+	}
+
 	return fsWatcher
 }
 
+// isMountpointExcluded checks mountpoint against fs_path_exclude rules
+// root filesystem is never excluded when AlwaysIncludeRoot is set
+func (fw *FileSystemWatcher) isMountpointExcluded(mountpoint string) bool {
+	rule := fw.getExcludingRule(mountpoint)
+	if rule == "" {
+		return false
+	}
+
+	if mountpoint == rootMountpoint && fw.config.AlwaysIncludeRoot {
+		logrus.Debugf("[FS] mountpoint %s is excluded by %s, but included as root filesystem", mountpoint, rule)
+		return false
+	}
+
+	logrus.Debugf("[FS] mountpoint excluded: %s (%s)", mountpoint, rule)
+	return true
+}
+
+// getExcludingRule returns description of the rule which excludes the mountpoint or empty string if it is not excluded
+func (fw *FileSystemWatcher) getExcludingRule(mountpoint string) string {
+	if fw.config.PathExcludeRecurse {
+		for path := range fw.ExcludePath {
+			if strings.HasPrefix(mountpoint, path) {
+				return fmt.Sprintf("fs_path_exclude prefix '%s' (fs_path_exclude_recurse)", path)
+			}
+		}
+	}
+
+	cacheKey := strings.ToLower(mountpoint)
+	if rule, cacheExists := fw.ExcludedPathCache[cacheKey]; cacheExists {
+		return rule
+	}
+
+	rule := ""
+	for _, glob := range fw.config.PathExclude {
+		if matched, _ := filepath.Match(glob, mountpoint); matched {
+			if glob == mountpoint {
+				rule = fmt.Sprintf("fs_path_exclude literal '%s'", glob)
+			} else {
+				rule = fmt.Sprintf("fs_path_exclude pattern '%s'", glob)
+			}
+			break
+		}
+	}
+	fw.ExcludedPathCache[cacheKey] = rule
+
+	return rule
+}
+
 func (fw *FileSystemWatcher) Results() (common.MeasurementsMap, error) {
 	results := common.MeasurementsMap{}
 	var errs common.ErrorCollector
@@ -64,47 +227,28 @@ func (fw *FileSystemWatcher) Results() (common.MeasurementsMap, error) {
 			continue
 		}
 
-		pathExcluded := false
-
-		if fw.config.PathExcludeRecurse {
-			for path := range fw.ExcludePath {
-				if strings.HasPrefix(partition.Mountpoint, path) {
-					logrus.Debugf("[FS] mountpoint excluded: %s", partition.Mountpoint)
-					pathExcluded = true
-					break
-				}
-			}
-		}
-
-		if pathExcluded {
+		if fw.isMountpointExcluded(partition.Mountpoint) {
 			continue
 		}
 
 		partitionMountPoint := strings.ToLower(partition.Mountpoint)
 
-		cacheExists := false
-		if pathExcluded, cacheExists = fw.ExcludedPathCache[partitionMountPoint]; cacheExists {
-			if pathExcluded {
-				logrus.Debugf("[FS] mountpoint excluded: %s", partition.Fstype)
-				continue
-			}
-		} else {
-			pathExcluded = false
-			for _, glob := range fw.config.PathExclude {
-				pathExcluded, _ = filepath.Match(glob, partition.Mountpoint)
-				if pathExcluded {
-					break
-				}
-			}
-			fw.ExcludedPathCache[partitionMountPoint] = pathExcluded
+		fw.fillFilesystemIDMetrics(results, partition.Mountpoint, fw.getFilesystemID(partition, partitions))
+		fw.fillReadOnlyMetrics(results, partition.Mountpoint, partition.Opts)
 
-			if pathExcluded {
-				logrus.Debugf("[FS] mountpoint excluded: %s", partition.Mountpoint)
+		var usage *disk.UsageStat
+		isNetworkFS := isNetworkFilesystem(partition.Fstype)
+		if isNetworkFS {
+			usage, err = fw.getUsageWithTimeout(partition.Mountpoint)
+			results["reachable."+partition.Mountpoint] = err != errStatTimeout
+			if err == errStatTimeout {
+				logrus.Warnf("[FS] '%s'(%s) did not respond within %v, considering it unreachable", partition.Mountpoint, partition.Device, fw.config.StatTimeout)
+				fw.fillUnreachableMetrics(results, partition.Mountpoint)
 				continue
 			}
+		} else {
+			usage, err = fw.getUsage(partition.Mountpoint)
 		}
-
-		usage, err := getFsPartitionUsageInfo(partition.Mountpoint)
 		if err != nil {
 			logrus.WithError(err).Errorf("[FS] Failed to get usage info for '%s'(%s)", partition.Mountpoint, partition.Device)
 			errs.Add(err)
@@ -116,8 +260,7 @@ func (fw *FileSystemWatcher) Results() (common.MeasurementsMap, error) {
 		ioCounters, err := getPartitionIOCounters(partition.Device)
 		if err != nil {
 			log := logrus.WithError(err)
-			isNetworkVolumeDrive := partition.Fstype == "smbfs" || partition.Fstype == "nfs"
-			if isNetworkVolumeDrive {
+			if isNetworkFS {
 				// this info is not available for network shares
 				log.Debugf("[FS] Skipping IO counters for network share '%s' (device %s)", partition.Mountpoint, partition.Device)
 				continue
@@ -147,9 +290,61 @@ func (fw *FileSystemWatcher) Results() (common.MeasurementsMap, error) {
 	totalIOCounters := calcTotalIOUsage(partitionIOCounters)
 	fw.fillTotalIOCountersMetrics(results, totalIOCounters)
 
+	physicalDisks, err := fw.getPhysicalDiskMeasurements()
+	if err != nil {
+		logrus.WithError(err).Errorf("[FS] Failed to read physical disk counters")
+		errs.Add(err)
+	}
+	results = results.AddWithPrefix("", physicalDisks)
+
 	return results, errs.Combine()
 }
 
+// getUsageWithTimeout prevents hanging on stat of unavailable network filesystems.
+// The stat call keeps running in background after the timeout, the mountpoint is considered unreachable until it returns
+func (fw *FileSystemWatcher) getUsageWithTimeout(mountpoint string) (*disk.UsageStat, error) {
+	fw.pendingStatsLock.Lock()
+	if _, pending := fw.pendingStats[mountpoint]; pending {
+		fw.pendingStatsLock.Unlock()
+		return nil, errStatTimeout
+	}
+	fw.pendingStats[mountpoint] = struct{}{}
+	fw.pendingStatsLock.Unlock()
+
+	type usageResult struct {
+		usage *disk.UsageStat
+		err   error
+	}
+
+	resultChan := make(chan usageResult, 1)
+	go func() {
+		usage, err := fw.getUsage(mountpoint)
+
+		fw.pendingStatsLock.Lock()
+		delete(fw.pendingStats, mountpoint)
+		fw.pendingStatsLock.Unlock()
+
+		resultChan <- usageResult{usage, err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		return res.usage, res.err
+	case <-time.After(fw.config.StatTimeout):
+		return nil, errStatTimeout
+	}
+}
+
+func (fw *FileSystemWatcher) fillUnreachableMetrics(results common.MeasurementsMap, mountName string) {
+	for _, metric := range fw.config.Metrics {
+		switch strings.ToLower(metric) {
+		case "free_b", "free_percent", "used_percent", "total_b", "inodes_total", "inodes_free", "inodes_used", "inodes_used_percent":
+			results[metric+"."+mountName] = nil
+		}
+	}
+	results["fill_state."+mountName] = nil
+}
+
 func (fw *FileSystemWatcher) fillUsageMetrics(results common.MeasurementsMap, mountName string, usage *disk.UsageStat) {
 	for _, metric := range fw.config.Metrics {
 		resultField := metric + "." + mountName
@@ -172,6 +367,12 @@ func (fw *FileSystemWatcher) fillUsageMetrics(results common.MeasurementsMap, mo
 			results[resultField] = float64(int64(usage.InodesUsedPercent*100+0.5)) / 100
 		}
 	}
+
+	thresholds, hasOverride := fw.config.FillThresholdsPerPath[mountName]
+	if !hasOverride {
+		thresholds = fw.config.FillThresholds
+	}
+	results["fill_state."+mountName] = thresholds.getFillState(usage.UsedPercent)
 }
 
 func (fw *FileSystemWatcher) fillIOCounterMetrics(results common.MeasurementsMap, mountName string, ioCounters *ioUsageInfo) {
@@ -0,0 +1,172 @@
+package fs
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/disk"
+	"github.com/sirupsen/logrus"
+	logrustest "github.com/sirupsen/logrus/hooks/test"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestFillStateMetric(t *testing.T) {
+	fw := NewWatcher(FileSystemWatcherConfig{
+		FillThresholds: FillThresholds{WarningPercent: 90, CriticalPercent: 95},
+		FillThresholdsPerPath: map[string]FillThresholds{
+			"/var": {WarningPercent: 97, CriticalPercent: 99},
+		},
+	})
+
+	var cases = map[string]struct {
+		usedPercent float64
+		expected    string
+	}{
+		"/":     {95, FillStateCritical},
+		"/home": {91.5, FillStateWarning},
+		"/boot": {20, FillStateOK},
+		"/var":  {95, FillStateOK},
+	}
+
+	for mountName, c := range cases {
+		results := common.MeasurementsMap{}
+		fw.fillUsageMetrics(results, mountName, &disk.UsageStat{UsedPercent: c.usedPercent})
+		assert.Equal(t, c.expected, results["fill_state."+mountName], "for mountpoint %s", mountName)
+	}
+}
+
+func TestFillThresholdsValidate(t *testing.T) {
+	assert.NoError(t, FillThresholds{WarningPercent: 90, CriticalPercent: 95}.Validate())
+	assert.Error(t, FillThresholds{WarningPercent: 95, CriticalPercent: 90}.Validate())
+	assert.Error(t, FillThresholds{WarningPercent: 95, CriticalPercent: 95}.Validate())
+	assert.Error(t, FillThresholds{WarningPercent: -1, CriticalPercent: 95}.Validate())
+	assert.Error(t, FillThresholds{WarningPercent: 90, CriticalPercent: 101}.Validate())
+}
+
+func TestRootMountpointExcluded(t *testing.T) {
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	fw := NewWatcher(FileSystemWatcherConfig{
+		PathExclude: []string{"/", "/mnt/*"},
+	})
+	assert.True(t, fw.isMountpointExcluded("/"))
+	assert.True(t, fw.isMountpointExcluded("/mnt/backup"))
+	assert.False(t, fw.isMountpointExcluded("/home"))
+
+	var warnings []string
+	for _, entry := range hook.AllEntries() {
+		if entry.Level == logrus.WarnLevel {
+			warnings = append(warnings, entry.Message)
+		}
+	}
+	if assert.Len(t, warnings, 1) {
+		assert.Contains(t, warnings[0], "fs_path_exclude literal '/'")
+	}
+}
+
+func TestRootMountpointAlwaysIncluded(t *testing.T) {
+	hook := logrustest.NewGlobal()
+	defer hook.Reset()
+
+	fw := NewWatcher(FileSystemWatcherConfig{
+		PathExclude:       []string{"/*"},
+		AlwaysIncludeRoot: true,
+	})
+	assert.False(t, fw.isMountpointExcluded("/"))
+	assert.True(t, fw.isMountpointExcluded("/boot"))
+
+	for _, entry := range hook.AllEntries() {
+		assert.NotEqual(t, logrus.WarnLevel, entry.Level)
+	}
+
+	results := common.MeasurementsMap{}
+	fw.config.Metrics = []string{"total_B", "inodes_used"}
+	fw.fillUsageMetrics(results, "/", &disk.UsageStat{Total: 1000, InodesUsed: 10})
+	assert.Equal(t, uint64(1000), results["total_B./"])
+	assert.Equal(t, uint64(10), results["inodes_used./"])
+}
+
+func TestExcludingRuleRecurse(t *testing.T) {
+	fw := NewWatcher(FileSystemWatcherConfig{
+		PathExclude:        []string{"/"},
+		PathExcludeRecurse: true,
+	})
+	assert.Equal(t, "fs_path_exclude prefix '/' (fs_path_exclude_recurse)", fw.getExcludingRule("/var"))
+}
+
+func TestNetworkFilesystemStatTimeout(t *testing.T) {
+	fw := NewWatcher(FileSystemWatcherConfig{
+		Metrics:     []string{"free_B", "used_percent"},
+		StatTimeout: 50 * time.Millisecond,
+	})
+
+	release := make(chan struct{})
+	defer close(release)
+	var calls int32
+	fw.getUsage = func(mountpoint string) (*disk.UsageStat, error) {
+		atomic.AddInt32(&calls, 1)
+		if mountpoint == "/mnt/hung" {
+			// simulates statfs on the hung NFS mount
+			<-release
+		}
+		return &disk.UsageStat{Free: 100, UsedPercent: 10}, nil
+	}
+
+	started := time.Now()
+	_, err := fw.getUsageWithTimeout("/mnt/hung")
+	assert.Equal(t, errStatTimeout, err)
+	assert.True(t, time.Since(started) < time.Second)
+
+	// the previous stat is still hanging, so it is not started again
+	_, err = fw.getUsageWithTimeout("/mnt/hung")
+	assert.Equal(t, errStatTimeout, err)
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	usage, err := fw.getUsageWithTimeout("/mnt/ok")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(100), usage.Free)
+
+	results := common.MeasurementsMap{}
+	fw.fillUnreachableMetrics(results, "/mnt/hung")
+	assert.Equal(t, common.MeasurementsMap{
+		"free_B./mnt/hung":       nil,
+		"used_percent./mnt/hung": nil,
+		"fill_state./mnt/hung":   nil,
+	}, results)
+}
+
+func TestIsNetworkFilesystem(t *testing.T) {
+	assert.True(t, isNetworkFilesystem("nfs4"))
+	assert.True(t, isNetworkFilesystem("CIFS"))
+	assert.True(t, isNetworkFilesystem("fuse.sshfs"))
+	assert.False(t, isNetworkFilesystem("ext4"))
+	assert.False(t, isNetworkFilesystem("fusectl"))
+}
+
+func TestReadOnlyMetrics(t *testing.T) {
+	fw := NewWatcher(FileSystemWatcherConfig{
+		ReadOnlyExpected: []string{"/mnt/iso", "/snap/*/*"},
+	})
+
+	results := common.MeasurementsMap{}
+	fw.fillReadOnlyMetrics(results, "/", "rw,relatime,errors=remount-ro")
+	// mounted rw by fstab, remounted ro by the kernel after I/O errors
+	fw.fillReadOnlyMetrics(results, "/data", "ro,relatime")
+	fw.fillReadOnlyMetrics(results, "/mnt/iso", "ro,nosuid,nodev")
+	fw.fillReadOnlyMetrics(results, "/snap/core/123", "ro,nodev,relatime")
+
+	assert.Equal(t, common.MeasurementsMap{
+		"read_only./":                         false,
+		"read_only_unexpected./":              false,
+		"read_only./data":                     true,
+		"read_only_unexpected./data":          true,
+		"read_only./mnt/iso":                  true,
+		"read_only_unexpected./mnt/iso":       false,
+		"read_only./snap/core/123":            true,
+		"read_only_unexpected./snap/core/123": false,
+	}, results)
+}
@@ -0,0 +1,197 @@
+package httpcheck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "httpcheck")
+
+const defaultTimeout = 5 * time.Second
+
+// Values of <name>.state
+const (
+	StateOK               = "ok"
+	StateUnexpectedStatus = "unexpected_status"
+	StateRedirect         = "redirect"
+	StateTLSError         = "tls_error"
+	StateTimeout          = "timeout"
+	StateConnectionError  = "connection_error"
+)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "httpcheck.<name>.up", ConfigOption: "http_checks"},
+		common.MetricDescriptor{Key: "httpcheck.<name>.status_code", ConfigOption: "http_checks"},
+		common.MetricDescriptor{Key: "httpcheck.<name>.response_time_ms", Unit: "ms", ConfigOption: "http_checks"},
+		common.MetricDescriptor{Key: "httpcheck.<name>.state", ConfigOption: "http_checks"},
+	)
+}
+
+// Check is an HTTP endpoint probed with a GET request. Redirects are not followed
+type Check struct {
+	Name         string  `toml:"name" comment:"Name used as the key of the results"`
+	URL          string  `toml:"url" comment:"URL to request, e.g. 'http://127.0.0.1:8080/health'"`
+	ExpectStatus int     `toml:"expect_status" comment:"HTTP status code the endpoint is considered up with. 0 accepts any 2xx. default 0"`
+	Timeout      float64 `toml:"timeout" comment:"Timeout in seconds to wait for the response. default 5.0"`
+}
+
+func (c Check) Validate() error {
+	if c.Name == "" {
+		return errors.New("name must be set")
+	}
+
+	if c.URL == "" {
+		return errors.New("url must be set")
+	}
+
+	u, err := url.Parse(c.URL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("url must start with http:// or https://")
+	}
+
+	if u.Host == "" {
+		return errors.New("url must contain the host")
+	}
+
+	if c.ExpectStatus != 0 && (c.ExpectStatus < 100 || c.ExpectStatus > 599) {
+		return fmt.Errorf("expect_status must be 0 or a valid HTTP status code, got %d", c.ExpectStatus)
+	}
+
+	if c.Timeout < 0 {
+		return errors.New("timeout must be >= 0")
+	}
+
+	return nil
+}
+
+func (c Check) timeout() time.Duration {
+	if c.Timeout == 0 {
+		return defaultTimeout
+	}
+
+	return time.Duration(c.Timeout * float64(time.Second))
+}
+
+// isExpected reports whether the endpoint is up with the status code
+func (c Check) isExpected(statusCode int) bool {
+	if c.ExpectStatus == 0 {
+		return statusCode >= 200 && statusCode < 300
+	}
+
+	return statusCode == c.ExpectStatus
+}
+
+// RunChecks probes the endpoints in parallel and reports <name>.up, <name>.status_code, <name>.response_time_ms
+// and <name>.state, one of ok, unexpected_status, redirect, tls_error, timeout or connection_error.
+// status_code and response_time_ms are nil if no response was received
+func RunChecks(ctx context.Context, checks []Check) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range checks {
+		wg.Add(1)
+		go func(check Check) {
+			defer wg.Done()
+
+			checkResults := runCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results.AddWithPrefix(check.Name+".", checkResults)
+		}(check)
+	}
+	wg.Wait()
+
+	return results
+}
+
+func runCheck(ctx context.Context, check Check) common.MeasurementsMap {
+	results := common.MeasurementsMap{
+		"up":               false,
+		"status_code":      nil,
+		"response_time_ms": nil,
+	}
+
+	client := &http.Client{
+		Timeout: check.timeout(),
+		Transport: &http.Transport{
+			// endpoints are probed directly, the hub proxy settings don't apply
+			Proxy:             nil,
+			DisableKeepAlives: true,
+		},
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+
+	req, err := http.NewRequest(http.MethodGet, check.URL, nil)
+	if err != nil {
+		results["state"] = StateConnectionError
+		log.WithError(err).Debugf("http check %s failed", check.Name)
+		return results
+	}
+
+	startedAt := time.Now()
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		results["state"] = errorState(err)
+		log.WithError(err).Debugf("http check %s failed", check.Name)
+		return results
+	}
+	defer resp.Body.Close()
+
+	// the response time includes reading the body
+	_, _ = io.Copy(ioutil.Discard, resp.Body)
+	results["response_time_ms"] = float64(time.Since(startedAt)) / float64(time.Millisecond)
+	results["status_code"] = resp.StatusCode
+
+	switch {
+	case check.isExpected(resp.StatusCode):
+		results["up"] = true
+		results["state"] = StateOK
+	case resp.StatusCode >= 300 && resp.StatusCode < 400:
+		results["state"] = StateRedirect
+	default:
+		results["state"] = StateUnexpectedStatus
+	}
+
+	return results
+}
+
+func errorState(err error) string {
+	var netErr net.Error
+	if errors.As(err, &netErr) && netErr.Timeout() {
+		return StateTimeout
+	}
+
+	var unknownAuthorityErr x509.UnknownAuthorityError
+	var certInvalidErr x509.CertificateInvalidError
+	var hostnameErr x509.HostnameError
+	var recordHeaderErr tls.RecordHeaderError
+	if errors.As(err, &unknownAuthorityErr) || errors.As(err, &certInvalidErr) ||
+		errors.As(err, &hostnameErr) || errors.As(err, &recordHeaderErr) {
+		return StateTLSError
+	}
+
+	return StateConnectionError
+}
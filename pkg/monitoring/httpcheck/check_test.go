@@ -0,0 +1,104 @@
+package httpcheck
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChecks(t *testing.T) {
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	}))
+	defer healthy.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer failing.Close()
+
+	release := make(chan struct{})
+	slow := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer slow.Close()
+	defer close(release)
+
+	redirecting := httptest.NewServer(http.RedirectHandler("/login", http.StatusFound))
+	defer redirecting.Close()
+
+	// the certificate of the test server is not trusted by the system
+	untrusted := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer untrusted.Close()
+
+	// nothing listens on the port after the listener is closed
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedURL := "http://" + listener.Addr().String()
+	require.NoError(t, listener.Close())
+
+	checks := []Check{
+		{Name: "healthy", URL: healthy.URL},
+		{Name: "failing", URL: failing.URL},
+		{Name: "slow", URL: slow.URL, Timeout: 0.3},
+		{Name: "redirecting", URL: redirecting.URL},
+		{Name: "redirect_expected", URL: redirecting.URL, ExpectStatus: http.StatusFound},
+		{Name: "untrusted", URL: untrusted.URL},
+		{Name: "closed", URL: closedURL},
+	}
+	for _, check := range checks {
+		require.NoError(t, check.Validate())
+	}
+
+	startedAt := time.Now()
+	results := RunChecks(context.Background(), checks)
+	assert.True(t, time.Since(startedAt) < 3*time.Second, "slow endpoint must not stall the collection")
+
+	assert.Equal(t, true, results["healthy.up"])
+	assert.Equal(t, http.StatusOK, results["healthy.status_code"])
+	assert.Equal(t, StateOK, results["healthy.state"])
+	assert.IsType(t, float64(0), results["healthy.response_time_ms"])
+
+	assert.Equal(t, false, results["failing.up"])
+	assert.Equal(t, http.StatusInternalServerError, results["failing.status_code"])
+	assert.Equal(t, StateUnexpectedStatus, results["failing.state"])
+	assert.IsType(t, float64(0), results["failing.response_time_ms"])
+
+	assert.Equal(t, false, results["slow.up"])
+	assert.Equal(t, StateTimeout, results["slow.state"])
+	assert.Contains(t, results, "slow.status_code")
+	assert.Nil(t, results["slow.status_code"])
+	assert.Contains(t, results, "slow.response_time_ms")
+	assert.Nil(t, results["slow.response_time_ms"])
+
+	assert.Equal(t, false, results["redirecting.up"])
+	assert.Equal(t, http.StatusFound, results["redirecting.status_code"])
+	assert.Equal(t, StateRedirect, results["redirecting.state"])
+
+	assert.Equal(t, true, results["redirect_expected.up"])
+	assert.Equal(t, StateOK, results["redirect_expected.state"])
+
+	assert.Equal(t, false, results["untrusted.up"])
+	assert.Equal(t, StateTLSError, results["untrusted.state"])
+	assert.Nil(t, results["untrusted.status_code"])
+
+	assert.Equal(t, false, results["closed.up"])
+	assert.Equal(t, StateConnectionError, results["closed.state"])
+}
+
+func TestCheckValidate(t *testing.T) {
+	assert.NoError(t, Check{Name: "api", URL: "http://127.0.0.1:8080/health"}.Validate())
+	assert.NoError(t, Check{Name: "api", URL: "https://localhost/health", ExpectStatus: 204, Timeout: 1}.Validate())
+	assert.Error(t, Check{URL: "http://127.0.0.1:8080/health"}.Validate())
+	assert.Error(t, Check{Name: "api"}.Validate())
+	assert.Error(t, Check{Name: "api", URL: "127.0.0.1:8080/health"}.Validate())
+	assert.Error(t, Check{Name: "api", URL: "http:///health"}.Validate())
+	assert.Error(t, Check{Name: "api", URL: "http://127.0.0.1:8080/health", ExpectStatus: 1000}.Validate())
+	assert.Error(t, Check{Name: "api", URL: "http://127.0.0.1:8080/health", Timeout: -1}.Validate())
+}
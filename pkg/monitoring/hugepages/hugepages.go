@@ -0,0 +1,57 @@
+package hugepages
+
+import (
+	"runtime"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "mem.hugepages_total", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.hugepages_free", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.hugepages_used_percent", Unit: "%", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.hugepage_size_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.anon_hugepages_B", Unit: "B", ConfigOption: "mem_monitoring"},
+	)
+}
+
+// GetMeasurements reads the usage of the hugepages pool and of transparent hugepages from /proc/meminfo:
+// hugepages_total, hugepages_free, hugepages_used_percent, hugepage_size_B and anon_hugepages_B.
+// hugepages_used_percent is nil if no hugepages are configured. Returns nil on other OSes than Linux
+func GetMeasurements() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	return readMeminfo(common.HostProc("meminfo"))
+}
+
+func readMeminfo(filePath string) (common.MeasurementsMap, error) {
+	values, err := common.ReadMeminfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	total, hasTotal := values["HugePages_Total"]
+	if !hasTotal {
+		// kernel is built without hugetlbfs support
+		return nil, nil
+	}
+
+	free := values["HugePages_Free"]
+	results := common.MeasurementsMap{
+		"hugepages_total":        total,
+		"hugepages_free":         free,
+		"hugepages_used_percent": nil,
+		"hugepage_size_B":        values["Hugepagesize"],
+	}
+	if total > 0 && total >= free {
+		results["hugepages_used_percent"] = float64(total-free) / float64(total) * 100
+	}
+	if anon, ok := values["AnonHugePages"]; ok {
+		results["anon_hugepages_B"] = anon
+	}
+
+	return results, nil
+}
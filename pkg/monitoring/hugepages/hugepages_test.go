@@ -0,0 +1,42 @@
+package hugepages
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadMeminfo(t *testing.T) {
+	results, err := readMeminfo(filepath.Join("testdata", "meminfo"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, common.MeasurementsMap{
+		"hugepages_total":        uint64(4096),
+		"hugepages_free":         uint64(1024),
+		"hugepages_used_percent": float64(75),
+		"hugepage_size_B":        uint64(2048 * 1024),
+		"anon_hugepages_B":       uint64(2097152 * 1024),
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("mem.", results)))
+}
+
+func TestReadMeminfoNoHugepages(t *testing.T) {
+	tmpFile, err := ioutil.TempFile("", "meminfo")
+	require.NoError(t, err)
+	defer os.Remove(tmpFile.Name())
+
+	_, err = tmpFile.WriteString("MemTotal:       32780792 kB\nHugePages_Total:       0\nHugePages_Free:        0\nHugepagesize:       2048 kB\n")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	results, err := readMeminfo(tmpFile.Name())
+	assert.NoError(t, err)
+	assert.Nil(t, results["hugepages_used_percent"])
+	assert.Equal(t, uint64(0), results["hugepages_total"])
+}
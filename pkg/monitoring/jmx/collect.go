@@ -0,0 +1,185 @@
+package jmx
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "jmx")
+
+const (
+	memoryMBean           = "java.lang:type=Memory"
+	garbageCollectorMBean = "java.lang:type=GarbageCollector,name=*"
+	threadingMBean        = "java.lang:type=Threading"
+)
+
+// metricNames are reported for every target, nil if the value couldn't be read
+var metricNames = []string{"heap_used_B", "heap_max_B", "gc_count", "gc_time_ms", "thread_count"}
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "jmx.<target>.heap_used_B", Unit: "B", ConfigOption: "jmx_targets"},
+		common.MetricDescriptor{Key: "jmx.<target>.heap_max_B", Unit: "B", ConfigOption: "jmx_targets"},
+		common.MetricDescriptor{Key: "jmx.<target>.gc_count", ConfigOption: "jmx_targets"},
+		common.MetricDescriptor{Key: "jmx.<target>.gc_time_ms", Unit: "ms", ConfigOption: "jmx_targets"},
+		common.MetricDescriptor{Key: "jmx.<target>.thread_count", ConfigOption: "jmx_targets"},
+	)
+}
+
+type readRequest struct {
+	Type      string   `json:"type"`
+	MBean     string   `json:"mbean"`
+	Attribute []string `json:"attribute"`
+}
+
+type readResponse struct {
+	Status int             `json:"status"`
+	Error  string          `json:"error"`
+	Value  json.RawMessage `json:"value"`
+}
+
+type memoryUsage struct {
+	Used int64 `json:"used"`
+	Max  int64 `json:"max"`
+}
+
+// CollectTargets reads the MBeans of the targets in parallel and reports them as <target>.<metric>:
+// heap_used_B and heap_max_B, gc_count and gc_time_ms summed over all garbage collectors and thread_count.
+// The metrics of the target which didn't respond within its timeout are reported as nil
+func CollectTargets(ctx context.Context, targets []Target) (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+
+			values, err := collectTarget(ctx, target)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for _, name := range metricNames {
+				results[target.Key()+"."+name] = values[name]
+			}
+			if err != nil {
+				log.WithError(err).Errorf("failed to collect measurements of %s", target.Key())
+				errs.Add(fmt.Errorf("jmx %s: %s", target.Key(), err.Error()))
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return results, errs.Combine()
+}
+
+// collectTarget sends the bulk read request of the MBeans to the Jolokia agent
+func collectTarget(ctx context.Context, target Target) (common.MeasurementsMap, error) {
+	body, err := json.Marshal([]readRequest{
+		{Type: "read", MBean: memoryMBean, Attribute: []string{"HeapMemoryUsage"}},
+		{Type: "read", MBean: garbageCollectorMBean, Attribute: []string{"CollectionCount", "CollectionTime"}},
+		{Type: "read", MBean: threadingMBean, Attribute: []string{"ThreadCount"}},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, target.URL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if target.User != "" {
+		req.SetBasicAuth(target.User, target.Password)
+	}
+
+	client := &http.Client{Timeout: target.timeout()}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected response from the Jolokia agent (HTTP %d)", resp.StatusCode)
+	}
+
+	var responses []readResponse
+	if err = json.Unmarshal(data, &responses); err != nil {
+		return nil, fmt.Errorf("failed to parse the response of the Jolokia agent: %s", err.Error())
+	}
+	if len(responses) != 3 {
+		return nil, fmt.Errorf("unexpected number of responses from the Jolokia agent: %d", len(responses))
+	}
+
+	return parseResponses(responses[0], responses[1], responses[2])
+}
+
+func parseResponses(memory, gc, threading readResponse) (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+
+	var heap struct {
+		HeapMemoryUsage memoryUsage `json:"HeapMemoryUsage"`
+	}
+	if err := memory.decode(&heap); err != nil {
+		errs.Add(fmt.Errorf("%s: %s", memoryMBean, err.Error()))
+	} else {
+		results["heap_used_B"] = heap.HeapMemoryUsage.Used
+		// -1 if the maximum is undefined
+		if heap.HeapMemoryUsage.Max >= 0 {
+			results["heap_max_B"] = heap.HeapMemoryUsage.Max
+		}
+	}
+
+	// the values are keyed by the object names of the garbage collectors
+	var collectors map[string]struct {
+		CollectionCount int64 `json:"CollectionCount"`
+		CollectionTime  int64 `json:"CollectionTime"`
+	}
+	if err := gc.decode(&collectors); err != nil {
+		errs.Add(fmt.Errorf("%s: %s", garbageCollectorMBean, err.Error()))
+	} else {
+		var count, timeMs int64
+		for _, c := range collectors {
+			count += c.CollectionCount
+			timeMs += c.CollectionTime
+		}
+		results["gc_count"] = count
+		results["gc_time_ms"] = timeMs
+	}
+
+	var threads struct {
+		ThreadCount int64 `json:"ThreadCount"`
+	}
+	if err := threading.decode(&threads); err != nil {
+		errs.Add(fmt.Errorf("%s: %s", threadingMBean, err.Error()))
+	} else {
+		results["thread_count"] = threads.ThreadCount
+	}
+
+	return results, errs.Combine()
+}
+
+func (r readResponse) decode(v interface{}) error {
+	if r.Status != http.StatusOK {
+		return fmt.Errorf("status %d: %s", r.Status, r.Error)
+	}
+
+	return json.Unmarshal(r.Value, v)
+}
@@ -0,0 +1,124 @@
+package jmx
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const jolokiaResponse = `[
+  {"request": {"type": "read", "mbean": "java.lang:type=Memory"}, "status": 200,
+   "value": {"HeapMemoryUsage": {"init": 262144000, "committed": 251658240, "max": 4164943872, "used": 104857600}}},
+  {"request": {"type": "read", "mbean": "java.lang:type=GarbageCollector,name=*"}, "status": 200,
+   "value": {
+     "java.lang:name=G1 Young Generation,type=GarbageCollector": {"CollectionCount": 12, "CollectionTime": 85},
+     "java.lang:name=G1 Old Generation,type=GarbageCollector": {"CollectionCount": 1, "CollectionTime": 40}
+   }},
+  {"request": {"type": "read", "mbean": "java.lang:type=Threading"}, "status": 200,
+   "value": {"ThreadCount": 37}}
+]`
+
+func newJolokiaServer(t *testing.T, response string) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		if user != "monitor" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var requests []readRequest
+		if !assert.NoError(t, json.NewDecoder(r.Body).Decode(&requests)) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		assert.Len(t, requests, 3)
+
+		_, _ = w.Write([]byte(response))
+	}))
+}
+
+func TestCollectTargets(t *testing.T) {
+	server := newJolokiaServer(t, jolokiaResponse)
+	defer server.Close()
+
+	// accepts connections but never responds
+	release := make(chan struct{})
+	stalled := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+	}))
+	defer stalled.Close()
+	defer close(release)
+
+	targets := []Target{
+		{Name: "tomcat", URL: server.URL, User: "monitor", Password: "secret"},
+		{Name: "kafka", URL: stalled.URL, Timeout: 0.5},
+	}
+	for _, target := range targets {
+		require.NoError(t, target.Validate())
+	}
+
+	startedAt := time.Now()
+	results, err := CollectTargets(context.Background(), targets)
+	assert.True(t, time.Since(startedAt) < 3*time.Second, "unresponsive target must not stall the collection")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "jmx kafka")
+	assert.NotContains(t, err.Error(), "jmx tomcat")
+
+	assert.Equal(t, int64(104857600), results["tomcat.heap_used_B"])
+	assert.Equal(t, int64(4164943872), results["tomcat.heap_max_B"])
+	assert.Equal(t, int64(13), results["tomcat.gc_count"])
+	assert.Equal(t, int64(125), results["tomcat.gc_time_ms"])
+	assert.Equal(t, int64(37), results["tomcat.thread_count"])
+
+	for _, name := range metricNames {
+		assert.Contains(t, results, "kafka."+name)
+		assert.Nil(t, results["kafka."+name])
+	}
+}
+
+func TestCollectTargetsPartialFailure(t *testing.T) {
+	server := newJolokiaServer(t, `[
+  {"status": 200, "value": {"HeapMemoryUsage": {"max": -1, "used": 1024}}},
+  {"status": 404, "error": "javax.management.InstanceNotFoundException : java.lang:type=GarbageCollector,name=*"},
+  {"status": 200, "value": {"ThreadCount": 5}}
+]`)
+	defer server.Close()
+
+	results, err := CollectTargets(context.Background(), []Target{{Name: "app", URL: server.URL, User: "monitor", Password: "secret"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "InstanceNotFoundException")
+
+	assert.Equal(t, int64(1024), results["app.heap_used_B"])
+	assert.Nil(t, results["app.heap_max_B"], "undefined maximum must be reported as nil")
+	assert.Nil(t, results["app.gc_count"])
+	assert.Nil(t, results["app.gc_time_ms"])
+	assert.Equal(t, int64(5), results["app.thread_count"])
+}
+
+func TestCollectTargetsUnauthorized(t *testing.T) {
+	server := newJolokiaServer(t, jolokiaResponse)
+	defer server.Close()
+
+	results, err := CollectTargets(context.Background(), []Target{{URL: server.URL, User: "monitor", Password: "wrong"}})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "HTTP 401")
+
+	key := Target{URL: server.URL}.Key()
+	assert.Contains(t, results, key+".heap_used_B")
+	assert.Nil(t, results[key+".heap_used_B"])
+}
+
+func TestTargetValidate(t *testing.T) {
+	assert.NoError(t, Target{URL: "http://127.0.0.1:8778/jolokia"}.Validate())
+	assert.Error(t, Target{}.Validate())
+	assert.Error(t, Target{URL: "127.0.0.1:8778/jolokia"}.Validate())
+	assert.Error(t, Target{URL: "http:///jolokia"}.Validate())
+	assert.Error(t, Target{URL: "http://127.0.0.1:8778/jolokia", Timeout: -1}.Validate())
+}
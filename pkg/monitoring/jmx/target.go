@@ -0,0 +1,64 @@
+package jmx
+
+import (
+	"errors"
+	"net/url"
+	"time"
+)
+
+const defaultTimeout = 5 * time.Second
+
+// Target is a JVM which MBeans are read over HTTP using the Jolokia agent
+type Target struct {
+	Name     string  `toml:"name" comment:"Name used as the key of the JVM results. The host of url is used if empty"`
+	URL      string  `toml:"url" comment:"URL of the Jolokia agent, e.g. 'http://127.0.0.1:8778/jolokia'"`
+	User     string  `toml:"user" comment:"User of the HTTP basic authentication of the agent, if enabled"`
+	Password string  `toml:"password" comment:"Password of the HTTP basic authentication of the agent"`
+	Timeout  float64 `toml:"timeout" comment:"Timeout in seconds to wait for the response of the agent. default 5.0"`
+}
+
+func (t Target) Validate() error {
+	if t.URL == "" {
+		return errors.New("url must be set")
+	}
+
+	u, err := url.Parse(t.URL)
+	if err != nil {
+		return err
+	}
+
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return errors.New("url must start with http:// or https://")
+	}
+
+	if u.Host == "" {
+		return errors.New("url must contain the host")
+	}
+
+	if t.Timeout < 0 {
+		return errors.New("timeout must be >= 0")
+	}
+
+	return nil
+}
+
+// Key returns the name of the target used in the results
+func (t Target) Key() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	if u, err := url.Parse(t.URL); err == nil && u.Host != "" {
+		return u.Host
+	}
+
+	return t.URL
+}
+
+func (t Target) timeout() time.Duration {
+	if t.Timeout == 0 {
+		return defaultTimeout
+	}
+
+	return time.Duration(t.Timeout * float64(time.Second))
+}
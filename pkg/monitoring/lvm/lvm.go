@@ -0,0 +1,204 @@
+package lvm
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/pkg/errors"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const commandTimeout = 10 * time.Second
+
+var log = logrus.WithField("package", "lvm")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "lvm.vg.<vg>.total_B", Unit: "B", ConfigOption: "lvm_monitoring"},
+		common.MetricDescriptor{Key: "lvm.vg.<vg>.free_B", Unit: "B", ConfigOption: "lvm_monitoring"},
+		common.MetricDescriptor{Key: "lvm.vg.<vg>.free_percent", Unit: "%", ConfigOption: "lvm_monitoring"},
+		common.MetricDescriptor{Key: "lvm.lv.<vg>/<lv>.size_B", Unit: "B", ConfigOption: "lvm_monitoring"},
+		common.MetricDescriptor{Key: "lvm.thinpool.<vg>/<lv>.data_percent", Unit: "%", ConfigOption: "lvm_monitoring"},
+		common.MetricDescriptor{Key: "lvm.thinpool.<vg>/<lv>.metadata_percent", Unit: "%", ConfigOption: "lvm_monitoring"},
+	)
+}
+
+type LVM struct {
+	invoker  common.Invoker
+	vgsPath  string
+	lvsPath  string
+	useSudo  bool
+	detected bool
+}
+
+type vgsReport struct {
+	Report []struct {
+		VG []struct {
+			Name string `json:"vg_name"`
+			Size string `json:"vg_size"`
+			Free string `json:"vg_free"`
+		} `json:"vg"`
+	} `json:"report"`
+}
+
+type lvsReport struct {
+	Report []struct {
+		LV []struct {
+			VGName          string `json:"vg_name"`
+			Name            string `json:"lv_name"`
+			Size            string `json:"lv_size"`
+			SegmentType     string `json:"segtype"`
+			DataPercent     string `json:"data_percent"`
+			MetadataPercent string `json:"metadata_percent"`
+		} `json:"lv"`
+	} `json:"report"`
+}
+
+// New detects LVM tools on the host. Use IsAvailable() to check if LVM metrics can be collected
+func New(invoker common.Invoker) *LVM {
+	l := &LVM{invoker: invoker}
+	if runtime.GOOS != "linux" {
+		return l
+	}
+
+	var err error
+	if l.vgsPath, err = exec.LookPath("vgs"); err != nil {
+		log.Debug("vgs binary not found. LVM monitoring disabled")
+		return l
+	}
+
+	if l.lvsPath, err = exec.LookPath("lvs"); err != nil {
+		log.Debug("lvs binary not found. LVM monitoring disabled")
+		return l
+	}
+
+	// LVM tools require root privileges to access devices
+	l.useSudo = os.Geteuid() != 0
+	l.detected = true
+
+	return l
+}
+
+func (l *LVM) IsAvailable() bool {
+	return l.detected
+}
+
+// GetMeasurements reports sizes of LVM volume groups, logical volumes and usage of thin pools
+func (l *LVM) GetMeasurements() (common.MeasurementsMap, error) {
+	if !l.detected {
+		return nil, nil
+	}
+
+	vgsOutput, err := l.run(l.vgsPath, "-o", "vg_name,vg_size,vg_free")
+	if err != nil {
+		return nil, errors.Wrap(err, "LVM: while listing volume groups")
+	}
+
+	results, err := parseVgs(vgsOutput)
+	if err != nil {
+		return nil, errors.Wrap(err, "LVM")
+	}
+
+	lvsOutput, err := l.run(l.lvsPath, "-o", "vg_name,lv_name,lv_size,segtype,data_percent,metadata_percent")
+	if err != nil {
+		return results, errors.Wrap(err, "LVM: while listing logical volumes")
+	}
+
+	lvResults, err := parseLvs(lvsOutput)
+	if err != nil {
+		return results, errors.Wrap(err, "LVM")
+	}
+
+	return results.AddWithPrefix("", lvResults), nil
+}
+
+func (l *LVM) run(binaryPath string, args ...string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	args = append([]string{"--reportformat", "json", "--units", "b", "--nosuffix"}, args...)
+	name := binaryPath
+	if l.useSudo {
+		args = append([]string{"-n", binaryPath}, args...)
+		name = "sudo"
+	}
+
+	// invoker is expected to return the standard output only, so the warnings LVM tools print don't break the JSON report
+	out, err := l.invoker.CommandWithContext(ctx, name, args...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %s", err.Error(), string(bytes.TrimSpace(common.CommandErrorOutput(err))))
+	}
+
+	return out, nil
+}
+
+func parseVgs(output []byte) (common.MeasurementsMap, error) {
+	var report vgsReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, errors.Wrap(err, "could not parse vgs output")
+	}
+
+	results := common.MeasurementsMap{}
+	for _, r := range report.Report {
+		for _, vg := range r.VG {
+			total, err := strconv.ParseUint(vg.Size, 10, 64)
+			if err != nil {
+				log.WithError(err).Debugf("could not parse size of volume group %s", vg.Name)
+				continue
+			}
+			free, err := strconv.ParseUint(vg.Free, 10, 64)
+			if err != nil {
+				log.WithError(err).Debugf("could not parse free space of volume group %s", vg.Name)
+				continue
+			}
+
+			prefix := "vg." + vg.Name + "."
+			results[prefix+"total_B"] = total
+			results[prefix+"free_B"] = free
+			if total > 0 {
+				results[prefix+"free_percent"] = common.RoundToTwoDecimalPlaces(float64(free) / float64(total) * 100)
+			}
+		}
+	}
+
+	return results, nil
+}
+
+func parseLvs(output []byte) (common.MeasurementsMap, error) {
+	var report lvsReport
+	if err := json.Unmarshal(output, &report); err != nil {
+		return nil, errors.Wrap(err, "could not parse lvs output")
+	}
+
+	results := common.MeasurementsMap{}
+	for _, r := range report.Report {
+		for _, lv := range r.LV {
+			fullName := lv.VGName + "/" + lv.Name
+			if size, err := strconv.ParseUint(lv.Size, 10, 64); err == nil {
+				results["lv."+fullName+".size_B"] = size
+			}
+
+			if lv.SegmentType != "thin-pool" {
+				continue
+			}
+
+			if dataPercent, err := strconv.ParseFloat(lv.DataPercent, 64); err == nil {
+				results["thinpool."+fullName+".data_percent"] = dataPercent
+			}
+			if metadataPercent, err := strconv.ParseFloat(lv.MetadataPercent, 64); err == nil {
+				results["thinpool."+fullName+".metadata_percent"] = metadataPercent
+			}
+		}
+	}
+
+	return results, nil
+}
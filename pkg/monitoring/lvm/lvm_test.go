@@ -0,0 +1,84 @@
+package lvm
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+type fakeInvoker map[string]string
+
+func (f fakeInvoker) CommandWithContext(_ context.Context, name string, _ ...string) ([]byte, error) {
+	return ioutil.ReadFile(filepath.Join("testdata", f[name]))
+}
+
+func TestParseVgs(t *testing.T) {
+	out, err := ioutil.ReadFile(filepath.Join("testdata", "vgs.json"))
+	assert.NoError(t, err)
+
+	results, err := parseVgs(out)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"vg.data.total_B":           uint64(1000203091968),
+		"vg.data.free_B":            uint64(250050772992),
+		"vg.data.free_percent":      25.0,
+		"vg.ubuntu-vg.total_B":      uint64(106296246272),
+		"vg.ubuntu-vg.free_B":       uint64(0),
+		"vg.ubuntu-vg.free_percent": 0.0,
+	}, results)
+}
+
+func TestParseLvs(t *testing.T) {
+	out, err := ioutil.ReadFile(filepath.Join("testdata", "lvs.json"))
+	assert.NoError(t, err)
+
+	results, err := parseLvs(out)
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"lv.data/pool0.size_B":                 uint64(536870912000),
+		"lv.data/vm-disk-1.size_B":             uint64(107374182400),
+		"lv.ubuntu-vg/root.size_B":             uint64(105226698752),
+		"lv.ubuntu-vg/swap_1.size_B":           uint64(1069547520),
+		"thinpool.data/pool0.data_percent":     42.17,
+		"thinpool.data/pool0.metadata_percent": 3.05,
+	}, results)
+}
+
+func TestGetMeasurements(t *testing.T) {
+	l := &LVM{
+		invoker:  fakeInvoker{"vgs": "vgs.json", "lvs": "lvs.json"},
+		vgsPath:  "vgs",
+		lvsPath:  "lvs",
+		detected: true,
+	}
+
+	results, err := l.GetMeasurements()
+	assert.NoError(t, err)
+	assert.Len(t, results, 12)
+	assert.Equal(t, 42.17, results["thinpool.data/pool0.data_percent"])
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("lvm.", results)))
+
+	l = &LVM{invoker: fakeInvoker{}}
+	results, err = l.GetMeasurements()
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
+
+type failingInvoker struct{}
+
+func (failingInvoker) CommandWithContext(_ context.Context, _ string, _ ...string) ([]byte, error) {
+	return nil, &common.CommandError{Err: errors.New("exit status 5"), Stderr: []byte("  Volume group \"vg0\" not found\n")}
+}
+
+func TestGetMeasurementsCommandError(t *testing.T) {
+	l := &LVM{invoker: failingInvoker{}, vgsPath: "vgs", lvsPath: "lvs", detected: true}
+
+	_, err := l.GetMeasurements()
+	assert.EqualError(t, err, `LVM: while listing volume groups: exit status 5: Volume group "vg0" not found`)
+}
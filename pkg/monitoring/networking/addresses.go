@@ -0,0 +1,99 @@
+package networking
+
+import (
+	"fmt"
+	"net"
+
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// ipv6AddrFlags holds the state of IPv6 address which is exposed by some OS only
+type ipv6AddrFlags struct {
+	Temporary  bool
+	Deprecated bool
+}
+
+func ipv6AddrFlagsKey(ifName string, ip net.IP) string {
+	return ifName + " " + ip.String()
+}
+
+// fillAddressMeasurements fills addresses assigned to non-excluded interfaces
+func (nw *NetWatcher) fillAddressMeasurements(results common.MeasurementsMap, interfaces []utilnet.InterfaceStat, excludedInterfacesByName map[string]struct{}) {
+	if !common.StrInSlice("addresses", nw.config.NetMetrics) {
+		return
+	}
+
+	v6Flags, err := getIPv6AddrFlags()
+	if err != nil {
+		logrus.WithError(err).Debugf("[NET] cannot read IPv6 address flags")
+	}
+
+	for i := range interfaces {
+		netIf := &interfaces[i]
+		if _, isExcluded := excludedInterfacesByName[netIf.Name]; isExcluded {
+			continue
+		}
+
+		results.AddWithPrefix(netIf.Name+".", addressMeasurements(netIf, v6Flags))
+	}
+}
+
+// addressMeasurements returns addresses of the interface with their family and scope, e.g.:
+// addr.1 = "fe80::1/64", addr.1.family = "ipv6", addr.1.scope = "link-local"
+// IPv6 addresses are flagged as temporary or deprecated if v6Flags are available
+func addressMeasurements(netIf *utilnet.InterfaceStat, v6Flags map[string]ipv6AddrFlags) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	var v4Count, v6Count int
+
+	n := 0
+	for _, addr := range netIf.Addrs {
+		ip, _, err := net.ParseCIDR(addr.Addr)
+		if err != nil {
+			ip = net.ParseIP(addr.Addr)
+		}
+		if ip == nil {
+			logrus.Warnf("[NET] Failed to parse IP address %s of %s", addr.Addr, netIf.Name)
+			continue
+		}
+
+		n++
+		key := fmt.Sprintf("addr.%d", n)
+		results[key] = addr.Addr
+		results[key+".scope"] = ipScope(ip)
+
+		if ip.To4() != nil {
+			v4Count++
+			results[key+".family"] = "ipv4"
+			continue
+		}
+
+		v6Count++
+		results[key+".family"] = "ipv6"
+		if v6Flags != nil {
+			flags := v6Flags[ipv6AddrFlagsKey(netIf.Name, ip)]
+			results[key+".temporary"] = flags.Temporary
+			results[key+".deprecated"] = flags.Deprecated
+		}
+	}
+
+	results["addr.ipv4_count"] = v4Count
+	results["addr.ipv6_count"] = v6Count
+
+	return results
+}
+
+func ipScope(ip net.IP) string {
+	switch {
+	case ip.IsLoopback():
+		return "loopback"
+	case ip.IsLinkLocalUnicast():
+		return "link-local"
+	case ip.IsGlobalUnicast():
+		return "global"
+	default:
+		return "other"
+	}
+}
@@ -0,0 +1,79 @@
+package networking
+
+import (
+	"net"
+	"testing"
+
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestAddressMeasurements(t *testing.T) {
+	netIf := &utilnet.InterfaceStat{
+		Name: "eth0",
+		Addrs: []utilnet.InterfaceAddr{
+			{Addr: "192.168.1.10/24"},
+			{Addr: "2001:db8::10/64"},
+			{Addr: "2001:db8::20/64"},
+			{Addr: "fe80::1/64"},
+			{Addr: "invalid"},
+		},
+	}
+	v6Flags := map[string]ipv6AddrFlags{
+		ipv6AddrFlagsKey("eth0", net.ParseIP("2001:db8::20")): {Deprecated: true},
+	}
+
+	assert.Equal(t, common.MeasurementsMap{
+		"addr.1":            "192.168.1.10/24",
+		"addr.1.family":     "ipv4",
+		"addr.1.scope":      "global",
+		"addr.2":            "2001:db8::10/64",
+		"addr.2.family":     "ipv6",
+		"addr.2.scope":      "global",
+		"addr.2.temporary":  false,
+		"addr.2.deprecated": false,
+		"addr.3":            "2001:db8::20/64",
+		"addr.3.family":     "ipv6",
+		"addr.3.scope":      "global",
+		"addr.3.temporary":  false,
+		"addr.3.deprecated": true,
+		"addr.4":            "fe80::1/64",
+		"addr.4.family":     "ipv6",
+		"addr.4.scope":      "link-local",
+		"addr.4.temporary":  false,
+		"addr.4.deprecated": false,
+		"addr.ipv4_count":   1,
+		"addr.ipv6_count":   3,
+	}, addressMeasurements(netIf, v6Flags))
+}
+
+func TestAddressMeasurementsWithoutFlags(t *testing.T) {
+	netIf := &utilnet.InterfaceStat{
+		Name:  "lo",
+		Addrs: []utilnet.InterfaceAddr{{Addr: "::1/128"}},
+	}
+
+	assert.Equal(t, common.MeasurementsMap{
+		"addr.1":          "::1/128",
+		"addr.1.family":   "ipv6",
+		"addr.1.scope":    "loopback",
+		"addr.ipv4_count": 0,
+		"addr.ipv6_count": 1,
+	}, addressMeasurements(netIf, nil))
+}
+
+func TestFillAddressMeasurementsExcluded(t *testing.T) {
+	nw := NewWatcher(NetWatcherConfig{NetMetrics: []string{"addresses"}})
+	interfaces := []utilnet.InterfaceStat{
+		{Name: "eth0", Addrs: []utilnet.InterfaceAddr{{Addr: "10.0.0.1/8"}}},
+		{Name: "docker0", Addrs: []utilnet.InterfaceAddr{{Addr: "172.17.0.1/16"}}},
+	}
+
+	results := common.MeasurementsMap{}
+	nw.fillAddressMeasurements(results, interfaces, map[string]struct{}{"docker0": {}})
+	assert.Equal(t, "10.0.0.1/8", results["eth0.addr.1"])
+	assert.Equal(t, 1, results["eth0.addr.ipv4_count"])
+	assert.NotContains(t, results, "docker0.addr.1")
+}
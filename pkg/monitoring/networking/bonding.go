@@ -0,0 +1,105 @@
+package networking
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// bondStatus is the state of a Linux bonding interface as reported in /proc/net/bonding/<bond>
+type bondStatus struct {
+	Mode        string
+	Up          bool
+	ActiveSlave string
+	// SlavesUp holds MII status of the slaves by their names
+	SlavesUp map[string]bool
+}
+
+// fillBondingMeasurements reports mode, state and the active slave of the bonding interfaces
+// and MII status of their slaves. Does nothing if the bonding driver is not loaded
+func (nw *NetWatcher) fillBondingMeasurements(results common.MeasurementsMap) {
+	bonds, err := readBondingStatuses(common.HostProc("net/bonding"))
+	if err != nil {
+		logrus.WithError(err).Debug("[NET] failed to read bonding status")
+	}
+
+	for name, bond := range bonds {
+		addBondMeasurements(results, name, bond)
+	}
+}
+
+func addBondMeasurements(results common.MeasurementsMap, name string, bond *bondStatus) {
+	results["bond_mode."+name] = bond.Mode
+	results["bond_up."+name] = bond.Up
+	if bond.ActiveSlave != "" {
+		results["bond_active_slave."+name] = bond.ActiveSlave
+	} else {
+		results["bond_active_slave."+name] = nil
+	}
+
+	for slave, up := range bond.SlavesUp {
+		results["bond_slave_up."+slave] = up
+	}
+}
+
+func readBondingStatuses(dir string) (map[string]*bondStatus, error) {
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	bonds := make(map[string]*bondStatus)
+	for _, file := range files {
+		data, err := ioutil.ReadFile(filepath.Join(dir, file.Name()))
+		if err != nil {
+			logrus.WithError(err).Debugf("[NET] failed to read bonding status of %s", file.Name())
+			continue
+		}
+
+		bonds[file.Name()] = parseBondingStatus(string(data))
+	}
+
+	return bonds, nil
+}
+
+func parseBondingStatus(data string) *bondStatus {
+	bond := &bondStatus{SlavesUp: make(map[string]bool)}
+
+	// MII Status lines before the first "Slave Interface" belong to the bond itself
+	var slave string
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.SplitN(line, ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		key, value := strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+
+		switch key {
+		case "Bonding Mode":
+			bond.Mode = value
+		case "Currently Active Slave":
+			if value != "None" {
+				bond.ActiveSlave = value
+			}
+		case "Slave Interface":
+			slave = value
+			bond.SlavesUp[slave] = false
+		case "MII Status":
+			if slave == "" {
+				bond.Up = value == "up"
+			} else {
+				bond.SlavesUp[slave] = value == "up"
+			}
+		}
+	}
+
+	return bond
+}
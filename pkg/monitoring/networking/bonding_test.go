@@ -0,0 +1,42 @@
+package networking
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadBondingStatuses(t *testing.T) {
+	bonds, err := readBondingStatuses(filepath.Join("testdata", "bonding"))
+	require.NoError(t, err)
+	require.Len(t, bonds, 2)
+
+	results := common.MeasurementsMap{}
+	for name, bond := range bonds {
+		addBondMeasurements(results, name, bond)
+	}
+
+	assert.Equal(t, common.MeasurementsMap{
+		"bond_mode.bond0":         "fault-tolerance (active-backup)",
+		"bond_up.bond0":           true,
+		"bond_active_slave.bond0": "eth0",
+		"bond_slave_up.eth0":      true,
+		"bond_slave_up.eth1":      false,
+
+		"bond_mode.bond1":         "IEEE 802.3ad Dynamic link aggregation",
+		"bond_up.bond1":           true,
+		"bond_active_slave.bond1": nil,
+		"bond_slave_up.eth2":      true,
+		"bond_slave_up.eth3":      true,
+	}, results)
+}
+
+func TestReadBondingStatusesNotLoaded(t *testing.T) {
+	bonds, err := readBondingStatuses(filepath.Join("testdata", "not-existing"))
+	assert.NoError(t, err)
+	assert.Nil(t, bonds)
+}
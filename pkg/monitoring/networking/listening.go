@@ -0,0 +1,156 @@
+package networking
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/shirou/gopsutil/process"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const (
+	listeningTimeout = 10 * time.Second
+	// maxListeningPorts bounds the number of the reported listeners on hosts with lots of sockets
+	maxListeningPorts = 500
+)
+
+var errListeningPending = errors.New("the previous listing of the sockets is still running")
+
+type connectionsResult struct {
+	connections []utilnet.ConnectionStat
+	err         error
+}
+
+// fillListeningMeasurements reports TCP sockets in LISTEN state and unconnected UDP sockets as listening.<proto>.<port>
+// with the name of the owning process, proto is one of tcp, tcp6, udp or udp6. The process name is nil
+// if it can't be determined, e.g. the socket belongs to another user and cagent isn't running as root
+func (nw *NetWatcher) fillListeningMeasurements(results common.MeasurementsMap) {
+	if !common.StrInSlice("listening", nw.config.NetMetrics) {
+		return
+	}
+
+	connections, err := nw.listConnections()
+	if err != nil {
+		logrus.WithError(err).Warn("[NET] failed to list the listening sockets")
+		return
+	}
+
+	results.AddWithPrefix("listening.", listeningMeasurements(connections, processName))
+}
+
+// listConnections lists the sockets within listeningTimeout. The listing which timed out is left running in background
+// and no new one is started till it finishes
+func (nw *NetWatcher) listConnections() ([]utilnet.ConnectionStat, error) {
+	if !atomic.CompareAndSwapInt32(&nw.listeningPending, 0, 1) {
+		return nil, errListeningPending
+	}
+
+	resultChan := make(chan connectionsResult, 1)
+	go func() {
+		defer atomic.StoreInt32(&nw.listeningPending, 0)
+
+		connections, err := utilnet.Connections("inet")
+		resultChan <- connectionsResult{connections, err}
+	}()
+
+	select {
+	case res := <-resultChan:
+		return res.connections, res.err
+	case <-time.After(listeningTimeout):
+		return nil, fmt.Errorf("timed out after %v", listeningTimeout)
+	}
+}
+
+// listeningMeasurements returns <proto>.<port> of the listening sockets with the names of the owning processes
+// joined by ",", or nil if none is known. No more than maxListeningPorts ports are reported
+func listeningMeasurements(connections []utilnet.ConnectionStat, processName func(pid int32) string) common.MeasurementsMap {
+	namesByKey := make(map[string]map[string]struct{})
+	for _, conn := range connections {
+		proto := listeningProto(conn)
+		if proto == "" {
+			continue
+		}
+
+		key := fmt.Sprintf("%s.%d", proto, conn.Laddr.Port)
+		names, exists := namesByKey[key]
+		if !exists {
+			names = make(map[string]struct{})
+			namesByKey[key] = names
+		}
+		if conn.Pid == 0 {
+			continue
+		}
+		if name := processName(conn.Pid); name != "" {
+			names[name] = struct{}{}
+		}
+	}
+
+	keys := make([]string, 0, len(namesByKey))
+	for key := range namesByKey {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	if len(keys) > maxListeningPorts {
+		logrus.Warnf("[NET] %d listening ports found, only %d are reported", len(keys), maxListeningPorts)
+		keys = keys[:maxListeningPorts]
+	}
+
+	results := common.MeasurementsMap{}
+	for _, key := range keys {
+		if len(namesByKey[key]) == 0 {
+			results[key] = nil
+			continue
+		}
+
+		names := make([]string, 0, len(namesByKey[key]))
+		for name := range namesByKey[key] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		results[key] = strings.Join(names, ",")
+	}
+
+	return results
+}
+
+// listeningProto returns the protocol of the listening socket, empty if the socket isn't listening
+func listeningProto(conn utilnet.ConnectionStat) string {
+	var proto string
+	switch {
+	case conn.Type == syscall.SOCK_STREAM && conn.Status == "LISTEN":
+		proto = "tcp"
+	case conn.Type == syscall.SOCK_DGRAM && conn.Raddr.Port == 0:
+		proto = "udp"
+	default:
+		return ""
+	}
+
+	if conn.Family == syscall.AF_INET6 {
+		proto += "6"
+	}
+
+	return proto
+}
+
+func processName(pid int32) string {
+	p, err := process.NewProcess(pid)
+	if err != nil {
+		return ""
+	}
+
+	name, err := p.Name()
+	if err != nil {
+		logrus.WithError(err).Debugf("[NET] failed to get the name of the process %d", pid)
+		return ""
+	}
+
+	return name
+}
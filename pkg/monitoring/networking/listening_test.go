@@ -0,0 +1,43 @@
+package networking
+
+import (
+	"syscall"
+	"testing"
+
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestListeningMeasurements(t *testing.T) {
+	connections := []utilnet.ConnectionStat{
+		{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "LISTEN", Laddr: utilnet.Addr{IP: "0.0.0.0", Port: 22}, Pid: 812},
+		{Family: syscall.AF_INET6, Type: syscall.SOCK_STREAM, Status: "LISTEN", Laddr: utilnet.Addr{IP: "::", Port: 22}, Pid: 812},
+		// nginx master and worker share the socket
+		{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "LISTEN", Laddr: utilnet.Addr{IP: "0.0.0.0", Port: 80}, Pid: 1001},
+		{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "LISTEN", Laddr: utilnet.Addr{IP: "0.0.0.0", Port: 80}, Pid: 1002},
+		// owned by another user, the PID isn't known without root
+		{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "LISTEN", Laddr: utilnet.Addr{IP: "127.0.0.1", Port: 5432}},
+		// the process exited meanwhile
+		{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Laddr: utilnet.Addr{IP: "0.0.0.0", Port: 161}, Pid: 4242},
+		{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Laddr: utilnet.Addr{IP: "127.0.0.53", Port: 53}, Pid: 640},
+		// not listening
+		{Family: syscall.AF_INET, Type: syscall.SOCK_STREAM, Status: "ESTABLISHED", Laddr: utilnet.Addr{IP: "10.0.0.5", Port: 22}, Raddr: utilnet.Addr{IP: "10.0.0.1", Port: 51234}, Pid: 2100},
+		{Family: syscall.AF_INET, Type: syscall.SOCK_DGRAM, Laddr: utilnet.Addr{IP: "10.0.0.5", Port: 40123}, Raddr: utilnet.Addr{IP: "10.0.0.1", Port: 123}, Pid: 700},
+	}
+
+	names := map[int32]string{812: "sshd", 1001: "nginx", 1002: "nginx", 640: "systemd-resolve", 2100: "sshd", 700: "chronyd"}
+	results := listeningMeasurements(connections, func(pid int32) string {
+		return names[pid]
+	})
+
+	assert.Equal(t, common.MeasurementsMap{
+		"tcp.22":   "sshd",
+		"tcp6.22":  "sshd",
+		"tcp.80":   "nginx",
+		"tcp.5432": nil,
+		"udp.161":  nil,
+		"udp.53":   "systemd-resolve",
+	}, results)
+}
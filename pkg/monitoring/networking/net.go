@@ -10,6 +10,12 @@ import (
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
+const (
+	linkDuplexFull    = "full"
+	linkDuplexHalf    = "half"
+	linkDuplexUnknown = "unknown"
+)
+
 type linkSpeedProvider interface {
 	// GetMaxAvailableLinkSpeed returns link speed in bytes per second
 	GetMaxAvailableLinkSpeed(ifName string) (float64, error)
@@ -3,12 +3,19 @@
 package networking
 
 import (
+	"encoding/hex"
 	"fmt"
 	"io/ioutil"
+	"net"
+	"path/filepath"
 	"strconv"
 	"strings"
 
 	"github.com/pkg/errors"
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
 type linuxLinkSpeedProvider struct {
@@ -36,3 +43,106 @@ func (p *linuxLinkSpeedProvider) GetMaxAvailableLinkSpeed(ifName string) (float6
 
 	return float64(megaBitsPerSecond) / 8 * 1000 * 1000, nil
 }
+
+func getLinkState(netIf *utilnet.InterfaceStat, _ linkSpeedProvider) (bool, interface{}) {
+	return readSysfsLinkState(common.HostSys("class/net"), netIf)
+}
+
+// readSysfsLinkState returns operational state and negotiated speed in bytes per second of the interface
+// speed is nil if it is unknown, e.g. interface is down
+func readSysfsLinkState(sysNetDir string, netIf *utilnet.InterfaceStat) (bool, interface{}) {
+	var linkUp bool
+	operState, err := ioutil.ReadFile(filepath.Join(sysNetDir, netIf.Name, "operstate"))
+	switch strings.TrimSpace(string(operState)) {
+	case "up":
+		linkUp = true
+	case "unknown", "":
+		// some virtual interfaces don't report operational state
+		if err != nil {
+			logrus.WithError(err).Debugf("[NET] cannot read operstate of %s", netIf.Name)
+		}
+		linkUp = !isInterfaceDown(netIf)
+	}
+
+	// reading speed of the interface which is down fails with EINVAL
+	data, err := ioutil.ReadFile(filepath.Join(sysNetDir, netIf.Name, "speed"))
+	if err != nil {
+		return linkUp, nil
+	}
+
+	megaBitsPerSecond, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil || megaBitsPerSecond < 0 {
+		return linkUp, nil
+	}
+
+	return linkUp, uint64(megaBitsPerSecond) * 1000 * 1000 / 8
+}
+
+func getLinkMTUAndDuplex(netIf *utilnet.InterfaceStat) (int, string) {
+	return readSysfsMTUAndDuplex(common.HostSys("class/net"), netIf)
+}
+
+// readSysfsMTUAndDuplex returns MTU and duplex mode of the interface: "full", "half" or "unknown"
+func readSysfsMTUAndDuplex(sysNetDir string, netIf *utilnet.InterfaceStat) (int, string) {
+	mtu := netIf.MTU
+	if data, err := ioutil.ReadFile(filepath.Join(sysNetDir, netIf.Name, "mtu")); err == nil {
+		if value, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			mtu = value
+		}
+	}
+
+	// duplex is not available for virtual interfaces, reading it fails with EINVAL if the interface is down
+	duplex := linkDuplexUnknown
+	data, _ := ioutil.ReadFile(filepath.Join(sysNetDir, netIf.Name, "duplex"))
+	switch value := strings.TrimSpace(string(data)); value {
+	case linkDuplexFull, linkDuplexHalf:
+		duplex = value
+	}
+
+	return mtu, duplex
+}
+
+// flags of IPv6 addresses from include/uapi/linux/if_addr.h
+const (
+	ifaFlagTemporary  = 0x01
+	ifaFlagDeprecated = 0x20
+)
+
+func getIPv6AddrFlags() (map[string]ipv6AddrFlags, error) {
+	return readIPv6AddrFlags(common.HostProc("net/if_inet6"))
+}
+
+// readIPv6AddrFlags parses /proc/net/if_inet6 file:
+// address, interface index, prefix length, scope, flags, interface name
+// 20010db8000000000000000000000010 02 40 00 80     eth0
+func readIPv6AddrFlags(path string) (map[string]ipv6AddrFlags, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]ipv6AddrFlags)
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+
+		ip, err := hex.DecodeString(fields[0])
+		if err != nil || len(ip) != net.IPv6len {
+			continue
+		}
+
+		flags, err := strconv.ParseUint(fields[4], 16, 32)
+		if err != nil {
+			continue
+		}
+
+		result[ipv6AddrFlagsKey(fields[5], ip)] = ipv6AddrFlags{
+			Temporary:  flags&ifaFlagTemporary != 0,
+			Deprecated: flags&ifaFlagDeprecated != 0,
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,57 @@
+// +build linux
+
+package networking
+
+import (
+	"net"
+	"testing"
+
+	utilnet "github.com/shirou/gopsutil/net"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadSysfsLinkState(t *testing.T) {
+	linkUp, speed := readSysfsLinkState("testdata/net", &utilnet.InterfaceStat{Name: "eth0", Flags: []string{"up", "broadcast"}})
+	assert.True(t, linkUp)
+	assert.Equal(t, uint64(1250000000), speed)
+
+	linkUp, speed = readSysfsLinkState("testdata/net", &utilnet.InterfaceStat{Name: "eth1", Flags: []string{"up", "broadcast"}})
+	assert.False(t, linkUp)
+	assert.Nil(t, speed)
+
+	// operstate is unknown, interface flags are used
+	linkUp, speed = readSysfsLinkState("testdata/net", &utilnet.InterfaceStat{Name: "tun0", Flags: []string{"up", "pointtopoint"}})
+	assert.True(t, linkUp)
+	assert.Nil(t, speed)
+}
+
+func TestReadIPv6AddrFlags(t *testing.T) {
+	flags, err := readIPv6AddrFlags("testdata/if_inet6")
+	assert.NoError(t, err)
+	assert.Len(t, flags, 5)
+
+	assert.Equal(t, ipv6AddrFlags{}, flags[ipv6AddrFlagsKey("eth0", net.ParseIP("2001:db8::10"))])
+	assert.Equal(t, ipv6AddrFlags{Temporary: true}, flags[ipv6AddrFlagsKey("eth0", net.ParseIP("2001:db8::1122:3344:5566:77aa"))])
+	assert.Equal(t, ipv6AddrFlags{Deprecated: true}, flags[ipv6AddrFlagsKey("eth0", net.ParseIP("2001:db8::20"))])
+	assert.Equal(t, ipv6AddrFlags{}, flags[ipv6AddrFlagsKey("lo", net.ParseIP("::1"))])
+}
+
+func TestReadSysfsMTUAndDuplex(t *testing.T) {
+	mtu, duplex := readSysfsMTUAndDuplex("testdata/net", &utilnet.InterfaceStat{Name: "eth0", MTU: 1500})
+	assert.Equal(t, 9000, mtu)
+	assert.Equal(t, "full", duplex)
+
+	mtu, duplex = readSysfsMTUAndDuplex("testdata/net", &utilnet.InterfaceStat{Name: "eth2", MTU: 1500})
+	assert.Equal(t, 1500, mtu)
+	assert.Equal(t, "half", duplex)
+
+	// virtual interface
+	mtu, duplex = readSysfsMTUAndDuplex("testdata/net", &utilnet.InterfaceStat{Name: "tun0", MTU: 1500})
+	assert.Equal(t, 1400, mtu)
+	assert.Equal(t, "unknown", duplex)
+
+	// MTU reported by the OS is used if it can't be read from sysfs
+	mtu, duplex = readSysfsMTUAndDuplex("testdata/net", &utilnet.InterfaceStat{Name: "wlan0", MTU: 2304})
+	assert.Equal(t, 2304, mtu)
+	assert.Equal(t, "unknown", duplex)
+}
@@ -0,0 +1,28 @@
+// +build !linux
+
+package networking
+
+import (
+	utilnet "github.com/shirou/gopsutil/net"
+)
+
+func getLinkState(netIf *utilnet.InterfaceStat, speedProvider linkSpeedProvider) (bool, interface{}) {
+	linkUp := !isInterfaceDown(netIf)
+
+	speed, err := speedProvider.GetMaxAvailableLinkSpeed(netIf.Name)
+	if err != nil {
+		return linkUp, nil
+	}
+
+	return linkUp, uint64(speed)
+}
+
+// getIPv6AddrFlags returns nil as the flags are not exposed on this OS
+func getIPv6AddrFlags() (map[string]ipv6AddrFlags, error) {
+	return nil, nil
+}
+
+// getLinkMTUAndDuplex returns MTU of the interface, duplex mode is not available on this OS
+func getLinkMTUAndDuplex(netIf *utilnet.InterfaceStat) (int, string) {
+	return netIf.MTU, linkDuplexUnknown
+}
@@ -0,0 +1,95 @@
+package networking
+
+import (
+	"bufio"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// tcpCounters are the system-wide TCP segment counters from /proc/net/snmp
+type tcpCounters struct {
+	RetransSegs uint64
+	OutSegs     uint64
+}
+
+// fillTCPMeasurements reports the rates of the retransmitted and of all sent TCP segments as tcp.retrans_per_s and tcp.out_segs_per_s.
+// They are nil in the first collection and after the counters were reset. Linux only
+func (nw *NetWatcher) fillTCPMeasurements(results common.MeasurementsMap) {
+	if runtime.GOOS != "linux" {
+		return
+	}
+
+	now := time.Now()
+	counters, err := readTCPCounters(common.HostProc("net/snmp"))
+	if err != nil {
+		logrus.WithError(err).Debug("[NET] failed to read TCP counters")
+	}
+
+	retrans, outSegs := calcTCPRates(nw.lastTCPCounters, counters, now.Sub(nw.lastTCPCountersAt))
+	results["tcp.retrans_per_s"] = retrans
+	results["tcp.out_segs_per_s"] = outSegs
+
+	nw.lastTCPCounters = counters
+	nw.lastTCPCountersAt = now
+}
+
+// calcTCPRates returns nil rates if any of the samples is missing or a counter decreased
+func calcTCPRates(prev, curr *tcpCounters, elapsed time.Duration) (retrans interface{}, outSegs interface{}) {
+	if prev == nil || curr == nil || elapsed <= 0 {
+		return nil, nil
+	}
+
+	if curr.RetransSegs < prev.RetransSegs || curr.OutSegs < prev.OutSegs {
+		return nil, nil
+	}
+
+	seconds := elapsed.Seconds()
+	return float64(curr.RetransSegs-prev.RetransSegs) / seconds, float64(curr.OutSegs-prev.OutSegs) / seconds
+}
+
+// readTCPCounters parses the pair of "Tcp:" lines of /proc/net/snmp, the first one holds the names of the counters
+func readTCPCounters(filePath string) (*tcpCounters, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var names []string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 || fields[0] != "Tcp:" {
+			continue
+		}
+
+		if names == nil {
+			names = fields
+			continue
+		}
+
+		counters := &tcpCounters{}
+		for i := 1; i < len(fields) && i < len(names); i++ {
+			switch names[i] {
+			case "RetransSegs":
+				counters.RetransSegs, err = strconv.ParseUint(fields[i], 10, 64)
+			case "OutSegs":
+				counters.OutSegs, err = strconv.ParseUint(fields[i], 10, 64)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		return counters, nil
+	}
+
+	return nil, scanner.Err()
+}
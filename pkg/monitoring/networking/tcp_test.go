@@ -0,0 +1,40 @@
+package networking
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadTCPCounters(t *testing.T) {
+	counters, err := readTCPCounters(filepath.Join("testdata", "snmp"))
+	require.NoError(t, err)
+	assert.Equal(t, &tcpCounters{RetransSegs: 150000, OutSegs: 98000000}, counters)
+
+	_, err = readTCPCounters(filepath.Join("testdata", "not-existing"))
+	assert.Error(t, err)
+}
+
+func TestCalcTCPRates(t *testing.T) {
+	prev, err := readTCPCounters(filepath.Join("testdata", "snmp"))
+	require.NoError(t, err)
+	curr, err := readTCPCounters(filepath.Join("testdata", "snmp-next"))
+	require.NoError(t, err)
+
+	retrans, outSegs := calcTCPRates(prev, curr, 60*time.Second)
+	assert.Equal(t, float64(10), retrans)
+	assert.Equal(t, float64(1000), outSegs)
+
+	// first collection
+	retrans, outSegs = calcTCPRates(nil, curr, 60*time.Second)
+	assert.Nil(t, retrans)
+	assert.Nil(t, outSegs)
+
+	// counters were reset
+	retrans, outSegs = calcTCPRates(curr, prev, 60*time.Second)
+	assert.Nil(t, retrans)
+	assert.Nil(t, outSegs)
+}
@@ -21,12 +21,51 @@ type NetWatcherConfig struct {
 	NetInterfaceMaxSpeed            uint64
 }
 
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "net.in_B_per_s.<iface>", Unit: "B/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.out_B_per_s.<iface>", Unit: "B/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.errors_per_s.<iface>", Unit: "1/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.dropped_per_s.<iface>", Unit: "1/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.total_in_B_per_s", Unit: "B/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.total_out_B_per_s", Unit: "B/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.net_util_percent.<iface>", Unit: "%", ConfigOption: "net_interface_max_speed"},
+		common.MetricDescriptor{Key: "net.link_up.<iface>", Unit: "bool", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.link_speed_B_per_s.<iface>", Unit: "B/s", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.mtu.<iface>", Unit: "B", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.duplex.<iface>", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.<n>", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.<n>.family", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.<n>.scope", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.<n>.temporary", Unit: "bool", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.<n>.deprecated", Unit: "bool", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.ipv4_count", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.<iface>.addr.ipv6_count", ConfigOption: "net_metrics"},
+		common.MetricDescriptor{Key: "net.bond_mode.<iface>", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.bond_up.<iface>", Unit: "bool", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.bond_active_slave.<iface>", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.bond_slave_up.<iface>", Unit: "bool", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.wifi_signal_dbm.<iface>", Unit: "dBm", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.wifi_link_quality.<iface>", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.wifi_ssid.<iface>", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.tcp.retrans_per_s", Unit: "1/s", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.tcp.out_segs_per_s", Unit: "1/s", ConfigOption: "net_monitoring"},
+		common.MetricDescriptor{Key: "net.listening.<proto>.<port>", ConfigOption: "net_metrics"},
+	)
+}
+
 type NetWatcher struct {
 	config NetWatcherConfig
 
 	lastIOCounters   []utilnet.IOCountersStat
 	lastIOCountersAt *time.Time
 
+	lastTCPCounters   *tcpCounters
+	lastTCPCountersAt time.Time
+
+	// listeningPending is set while the sockets are listed, see fillListeningMeasurements
+	listeningPending int32
+
 	netInterfaceExcludeRegexCompiled []*regexp.Regexp
 	constantlyExcludedInterfaceCache map[string]bool
 }
@@ -228,6 +267,45 @@ func (nw *NetWatcher) fillCountersMeasurements(results common.MeasurementsMap, i
 	return nil
 }
 
+// fillLinkStateMeasurements fills operational state, negotiated link speed, MTU and duplex mode of non-excluded interfaces
+func (nw *NetWatcher) fillLinkStateMeasurements(results common.MeasurementsMap, interfaces []utilnet.InterfaceStat, excludedInterfacesByName map[string]struct{}) {
+	linkUpEnabled := common.StrInSlice("link_up", nw.config.NetMetrics)
+	linkSpeedEnabled := common.StrInSlice("link_speed_B_per_s", nw.config.NetMetrics)
+	mtuEnabled := common.StrInSlice("mtu", nw.config.NetMetrics)
+	duplexEnabled := common.StrInSlice("duplex", nw.config.NetMetrics)
+	if !linkUpEnabled && !linkSpeedEnabled && !mtuEnabled && !duplexEnabled {
+		return
+	}
+
+	linkSpeedProvider := newLinkSpeedProvider()
+	for i := range interfaces {
+		netIf := &interfaces[i]
+		if _, isExcluded := excludedInterfacesByName[netIf.Name]; isExcluded {
+			continue
+		}
+
+		if linkUpEnabled || linkSpeedEnabled {
+			linkUp, speed := getLinkState(netIf, linkSpeedProvider)
+			if linkUpEnabled {
+				results["link_up."+netIf.Name] = linkUp
+			}
+			if linkSpeedEnabled {
+				results["link_speed_B_per_s."+netIf.Name] = speed
+			}
+		}
+
+		if mtuEnabled || duplexEnabled {
+			mtu, duplex := getLinkMTUAndDuplex(netIf)
+			if mtuEnabled {
+				results["mtu."+netIf.Name] = mtu
+			}
+			if duplexEnabled {
+				results["duplex."+netIf.Name] = duplex
+			}
+		}
+	}
+}
+
 func (nw *NetWatcher) Results() (common.MeasurementsMap, error) {
 	results := common.MeasurementsMap{}
 
@@ -240,6 +318,12 @@ func (nw *NetWatcher) Results() (common.MeasurementsMap, error) {
 	excludedInterfacesByNameMap := nw.ExcludedInterfacesByName(interfaces)
 	// fill counters measurements into results
 	err = nw.fillCountersMeasurements(results, interfaces, excludedInterfacesByNameMap)
+	nw.fillLinkStateMeasurements(results, interfaces, excludedInterfacesByNameMap)
+	nw.fillAddressMeasurements(results, interfaces, excludedInterfacesByNameMap)
+	nw.fillBondingMeasurements(results)
+	nw.fillWirelessMeasurements(results, excludedInterfacesByNameMap)
+	nw.fillTCPMeasurements(results)
+	nw.fillListeningMeasurements(results)
 	if err != nil {
 		logrus.Errorf("[NET] Failed to collect counters: %s", err.Error())
 		return results, err
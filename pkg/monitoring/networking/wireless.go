@@ -0,0 +1,131 @@
+package networking
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const iwTimeout = 5 * time.Second
+
+// wirelessStats is the signal of a wireless interface as reported in /proc/net/wireless
+type wirelessStats struct {
+	// LinkQuality is the driver specific quality of the link, e.g. 0-70
+	LinkQuality float64
+	SignalDBm   float64
+}
+
+// fillWirelessMeasurements reports the signal of the wireless interfaces as wifi_signal_dbm.<iface>, wifi_link_quality.<iface>
+// and the connected network as wifi_ssid.<iface>. Wired interfaces are skipped.
+// Does nothing if the wireless extensions are not available
+func (nw *NetWatcher) fillWirelessMeasurements(results common.MeasurementsMap, excludedInterfacesByName map[string]struct{}) {
+	stats, err := readWirelessStats(common.HostProc("net/wireless"))
+	if err != nil {
+		logrus.WithError(err).Debug("[NET] failed to read wireless stats")
+	}
+
+	for name, st := range stats {
+		if _, isExcluded := excludedInterfacesByName[name]; isExcluded || !isWirelessInterface(common.HostSys("class/net"), name) {
+			continue
+		}
+
+		results["wifi_signal_dbm."+name] = st.SignalDBm
+		results["wifi_link_quality."+name] = st.LinkQuality
+		if ssid := readSSID(name); ssid != "" {
+			results["wifi_ssid."+name] = ssid
+		} else {
+			results["wifi_ssid."+name] = nil
+		}
+	}
+}
+
+// isWirelessInterface checks the wireless marker the kernel exposes for wireless interfaces in sysfs
+func isWirelessInterface(sysNetDir string, name string) bool {
+	_, err := os.Stat(filepath.Join(sysNetDir, name, "wireless"))
+	return err == nil
+}
+
+func readWirelessStats(filePath string) (map[string]*wirelessStats, error) {
+	file, err := os.Open(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer file.Close()
+
+	return parseWirelessStats(bufio.NewScanner(file)), nil
+}
+
+// parseWirelessStats parses the /proc/net/wireless lines like
+//  wlan0: 0000   54.  -56.  -256        0      0      0      0     12        0
+// the first two lines are the header
+func parseWirelessStats(scanner *bufio.Scanner) map[string]*wirelessStats {
+	stats := make(map[string]*wirelessStats)
+	for scanner.Scan() {
+		parts := strings.SplitN(scanner.Text(), ":", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		fields := strings.Fields(parts[1])
+		if len(fields) < 3 {
+			continue
+		}
+
+		// values marked with '.' were updated since the last read
+		quality, err := strconv.ParseFloat(strings.TrimSuffix(fields[1], "."), 64)
+		if err != nil {
+			continue
+		}
+		signal, err := strconv.ParseFloat(strings.TrimSuffix(fields[2], "."), 64)
+		if err != nil {
+			continue
+		}
+
+		stats[strings.TrimSpace(parts[0])] = &wirelessStats{LinkQuality: quality, SignalDBm: signal}
+	}
+
+	return stats
+}
+
+// readSSID returns the SSID of the network the interface is connected to, empty if not connected or iw is not installed
+func readSSID(name string) string {
+	if _, err := exec.LookPath("iw"); err != nil {
+		common.LogOncef(logrus.InfoLevel, "[NET] iw is not installed, wifi_ssid is not reported")
+		return ""
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), iwTimeout)
+	defer cancel()
+
+	out, err := exec.CommandContext(ctx, "iw", "dev", name, "link").Output()
+	if err != nil {
+		logrus.WithError(err).Debugf("[NET] failed to read the link of %s", name)
+		return ""
+	}
+
+	return parseIwLinkSSID(string(out))
+}
+
+// parseIwLinkSSID parses the output of 'iw dev <iface> link'
+func parseIwLinkSSID(output string) string {
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "SSID:") {
+			return strings.TrimSpace(strings.TrimPrefix(line, "SSID:"))
+		}
+	}
+
+	return ""
+}
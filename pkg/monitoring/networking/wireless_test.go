@@ -0,0 +1,39 @@
+package networking
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadWirelessStats(t *testing.T) {
+	stats, err := readWirelessStats(filepath.Join("testdata", "wireless"))
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]*wirelessStats{
+		"wlan0":  {LinkQuality: 54, SignalDBm: -56},
+		"wlp3s0": {LinkQuality: 21, SignalDBm: -89},
+	}, stats)
+
+	stats, err = readWirelessStats(filepath.Join("testdata", "not-existing"))
+	assert.NoError(t, err)
+	assert.Nil(t, stats)
+}
+
+func TestIsWirelessInterface(t *testing.T) {
+	assert.True(t, isWirelessInterface(filepath.Join("testdata", "net"), "wlan0"))
+	assert.False(t, isWirelessInterface(filepath.Join("testdata", "net"), "eth0"))
+}
+
+func TestParseIwLinkSSID(t *testing.T) {
+	output := `Connected to 64:70:02:aa:bb:cc (on wlan0)
+	SSID: Office WiFi
+	freq: 5180
+	signal: -56 dBm
+	tx bitrate: 866.7 MBit/s
+`
+	assert.Equal(t, "Office WiFi", parseIwLinkSSID(output))
+	assert.Equal(t, "", parseIwLinkSSID("Not connected.\n"))
+}
@@ -0,0 +1,167 @@
+package ntp
+
+import (
+	"context"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "ntp")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "time.ntp_offset_ms", Unit: "ms"},
+		common.MetricDescriptor{Key: "time.synced", Unit: "bool"},
+		common.MetricDescriptor{Key: "time.ntp_source"},
+	)
+}
+
+const (
+	sntpTimeout    = 3 * time.Second
+	commandTimeout = 5 * time.Second
+
+	chronycLeapStatusNotSynced = "Not synchronised"
+)
+
+// System time     : 0.000012345 seconds fast of NTP time
+var chronycSystemTimeRegexp = regexp.MustCompile(`(?m)^System time\s*:\s*([\d.]+) seconds (fast|slow) of NTP time`)
+
+// Leap status     : Normal
+var chronycLeapStatusRegexp = regexp.MustCompile(`(?m)^Leap status\s*:\s*(.+?)\s*$`)
+
+type clockStatus struct {
+	source string
+	offset *time.Duration
+	synced *bool
+}
+
+// GetMeasurements reports the offset of the local clock and whether it is synchronized.
+// Local sources (chronyc, timedatectl) are tried first, then the SNTP servers.
+// Clock is considered synced if its offset doesn't exceed syncThreshold.
+// If no source is available nil values are reported
+func GetMeasurements(servers []string, syncThreshold time.Duration) common.MeasurementsMap {
+	return getMeasurements(common.Invoke{}, servers, syncThreshold)
+}
+
+func getMeasurements(invoker common.Invoker, servers []string, syncThreshold time.Duration) common.MeasurementsMap {
+	status := getClockStatus(invoker, servers)
+
+	results := common.MeasurementsMap{
+		"ntp_offset_ms": nil,
+		"synced":        nil,
+		"ntp_source":    nil,
+	}
+	if status == nil {
+		return results
+	}
+
+	results["ntp_source"] = status.source
+
+	if status.offset != nil {
+		results["ntp_offset_ms"] = float64(*status.offset) / float64(time.Millisecond)
+
+		absOffset := *status.offset
+		if absOffset < 0 {
+			absOffset = -absOffset
+		}
+		results["synced"] = absOffset <= syncThreshold && (status.synced == nil || *status.synced)
+	} else if status.synced != nil {
+		results["synced"] = *status.synced
+	}
+
+	return results
+}
+
+func getClockStatus(invoker common.Invoker, servers []string) *clockStatus {
+	var syncedByTimedatectl *bool
+	if runtime.GOOS == "linux" {
+		if status := chronycStatus(invoker); status != nil {
+			return status
+		}
+
+		syncedByTimedatectl = timedatectlSynced(invoker)
+	}
+
+	for _, server := range servers {
+		offset, err := querySNTP(server, sntpTimeout)
+		if err != nil {
+			log.WithError(err).Debugf("failed to query NTP server %s", server)
+			continue
+		}
+
+		return &clockStatus{source: server, offset: &offset, synced: syncedByTimedatectl}
+	}
+
+	if syncedByTimedatectl != nil {
+		return &clockStatus{source: "timedatectl", synced: syncedByTimedatectl}
+	}
+
+	return nil
+}
+
+func chronycStatus(invoker common.Invoker) *clockStatus {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	output, err := invoker.CommandWithContext(ctx, "chronyc", "tracking")
+	if err != nil {
+		log.WithError(err).Debug("chronyc is not available")
+		return nil
+	}
+
+	return parseChronycTracking(string(output))
+}
+
+func parseChronycTracking(output string) *clockStatus {
+	match := chronycSystemTimeRegexp.FindStringSubmatch(output)
+	if match == nil {
+		return nil
+	}
+
+	seconds, err := strconv.ParseFloat(match[1], 64)
+	if err != nil {
+		return nil
+	}
+	if match[2] == "slow" {
+		seconds = -seconds
+	}
+	offset := time.Duration(seconds * float64(time.Second))
+
+	status := &clockStatus{source: "chronyc", offset: &offset}
+	if match = chronycLeapStatusRegexp.FindStringSubmatch(output); match != nil {
+		synced := match[1] != chronycLeapStatusNotSynced
+		status.synced = &synced
+	}
+
+	return status
+}
+
+func timedatectlSynced(invoker common.Invoker) *bool {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	output, err := invoker.CommandWithContext(ctx, "timedatectl", "show", "--property=NTPSynchronized", "--value")
+	if err != nil {
+		log.WithError(err).Debug("timedatectl is not available")
+		return nil
+	}
+
+	var synced bool
+	switch strings.TrimSpace(string(output)) {
+	case "yes":
+		synced = true
+	case "no":
+		synced = false
+	default:
+		return nil
+	}
+
+	return &synced
+}
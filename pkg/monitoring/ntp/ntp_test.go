@@ -0,0 +1,110 @@
+package ntp
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+type unavailableInvoker struct{}
+
+func (unavailableInvoker) CommandWithContext(_ context.Context, name string, _ ...string) ([]byte, error) {
+	return nil, errors.New(name + ": command not found")
+}
+
+// startMockNTPServer answers SNTP requests with the time shifted by serverShift
+func startMockNTPServer(t *testing.T, serverShift time.Duration) (string, func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	go func() {
+		buf := make([]byte, ntpPacketSize)
+		for {
+			n, addr, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+			if n < ntpPacketSize {
+				continue
+			}
+
+			resp := make([]byte, ntpPacketSize)
+			// leap indicator 0, version 3, mode 4 (server)
+			resp[0] = 0x1C
+			resp[1] = 2
+			copy(resp[24:32], buf[40:48])
+			putNTPTime(resp[32:40], time.Now().Add(serverShift))
+			putNTPTime(resp[40:48], time.Now().Add(serverShift))
+
+			_, _ = conn.WriteTo(resp, addr)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestGetMeasurementsSNTP(t *testing.T) {
+	server, stop := startMockNTPServer(t, -250*time.Millisecond)
+	defer stop()
+
+	results := getMeasurements(unavailableInvoker{}, []string{server}, 100*time.Millisecond)
+
+	offset, ok := results["ntp_offset_ms"].(float64)
+	if assert.True(t, ok) {
+		assert.InDelta(t, 250.0, offset, 20.0)
+	}
+	assert.Equal(t, false, results["synced"])
+	assert.Equal(t, server, results["ntp_source"])
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("time.", results)))
+
+	results = getMeasurements(unavailableInvoker{}, []string{server}, time.Second)
+	assert.Equal(t, true, results["synced"])
+}
+
+func TestGetMeasurementsServerUnavailable(t *testing.T) {
+	// reserve the port and close it, so nobody answers
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	server := conn.LocalAddr().String()
+	conn.Close()
+
+	results := getMeasurements(unavailableInvoker{}, []string{server}, 100*time.Millisecond)
+	assert.Nil(t, results["ntp_offset_ms"])
+	assert.Nil(t, results["synced"])
+	assert.Nil(t, results["ntp_source"])
+}
+
+func TestParseChronycTracking(t *testing.T) {
+	const output = `Reference ID    : C0A80001 (192.168.0.1)
+Stratum         : 3
+Ref time (UTC)  : Thu Oct 15 09:00:00 2026
+System time     : 0.000512000 seconds slow of NTP time
+Last offset     : -0.000001234 seconds
+RMS offset      : 0.000012345 seconds
+Frequency       : 1.234 ppm fast
+Leap status     : Normal
+`
+
+	status := parseChronycTracking(output)
+	if assert.NotNil(t, status) {
+		assert.Equal(t, "chronyc", status.source)
+		assert.Equal(t, -512*time.Microsecond, *status.offset)
+		assert.Equal(t, true, *status.synced)
+	}
+
+	status = parseChronycTracking("Leap status     : Not synchronised\nSystem time     : 1.5 seconds fast of NTP time\n")
+	if assert.NotNil(t, status) {
+		assert.Equal(t, 1500*time.Millisecond, *status.offset)
+		assert.Equal(t, false, *status.synced)
+	}
+
+	assert.Nil(t, parseChronycTracking("506 Cannot talk to daemon"))
+}
@@ -0,0 +1,88 @@
+package ntp
+
+import (
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+)
+
+const (
+	ntpPacketSize = 48
+	ntpPort       = "123"
+
+	// leap indicator 0, version 3, mode 3 (client)
+	sntpClientHeader = 0x1B
+	sntpModeServer   = 4
+)
+
+// seconds between NTP epoch (1900) and Unix epoch (1970)
+const ntpEpochOffset = 2208988800
+
+// querySNTP performs single SNTP request (RFC 4330) and returns the offset of the local clock relative to the server.
+// Positive offset means the local clock is ahead
+func querySNTP(server string, timeout time.Duration) (time.Duration, error) {
+	if _, _, err := net.SplitHostPort(server); err != nil {
+		server = net.JoinHostPort(server, ntpPort)
+	}
+
+	conn, err := net.DialTimeout("udp", server, timeout)
+	if err != nil {
+		return 0, err
+	}
+	defer conn.Close()
+
+	if err = conn.SetDeadline(time.Now().Add(timeout)); err != nil {
+		return 0, err
+	}
+
+	req := make([]byte, ntpPacketSize)
+	req[0] = sntpClientHeader
+	sentAt := time.Now()
+	putNTPTime(req[40:48], sentAt)
+
+	if _, err = conn.Write(req); err != nil {
+		return 0, err
+	}
+
+	resp := make([]byte, ntpPacketSize)
+	n, err := conn.Read(resp)
+	receivedAt := time.Now()
+	if err != nil {
+		return 0, err
+	}
+
+	if n < ntpPacketSize {
+		return 0, fmt.Errorf("short NTP response of %d bytes", n)
+	}
+	if mode := resp[0] & 0x07; mode != sntpModeServer {
+		return 0, fmt.Errorf("unexpected NTP response mode %d", mode)
+	}
+	if stratum := resp[1]; stratum == 0 {
+		return 0, fmt.Errorf("NTP server sent kiss-o'-death packet '%s'", string(resp[12:16]))
+	}
+	if binary.BigEndian.Uint64(resp[24:32]) != binary.BigEndian.Uint64(req[40:48]) {
+		return 0, fmt.Errorf("NTP response doesn't match the request")
+	}
+
+	serverReceivedAt := getNTPTime(resp[32:40])
+	serverSentAt := getNTPTime(resp[40:48])
+
+	// offset of the server clock: ((t2 - t1) + (t3 - t4)) / 2
+	serverOffset := (serverReceivedAt.Sub(sentAt) + serverSentAt.Sub(receivedAt)) / 2
+
+	return -serverOffset, nil
+}
+
+func putNTPTime(b []byte, t time.Time) {
+	seconds := uint64(t.Unix() + ntpEpochOffset)
+	fraction := (uint64(t.Nanosecond()) << 32) / uint64(time.Second)
+	binary.BigEndian.PutUint64(b, seconds<<32|fraction)
+}
+
+func getNTPTime(b []byte) time.Time {
+	v := binary.BigEndian.Uint64(b)
+	seconds := int64(v>>32) - ntpEpochOffset
+	nanoseconds := int64((v & 0xffffffff) * uint64(time.Second) >> 32)
+	return time.Unix(seconds, nanoseconds)
+}
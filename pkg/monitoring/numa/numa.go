@@ -0,0 +1,119 @@
+package numa
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "numa")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "numa.<node>.mem_free_B", Unit: "B", ConfigOption: "numa_monitoring"},
+		common.MetricDescriptor{Key: "numa.<node>.mem_used_B", Unit: "B", ConfigOption: "numa_monitoring"},
+		common.MetricDescriptor{Key: "numa.<node>.numa_miss", ConfigOption: "numa_monitoring"},
+		common.MetricDescriptor{Key: "numa.<node>.numa_foreign", ConfigOption: "numa_monitoring"},
+	)
+}
+
+// GetMeasurements reads the memory usage of the NUMA nodes from /sys/devices/system/node/node*/meminfo
+// and the numa_miss/numa_foreign allocation counters from node*/numastat.
+// Returns nil on hosts with a single NUMA node or if the information is not available.
+func GetMeasurements() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	return readNodes(common.HostSys("/devices/system/node"))
+}
+
+func readNodes(nodesRoot string) (common.MeasurementsMap, error) {
+	nodes, err := filepath.Glob(filepath.Join(nodesRoot, "node[0-9]*"))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(nodes) < 2 {
+		return nil, nil
+	}
+
+	results := common.MeasurementsMap{}
+	for _, nodePath := range nodes {
+		prefix := filepath.Base(nodePath) + "."
+
+		meminfo, err := readKeyValues(filepath.Join(nodePath, "meminfo"))
+		if err != nil {
+			log.WithError(err).Debugf("could not read meminfo of %s", nodePath)
+		}
+
+		total, hasTotal := meminfo["MemTotal"]
+		free, hasFree := meminfo["MemFree"]
+		if hasFree {
+			results[prefix+"mem_free_B"] = free
+		}
+		if used, ok := meminfo["MemUsed"]; ok {
+			results[prefix+"mem_used_B"] = used
+		} else if hasTotal && hasFree && total >= free {
+			results[prefix+"mem_used_B"] = total - free
+		}
+
+		numastat, err := readKeyValues(filepath.Join(nodePath, "numastat"))
+		if err != nil {
+			log.WithError(err).Debugf("could not read numastat of %s", nodePath)
+		}
+
+		for _, counter := range []string{"numa_miss", "numa_foreign"} {
+			if value, ok := numastat[counter]; ok {
+				results[prefix+counter] = value
+			}
+		}
+	}
+
+	return results, nil
+}
+
+// readKeyValues parses files with "key value [kB]" lines.
+// Lines of node meminfo are prefixed with "Node <n>", values in kB are returned in bytes
+func readKeyValues(filePath string) (map[string]uint64, error) {
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	values := make(map[string]uint64)
+	scanner := bufio.NewScanner(strings.NewReader(string(buf)))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && fields[0] == "Node" {
+			fields = fields[2:]
+		}
+		if len(fields) < 2 {
+			continue
+		}
+
+		value, err := strconv.ParseUint(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+
+		if len(fields) > 2 && fields[2] == "kB" {
+			value *= 1024
+		}
+
+		values[strings.TrimSuffix(fields[0], ":")] = value
+	}
+
+	return values, nil
+}
@@ -0,0 +1,35 @@
+package numa
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadNodes(t *testing.T) {
+	results, err := readNodes(filepath.Join("testdata", "node"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, common.MeasurementsMap{
+		"node0.mem_free_B":   uint64(4115228 * 1024),
+		"node0.mem_used_B":   uint64(12188664 * 1024),
+		"node0.numa_miss":    uint64(1024),
+		"node0.numa_foreign": uint64(2048),
+		"node1.mem_free_B":   uint64(10240000 * 1024),
+		"node1.mem_used_B":   uint64(6272180 * 1024),
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("numa.", results)))
+}
+
+func TestReadNodesSingleNode(t *testing.T) {
+	results, err := readNodes(filepath.Join("testdata", "node", "node0"))
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+
+	results, err = readNodes(filepath.Join("testdata", "not-existing"))
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
@@ -0,0 +1,54 @@
+package raid
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const syncActionRepair = "repair"
+
+// mdSyncInfo is the state of the array scrubbing exposed by the md driver in /sys/block/<array>/md:
+// https://www.kernel.org/doc/html/latest/admin-guide/md.html
+type mdSyncInfo struct {
+	// SyncAction is the currently running action, e.g. idle, check, repair, resync or recover
+	SyncAction string
+	// LastSyncAction is the last check or repair requested, empty on old kernels
+	LastSyncAction string
+	// MismatchCount is the number of sectors found mismatched (check) or repaired (repair) by the last sync action, nil if not available
+	MismatchCount *uint64
+}
+
+func readMdSyncInfo(sysBlockPath string, raidName string) mdSyncInfo {
+	mdPath := filepath.Join(sysBlockPath, raidName, "md")
+
+	info := mdSyncInfo{
+		SyncAction:     readSysfsString(filepath.Join(mdPath, "sync_action")),
+		LastSyncAction: readSysfsString(filepath.Join(mdPath, "last_sync_action")),
+	}
+
+	if mismatches := readSysfsString(filepath.Join(mdPath, "mismatch_cnt")); mismatches != "" {
+		count, err := strconv.ParseUint(mismatches, 10, 64)
+		if err != nil {
+			log.WithError(err).Debugf("could not parse mismatch_cnt of %s", raidName)
+		} else {
+			info.MismatchCount = &count
+		}
+	}
+
+	return info
+}
+
+func readSysfsString(filePath string) string {
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		if !os.IsNotExist(err) {
+			log.WithError(err).Debugf("could not read file: %s", filePath)
+		}
+		return ""
+	}
+
+	return strings.TrimSpace(string(buf))
+}
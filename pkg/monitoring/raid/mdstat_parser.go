@@ -18,10 +18,32 @@ type raidInfo struct {
 	Active       []int
 	Failed       []int
 	IsRebuilding bool
+
+	RebuildProgressPercent float64
+
+	// SizeBlocks is the array size in 1K blocks
+	SizeBlocks uint64
+	// ChunkKB is 0 for the levels without striping
+	ChunkKB int
+
+	BitmapPresent    bool
+	BitmapPages      int
+	BitmapPagesTotal int
+	BitmapChunkKB    int
 }
 
 var raidStatusRegex = regexp.MustCompile(`\[([U_]+)\]`)
 
+// matches the progress of recovery e.g. "[==>......]  recovery = 12.6% (37043392/292945152) finish=127.5min speed=33440K/sec"
+var raidRecoveryProgressRegex = regexp.MustCompile(`recovery\s*=\s*([\d.]+)%`)
+
+// matches the size and chunk parts of the status line e.g. "1318680576 blocks level 5, 1024k chunk, algorithm 2 [10/10] [UUUUUUUUUU]"
+var raidSizeRegex = regexp.MustCompile(`^(\d+) blocks`)
+var raidChunkRegex = regexp.MustCompile(`(\d+)[kK] chunk`)
+
+// matches write-intent bitmap line e.g. "bitmap: 0/234 pages [0KB], 512KB chunk"
+var raidBitmapRegex = regexp.MustCompile(`bitmap:\s+(\d+)/(\d+)\s+pages\s+\[\d+KB\],\s+(\d+)(KB|B)\s+chunk`)
+
 func (r raidInfo) GetFailedDevices() (failedDevices []string) {
 	for _, deviceIndex := range r.Failed {
 		if deviceIndex < len(r.Devices) {
@@ -34,72 +56,115 @@ func (r raidInfo) GetFailedDevices() (failedDevices []string) {
 
 func parseMdstat(data string) raidArrays {
 	var raids []raidInfo
-	var err error
-	lines := strings.Split(data, "\n")
+	// index of the array which the following lines belong to
+	current := -1
+	statusParsed := false
 
-	for n, line := range lines {
+	for _, line := range strings.Split(data, "\n") {
 		line = strings.TrimSpace(line)
 		if line == "" {
 			continue
 		}
 
 		if strings.HasPrefix(line, "Personalities") || strings.HasPrefix(line, "unused") {
+			current = -1
 			continue
 		}
 
-		line = strings.ReplaceAll(line, "(auto-read-only)", "")
+		if raid, isHeader := parseHeaderLine(line); isHeader {
+			raids = append(raids, raid)
+			current = len(raids) - 1
+			statusParsed = false
+			continue
+		}
 
-		parts := strings.Fields(line)
-		if len(parts) < 5 || parts[1] != ":" {
+		if current < 0 {
 			continue
 		}
-		raidState := parts[2]
-		raidType := ""
-		raidLevel := 0
-		deviceIndex := 3
-		if raidState != "inactive" {
-			raidType = parts[3]
-			raidLevel, err = strconv.Atoi(strings.TrimPrefix(raidType, "raid"))
-			if err != nil {
-				log.WithError(err).Warnf("could not determine raid level from line '%s'", line)
-				raidLevel = -1
+
+		raid := &raids[current]
+		switch {
+		case !statusParsed:
+			// status line always follows the header
+			raid.Inactive, raid.Active = parseStatusLine(line)
+			parseCapacity(raid, line)
+			statusParsed = true
+		case strings.Contains(line, "bitmap"):
+			parseBitmapLine(raid, line)
+		case strings.Contains(line, "recovery"):
+			raid.IsRebuilding = true
+			if matches := raidRecoveryProgressRegex.FindStringSubmatch(line); len(matches) > 0 {
+				raid.RebuildProgressPercent, _ = strconv.ParseFloat(matches[1], 64)
 			}
-			deviceIndex = 4
 		}
-		raid := raidInfo{Name: parts[0], State: raidState, Type: raidType, RaidLevel: raidLevel, Devices: parts[4:]}
-
-		raid.Devices = parts[deviceIndex:]
-		for i, device := range raid.Devices {
-			p := strings.Index(device, "[")
-			if p > 0 {
-				raid.Devices[i] = device[0:p]
-				if strings.Contains(device, "(F)") {
-					raid.Failed = append(raid.Failed, i)
-				}
+	}
+
+	return raids
+}
+
+// parseHeaderLine parses the first line of md device description e.g. "md0 : active raid1 sdb1[1] sda1[0]"
+func parseHeaderLine(line string) (raidInfo, bool) {
+	line = strings.ReplaceAll(line, "(auto-read-only)", "")
+
+	parts := strings.Fields(line)
+	if len(parts) < 5 || parts[1] != ":" {
+		return raidInfo{}, false
+	}
+
+	raidState := parts[2]
+	raidType := ""
+	raidLevel := 0
+	deviceIndex := 3
+	if raidState != "inactive" {
+		var err error
+		raidType = parts[3]
+		raidLevel, err = strconv.Atoi(strings.TrimPrefix(raidType, "raid"))
+		if err != nil {
+			log.WithError(err).Warnf("could not determine raid level from line '%s'", line)
+			raidLevel = -1
+		}
+		deviceIndex = 4
+	}
+	raid := raidInfo{Name: parts[0], State: raidState, Type: raidType, RaidLevel: raidLevel}
+
+	raid.Devices = parts[deviceIndex:]
+	for i, device := range raid.Devices {
+		p := strings.Index(device, "[")
+		if p > 0 {
+			raid.Devices[i] = device[0:p]
+			if strings.Contains(device, "(F)") {
+				raid.Failed = append(raid.Failed, i)
 			}
 		}
+	}
 
-		if len(lines) <= n+3 {
-			log.Errorf("error parsing %s: too few lines for md device", raid.Name)
-			return raids
-		}
+	return raid, true
+}
 
-		raid.Inactive, raid.Active = parseStatusLine(lines[n+1])
+func parseCapacity(raid *raidInfo, line string) {
+	if matches := raidSizeRegex.FindStringSubmatch(line); len(matches) > 0 {
+		raid.SizeBlocks, _ = strconv.ParseUint(matches[1], 10, 64)
+	}
 
-		syncLineIdx := n + 2
-		if strings.Contains(lines[n+2], "bitmap") {
-			// skip bitmap line
-			syncLineIdx++
-		}
+	if matches := raidChunkRegex.FindStringSubmatch(line); len(matches) > 0 {
+		raid.ChunkKB, _ = strconv.Atoi(matches[1])
+	}
+}
 
-		isRecovering := strings.Contains(lines[syncLineIdx], "recovery")
-		if isRecovering {
-			raid.IsRebuilding = true
-		}
+func parseBitmapLine(raid *raidInfo, line string) {
+	matches := raidBitmapRegex.FindStringSubmatch(line)
+	if len(matches) == 0 {
+		log.Warnf("could not parse bitmap line '%s' of %s", strings.TrimSpace(line), raid.Name)
+		return
+	}
 
-		raids = append(raids, raid)
+	raid.BitmapPresent = true
+	raid.BitmapPages, _ = strconv.Atoi(matches[1])
+	raid.BitmapPagesTotal, _ = strconv.Atoi(matches[2])
+	raid.BitmapChunkKB, _ = strconv.Atoi(matches[3])
+	if matches[4] == "B" {
+		raid.BitmapChunkKB /= 1024
 	}
-	return raids
 }
 
 func parseStatusLine(line string) ([]int, []int) {
@@ -1,6 +1,8 @@
 package raid
 
 import (
+	"io/ioutil"
+	"path/filepath"
 	"testing"
 
 	"github.com/davecgh/go-spew/spew"
@@ -95,3 +97,89 @@ unused devices: <none>`
 	assert.Equal(t, []int(nil), ra[0].Failed)
 	assert.Equal(t, false, ra[0].IsRebuilding)
 }
+
+func TestParseMdstatBitmap(t *testing.T) {
+	var cases = map[string][]raidInfo{
+		"mdstat_good3_bitmap": {
+			{Name: "md0", BitmapPresent: true, BitmapPages: 0, BitmapPagesTotal: 234, BitmapChunkKB: 512},
+		},
+		"mdstat_recovery_bitmap": {
+			{Name: "md127", BitmapPresent: true, BitmapPages: 3, BitmapPagesTotal: 8, BitmapChunkKB: 65536},
+		},
+		"mdstat_good2": {
+			{Name: "md1"}, {Name: "md2"}, {Name: "md3"}, {Name: "md0"},
+		},
+	}
+
+	for fileName, expected := range cases {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", fileName))
+		assert.NoError(t, err)
+
+		ra := parseMdstat(string(data))
+		if !assert.Len(t, ra, len(expected), fileName) {
+			continue
+		}
+
+		for i := range expected {
+			assert.Equal(t, expected[i].Name, ra[i].Name, fileName)
+			assert.Equal(t, expected[i].BitmapPresent, ra[i].BitmapPresent, fileName)
+			assert.Equal(t, expected[i].BitmapPages, ra[i].BitmapPages, fileName)
+			assert.Equal(t, expected[i].BitmapPagesTotal, ra[i].BitmapPagesTotal, fileName)
+			assert.Equal(t, expected[i].BitmapChunkKB, ra[i].BitmapChunkKB, fileName)
+		}
+	}
+
+	ra := parseMdstat("md0 : active raid1 sdb1[1] sda1[0]\n      976630464 blocks super 1.2 [2/2] [UU]\n      bitmap: 1/1 pages [4KB], 65536B chunk, file: /var/md0-bitmap\n\n")
+	if assert.Len(t, ra, 1) {
+		assert.True(t, ra[0].BitmapPresent)
+		assert.Equal(t, 64, ra[0].BitmapChunkKB)
+	}
+}
+
+func TestParseMdstatConcurrentRecovery(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "mdstat_recovery_concurrent"))
+	assert.NoError(t, err)
+
+	ra := parseMdstat(string(data))
+	if !assert.Len(t, ra, 3) {
+		return
+	}
+
+	assert.Equal(t, "md0", ra[0].Name)
+	assert.False(t, ra[0].IsRebuilding)
+	assert.Equal(t, 0.0, ra[0].RebuildProgressPercent)
+	assert.True(t, ra[0].BitmapPresent)
+
+	assert.Equal(t, "md1", ra[1].Name)
+	assert.True(t, ra[1].IsRebuilding)
+	assert.Equal(t, 34.5, ra[1].RebuildProgressPercent)
+	assert.Equal(t, []int{1}, ra[1].Inactive)
+	assert.True(t, ra[1].BitmapPresent)
+	assert.Equal(t, 2, ra[1].BitmapPages)
+
+	assert.Equal(t, "md2", ra[2].Name)
+	assert.True(t, ra[2].IsRebuilding)
+	assert.Equal(t, 71.2, ra[2].RebuildProgressPercent)
+	assert.Equal(t, []int{3}, ra[2].Inactive)
+	assert.False(t, ra[2].BitmapPresent)
+}
+
+func TestParseMdstatCapacity(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "mdstat_raid5_chunk"))
+	assert.NoError(t, err)
+
+	ra := parseMdstat(string(data))
+	if !assert.Len(t, ra, 2) {
+		return
+	}
+
+	assert.Equal(t, "md0", ra[0].Name)
+	assert.Equal(t, uint64(3906764800), ra[0].SizeBlocks)
+	assert.Equal(t, 512, ra[0].ChunkKB)
+	// bitmap chunk is not confused with the array chunk
+	assert.Equal(t, 65536, ra[0].BitmapChunkKB)
+
+	assert.Equal(t, "md1", ra[1].Name)
+	assert.Equal(t, uint64(976630464), ra[1].SizeBlocks)
+	assert.Equal(t, 0, ra[1].ChunkKB)
+}
@@ -1,6 +1,7 @@
 package raid
 
 import (
+	"context"
 	"fmt"
 	"io/ioutil"
 	"os"
@@ -20,29 +21,65 @@ const (
 	raidStatusRebuilding = "rebuilding"
 )
 
+// mdstat reports sizes in 1K blocks
+const mdstatBlockSize = 1024
+
 var log = logrus.WithField("package", "raid")
 
+const remoteCommandTimeout = 10 * time.Second
+
 type RAID struct {
 	mdstatFilePath string
-	enabled        bool
+	// sysBlockPath is used to read the state of the arrays scrubbing. It is not read if empty
+	sysBlockPath string
+	enabled      bool
+
+	// remoteInvoker reads mdstat of a remote host. Local file is read if it is nil
+	remoteInvoker common.Invoker
 }
 
 func CreateModule(enabled bool) monitoring.Module {
 	return &RAID{
 		mdstatFilePath: common.HostProc("mdstat"),
+		sysBlockPath:   common.HostSys("block"),
 		enabled:        enabled,
 	}
 }
 
+// CreateRemoteModule creates module which reads mdstat file at mdstatFilePath of a remote host using the invoker
+func CreateRemoteModule(invoker common.Invoker, mdstatFilePath string) monitoring.Module {
+	return &RAID{
+		mdstatFilePath: mdstatFilePath,
+		enabled:        true,
+		remoteInvoker:  invoker,
+	}
+}
+
 func (r *RAID) GetDescription() string {
 	return fmt.Sprintf("software RAID monitoring using %s file", r.mdstatFilePath)
 }
 
 func (r *RAID) IsEnabled() bool {
+	if r.remoteInvoker != nil {
+		return r.enabled
+	}
 	return r.enabled && runtime.GOOS == "linux"
 }
 
 func (r *RAID) readAndParseMdstat() raidArrays {
+	if r.remoteInvoker != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), remoteCommandTimeout)
+		defer cancel()
+
+		buf, err := r.remoteInvoker.CommandWithContext(ctx, "cat", r.mdstatFilePath)
+		if err != nil {
+			// most likely md driver is not loaded
+			log.WithError(err).Debugf("could not read remote %s file", r.mdstatFilePath)
+			return nil
+		}
+		return parseMdstat(string(buf))
+	}
+
 	buf, err := ioutil.ReadFile(r.mdstatFilePath)
 	if os.IsNotExist(err) {
 		return nil
@@ -69,7 +106,7 @@ func (r *RAID) Run() ([]*monitoring.ModuleReport, error) {
 		"",
 	)
 
-	virtualDrives := make(map[string]int)
+	virtualDrives := make(map[string]interface{})
 	raidStatuses := make(map[string]string)
 	atLeastOneDegraded := false
 	for _, raidInfo := range ([]raidInfo)(raidArrays) {
@@ -81,9 +118,23 @@ func (r *RAID) Run() ([]*monitoring.ModuleReport, error) {
 		}
 		raidName := raidInfo.Name
 		virtualDrives[fmt.Sprintf("%s raid level", raidName)] = raidInfo.RaidLevel
+		virtualDrives[fmt.Sprintf("%s size blocks", raidName)] = raidInfo.SizeBlocks
+		virtualDrives[fmt.Sprintf("%s size B", raidName)] = raidInfo.SizeBlocks * mdstatBlockSize
+		if raidInfo.ChunkKB > 0 {
+			virtualDrives[fmt.Sprintf("%s chunk KB", raidName)] = raidInfo.ChunkKB
+		} else {
+			virtualDrives[fmt.Sprintf("%s chunk KB", raidName)] = nil
+		}
+		virtualDrives[fmt.Sprintf("%s bitmap present", raidName)] = raidInfo.BitmapPresent
+		if raidInfo.BitmapPresent {
+			virtualDrives[fmt.Sprintf("%s bitmap pages", raidName)] = raidInfo.BitmapPages
+			virtualDrives[fmt.Sprintf("%s bitmap pages total", raidName)] = raidInfo.BitmapPagesTotal
+			virtualDrives[fmt.Sprintf("%s bitmap chunk KB", raidName)] = raidInfo.BitmapChunkKB
+		}
 
 		if raidInfo.IsRebuilding {
 			status = raidStatusRebuilding
+			virtualDrives[fmt.Sprintf("%s rebuild progress percent", raidName)] = raidInfo.RebuildProgressPercent
 			report.AddWarning(fmt.Sprintf("Raid %s rebuilding.", raidName))
 		}
 
@@ -104,6 +155,10 @@ func (r *RAID) Run() ([]*monitoring.ModuleReport, error) {
 			status = raidStatusDegraded
 		}
 
+		if r.sysBlockPath != "" {
+			addSyncMeasurements(&report, virtualDrives, raidName, readMdSyncInfo(r.sysBlockPath, raidName))
+		}
+
 		if status == raidStatusDegraded {
 			atLeastOneDegraded = true
 		}
@@ -121,3 +176,31 @@ func (r *RAID) Run() ([]*monitoring.ModuleReport, error) {
 
 	return []*monitoring.ModuleReport{&report}, nil
 }
+
+// addSyncMeasurements reports the last scrub of the array. Mismatches found by a check raise an alert,
+// the ones fixed by a repair are reported as warning
+func addSyncMeasurements(report *monitoring.ModuleReport, virtualDrives map[string]interface{}, raidName string, info mdSyncInfo) {
+	if info.SyncAction != "" {
+		virtualDrives[fmt.Sprintf("%s sync action", raidName)] = info.SyncAction
+	}
+
+	if info.LastSyncAction != "" {
+		virtualDrives[fmt.Sprintf("%s last sync action", raidName)] = info.LastSyncAction
+	}
+
+	if info.MismatchCount == nil {
+		return
+	}
+
+	mismatches := *info.MismatchCount
+	virtualDrives[fmt.Sprintf("%s mismatch count", raidName)] = mismatches
+	if mismatches == 0 {
+		return
+	}
+
+	if info.LastSyncAction == syncActionRepair {
+		report.AddWarning(fmt.Sprintf("Raid %s: %d mismatched sectors repaired by the last repair.", raidName, mismatches))
+	} else {
+		report.AddAlert(fmt.Sprintf("Raid %s: %d mismatched sectors found by the last check.", raidName, mismatches))
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 
@@ -45,7 +46,10 @@ func TestRAIDModule(t *testing.T) {
 		"mdstat_good2":        {true, noAlerts, noWarnings},
 		"mdstat_good3_bitmap": {true, noAlerts, noWarnings},
 
-		"mdstat_recovery": {true, noAlerts, []monitoring.Warning{"Raid md127 rebuilding."}},
+		"mdstat_recovery":            {true, noAlerts, []monitoring.Warning{"Raid md127 rebuilding."}},
+		"mdstat_recovery_bitmap":     {true, noAlerts, []monitoring.Warning{"Raid md127 rebuilding."}},
+		"mdstat_recovery_concurrent": {true, noAlerts, []monitoring.Warning{"Raid md1 rebuilding.", "Raid md2 rebuilding."}},
+		"mdstat_raid5_chunk":         {true, noAlerts, noWarnings},
 	}
 
 	for fileName, expected := range testMap {
@@ -63,3 +67,69 @@ func TestRAIDModule(t *testing.T) {
 		})
 	}
 }
+
+func TestRAIDModuleBitmapMeasurements(t *testing.T) {
+	reports, err := helperInitModule("mdstat_good3_bitmap").Run()
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 1) {
+		virtualDrives := reports[0].Measurements["Virtual Drives"].(map[string]interface{})
+		assert.Equal(t, true, virtualDrives["md0 bitmap present"])
+		assert.Equal(t, 0, virtualDrives["md0 bitmap pages"])
+		assert.Equal(t, 234, virtualDrives["md0 bitmap pages total"])
+		assert.Equal(t, 512, virtualDrives["md0 bitmap chunk KB"])
+	}
+
+	reports, err = helperInitModule("mdstat_good2").Run()
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 1) {
+		virtualDrives := reports[0].Measurements["Virtual Drives"].(map[string]interface{})
+		assert.Equal(t, false, virtualDrives["md1 bitmap present"])
+		assert.NotContains(t, virtualDrives, "md1 bitmap pages")
+	}
+}
+
+func TestRAIDModuleCapacityMeasurements(t *testing.T) {
+	reports, err := helperInitModule("mdstat_raid5_chunk").Run()
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 1) {
+		virtualDrives := reports[0].Measurements["Virtual Drives"].(map[string]interface{})
+		assert.Equal(t, 512, virtualDrives["md0 chunk KB"])
+		assert.Equal(t, uint64(3906764800), virtualDrives["md0 size blocks"])
+		assert.Equal(t, uint64(3906764800*1024), virtualDrives["md0 size B"])
+
+		chunk, exists := virtualDrives["md1 chunk KB"]
+		assert.True(t, exists)
+		assert.Nil(t, chunk)
+		assert.Equal(t, uint64(976630464), virtualDrives["md1 size blocks"])
+	}
+}
+
+func TestRAIDModuleMismatchCount(t *testing.T) {
+	m := helperInitModule("mdstat_raid5_chunk")
+	m.sysBlockPath = filepath.Join("testdata", "sys", "block")
+
+	reports, err := m.Run()
+	assert.NoError(t, err)
+	if assert.Len(t, reports, 1) {
+		virtualDrives := reports[0].Measurements["Virtual Drives"].(map[string]interface{})
+		assert.Equal(t, uint64(128), virtualDrives["md0 mismatch count"])
+		assert.Equal(t, "check", virtualDrives["md0 last sync action"])
+		assert.Equal(t, "idle", virtualDrives["md0 sync action"])
+		assert.Equal(t, uint64(0), virtualDrives["md1 mismatch count"])
+		assert.Equal(t, "repair", virtualDrives["md1 last sync action"])
+
+		assert.EqualValues(t, []monitoring.Alert{"Raid md0: 128 mismatched sectors found by the last check."}, reports[0].Alerts)
+		assert.Empty(t, reports[0].Warnings)
+	}
+}
+
+func TestAddSyncMeasurementsRepair(t *testing.T) {
+	report := monitoring.NewReport("test", time.Now(), "")
+	virtualDrives := make(map[string]interface{})
+	mismatches := uint64(8)
+
+	addSyncMeasurements(&report, virtualDrives, "md2", mdSyncInfo{SyncAction: "idle", LastSyncAction: "repair", MismatchCount: &mismatches})
+	assert.Empty(t, report.Alerts)
+	assert.EqualValues(t, []monitoring.Warning{"Raid md2: 8 mismatched sectors repaired by the last repair."}, report.Warnings)
+	assert.Equal(t, uint64(8), virtualDrives["md2 mismatch count"])
+}
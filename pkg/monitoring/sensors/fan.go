@@ -0,0 +1,110 @@
+package sensors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "fan.<chip>.<n>.rpm", Unit: "rpm", ConfigOption: "fan_monitoring"},
+		common.MetricDescriptor{Key: "fan.<chip>.<n>.stalled", Unit: "bool", ConfigOption: "fan_monitoring"},
+	)
+}
+
+var fanInputRegexp = regexp.MustCompile(`^fan([0-9]+)_input$`)
+
+// ReadFanSpeeds reports the fans exposed on Linux via hwmon sysfs interface as <chip>.<n>.rpm.
+// <chip>.<n>.stalled is true if the fan stands still while a temperature of the same chip is >= stallTemperature (°C).
+// Returns nil if no fans are available
+func ReadFanSpeeds(stallTemperature float64) (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	return readFans(common.HostSys("/class/hwmon"), stallTemperature)
+}
+
+func readFans(hwmonRoot string, stallTemperature float64) (common.MeasurementsMap, error) {
+	chipDirs, err := filepath.Glob(filepath.Join(hwmonRoot, "hwmon*"))
+	if err != nil {
+		return nil, err
+	}
+
+	results := common.MeasurementsMap{}
+	for _, chipDir := range chipDirs {
+		// CentOS has an intermediate /device directory
+		if _, err := os.Stat(filepath.Join(chipDir, "name")); err != nil {
+			chipDir = filepath.Join(chipDir, "device")
+		}
+
+		files, err := ioutil.ReadDir(chipDir)
+		if err != nil {
+			log.WithError(err).Debugf("could not list %s", chipDir)
+			continue
+		}
+
+		chip := readHwmonString(filepath.Join(chipDir, "name"))
+		if chip == "" {
+			chip = filepath.Base(chipDir)
+		}
+
+		var maxTemperature float64
+		rpms := make(map[string]uint64)
+		for _, file := range files {
+			name := file.Name()
+			switch {
+			case fanInputRegexp.MatchString(name):
+				rpm, err := strconv.ParseUint(readHwmonString(filepath.Join(chipDir, name)), 10, 64)
+				if err != nil {
+					log.WithError(err).Debugf("could not parse %s of %s", name, chip)
+					continue
+				}
+				rpms[fanInputRegexp.FindStringSubmatch(name)[1]] = rpm
+			case strings.HasPrefix(name, "temp") && strings.HasSuffix(name, "_input"):
+				temperature, err := readTemperatureValue(filepath.Join(chipDir, name))
+				if err == nil && temperature > maxTemperature {
+					maxTemperature = temperature
+				}
+			}
+		}
+
+		for n, rpm := range rpms {
+			prefix := chip + "." + n + "."
+			results[prefix+"rpm"] = rpm
+			results[prefix+"stalled"] = rpm == 0 && maxTemperature >= stallTemperature
+		}
+	}
+
+	if len(results) == 0 {
+		return nil, nil
+	}
+
+	return results, nil
+}
+
+// readTemperatureValue reads the temperature in millidegree Celsius and converts it to °C
+func readTemperatureValue(filePath string) (float64, error) {
+	value, err := strconv.ParseFloat(readHwmonString(filePath), 64)
+	if err != nil {
+		return 0, err
+	}
+
+	return value / 1000.0, nil
+}
+
+func readHwmonString(filePath string) string {
+	buf, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return ""
+	}
+
+	return strings.TrimSpace(string(buf))
+}
@@ -0,0 +1,32 @@
+package sensors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadFans(t *testing.T) {
+	results, err := readFans(filepath.Join("testdata", "hwmon"), 60)
+	assert.NoError(t, err)
+
+	// fan 2 of nct6775 stands still while temp1 is 72°C, the it8728 fan is stopped on a cool chip
+	assert.Equal(t, common.MeasurementsMap{
+		"nct6775.1.rpm":     uint64(1245),
+		"nct6775.1.stalled": false,
+		"nct6775.2.rpm":     uint64(0),
+		"nct6775.2.stalled": true,
+		"it8728.1.rpm":      uint64(0),
+		"it8728.1.stalled":  false,
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("fan.", results)))
+}
+
+func TestReadFansNoHwmon(t *testing.T) {
+	results, err := readFans(filepath.Join("testdata", "not-existing"), 60)
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
@@ -0,0 +1,84 @@
+package sensors
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "cpu.<n>.throttle_count", ConfigOption: "temperature_monitoring"},
+		common.MetricDescriptor{Key: "cpu.<n>.throttled", Unit: "bool", ConfigOption: "temperature_monitoring"},
+	)
+}
+
+// ThrottleWatcher reports the thermal throttling counters of the CPU cores exposed on Linux in
+// /sys/devices/system/cpu/cpu*/thermal_throttle/core_throttle_count
+type ThrottleWatcher struct {
+	cpuRoot    string
+	prevCounts map[string]uint64
+}
+
+func NewThrottleWatcher() *ThrottleWatcher {
+	return &ThrottleWatcher{
+		cpuRoot: common.HostSys("/devices/system/cpu"),
+	}
+}
+
+// Results returns <n>.throttle_count and <n>.throttled of every CPU core, the latter is true if the counter increased since the previous call.
+// Returns nil if the counters are not available
+func (tw *ThrottleWatcher) Results() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	counts, err := readThrottleCounts(tw.cpuRoot)
+	if err != nil || len(counts) == 0 {
+		return nil, err
+	}
+
+	results := common.MeasurementsMap{}
+	for cpu, count := range counts {
+		prevCount, hasPrev := tw.prevCounts[cpu]
+		results[cpu+".throttle_count"] = count
+		results[cpu+".throttled"] = hasPrev && count > prevCount
+	}
+	tw.prevCounts = counts
+
+	return results, nil
+}
+
+func readThrottleCounts(cpuRoot string) (map[string]uint64, error) {
+	files, err := filepath.Glob(filepath.Join(cpuRoot, "cpu[0-9]*", "thermal_throttle", "core_throttle_count"))
+	if err != nil {
+		return nil, err
+	}
+
+	counts := make(map[string]uint64)
+	for _, file := range files {
+		buf, err := ioutil.ReadFile(file)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.WithError(err).Debugf("could not read file: %s", file)
+			}
+			continue
+		}
+
+		count, err := strconv.ParseUint(strings.TrimSpace(string(buf)), 10, 64)
+		if err != nil {
+			log.WithError(err).Debugf("could not parse throttle counter from file: %s", file)
+			continue
+		}
+
+		// the counters are reported by the CPU number, e.g. 0 for cpu0
+		counts[strings.TrimPrefix(filepath.Base(filepath.Dir(filepath.Dir(file))), "cpu")] = count
+	}
+
+	return counts, nil
+}
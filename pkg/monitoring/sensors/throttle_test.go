@@ -0,0 +1,49 @@
+// +build linux
+
+package sensors
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestThrottleWatcherResults(t *testing.T) {
+	tw := &ThrottleWatcher{cpuRoot: filepath.Join("testdata", "cpu")}
+
+	results, err := tw.Results()
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"0.throttle_count": uint64(5),
+		"0.throttled":      false,
+		"1.throttle_count": uint64(0),
+		"1.throttled":      false,
+	}, results)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddWithPrefix("cpu.", results)))
+
+	// cpu0 was throttled since the previous cycle
+	tw.cpuRoot = filepath.Join("testdata", "cpu-next")
+	results, err = tw.Results()
+	assert.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"0.throttle_count": uint64(7),
+		"0.throttled":      true,
+		"1.throttle_count": uint64(0),
+		"1.throttled":      false,
+	}, results)
+
+	results, err = tw.Results()
+	assert.NoError(t, err)
+	assert.Equal(t, false, results["0.throttled"])
+}
+
+func TestThrottleWatcherNotAvailable(t *testing.T) {
+	tw := &ThrottleWatcher{cpuRoot: filepath.Join("testdata", "not-existing")}
+
+	results, err := tw.Results()
+	assert.NoError(t, err)
+	assert.Nil(t, results)
+}
@@ -0,0 +1,87 @@
+package services
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// RunningSystemdServices returns the names of the running systemd services of the list returned by ListServices
+func RunningSystemdServices(servicesList map[string]interface{}) []string {
+	list, _ := servicesList["list"].([]map[string]string)
+
+	var names []string
+	for _, service := range list {
+		if service["manager"] == "systemd" && service["state"] == "running" {
+			names = append(names, service["name"])
+		}
+	}
+
+	return names
+}
+
+// OpenFDs returns the numbers of the file descriptors and of the sockets opened by the main processes of the services
+// as <service>.open_fds and <service>.open_sockets. They are nil if the service is not running (PID 0),
+// the process exited meanwhile or its descriptors can't be read due to permissions. Linux only
+func OpenFDs(pids map[string]uint32) (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, ErrorNotImplementedForOS
+	}
+
+	results := common.MeasurementsMap{}
+	for name, pid := range pids {
+		results[name+".open_fds"] = nil
+		results[name+".open_sockets"] = nil
+		if pid == 0 {
+			continue
+		}
+
+		fds, sockets, err := countOpenFDs(common.HostProc(strconv.FormatUint(uint64(pid), 10), "fd"))
+		if err != nil {
+			if !os.IsNotExist(err) && !os.IsPermission(err) {
+				log.WithError(err).Debugf("[Services] failed to count open files of '%s'", name)
+			}
+			continue
+		}
+
+		results[name+".open_fds"] = fds
+		results[name+".open_sockets"] = sockets
+	}
+
+	return results, nil
+}
+
+// countOpenFDs counts the entries of /proc/<pid>/fd, the links of the sockets point to "socket:[<inode>]"
+func countOpenFDs(fdDir string) (fds int, sockets int, err error) {
+	dir, err := os.Open(fdDir)
+	if err != nil {
+		return 0, 0, err
+	}
+	defer dir.Close()
+
+	names, err := dir.Readdirnames(-1)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	for _, name := range names {
+		target, err := os.Readlink(filepath.Join(fdDir, name))
+		if err != nil {
+			// closed meanwhile
+			continue
+		}
+
+		fds++
+		if strings.HasPrefix(target, "socket:") {
+			sockets++
+		}
+	}
+
+	return fds, sockets, nil
+}
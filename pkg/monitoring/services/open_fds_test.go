@@ -0,0 +1,49 @@
+package services
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCountOpenFDs(t *testing.T) {
+	fdDir, err := ioutil.TempDir("", "cagent-fd")
+	require.NoError(t, err)
+	defer os.RemoveAll(fdDir)
+
+	// mimic /proc/<pid>/fd
+	for fd, target := range map[string]string{
+		"0": "/dev/null",
+		"1": "pipe:[29385]",
+		"2": "/var/log/nginx/error.log",
+		"3": "socket:[31277]",
+		"4": "socket:[31278]",
+		"5": "anon_inode:[eventpoll]",
+		"6": "/var/log/nginx/access.log",
+	} {
+		require.NoError(t, os.Symlink(target, filepath.Join(fdDir, fd)))
+	}
+
+	fds, sockets, err := countOpenFDs(fdDir)
+	require.NoError(t, err)
+	assert.Equal(t, 7, fds)
+	assert.Equal(t, 2, sockets)
+
+	_, _, err = countOpenFDs(filepath.Join(fdDir, "not-existing"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRunningSystemdServices(t *testing.T) {
+	servicesList := map[string]interface{}{"list": []map[string]string{
+		{"name": "nginx.service", "state": "running", "manager": "systemd"},
+		{"name": "cron.service", "state": "dead", "manager": "systemd"},
+		{"name": "sshd", "state": "running", "manager": "openrc"},
+	}}
+
+	assert.Equal(t, []string{"nginx.service"}, RunningSystemdServices(servicesList))
+	assert.Empty(t, RunningSystemdServices(nil))
+}
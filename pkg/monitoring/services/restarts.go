@@ -0,0 +1,35 @@
+package services
+
+import (
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// RestartTracker detects restarts of services by the change of their main PID between the collections
+type RestartTracker struct {
+	lastPIDs map[string]uint32
+	restarts map[string]uint64
+}
+
+func NewRestartTracker() *RestartTracker {
+	return &RestartTracker{
+		lastPIDs: make(map[string]uint32),
+		restarts: make(map[string]uint64),
+	}
+}
+
+// Update compares the main PIDs of the services with the ones of the previous call and returns the number
+// of restarts since cagent started as <service>.restarts. PID 0 means the service is not running,
+// so a service stopped and started again between the calls is not counted.
+func (t *RestartTracker) Update(pids map[string]uint32) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+	for name, pid := range pids {
+		if lastPID := t.lastPIDs[name]; lastPID != 0 && pid != 0 && pid != lastPID {
+			t.restarts[name]++
+		}
+		t.lastPIDs[name] = pid
+
+		results[name+".restarts"] = t.restarts[name]
+	}
+
+	return results
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestRestartTracker(t *testing.T) {
+	tracker := NewRestartTracker()
+
+	assert.Equal(t, common.MeasurementsMap{
+		"nginx.service.restarts": uint64(0),
+		"redis.service.restarts": uint64(0),
+	}, tracker.Update(map[string]uint32{"nginx.service": 1200, "redis.service": 0}))
+
+	// nginx crashed and was restarted by systemd, redis started
+	assert.Equal(t, common.MeasurementsMap{
+		"nginx.service.restarts": uint64(1),
+		"redis.service.restarts": uint64(0),
+	}, tracker.Update(map[string]uint32{"nginx.service": 1350, "redis.service": 800}))
+
+	// nginx is stopped, the start after it is not a restart
+	tracker.Update(map[string]uint32{"nginx.service": 0, "redis.service": 800})
+	assert.Equal(t, common.MeasurementsMap{
+		"nginx.service.restarts": uint64(1),
+		"redis.service.restarts": uint64(0),
+	}, tracker.Update(map[string]uint32{"nginx.service": 1500, "redis.service": 800}))
+
+	assert.Equal(t, common.MeasurementsMap{
+		"nginx.service.restarts": uint64(2),
+		"redis.service.restarts": uint64(1),
+	}, tracker.Update(map[string]uint32{"nginx.service": 1600, "redis.service": 900}))
+}
@@ -3,7 +3,19 @@ package services
 import (
 	"errors"
 	"runtime"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
 // ErrorNotImplementedForOS returned in case we don't yet implement service manager parsing or this OS. Should be checked and ignored
 var ErrorNotImplementedForOS = errors.New("Services list not implemented for " + runtime.GOOS)
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "service.<service>.restarts", ConfigOption: "services_track_restarts"},
+		common.MetricDescriptor{Key: "service.<service>.open_fds", ConfigOption: "services_open_fds"},
+		common.MetricDescriptor{Key: "service.<service>.open_sockets", ConfigOption: "services_open_fds"},
+		common.MetricDescriptor{Key: "systemd.failed_units", ConfigOption: "systemd_failed_units"},
+		common.MetricDescriptor{Key: "systemd.failed.<n>", ConfigOption: "systemd_failed_units"},
+	)
+}
@@ -11,6 +11,7 @@ import (
 	"golang.org/x/sys/windows"
 	"golang.org/x/sys/windows/svc/mgr"
 
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 	"github.com/cloudradar-monitoring/cagent/pkg/winapi"
 )
 
@@ -165,19 +166,75 @@ func formatState(serviceState uint32) string {
 }
 
 func queryState(handle windows.Handle) (uint32, error) {
+	status, err := queryStatus(handle)
+	if err != nil {
+		return 0, err
+	}
+
+	return status.CurrentState, nil
+}
+
+func queryStatus(handle windows.Handle) (*windows.SERVICE_STATUS_PROCESS, error) {
 	var p *windows.SERVICE_STATUS_PROCESS
 	var bytesNeeded uint32
 	var buf []byte
 
 	if err := windows.QueryServiceStatusEx(handle, windows.SC_STATUS_PROCESS_INFO, nil, 0, &bytesNeeded); err != windows.ERROR_INSUFFICIENT_BUFFER {
-		return 0, err
+		return nil, err
 	}
 
 	buf = make([]byte, bytesNeeded)
 	p = (*windows.SERVICE_STATUS_PROCESS)(unsafe.Pointer(&buf[0]))
 	if err := windows.QueryServiceStatusEx(handle, windows.SC_STATUS_PROCESS_INFO, &buf[0], uint32(len(buf)), &bytesNeeded); err != nil {
+		return nil, err
+	}
+
+	return p, nil
+}
+
+// ServicePIDs returns the process IDs of the services by their names, 0 if the service is not running
+func ServicePIDs(names []string) (map[string]uint32, error) {
+	svcManager, err := mgr.Connect()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err := svcManager.Disconnect()
+		if err != nil {
+			log.Debugf("could not disconnect from Windows serviceInfo Manager: %s", err)
+		}
+	}()
+
+	errs := common.ErrorCollector{}
+	result := make(map[string]uint32, len(names))
+	for _, name := range names {
+		pid, err := queryServicePID(svcManager, name)
+		if err != nil {
+			errs.Add(errors.Wrapf(err, "could not query process ID of service %s", name))
+			continue
+		}
+		result[name] = pid
+	}
+
+	return result, errs.Combine()
+}
+
+func queryServicePID(svcManager *mgr.Mgr, serviceName string) (uint32, error) {
+	s, err := openService(svcManager, serviceName)
+	if err != nil {
 		return 0, err
 	}
+	defer s.Close()
+
+	status, err := queryStatus(s.Handle)
+	if err != nil {
+		return 0, err
+	}
+
+	return status.ProcessId, nil
+}
 
-	return p.CurrentState, nil
+// FailedSystemdUnits is not available on Windows
+func FailedSystemdUnits() (common.MeasurementsMap, error) {
+	return nil, ErrorNotImplementedForOS
 }
@@ -0,0 +1,68 @@
+// +build !windows
+
+package services
+
+import (
+	"bufio"
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// FailedSystemdUnits reports the number of failed systemd units of any type and their names:
+// failed_units = 2, failed.1 = "foo.service", failed.2 = "bar.mount"
+func FailedSystemdUnits() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" || !isSystemd() {
+		return nil, ErrorNotImplementedForOS
+	}
+
+	cmd := exec.Command("systemctl",
+		"--failed",    // show only failed units
+		"--no-legend", // disable the header and the footer
+		"--no-pager",  // disable results pagination
+		"--plain",     // disable colors and status bullet
+		"list-units")
+	setPathEnvVar(cmd)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Systemctl --failed: %s, %s", err.Error(), errb.String())
+	}
+
+	units := parseFailedUnits(outb.String())
+
+	results := common.MeasurementsMap{"failed_units": len(units)}
+	for i, unit := range units {
+		results[fmt.Sprintf("failed.%d", i+1)] = unit
+	}
+
+	return results, nil
+}
+
+// parseFailedUnits parses the output of `systemctl --failed --no-legend`:
+// UNIT LOAD ACTIVE SUB DESCRIPTION
+// Older systemd versions print the status bullet even with --plain
+func parseFailedUnits(output string) []string {
+	var units []string
+
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		parts := strings.Fields(scanner.Text())
+		if len(parts) > 0 && (parts[0] == "●" || parts[0] == "*") {
+			parts = parts[1:]
+		}
+		if len(parts) == 0 {
+			continue
+		}
+
+		units = append(units, parts[0])
+	}
+
+	return units
+}
@@ -0,0 +1,18 @@
+// +build !windows
+
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseFailedUnits(t *testing.T) {
+	output := `nginx.service       loaded failed failed A high performance web server and a reverse proxy server
+● mnt-backup.mount    loaded failed failed /mnt/backup
+`
+
+	assert.Equal(t, []string{"nginx.service", "mnt-backup.mount"}, parseFailedUnits(output))
+	assert.Empty(t, parseFailedUnits(""))
+}
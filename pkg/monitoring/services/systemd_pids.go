@@ -0,0 +1,60 @@
+// +build !windows
+
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// ServicePIDs returns the main PIDs of the services by their names, 0 if the service is not running.
+// Only systemd is supported
+func ServicePIDs(names []string) (map[string]uint32, error) {
+	if runtime.GOOS != "linux" || !isSystemd() {
+		return nil, ErrorNotImplementedForOS
+	}
+
+	cmd := exec.Command("systemctl", append([]string{"show", "--property=MainPID", "--no-pager"}, names...)...)
+	setPathEnvVar(cmd)
+
+	var outb, errb bytes.Buffer
+	cmd.Stdout = &outb
+	cmd.Stderr = &errb
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("Systemctl show: %s, %s", err.Error(), errb.String())
+	}
+
+	return parseMainPIDs(outb.String(), names)
+}
+
+// parseMainPIDs parses the output of `systemctl show --property=MainPID <names>`:
+// the properties of every unit in the order of the names separated by an empty line
+func parseMainPIDs(output string, names []string) (map[string]uint32, error) {
+	var pids []uint32
+	for _, line := range strings.Split(output, "\n") {
+		if !strings.HasPrefix(line, "MainPID=") {
+			continue
+		}
+
+		pid, err := strconv.ParseUint(strings.TrimPrefix(line, "MainPID="), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse systemctl show output: %s", err.Error())
+		}
+		pids = append(pids, uint32(pid))
+	}
+
+	if len(pids) != len(names) {
+		return nil, fmt.Errorf("systemctl show returned the main PID of %d units instead of %d", len(pids), len(names))
+	}
+
+	result := make(map[string]uint32, len(names))
+	for i, name := range names {
+		result[name] = pids[i]
+	}
+
+	return result, nil
+}
@@ -0,0 +1,20 @@
+// +build !windows
+
+package services
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMainPIDs(t *testing.T) {
+	output := "MainPID=1200\n\nMainPID=0\n"
+
+	pids, err := parseMainPIDs(output, []string{"nginx", "redis.service"})
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]uint32{"nginx": 1200, "redis.service": 0}, pids)
+
+	_, err = parseMainPIDs(output, []string{"nginx"})
+	assert.Error(t, err)
+}
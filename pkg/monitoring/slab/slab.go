@@ -0,0 +1,51 @@
+package slab
+
+import (
+	"runtime"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// meminfoFields maps the /proc/meminfo fields to the measurement keys
+var meminfoFields = map[string]string{
+	"Slab":         "slab_B",
+	"SReclaimable": "slab_reclaimable_B",
+	"SUnreclaim":   "slab_unreclaimable_B",
+	"KernelStack":  "kernel_stack_B",
+}
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "mem.slab_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.slab_reclaimable_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.slab_unreclaimable_B", Unit: "B", ConfigOption: "mem_monitoring"},
+		common.MetricDescriptor{Key: "mem.kernel_stack_B", Unit: "B", ConfigOption: "mem_monitoring"},
+	)
+}
+
+// GetMeasurements reads the memory used by the kernel from /proc/meminfo: slab_B split into slab_reclaimable_B
+// and slab_unreclaimable_B, and kernel_stack_B. A growing unreclaimable slab usually means a kernel memory leak.
+// The fields the kernel doesn't expose are omitted. Returns nil on other OSes than Linux
+func GetMeasurements() (common.MeasurementsMap, error) {
+	if runtime.GOOS != "linux" {
+		return nil, nil
+	}
+
+	return readMeminfo(common.HostProc("meminfo"))
+}
+
+func readMeminfo(filePath string) (common.MeasurementsMap, error) {
+	values, err := common.ReadMeminfo(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	results := common.MeasurementsMap{}
+	for field, key := range meminfoFields {
+		if value, ok := values[field]; ok {
+			results[key] = value
+		}
+	}
+
+	return results, nil
+}
@@ -0,0 +1,22 @@
+package slab
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestReadMeminfo(t *testing.T) {
+	results, err := readMeminfo(filepath.Join("testdata", "meminfo"))
+	assert.NoError(t, err)
+
+	assert.Equal(t, common.MeasurementsMap{
+		"slab_B":               uint64(1248576 * 1024),
+		"slab_reclaimable_B":   uint64(912340 * 1024),
+		"slab_unreclaimable_B": uint64(336236 * 1024),
+		"kernel_stack_B":       uint64(18720 * 1024),
+	}, results)
+}
@@ -0,0 +1,121 @@
+package snmp
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "snmp")
+
+func init() {
+	common.RegisterMetrics(common.MetricDescriptor{Key: "snmp.<target>.<metric>", ConfigOption: "snmp_targets"})
+}
+
+// CollectTargets polls the targets in parallel and reports the values of their OIDs as <target>.<name>.
+// The metrics of the target which didn't respond within its timeout are reported as nil
+func CollectTargets(ctx context.Context, targets []Target) (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+
+			values, err := collectTarget(ctx, target)
+
+			mu.Lock()
+			defer mu.Unlock()
+			for name := range target.OIDs {
+				results[target.Key()+"."+name] = values[name]
+			}
+			if err != nil {
+				log.WithError(err).Errorf("failed to collect measurements of %s", target.Key())
+				errs.Add(fmt.Errorf("snmp %s: %s", target.Key(), err.Error()))
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return results, errs.Combine()
+}
+
+// collectTarget returns the values of the target OIDs by the metric names
+func collectTarget(ctx context.Context, target Target) (map[string]interface{}, error) {
+	client, err := target.client()
+	if err != nil {
+		return nil, err
+	}
+	client.Context = ctx
+
+	if err = client.Connect(); err != nil {
+		return nil, err
+	}
+	defer client.Conn.Close()
+
+	namesByOID := make(map[string]string, len(target.OIDs))
+	oids := make([]string, 0, len(target.OIDs))
+	for name, oid := range target.OIDs {
+		oid = normalizeOID(oid)
+		namesByOID[oid] = name
+		oids = append(oids, oid)
+	}
+	sort.Strings(oids)
+
+	values := make(map[string]interface{}, len(oids))
+	for start := 0; start < len(oids); start += client.MaxOids {
+		end := start + client.MaxOids
+		if end > len(oids) {
+			end = len(oids)
+		}
+
+		packet, err := client.Get(oids[start:end])
+		if err != nil {
+			return values, err
+		}
+		if packet.Error != gosnmp.NoError {
+			return values, fmt.Errorf("device responded with error %s", packet.Error.String())
+		}
+
+		for _, variable := range packet.Variables {
+			if name, ok := namesByOID[normalizeOID(variable.Name)]; ok {
+				values[name] = pduValue(variable)
+			}
+		}
+	}
+
+	return values, nil
+}
+
+// pduValue converts the value of the variable to the measurement value, nil if the device doesn't have the OID
+func pduValue(pdu gosnmp.SnmpPDU) interface{} {
+	switch pdu.Type {
+	case gosnmp.NoSuchObject, gosnmp.NoSuchInstance, gosnmp.EndOfMibView, gosnmp.Null:
+		return nil
+	case gosnmp.OctetString:
+		if b, ok := pdu.Value.([]byte); ok {
+			return string(b)
+		}
+	case gosnmp.Integer:
+		return gosnmp.ToBigInt(pdu.Value).Int64()
+	case gosnmp.Counter32, gosnmp.Gauge32, gosnmp.TimeTicks, gosnmp.Counter64, gosnmp.Uinteger32:
+		return gosnmp.ToBigInt(pdu.Value).Uint64()
+	}
+
+	return pdu.Value
+}
+
+// normalizeOID returns the OID with the leading dot as reported by gosnmp
+func normalizeOID(oid string) string {
+	return "." + strings.TrimPrefix(oid, ".")
+}
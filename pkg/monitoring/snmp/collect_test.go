@@ -0,0 +1,111 @@
+package snmp
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// serveMockAgent answers SNMP v2c Get requests with the values by OID, the OIDs it doesn't know are reported as noSuchObject
+func serveMockAgent(t *testing.T, values map[string]gosnmp.SnmpPDU) (addr string, stop func()) {
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	go func() {
+		decoder := &gosnmp.GoSNMP{Version: gosnmp.Version2c, Community: "private"}
+		buf := make([]byte, 65535)
+		for {
+			n, from, err := conn.ReadFrom(buf)
+			if err != nil {
+				return
+			}
+
+			req, err := decoder.SnmpDecodePacket(buf[:n])
+			if err != nil || req.Community != "private" {
+				continue
+			}
+
+			resp := &gosnmp.SnmpPacket{
+				Version:   req.Version,
+				Community: req.Community,
+				PDUType:   gosnmp.GetResponse,
+				RequestID: req.RequestID,
+			}
+			for _, v := range req.Variables {
+				pdu, ok := values[v.Name]
+				if !ok {
+					pdu = gosnmp.SnmpPDU{Type: gosnmp.NoSuchObject}
+				}
+				pdu.Name = v.Name
+				resp.Variables = append(resp.Variables, pdu)
+			}
+
+			out, err := resp.MarshalMsg()
+			if err != nil {
+				t.Errorf("failed to marshal response: %s", err)
+				return
+			}
+			_, _ = conn.WriteTo(out, from)
+		}
+	}()
+
+	return conn.LocalAddr().String(), func() { conn.Close() }
+}
+
+func TestCollectTargets(t *testing.T) {
+	addr, stop := serveMockAgent(t, map[string]gosnmp.SnmpPDU{
+		".1.3.6.1.2.1.1.3.0":          {Type: gosnmp.TimeTicks, Value: uint32(123456)},
+		".1.3.6.1.2.1.1.5.0":          {Type: gosnmp.OctetString, Value: []byte("pdu-rack1")},
+		".1.3.6.1.4.1.318.1.1.12.2.3": {Type: gosnmp.Integer, Value: 42},
+	})
+	defer stop()
+
+	// the device which never answers
+	silent, err := net.ListenPacket("udp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer silent.Close()
+
+	targets := []Target{
+		{
+			Name:      "pdu",
+			Host:      addr,
+			Community: "private",
+			OIDs: map[string]string{
+				"uptime":  "1.3.6.1.2.1.1.3.0",
+				"sysname": ".1.3.6.1.2.1.1.5.0",
+				"load":    "1.3.6.1.4.1.318.1.1.12.2.3",
+				"missing": "1.3.6.1.2.1.1.99.0",
+			},
+		},
+		{
+			Name:    "switch",
+			Host:    silent.LocalAddr().String(),
+			Timeout: 0.2,
+			OIDs:    map[string]string{"uptime": "1.3.6.1.2.1.1.3.0"},
+		},
+	}
+	for _, target := range targets {
+		require.NoError(t, target.Validate())
+	}
+
+	startedAt := time.Now()
+	results, err := CollectTargets(context.Background(), targets)
+	assert.True(t, time.Since(startedAt) < 3*time.Second, "unresponsive target must not stall the collection")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "snmp switch")
+	assert.NotContains(t, err.Error(), "snmp pdu")
+
+	assert.Equal(t, uint64(123456), results["pdu.uptime"])
+	assert.Equal(t, "pdu-rack1", results["pdu.sysname"])
+	assert.Equal(t, int64(42), results["pdu.load"])
+	assert.Contains(t, results, "pdu.missing")
+	assert.Nil(t, results["pdu.missing"])
+	assert.Contains(t, results, "switch.uptime")
+	assert.Nil(t, results["switch.uptime"])
+}
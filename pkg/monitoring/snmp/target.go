@@ -0,0 +1,199 @@
+package snmp
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gosnmp/gosnmp"
+)
+
+const (
+	defaultPort      = "161"
+	defaultCommunity = "public"
+	defaultTimeout   = 5 * time.Second
+)
+
+// Target is a device which is polled over SNMP
+type Target struct {
+	Name         string            `toml:"name" comment:"Name used as the key of the device results. host is used if empty"`
+	Host         string            `toml:"host" comment:"Host name or IP address with an optional port, e.g. '192.168.1.20:1161'. default port 161"`
+	Version      string            `toml:"version" comment:"SNMP version: '1', '2c' or '3'. default '2c'"`
+	Community    string            `toml:"community" comment:"Community of SNMP v1 and v2c. default 'public'"`
+	User         string            `toml:"user" comment:"SNMP v3 user name"`
+	AuthProtocol string            `toml:"auth_protocol" comment:"SNMP v3 authentication protocol: 'MD5', 'SHA', 'SHA224', 'SHA256', 'SHA384' or 'SHA512'. Empty to disable authentication"`
+	AuthPassword string            `toml:"auth_password" comment:"SNMP v3 authentication password"`
+	PrivProtocol string            `toml:"priv_protocol" comment:"SNMP v3 privacy protocol: 'DES', 'AES', 'AES192' or 'AES256'. Empty to disable encryption"`
+	PrivPassword string            `toml:"priv_password" comment:"SNMP v3 privacy password"`
+	Timeout      float64           `toml:"timeout" comment:"Timeout in seconds to wait for the response of the device. default 5.0"`
+	OIDs         map[string]string `toml:"oids" comment:"OIDs to collect by the metric names, e.g. { uptime = '1.3.6.1.2.1.1.3.0' }"`
+}
+
+var authProtocols = map[string]gosnmp.SnmpV3AuthProtocol{
+	"MD5":    gosnmp.MD5,
+	"SHA":    gosnmp.SHA,
+	"SHA224": gosnmp.SHA224,
+	"SHA256": gosnmp.SHA256,
+	"SHA384": gosnmp.SHA384,
+	"SHA512": gosnmp.SHA512,
+}
+
+var privProtocols = map[string]gosnmp.SnmpV3PrivProtocol{
+	"DES":    gosnmp.DES,
+	"AES":    gosnmp.AES,
+	"AES192": gosnmp.AES192,
+	"AES256": gosnmp.AES256,
+}
+
+func (t Target) Validate() error {
+	if t.Host == "" {
+		return errors.New("host must be set")
+	}
+
+	if len(t.OIDs) == 0 {
+		return errors.New("oids must be set")
+	}
+
+	for name, oid := range t.OIDs {
+		if name == "" || strings.Contains(name, ".") {
+			return fmt.Errorf("metric name '%s' must be non-empty and must not contain dots", name)
+		}
+		if !isNumericOID(oid) {
+			return fmt.Errorf("OID '%s' of %s must be numeric, e.g. '1.3.6.1.2.1.1.3.0'", oid, name)
+		}
+	}
+
+	if t.Timeout < 0 {
+		return errors.New("timeout must be >= 0")
+	}
+
+	switch t.Version {
+	case "", "1", "2c":
+		return nil
+	case "3":
+	default:
+		return fmt.Errorf("unknown version '%s', must be '1', '2c' or '3'", t.Version)
+	}
+
+	if t.User == "" {
+		return errors.New("user must be set for version 3")
+	}
+
+	if t.AuthProtocol != "" {
+		if _, ok := authProtocols[strings.ToUpper(t.AuthProtocol)]; !ok {
+			return fmt.Errorf("unknown auth_protocol '%s'", t.AuthProtocol)
+		}
+		if t.AuthPassword == "" {
+			return errors.New("auth_password must be set if auth_protocol is set")
+		}
+	}
+
+	if t.PrivProtocol != "" {
+		if _, ok := privProtocols[strings.ToUpper(t.PrivProtocol)]; !ok {
+			return fmt.Errorf("unknown priv_protocol '%s'", t.PrivProtocol)
+		}
+		if t.AuthProtocol == "" {
+			return errors.New("priv_protocol requires auth_protocol to be set")
+		}
+		if t.PrivPassword == "" {
+			return errors.New("priv_password must be set if priv_protocol is set")
+		}
+	}
+
+	return nil
+}
+
+// Key returns the name of the target used in the results
+func (t Target) Key() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	if host, _, err := net.SplitHostPort(t.Host); err == nil {
+		return host
+	}
+
+	return t.Host
+}
+
+func (t Target) timeout() time.Duration {
+	if t.Timeout == 0 {
+		return defaultTimeout
+	}
+
+	return time.Duration(t.Timeout * float64(time.Second))
+}
+
+// client returns the SNMP client configured for the target, it is not connected yet
+func (t Target) client() (*gosnmp.GoSNMP, error) {
+	host, port := t.Host, defaultPort
+	if h, p, err := net.SplitHostPort(t.Host); err == nil {
+		host, port = h, p
+	}
+
+	portNum, err := strconv.ParseUint(port, 10, 16)
+	if err != nil {
+		return nil, fmt.Errorf("invalid port '%s'", port)
+	}
+
+	client := &gosnmp.GoSNMP{
+		Target:    host,
+		Port:      uint16(portNum),
+		Transport: "udp",
+		Community: t.Community,
+		Version:   gosnmp.Version2c,
+		Timeout:   t.timeout(),
+		// the timeout applies to the whole request, so the device can't stall the collection
+		Retries: 0,
+		MaxOids: gosnmp.MaxOids,
+	}
+	if client.Community == "" {
+		client.Community = defaultCommunity
+	}
+
+	switch t.Version {
+	case "1":
+		client.Version = gosnmp.Version1
+	case "3":
+		client.Version = gosnmp.Version3
+		client.SecurityModel = gosnmp.UserSecurityModel
+
+		params := &gosnmp.UsmSecurityParameters{
+			UserName:               t.User,
+			AuthenticationProtocol: gosnmp.NoAuth,
+			PrivacyProtocol:        gosnmp.NoPriv,
+		}
+		client.MsgFlags = gosnmp.NoAuthNoPriv
+		if t.AuthProtocol != "" {
+			params.AuthenticationProtocol = authProtocols[strings.ToUpper(t.AuthProtocol)]
+			params.AuthenticationPassphrase = t.AuthPassword
+			client.MsgFlags = gosnmp.AuthNoPriv
+		}
+		if t.PrivProtocol != "" {
+			params.PrivacyProtocol = privProtocols[strings.ToUpper(t.PrivProtocol)]
+			params.PrivacyPassphrase = t.PrivPassword
+			client.MsgFlags = gosnmp.AuthPriv
+		}
+		client.SecurityParameters = params
+	}
+
+	return client, nil
+}
+
+func isNumericOID(oid string) bool {
+	parts := strings.Split(strings.TrimPrefix(oid, "."), ".")
+	if len(parts) < 2 {
+		return false
+	}
+
+	for _, part := range parts {
+		if _, err := strconv.ParseUint(part, 10, 32); err != nil {
+			return false
+		}
+	}
+
+	return true
+}
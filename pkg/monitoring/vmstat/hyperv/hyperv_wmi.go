@@ -18,6 +18,11 @@ import (
 var guestNetworkRegexp *regexp.Regexp
 
 func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "virt.hyper-v.list", ConfigOption: "virtual_machines_stat"},
+		common.MetricDescriptor{Key: "virt.hyper-v.cpu_wait_time_per_dispatch_total_ns", Unit: "ns", ConfigOption: "virtual_machines_stat"},
+	)
+
 	guestNetworkRegexp = regexp.MustCompile(`^Microsoft:GuestNetwork\\(\w{8}-\w{4}-\w{4}-\w{4}-\w{12})\\(\w{8}-\w{4}-\w{4}-\w{4}-\w{12})$`)
 }
 
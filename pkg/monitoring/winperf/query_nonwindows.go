@@ -0,0 +1,12 @@
+// +build !windows
+
+package winperf
+
+import (
+	"errors"
+)
+
+// NewQuery fails as the performance counters are not available
+func NewQuery() (Query, error) {
+	return nil, errors.New("performance counters are supported on Windows only")
+}
@@ -0,0 +1,68 @@
+// +build windows
+
+package winperf
+
+import (
+	"fmt"
+
+	"github.com/cloudradar-monitoring/cagent/perfcounters"
+)
+
+// pdhQuery reads the counters using pdh.dll
+type pdhQuery struct {
+	query   perfcounters.PerformanceQuery
+	handles map[string]perfcounters.PDH_HCOUNTER
+}
+
+// NewQuery opens a PDH query
+func NewQuery() (Query, error) {
+	query := &perfcounters.PerformanceQueryImpl{}
+	if err := query.Open(); err != nil {
+		return nil, fmt.Errorf("failed to open performance query: %s", err.Error())
+	}
+
+	return &pdhQuery{
+		query:   query,
+		handles: make(map[string]perfcounters.PDH_HCOUNTER),
+	}, nil
+}
+
+func (q *pdhQuery) AddCounter(path string) error {
+	var handle perfcounters.PDH_HCOUNTER
+	var err error
+
+	// Systems from Vista onward support English counter names independent from the OS language
+	if q.query.IsVistaOrNewer() {
+		handle, err = q.query.AddEnglishCounterToQuery(path)
+	} else {
+		handle, err = q.query.AddCounterToQuery(path)
+	}
+	if err != nil {
+		return err
+	}
+
+	q.handles[path] = handle
+	return nil
+}
+
+func (q *pdhQuery) Collect() (map[string][]Value, error) {
+	if err := q.query.CollectData(); err != nil {
+		return nil, err
+	}
+
+	values := make(map[string][]Value, len(q.handles))
+	for path, handle := range q.handles {
+		counterValues, err := q.query.GetFormattedCounterArrayDouble(handle)
+		if err != nil {
+			// e.g. no instances of a wildcard counter are running at the moment
+			log.WithError(err).Debugf("failed to get the value of counter '%s'", path)
+			continue
+		}
+
+		for _, v := range counterValues {
+			values[path] = append(values[path], Value{Instance: v.InstanceName, Value: v.Value})
+		}
+	}
+
+	return values, nil
+}
@@ -0,0 +1,133 @@
+package winperf
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+var log = logrus.WithField("package", "winperf")
+
+// counterPathRegex matches counter paths like \Processor(_Total)\% Processor Time or \\host\Memory\Available Bytes
+var counterPathRegex = regexp.MustCompile(`^(\\\\[^\\]+)?\\([^\\(]+)(\(([^\\]+)\))?\\([^\\]+)$`)
+
+var nonAlphanumericRegex = regexp.MustCompile(`[^a-z0-9]+`)
+
+// Value is a formatted value of a counter instance
+type Value struct {
+	// Instance is the instance name, empty for counters of single-instance objects
+	Instance string
+	Value    float64
+}
+
+// Query is the PDH layer the counters are read from
+type Query interface {
+	// AddCounter adds the counter to the query, fails if the counter does not exist
+	AddCounter(path string) error
+	// Collect samples all added counters and returns their values by path
+	Collect() (map[string][]Value, error)
+}
+
+// Collector reads the configured Windows performance counters
+type Collector struct {
+	query Query
+	paths []string
+}
+
+// ValidateCounterPath checks the syntax of the counter path. Wildcards are supported for instances only
+func ValidateCounterPath(path string) error {
+	parts := counterPathRegex.FindStringSubmatch(path)
+	if parts == nil {
+		return fmt.Errorf("invalid counter path '%s', expected format is \\Object(Instance)\\Counter or \\Object\\Counter", path)
+	}
+
+	if strings.Contains(parts[2], "*") || strings.Contains(parts[5], "*") {
+		return fmt.Errorf("invalid counter path '%s', wildcards are supported for instances only", path)
+	}
+
+	return nil
+}
+
+// New registers the counters in the PDH query. Counters which can't be added are reported in the returned error
+// and skipped, the collector is returned anyway
+func New(paths []string) (*Collector, error) {
+	query, err := NewQuery()
+	if err != nil {
+		return nil, err
+	}
+
+	return newCollector(query, paths)
+}
+
+func newCollector(query Query, paths []string) (*Collector, error) {
+	c := &Collector{query: query}
+
+	errs := common.ErrorCollector{}
+	for _, path := range paths {
+		if err := query.AddCounter(path); err != nil {
+			errs.Add(fmt.Errorf("failed to add counter '%s': %s", path, err.Error()))
+			continue
+		}
+		c.paths = append(c.paths, path)
+	}
+
+	if len(c.paths) > 0 {
+		// rate counters like % Processor Time need two samples, take the first one now
+		// so they have a value in the first collection
+		if _, err := query.Collect(); err != nil {
+			log.WithError(err).Debug("failed to take the initial sample")
+		}
+	}
+
+	return c, errs.Combine()
+}
+
+// GetMeasurements returns the counter values by their sanitized paths, see MetricName.
+// Counters with wildcard instances are reported for every instance
+func (c *Collector) GetMeasurements() (common.MeasurementsMap, error) {
+	if len(c.paths) == 0 {
+		return nil, nil
+	}
+
+	values, err := c.query.Collect()
+	if err != nil {
+		return nil, err
+	}
+
+	results := common.MeasurementsMap{}
+	for _, path := range c.paths {
+		counterValues := values[path]
+		if !strings.Contains(path, "*") {
+			if len(counterValues) > 0 {
+				results[MetricName(path)] = counterValues[0].Value
+			}
+			continue
+		}
+
+		for _, v := range counterValues {
+			results[MetricName(instancePath(path, v.Instance))] = v.Value
+		}
+	}
+
+	return results, nil
+}
+
+// instancePath replaces the wildcard instance of the path with the instance name
+func instancePath(path string, instance string) string {
+	parts := counterPathRegex.FindStringSubmatchIndex(path)
+	if parts == nil || parts[8] < 0 {
+		return path
+	}
+
+	return path[:parts[8]] + instance + path[parts[9]:]
+}
+
+// MetricName converts the counter path to the metric name, e.g.
+// \Processor(_Total)\% Processor Time to processor_total_processor_time
+func MetricName(path string) string {
+	return strings.Trim(nonAlphanumericRegex.ReplaceAllString(strings.ToLower(path), "_"), "_")
+}
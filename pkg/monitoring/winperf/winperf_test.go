@@ -0,0 +1,98 @@
+package winperf
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+type fakeQuery struct {
+	values   map[string][]Value
+	added    []string
+	collects int
+}
+
+func (q *fakeQuery) AddCounter(path string) error {
+	if _, exists := q.values[path]; !exists {
+		return errors.New("the specified counter could not be found")
+	}
+
+	q.added = append(q.added, path)
+	return nil
+}
+
+func (q *fakeQuery) Collect() (map[string][]Value, error) {
+	q.collects++
+	return q.values, nil
+}
+
+func TestCollectorGetMeasurements(t *testing.T) {
+	query := &fakeQuery{values: map[string][]Value{
+		`\Processor(_Total)\% Processor Time`: {{Instance: "_Total", Value: 12.5}},
+		`\Memory\Available Bytes`:             {{Value: 1048576}},
+		`\LogicalDisk(*)\Avg. Disk Queue Length`: {
+			{Instance: "C:", Value: 0.5},
+			{Instance: "D:", Value: 2},
+			{Instance: "_Total", Value: 2.5},
+		},
+	}}
+
+	c, err := newCollector(query, []string{
+		`\Processor(_Total)\% Processor Time`,
+		`\LogicalDisk(*)\Avg. Disk Queue Length`,
+		`\Memory\Available Bytes`,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, query.collects, "initial sample is taken")
+
+	results, err := c.GetMeasurements()
+	require.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{
+		"processor_total_processor_time":          12.5,
+		"memory_available_bytes":                  float64(1048576),
+		"logicaldisk_c_avg_disk_queue_length":     0.5,
+		"logicaldisk_d_avg_disk_queue_length":     float64(2),
+		"logicaldisk_total_avg_disk_queue_length": 2.5,
+	}, results)
+}
+
+func TestCollectorInvalidCounter(t *testing.T) {
+	query := &fakeQuery{values: map[string][]Value{
+		`\Memory\Available Bytes`: {{Value: 1024}},
+	}}
+
+	c, err := newCollector(query, []string{`\Memory\Not Existing`, `\Memory\Available Bytes`})
+	assert.EqualError(t, err, `failed to add counter '\Memory\Not Existing': the specified counter could not be found`)
+	require.NotNil(t, c)
+	assert.Equal(t, []string{`\Memory\Available Bytes`}, query.added)
+
+	results, err := c.GetMeasurements()
+	require.NoError(t, err)
+	assert.Equal(t, common.MeasurementsMap{"memory_available_bytes": float64(1024)}, results)
+}
+
+func TestValidateCounterPath(t *testing.T) {
+	for _, path := range []string{
+		`\Processor(_Total)\% Processor Time`,
+		`\LogicalDisk(*)\Avg. Disk Queue Length`,
+		`\Process(svchost#1)\Working Set`,
+		`\Memory\Available Bytes`,
+		`\\server01\Memory\Available Bytes`,
+	} {
+		assert.NoError(t, ValidateCounterPath(path), path)
+	}
+
+	for _, path := range []string{
+		``,
+		`Memory\Available Bytes`,
+		`\Memory`,
+		`\Memory\Available Bytes\`,
+		`\Processor(_Total)\*`,
+	} {
+		assert.Error(t, ValidateCounterPath(path), path)
+	}
+}
@@ -0,0 +1,30 @@
+package osinfo
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// EntropyAvail returns the number of bits of entropy available in the kernel random pool. Linux only
+func EntropyAvail() (int, error) {
+	return readEntropyAvail(common.HostProc("sys/kernel/random/entropy_avail"))
+}
+
+func readEntropyAvail(path string) (int, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+
+	avail, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return 0, errors.Wrapf(err, "could not parse %s", path)
+	}
+
+	return avail, nil
+}
@@ -0,0 +1,148 @@
+package osinfo
+
+import (
+	"context"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const dpkgKernelPackagePrefix = "linux-image-"
+
+// KernelRebootRequired reports whether a kernel newer than the running one is installed.
+// Installed kernels are listed using dpkg or rpm, running is the release of the running kernel as reported by uname -r
+func KernelRebootRequired(ctx context.Context, invoker common.Invoker, running string) (bool, error) {
+	installed, err := installedKernels(ctx, invoker)
+	if err != nil {
+		return false, err
+	}
+
+	for _, version := range installed {
+		// e.g. lowlatency kernels installed next to the generic ones don't replace the running one
+		if kernelFlavor(version) != kernelFlavor(running) {
+			continue
+		}
+
+		if compareKernelVersions(version, running) > 0 {
+			return true, nil
+		}
+	}
+
+	return false, nil
+}
+
+// installedKernels returns the releases of the installed kernel packages in uname -r format
+func installedKernels(ctx context.Context, invoker common.Invoker) ([]string, error) {
+	out, dpkgErr := invoker.CommandWithContext(ctx, "dpkg-query", "-W", "-f=${Package} ${Status}\n", dpkgKernelPackagePrefix+"[0-9]*")
+	if dpkgErr == nil {
+		return parseDpkgKernels(string(out)), nil
+	}
+
+	// rpm exits with an error if one of the packages is not installed, e.g. kernel-core on CentOS 7
+	out, rpmErr := invoker.CommandWithContext(ctx, "rpm", "-q", "--qf", "%{VERSION}-%{RELEASE}.%{ARCH}\n", "kernel", "kernel-core")
+	if versions := parseRPMKernels(string(out)); len(versions) > 0 {
+		return versions, nil
+	}
+
+	return nil, errors.Errorf("could not list installed kernels: dpkg-query: %v, rpm: %v", dpkgErr, rpmErr)
+}
+
+func parseDpkgKernels(out string) []string {
+	var versions []string
+	for _, line := range strings.Split(out, "\n") {
+		fields := strings.Fields(line)
+		// removed packages with config files left have "deinstall ok config-files" status
+		if len(fields) != 4 || fields[3] != "installed" || !strings.HasPrefix(fields[0], dpkgKernelPackagePrefix) {
+			continue
+		}
+
+		versions = append(versions, strings.TrimPrefix(fields[0], dpkgKernelPackagePrefix))
+	}
+
+	return versions
+}
+
+func parseRPMKernels(out string) []string {
+	var versions []string
+	for _, line := range strings.Split(out, "\n") {
+		line = strings.TrimSpace(line)
+		// skip "package kernel-core is not installed" messages
+		if line == "" || !unicode.IsDigit(rune(line[0])) {
+			continue
+		}
+
+		versions = append(versions, line)
+	}
+
+	return versions
+}
+
+// kernelFlavor returns the flavor suffix of Debian kernel releases, e.g. "generic" for 5.15.0-91-generic
+func kernelFlavor(version string) string {
+	i := strings.LastIndex(version, "-")
+	if i < 0 || strings.IndexFunc(version[i+1:], unicode.IsDigit) >= 0 {
+		return ""
+	}
+
+	return version[i+1:]
+}
+
+// compareKernelVersions compares kernel releases like 5.15.0-91-generic or 3.10.0-1160.el7.x86_64.
+// Numeric parts are compared as numbers, the others lexicographically. Returns a positive number if a is newer
+func compareKernelVersions(a, b string) int {
+	partsA, partsB := splitVersion(a), splitVersion(b)
+	for i := 0; i < len(partsA) && i < len(partsB); i++ {
+		numA, errA := strconv.ParseUint(partsA[i], 10, 64)
+		numB, errB := strconv.ParseUint(partsB[i], 10, 64)
+
+		switch {
+		case errA == nil && errB == nil:
+			if numA != numB {
+				if numA > numB {
+					return 1
+				}
+				return -1
+			}
+		case errA == nil:
+			// as rpm does, numeric part is newer than alphabetic one, e.g. 1160.2.1.el7 > 1160.el7
+			return 1
+		case errB == nil:
+			return -1
+		case partsA[i] != partsB[i]:
+			return strings.Compare(partsA[i], partsB[i])
+		}
+	}
+
+	return len(partsA) - len(partsB)
+}
+
+// splitVersion splits version into the runs of digits and letters, other characters are separators
+func splitVersion(version string) []string {
+	var parts []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			parts = append(parts, string(current))
+			current = current[:0]
+		}
+	}
+
+	for _, r := range version {
+		switch {
+		case !unicode.IsDigit(r) && !unicode.IsLetter(r):
+			flush()
+		case len(current) > 0 && unicode.IsDigit(r) != unicode.IsDigit(current[0]):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+	}
+	flush()
+
+	return parts
+}
@@ -0,0 +1,81 @@
+package osinfo
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeInvoker map[string]string
+
+func (f fakeInvoker) CommandWithContext(_ context.Context, name string, _ ...string) ([]byte, error) {
+	out, exists := f[name]
+	if !exists {
+		return nil, errors.New(name + ": command not found")
+	}
+
+	return []byte(out), nil
+}
+
+func TestParseOSRelease(t *testing.T) {
+	data, err := ioutil.ReadFile(filepath.Join("testdata", "os-release"))
+	require.NoError(t, err)
+
+	assert.Equal(t, &Distro{Name: "Ubuntu", Version: "22.04"}, parseOSRelease(string(data)))
+	assert.Equal(t, &Distro{Name: "Arch Linux"}, parseOSRelease("NAME='Arch Linux'\nBUILD_ID=rolling\n"))
+}
+
+func TestKernelRebootRequiredDpkg(t *testing.T) {
+	invoker := fakeInvoker{"dpkg-query": "linux-image-5.15.0-88-generic deinstall ok config-files\n" +
+		"linux-image-5.15.0-91-generic install ok installed\n" +
+		"linux-image-5.15.0-100-generic install ok installed\n" +
+		"linux-image-6.5.0-14-lowlatency install ok installed\n"}
+
+	required, err := KernelRebootRequired(context.Background(), invoker, "5.15.0-91-generic")
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	required, err = KernelRebootRequired(context.Background(), invoker, "5.15.0-100-generic")
+	assert.NoError(t, err)
+	assert.False(t, required, "kernels of other flavors are ignored")
+}
+
+func TestKernelRebootRequiredRPM(t *testing.T) {
+	invoker := fakeInvoker{"rpm": "package kernel is not installed\n" +
+		"5.14.0-362.8.1.el9_3.x86_64\n" +
+		"5.14.0-362.13.1.el9_3.x86_64\n"}
+
+	required, err := KernelRebootRequired(context.Background(), invoker, "5.14.0-362.8.1.el9_3.x86_64")
+	assert.NoError(t, err)
+	assert.True(t, required)
+
+	required, err = KernelRebootRequired(context.Background(), invoker, "5.14.0-362.13.1.el9_3.x86_64")
+	assert.NoError(t, err)
+	assert.False(t, required)
+}
+
+func TestKernelRebootRequiredNoPackageManager(t *testing.T) {
+	_, err := KernelRebootRequired(context.Background(), fakeInvoker{}, "5.15.0-91-generic")
+	assert.Error(t, err)
+}
+
+func TestCompareKernelVersions(t *testing.T) {
+	assert.Equal(t, 0, compareKernelVersions("5.15.0-91-generic", "5.15.0-91-generic"))
+	assert.True(t, compareKernelVersions("5.15.0-100-generic", "5.15.0-91-generic") > 0)
+	assert.True(t, compareKernelVersions("3.10.0-1160.el7.x86_64", "3.10.0-1160.2.1.el7.x86_64") < 0)
+	assert.True(t, compareKernelVersions("6.1.0", "5.19.17") > 0)
+}
+
+func TestReadEntropyAvail(t *testing.T) {
+	avail, err := readEntropyAvail(filepath.Join("testdata", "entropy_avail"))
+	require.NoError(t, err)
+	assert.Equal(t, 3527, avail)
+
+	_, err = readEntropyAvail(filepath.Join("testdata", "not-existing"))
+	assert.Error(t, err)
+}
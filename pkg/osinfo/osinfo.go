@@ -1,6 +1,9 @@
 package osinfo
 
 import (
+	"strconv"
+	"strings"
+
 	"github.com/pkg/errors"
 )
 
@@ -11,3 +14,40 @@ var (
 func GetOsName() (string, error) {
 	return osName()
 }
+
+// Distro holds the name and version of the OS distribution, e.g. "Ubuntu" and "22.04"
+type Distro struct {
+	Name    string
+	Version string
+}
+
+func GetDistro() (*Distro, error) {
+	return distro()
+}
+
+// parseOSRelease reads NAME and VERSION_ID of os-release(5) file
+func parseOSRelease(data string) *Distro {
+	d := &Distro{}
+	for _, line := range strings.Split(data, "\n") {
+		parts := strings.SplitN(strings.TrimSpace(line), "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+
+		value := parts[1]
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, `'"`)
+		}
+
+		switch parts[0] {
+		case "NAME":
+			d.Name = value
+		case "VERSION_ID":
+			d.Version = value
+		}
+	}
+
+	return d
+}
@@ -135,6 +135,15 @@ func osName() (string, error) {
 	return "", ErrUnknownOSType
 }
 
+func distro() (*Distro, error) {
+	data, err := ioutil.ReadFile(osReleaseFile)
+	if err != nil {
+		return nil, errors.Wrapf(err, "osinfo: couldn't read release info from \"%s\"", osReleaseFile)
+	}
+
+	return parseOSRelease(string(data)), nil
+}
+
 func prettyID(id string) string {
 	switch id {
 	case "debian":
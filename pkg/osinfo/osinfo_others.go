@@ -6,6 +6,7 @@ import (
 	"os/exec"
 
 	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/host"
 
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
@@ -24,3 +25,12 @@ func osName() (string, error) {
 
 	return string(data), nil
 }
+
+func distro() (*Distro, error) {
+	info, err := host.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Distro{Name: info.Platform, Version: info.PlatformVersion}, nil
+}
@@ -12,3 +12,12 @@ func osName() (string, error) {
 	}
 	return info.Platform + " " + info.PlatformVersion + " " + info.PlatformFamily, nil
 }
+
+func distro() (*Distro, error) {
+	info, err := host.Info()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Distro{Name: info.Platform, Version: info.PlatformVersion}, nil
+}
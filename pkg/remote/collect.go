@@ -0,0 +1,166 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring/raid"
+)
+
+var log = logrus.WithField("package", "remote")
+
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "remote.<target>.cpu.load.avg.<avg>", ConfigOption: "remote_targets"},
+		common.MetricDescriptor{Key: "remote.<target>.fs.total_B.<mount>", Unit: "B", ConfigOption: "remote_targets"},
+		common.MetricDescriptor{Key: "remote.<target>.fs.free_B.<mount>", Unit: "B", ConfigOption: "remote_targets"},
+		common.MetricDescriptor{Key: "remote.<target>.fs.used_percent.<mount>", Unit: "%", ConfigOption: "remote_targets"},
+		common.MetricDescriptor{Key: "remote.<target>.fs.free_percent.<mount>", Unit: "%", ConfigOption: "remote_targets"},
+		common.MetricDescriptor{Key: "remote.<target>.modules", ConfigOption: "remote_targets"},
+	)
+}
+
+const (
+	connectTimeout = 10 * time.Second
+	commandTimeout = 10 * time.Second
+)
+
+// CollectTargets connects to the targets in parallel and collects their measurements keyed by Target.Key()
+func CollectTargets(targets []Target) (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, target := range targets {
+		wg.Add(1)
+		go func(target Target) {
+			defer wg.Done()
+
+			measurements, err := collectTarget(target)
+
+			mu.Lock()
+			defer mu.Unlock()
+			results[target.Key()] = measurements
+			if err != nil {
+				log.WithError(err).Errorf("failed to collect measurements of %s", target.Key())
+				errs.Add(fmt.Errorf("remote %s: %s", target.Key(), err.Error()))
+			}
+		}(target)
+	}
+	wg.Wait()
+
+	return results, errs.Combine()
+}
+
+func collectTarget(target Target) (common.MeasurementsMap, error) {
+	invoker, err := Dial(target, connectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	defer invoker.Close()
+
+	return Collect(invoker, target.procPath())
+}
+
+// Collect gathers load average, file systems usage and software RAID health using the invoker.
+// procPath is the location of the proc filesystem on the host
+func Collect(invoker common.Invoker, procPath string) (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{}
+	errs := common.ErrorCollector{}
+
+	loadAvg, err := run(invoker, "cat", path.Join(procPath, "loadavg"))
+	if err == nil {
+		var load common.MeasurementsMap
+		load, err = parseLoadAvg(loadAvg)
+		results = results.AddWithPrefix("cpu.", load)
+	}
+	errs.Add(err)
+
+	df, err := run(invoker, "df", "-kP")
+	if err == nil {
+		results = results.AddWithPrefix("fs.", parseDf(df))
+	}
+	errs.Add(err)
+
+	reports, err := raid.CreateRemoteModule(invoker, path.Join(procPath, "mdstat")).Run()
+	errs.Add(err)
+	if len(reports) > 0 {
+		results["modules"] = reports
+	}
+
+	return results, errs.Combine()
+}
+
+func run(invoker common.Invoker, name string, arg ...string) (string, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), commandTimeout)
+	defer cancel()
+
+	out, err := invoker.CommandWithContext(ctx, name, arg...)
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %s", name, strings.Join(arg, " "), err.Error())
+	}
+
+	return string(out), nil
+}
+
+// parseLoadAvg parses /proc/loadavg e.g. "0.20 0.18 0.12 1/80 11206"
+func parseLoadAvg(data string) (common.MeasurementsMap, error) {
+	fields := strings.Fields(data)
+	if len(fields) < 3 {
+		return nil, fmt.Errorf("unexpected loadavg format: '%s'", strings.TrimSpace(data))
+	}
+
+	results := common.MeasurementsMap{}
+	for i, period := range []string{"1", "5", "15"} {
+		v, err := strconv.ParseFloat(fields[i], 64)
+		if err != nil {
+			return nil, fmt.Errorf("unexpected loadavg format: '%s'", strings.TrimSpace(data))
+		}
+		results["load.avg."+period] = v
+	}
+
+	return results, nil
+}
+
+// parseDf parses the POSIX output of 'df -kP':
+// Filesystem     1024-blocks    Used Available Capacity Mounted on
+// /dev/sda1         20509264 9316200  10128104      48% /
+func parseDf(data string) common.MeasurementsMap {
+	results := common.MeasurementsMap{}
+
+	lines := strings.Split(data, "\n")
+	for _, line := range lines[1:] {
+		fields := strings.Fields(line)
+		if len(fields) < 6 {
+			continue
+		}
+
+		total, err1 := strconv.ParseUint(fields[1], 10, 64)
+		used, err2 := strconv.ParseUint(fields[2], 10, 64)
+		available, err3 := strconv.ParseUint(fields[3], 10, 64)
+		if err1 != nil || err2 != nil || err3 != nil || total == 0 || used+available == 0 {
+			// pseudo file systems
+			continue
+		}
+
+		// mountpoint may contain spaces
+		mountpoint := strings.Join(fields[5:], " ")
+		usedPercent := float64(used) / float64(used+available) * 100
+
+		results["total_B."+mountpoint] = total * 1024
+		results["free_B."+mountpoint] = float64(available * 1024)
+		results["used_percent."+mountpoint] = common.RoundToTwoDecimalPlaces(usedPercent)
+		results["free_percent."+mountpoint] = common.RoundToTwoDecimalPlaces(100 - usedPercent)
+	}
+
+	return results
+}
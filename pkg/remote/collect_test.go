@@ -0,0 +1,101 @@
+package remote
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+	"github.com/cloudradar-monitoring/cagent/pkg/monitoring"
+)
+
+// fakeSSHInvoker returns fixture outputs for the commands as they would be run on the remote host
+type fakeSSHInvoker map[string]string
+
+func (f fakeSSHInvoker) CommandWithContext(_ context.Context, name string, arg ...string) ([]byte, error) {
+	cmd := strings.Join(append([]string{name}, arg...), " ")
+	out, exists := f[cmd]
+	if !exists {
+		return nil, errors.New(cmd + ": command not found")
+	}
+	return []byte(out), nil
+}
+
+const mdstatRecovery = `Personalities : [raid1]
+md0 : active raid1 sdb1[2] sda1[0]
+      976630464 blocks super 1.2 [2/1] [U_]
+      [==>..................]  recovery = 12.6% (123456789/976630464) finish=87.3min speed=162840K/sec
+
+unused devices: <none>
+`
+
+const dfOutput = `Filesystem     1024-blocks    Used Available Capacity Mounted on
+/dev/sda1         20509264 9316200  10128104      48% /
+tmpfs                    0       0         0       -  /dev/shm
+//nas/share       1000000  250000    750000      25% /mnt/nas share
+`
+
+func TestCollect(t *testing.T) {
+	invoker := fakeSSHInvoker{
+		"cat /host/proc/loadavg": "0.20 0.18 0.12 1/80 11206\n",
+		"cat /host/proc/mdstat":  mdstatRecovery,
+		"df -kP":                 dfOutput,
+	}
+
+	results, err := Collect(invoker, "/host/proc")
+	assert.NoError(t, err)
+
+	assert.Equal(t, 0.2, results["cpu.load.avg.1"])
+	assert.Equal(t, 0.18, results["cpu.load.avg.5"])
+	assert.Equal(t, 0.12, results["cpu.load.avg.15"])
+
+	assert.Equal(t, uint64(20509264*1024), results["fs.total_B./"])
+	assert.Equal(t, float64(10128104*1024), results["fs.free_B./"])
+	assert.Equal(t, 47.91, results["fs.used_percent./"])
+	assert.Equal(t, 25.0, results["fs.used_percent./mnt/nas share"])
+	assert.NotContains(t, results, "fs.total_B./dev/shm")
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddInnerWithPrefix("remote", common.MeasurementsMap{"web1": results})))
+
+	reports, ok := results["modules"].([]*monitoring.ModuleReport)
+	if assert.True(t, ok) && assert.Len(t, reports, 1) {
+		assert.Equal(t, []monitoring.Warning{"Raid md0 rebuilding."}, reports[0].Warnings)
+		assert.Contains(t, reports[0].Name, "/host/proc/mdstat")
+	}
+}
+
+func TestCollectWithoutMdstat(t *testing.T) {
+	invoker := fakeSSHInvoker{
+		"cat /proc/loadavg": "1.00 0.50 0.25 2/100 123\n",
+		"df -kP":            dfOutput,
+	}
+
+	results, err := Collect(invoker, defaultProcPath)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, results["cpu.load.avg.1"])
+	assert.NotContains(t, results, "modules")
+}
+
+func TestTargetValidate(t *testing.T) {
+	target := Target{Host: "appliance.local", User: "monitor", Password: "secret", KnownHostsFile: "/etc/cagent/known_hosts"}
+	assert.NoError(t, target.Validate())
+	assert.Equal(t, "appliance.local", target.Key())
+	assert.Equal(t, "appliance.local:22", target.address())
+
+	target.Host = "10.0.0.5:2222"
+	assert.Equal(t, "10.0.0.5", target.Key())
+	assert.Equal(t, "10.0.0.5:2222", target.address())
+
+	target.Name = "switch-1"
+	assert.Equal(t, "switch-1", target.Key())
+
+	target.Password = ""
+	assert.Error(t, target.Validate())
+}
+
+func TestShellCommand(t *testing.T) {
+	assert.Equal(t, `cat '/proc/mdstat'`, shellCommand("cat", "/proc/mdstat"))
+	assert.Equal(t, `cat '/it'\''s'`, shellCommand("cat", "/it's"))
+}
@@ -0,0 +1,99 @@
+package remote
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+	"golang.org/x/crypto/ssh/knownhosts"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// SSHInvoker runs commands on a remote host over SSH
+type SSHInvoker struct {
+	client *ssh.Client
+}
+
+var _ common.Invoker = (*SSHInvoker)(nil)
+
+// Dial connects to the target. Invoker must be closed after use
+func Dial(target Target, timeout time.Duration) (*SSHInvoker, error) {
+	var auth []ssh.AuthMethod
+	if target.KeyFile != "" {
+		key, err := ioutil.ReadFile(target.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read key_file: %s", err.Error())
+		}
+
+		signer, err := ssh.ParsePrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse key_file: %s", err.Error())
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+
+	if target.Password != "" {
+		auth = append(auth, ssh.Password(target.Password))
+	}
+
+	hostKeyCallback, err := knownhosts.New(target.KnownHostsFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read known_hosts_file: %s", err.Error())
+	}
+
+	client, err := ssh.Dial("tcp", target.address(), &ssh.ClientConfig{
+		User:            target.User,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         timeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &SSHInvoker{client: client}, nil
+}
+
+func (i *SSHInvoker) CommandWithContext(ctx context.Context, name string, arg ...string) ([]byte, error) {
+	session, err := i.client.NewSession()
+	if err != nil {
+		return nil, err
+	}
+	defer session.Close()
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			// interrupts the running command
+			session.Close()
+		case <-done:
+		}
+	}()
+
+	out, err := session.Output(shellCommand(name, arg...))
+	if ctx.Err() != nil {
+		return out, ctx.Err()
+	}
+
+	return out, err
+}
+
+func (i *SSHInvoker) Close() error {
+	return i.client.Close()
+}
+
+// shellCommand quotes the arguments, as the command is interpreted by the remote shell
+func shellCommand(name string, arg ...string) string {
+	parts := []string{name}
+	for _, a := range arg {
+		parts = append(parts, "'"+strings.ReplaceAll(a, "'", `'\''`)+"'")
+	}
+
+	return strings.Join(parts, " ")
+}
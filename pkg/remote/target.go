@@ -0,0 +1,71 @@
+package remote
+
+import (
+	"errors"
+	"net"
+)
+
+const (
+	defaultSSHPort  = "22"
+	defaultProcPath = "/proc"
+)
+
+// Target is a remote host which is monitored over SSH
+type Target struct {
+	Name           string `toml:"name" comment:"Name used as the key of the host results. host is used if empty"`
+	Host           string `toml:"host" comment:"Host name or IP address with an optional port, e.g. '192.168.1.10:2222'"`
+	User           string `toml:"user" comment:"SSH user name"`
+	Password       string `toml:"password" comment:"SSH password. Can be omitted if key_file is set"`
+	KeyFile        string `toml:"key_file" comment:"Path to the private key file"`
+	KnownHostsFile string `toml:"known_hosts_file" comment:"Path to the known_hosts file used to verify the host key"`
+	ProcPath       string `toml:"proc_path" comment:"Location of the proc filesystem on the host. default '/proc'"`
+}
+
+func (t Target) Validate() error {
+	if t.Host == "" {
+		return errors.New("host must be set")
+	}
+
+	if t.User == "" {
+		return errors.New("user must be set")
+	}
+
+	if t.Password == "" && t.KeyFile == "" {
+		return errors.New("either password or key_file must be set")
+	}
+
+	if t.KnownHostsFile == "" {
+		return errors.New("known_hosts_file must be set to verify the host key")
+	}
+
+	return nil
+}
+
+// Key returns the name of the target used in the results
+func (t Target) Key() string {
+	if t.Name != "" {
+		return t.Name
+	}
+
+	if host, _, err := net.SplitHostPort(t.Host); err == nil {
+		return host
+	}
+
+	return t.Host
+}
+
+func (t Target) address() string {
+	if _, _, err := net.SplitHostPort(t.Host); err == nil {
+		return t.Host
+	}
+
+	return net.JoinHostPort(t.Host, defaultSSHPort)
+}
+
+func (t Target) procPath() string {
+	if t.ProcPath == "" {
+		return defaultProcPath
+	}
+
+	return t.ProcPath
+}
@@ -1,9 +1,17 @@
 package smart
 
 import (
+	"os/exec"
+	"time"
+
 	"github.com/pkg/errors"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
+// smartctl exit status bit set when device open failed, e.g. it is busy or in a low-power mode
+const smartctlExitStatusDeviceOpenFailed = 1 << 1
+
 // Option callback for connection option
 type Option func(*SMART) error
 
@@ -25,3 +33,37 @@ func Executable(val string, tryDefaultOnFail bool) Option {
 		return sm.detectTools(defaultSmartctlPath)
 	}
 }
+
+// HealthOnly makes smartctl to retrieve only the overall health self-assessment of disks instead of all attributes
+func HealthOnly(enabled bool) Option {
+	return func(sm *SMART) error {
+		sm.healthOnly = enabled
+		return nil
+	}
+}
+
+// CommandRetries re-runs smartctl up to retries times with given delay if it failed to open the device
+func CommandRetries(retries int, delay time.Duration) Option {
+	return func(sm *SMART) error {
+		if retries <= 0 {
+			return nil
+		}
+
+		sm.invoker = &common.RetryInvoker{
+			Invoker:     sm.invoker,
+			Retries:     retries,
+			Delay:       delay,
+			IsRetryable: isSmartctlTransientError,
+		}
+
+		return nil
+	}
+}
+
+func isSmartctlTransientError(output []byte, err error) bool {
+	if !common.IsTransientCommandError(output, err) {
+		return false
+	}
+
+	return err.(*exec.ExitError).ExitCode()&smartctlExitStatusDeviceOpenFailed != 0
+}
@@ -0,0 +1,27 @@
+// +build !windows
+
+package smart
+
+import (
+	"os/exec"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsSmartctlTransientError(t *testing.T) {
+	exitErr := func(code string) error {
+		return exec.Command("/bin/sh", "-c", "exit "+code).Run()
+	}
+
+	// device open failed, e.g. device is busy
+	assert.True(t, isSmartctlTransientError([]byte("Smartctl open device: /dev/sda failed: Device busy"), exitErr("2")))
+	// device open failed because of missing permissions
+	assert.False(t, isSmartctlTransientError([]byte("Smartctl open device: /dev/sda failed: Permission denied"), exitErr("2")))
+	// command line did not parse
+	assert.False(t, isSmartctlTransientError(nil, exitErr("1")))
+	// disk failing is not a command failure
+	assert.False(t, isSmartctlTransientError(nil, exitErr("8")))
+	// not installed
+	assert.False(t, isSmartctlTransientError(nil, exitErr("127")))
+}
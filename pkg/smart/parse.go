@@ -1,6 +1,7 @@
 package smart
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"os/exec"
@@ -34,7 +35,10 @@ type ataSMARTAttributes struct {
 		Worst      int    `json:"worst"`
 		Thresh     int    `json:"thresh"`
 		WhenFailed string `json:"when_failed"`
-		Raw        struct {
+		Flags      struct {
+			Prefailure bool `json:"prefailure"`
+		} `json:"flags"`
+		Raw struct {
 			Value  int    `json:"value"`
 			String string `json:"string"`
 		} `json:"raw"`
@@ -151,8 +155,44 @@ type parseResult struct {
 	} `json:"ata_smart_selective_self_test_log"`
 }
 
+// values of the WHEN_FAILED column of the ATA attributes
+const (
+	ataWhenFailedNow  = "now"
+	ataWhenFailedPast = "past"
+)
+
 var smartctlVersionRegexp = regexp.MustCompile(`^smartctl\s(\d.\d)\s(\w|\W)+$`)
 
+func init() {
+	common.RegisterMetrics(
+		common.MetricDescriptor{Key: "smartmon.<disk>.model_name", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.model_family", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.serial_number", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.firmware_version", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.device_type", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.device_protocol", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.type_of", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.rotation_rate", Unit: "rpm", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.interface_speed_Bps", Unit: "B/s", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.temperature_C", Unit: "°C", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.power_on_hours", Unit: "h", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.power_on_time_hours", Unit: "h", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.power_cycle_count", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.smart_status", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.health", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.reallocated_sector_count", ConfigOption: "smart_monitoring"},
+
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.value", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.worst", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.threshold", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.pre_fail", Unit: "bool", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.failing_now", Unit: "bool", ConfigOption: "smart_monitoring"},
+		common.MetricDescriptor{Key: "smartmon.<disk>.<attr>.failed_ever", Unit: "bool", ConfigOption: "smart_monitoring"},
+
+		common.MetricDescriptor{Key: "smartmon.messages", ConfigOption: "smart_monitoring"},
+	)
+}
+
 // Parse detect hardware disks and parse their S.M.A.R.T
 func (sm *SMART) Parse() (common.MeasurementsMap, []error) {
 	rawDisksOutput, err := sm.detectDisks()
@@ -171,7 +211,7 @@ func (sm *SMART) Parse() (common.MeasurementsMap, []error) {
 		errs = append(errs, err)
 	}
 
-	result, parseErrors := smartCtlParse(jsonOutput)
+	result, parseErrors := smartCtlParse(jsonOutput, sm.healthOnly)
 
 	return result, append(errs, parseErrors...)
 }
@@ -181,7 +221,7 @@ func (sm *SMART) smartCtlRun(disks []string) ([]string, error) {
 	var errStr string
 
 	for _, disk := range disks {
-		cmd := sm.smartctlPrepare(disk)
+		name, args := sm.smartctlPrepare(disk)
 
 		var err error
 		var output []byte
@@ -189,7 +229,7 @@ func (sm *SMART) smartCtlRun(disks []string) ([]string, error) {
 		// If all is well with the disk, the exit status (return value) of smartctl is 0 (all bits turned off).
 		// If a problem occurs, or an error, potential error, or fault is detected, then a non-zero status is returned
 		// https://www.smartmontools.org/browser/trunk/smartmontools/smartctl.8.in
-		if output, err = cmd.CombinedOutput(); err != nil {
+		if output, err = sm.invoker.CommandWithContext(context.Background(), name, args...); err != nil {
 			if exitErr, ok := err.(*exec.ExitError); ok {
 				if status, ok := exitErr.Sys().(syscall.WaitStatus); ok && status.ExitStatus() == 1 {
 					var errResult smartErrorResult
@@ -221,7 +261,7 @@ func (sm *SMART) smartCtlRun(disks []string) ([]string, error) {
 	return result, nil
 }
 
-func smartCtlParse(raw []string) (common.MeasurementsMap, []error) {
+func smartCtlParse(raw []string, healthOnly bool) (common.MeasurementsMap, []error) {
 	var parsedDisks []*parseResult
 
 	var errs []error
@@ -240,10 +280,18 @@ func smartCtlParse(raw []string) (common.MeasurementsMap, []error) {
 	for _, disk := range parsedDisks {
 		output := make(map[string]interface{})
 
+		if healthOnly {
+			marshaledDisks[disk.Device.Name] = parseHealth(output, disk)
+			continue
+		}
+
 		diskName := parseBase(output, disk)
 
 		switch disk.Device.Protocol {
 		case "NVMe":
+			if disk.NVMESmartHealthInformationLog != nil {
+				parseNVMeHealthLog(output, disk.NVMESmartHealthInformationLog)
+			}
 		case "ATA":
 			if disk.ATASmartAttributes != nil {
 				parseATAAttributes(output, disk.ATASmartAttributes)
@@ -290,8 +338,7 @@ func smartctlParseVersion(buildStr string) (int, int, error) {
 }
 
 func parseBase(output map[string]interface{}, d *parseResult) string {
-	output["device_type"] = d.Device.Type
-	output["device_protocol"] = d.Device.Protocol
+	parseHealth(output, d)
 	output["model_name"] = d.ModelName
 	output["model_family"] = ""
 	if d.ModelFamily != nil {
@@ -299,18 +346,8 @@ func parseBase(output map[string]interface{}, d *parseResult) string {
 	}
 	output["serial_number"] = d.SerialNumber
 	output["firmware_version"] = d.FirmwareVersion
-	output["power_cycle_count"] = 0
 	if d.PowerCycleCount != nil {
 		output["power_cycle_count"] = *d.PowerCycleCount
-		output["power_cycle_count"] = *d.PowerCycleCount
-	}
-
-	if d.SmartStatus == nil {
-		output["smart_status"] = "NOT AVAILABLE"
-	} else if d.SmartStatus.Passed {
-		output["smart_status"] = "PASSED"
-	} else {
-		output["smart_status"] = "FAILED"
 	}
 
 	if d.Temperature != nil {
@@ -318,6 +355,8 @@ func parseBase(output map[string]interface{}, d *parseResult) string {
 	}
 
 	if d.PowerOnTime != nil {
+		output["power_on_hours"] = d.PowerOnTime.Hours
+		// kept for compatibility, same as power_on_hours
 		output["power_on_time_hours"] = d.PowerOnTime.Hours
 	}
 
@@ -350,11 +389,70 @@ func parseBase(output map[string]interface{}, d *parseResult) string {
 	return d.Device.Name
 }
 
+// parseHealth fills the overall health self-assessment of the disk.
+// health is 1 if the disk passed it, 0 if it failed and nil if the assessment is not available
+func parseHealth(output map[string]interface{}, d *parseResult) map[string]interface{} {
+	output["device_type"] = d.Device.Type
+	output["device_protocol"] = d.Device.Protocol
+
+	if d.SmartStatus == nil {
+		output["smart_status"] = "NOT AVAILABLE"
+		output["health"] = nil
+	} else if d.SmartStatus.Passed {
+		output["smart_status"] = "PASSED"
+		output["health"] = 1
+	} else {
+		output["smart_status"] = "FAILED"
+		output["health"] = 0
+	}
+
+	return output
+}
+
+// parseATAAttributes fills reallocated_sector_count and power_on_hours (attribute 9) and power_cycle_count (attribute 12)
+// if smartctl didn't report the latter two in the device info.
+// For every attribute its normalized value, worst value and threshold are reported as <attr>.value, <attr>.worst and <attr>.threshold,
+// along with <attr>.pre_fail, <attr>.failing_now and <attr>.failed_ever
 func parseATAAttributes(output map[string]interface{}, d *ataSMARTAttributes) {
 	for _, at := range d.Table {
-		if at.ID == 5 {
+		name := ataAttributeKey(at.ID, at.Name)
+		output[name+".value"] = at.Value
+		output[name+".worst"] = at.Worst
+		output[name+".threshold"] = at.Thresh
+		output[name+".pre_fail"] = at.Flags.Prefailure
+		// threshold 0 means the attribute never fails
+		output[name+".failing_now"] = at.WhenFailed == ataWhenFailedNow || (at.Thresh > 0 && at.Value <= at.Thresh)
+		output[name+".failed_ever"] = at.WhenFailed == ataWhenFailedNow || at.WhenFailed == ataWhenFailedPast || (at.Thresh > 0 && at.Worst <= at.Thresh)
+
+		switch at.ID {
+		case 5:
 			output["reallocated_sector_count"] = at.Raw.Value
-			break
+		case 9:
+			setIfMissing(output, "power_on_hours", at.Raw.Value)
+		case 12:
+			setIfMissing(output, "power_cycle_count", at.Raw.Value)
 		}
 	}
 }
+
+// ataAttributeKey returns the lower-cased smartctl name of the attribute, attribute_<id> for the unnamed ones
+func ataAttributeKey(id int, name string) string {
+	if name == "" || strings.EqualFold(name, "Unknown_Attribute") {
+		return fmt.Sprintf("attribute_%d", id)
+	}
+
+	return strings.ToLower(name)
+}
+
+// parseNVMeHealthLog fills power_on_hours and power_cycle_count from the NVMe SMART log
+// if smartctl didn't report them in the device info
+func parseNVMeHealthLog(output map[string]interface{}, d *nvmeSmartHealthInformationLog) {
+	setIfMissing(output, "power_on_hours", d.PowerOnHours)
+	setIfMissing(output, "power_cycle_count", d.PowerCycles)
+}
+
+func setIfMissing(output map[string]interface{}, key string, value interface{}) {
+	if _, exists := output[key]; !exists {
+		output[key] = value
+	}
+}
@@ -0,0 +1,173 @@
+package smart
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const smartctlHealthPassedOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 0},
+  "device": {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+  "smart_status": {"passed": true}
+}`
+
+const smartctlHealthFailedOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 8},
+  "device": {"name": "/dev/sdb", "info_name": "/dev/sdb [SAT]", "type": "sat", "protocol": "ATA"},
+  "smart_status": {"passed": false}
+}`
+
+const smartctlHealthUnknownOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 4},
+  "device": {"name": "/dev/sdc", "info_name": "/dev/sdc", "type": "scsi", "protocol": "SCSI"}
+}`
+
+func TestSmartCtlParseHealth(t *testing.T) {
+	result, errs := smartCtlParse([]string{smartctlHealthPassedOutput, smartctlHealthFailedOutput, smartctlHealthUnknownOutput}, true)
+	assert.Empty(t, errs)
+
+	assert.Equal(t, map[string]interface{}{
+		"device_type":     "sat",
+		"device_protocol": "ATA",
+		"smart_status":    "PASSED",
+		"health":          1,
+	}, result["/dev/sda"])
+
+	assert.Equal(t, 0, result["/dev/sdb"].(map[string]interface{})["health"])
+	assert.Equal(t, "FAILED", result["/dev/sdb"].(map[string]interface{})["smart_status"])
+
+	health, exists := result["/dev/sdc"].(map[string]interface{})["health"]
+	assert.True(t, exists)
+	assert.Nil(t, health)
+
+	// health is reported along with all the attributes as well
+	result, errs = smartCtlParse([]string{smartctlHealthPassedOutput, smartctlHealthFailedOutput}, false)
+	assert.Empty(t, errs)
+	assert.Equal(t, 1, result["/dev/sda"].(map[string]interface{})["health"])
+	assert.Equal(t, 0, result["/dev/sdb"].(map[string]interface{})["health"])
+}
+
+const smartctlATAOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 0},
+  "device": {"name": "/dev/sda", "info_name": "/dev/sda [SAT]", "type": "sat", "protocol": "ATA"},
+  "model_name": "WDC WD40EFRX-68N32N0",
+  "smart_status": {"passed": true},
+  "rotation_rate": 5400,
+  "ata_smart_attributes": {
+    "revision": 16,
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 200, "worst": 200, "thresh": 140, "when_failed": "", "raw": {"value": 0, "string": "0"}},
+      {"id": 9, "name": "Power_On_Hours", "value": 62, "worst": 62, "thresh": 0, "when_failed": "", "raw": {"value": 28112, "string": "28112"}},
+      {"id": 12, "name": "Power_Cycle_Count", "value": 100, "worst": 100, "thresh": 0, "when_failed": "", "raw": {"value": 47, "string": "47"}}
+    ]
+  }
+}`
+
+const smartctlNVMeOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 0},
+  "device": {"name": "/dev/nvme0", "info_name": "/dev/nvme0", "type": "nvme", "protocol": "NVMe"},
+  "model_name": "Samsung SSD 970 EVO Plus 500GB",
+  "smart_status": {"passed": true},
+  "nvme_smart_health_information_log": {
+    "critical_warning": 0,
+    "temperature": 38,
+    "available_spare": 100,
+    "percentage_used": 1,
+    "power_cycles": 312,
+    "power_on_hours": 4021,
+    "unsafe_shutdowns": 19,
+    "media_errors": 0
+  }
+}`
+
+func TestSmartCtlParsePowerOn(t *testing.T) {
+	result, errs := smartCtlParse([]string{smartctlATAOutput, smartctlNVMeOutput}, false)
+	assert.Empty(t, errs)
+
+	ata := result["/dev/sda"].(map[string]interface{})
+	assert.Equal(t, 28112, ata["power_on_hours"])
+	assert.Equal(t, 47, ata["power_cycle_count"])
+	assert.Equal(t, 0, ata["reallocated_sector_count"])
+
+	nvme := result["/dev/nvme0"].(map[string]interface{})
+	assert.Equal(t, 4021, nvme["power_on_hours"])
+	assert.Equal(t, 312, nvme["power_cycle_count"])
+
+	// the values of the device info take precedence over the attributes
+	withDeviceInfo := strings.Replace(smartctlATAOutput, `"rotation_rate": 5400,`, `"rotation_rate": 5400, "power_on_time": {"hours": 28113}, "power_cycle_count": 48,`, 1)
+	result, errs = smartCtlParse([]string{withDeviceInfo}, false)
+	assert.Empty(t, errs)
+	ata = result["/dev/sda"].(map[string]interface{})
+	assert.Equal(t, 28113, ata["power_on_hours"])
+	assert.Equal(t, 48, ata["power_cycle_count"])
+
+	// not reported by the drive
+	result, errs = smartCtlParse([]string{smartctlHealthPassedOutput}, false)
+	assert.Empty(t, errs)
+	assert.NotContains(t, result["/dev/sda"], "power_on_hours")
+	assert.NotContains(t, result["/dev/sda"], "power_cycle_count")
+}
+
+const smartctlATAFailingOutput = `{
+  "json_format_version": [1, 0],
+  "smartctl": {"version": [7, 1], "exit_status": 8},
+  "device": {"name": "/dev/sdb", "info_name": "/dev/sdb [SAT]", "type": "sat", "protocol": "ATA"},
+  "model_name": "ST2000DM001-1CH164",
+  "smart_status": {"passed": false},
+  "rotation_rate": 7200,
+  "ata_smart_attributes": {
+    "revision": 10,
+    "table": [
+      {"id": 5, "name": "Reallocated_Sector_Ct", "value": 8, "worst": 8, "thresh": 10, "when_failed": "now",
+       "flags": {"value": 51, "string": "PO--CK ", "prefailure": true}, "raw": {"value": 3912, "string": "3912"}},
+      {"id": 10, "name": "Spin_Retry_Count", "value": 100, "worst": 96, "thresh": 97, "when_failed": "past",
+       "flags": {"value": 19, "string": "PO--C- ", "prefailure": true}, "raw": {"value": 0, "string": "0"}},
+      {"id": 187, "name": "Reported_Uncorrect", "value": 1, "worst": 1, "thresh": 0, "when_failed": "",
+       "flags": {"value": 50, "string": "-O--CK ", "prefailure": false}, "raw": {"value": 1830, "string": "1830"}},
+      {"id": 190, "name": "Unknown_Attribute", "value": 65, "worst": 52, "thresh": 45, "when_failed": "",
+       "flags": {"value": 34, "string": "-O---K ", "prefailure": false}, "raw": {"value": 35, "string": "35"}}
+    ]
+  }
+}`
+
+func TestSmartCtlParseATAAttributeThresholds(t *testing.T) {
+	result, errs := smartCtlParse([]string{smartctlATAOutput, smartctlATAFailingOutput}, false)
+	assert.Empty(t, errs)
+	assert.Empty(t, common.UnregisteredMetrics(common.MeasurementsMap{}.AddInnerWithPrefix("smartmon", result)))
+
+	failing := result["/dev/sdb"].(map[string]interface{})
+	assert.Equal(t, 8, failing["reallocated_sector_ct.value"])
+	assert.Equal(t, 8, failing["reallocated_sector_ct.worst"])
+	assert.Equal(t, 10, failing["reallocated_sector_ct.threshold"])
+	assert.Equal(t, true, failing["reallocated_sector_ct.pre_fail"])
+	assert.Equal(t, true, failing["reallocated_sector_ct.failing_now"])
+	assert.Equal(t, true, failing["reallocated_sector_ct.failed_ever"])
+	assert.Equal(t, 3912, failing["reallocated_sector_count"])
+
+	// recovered, but the worst value was below the threshold
+	assert.Equal(t, false, failing["spin_retry_count.failing_now"])
+	assert.Equal(t, true, failing["spin_retry_count.failed_ever"])
+
+	// threshold 0 never fails
+	assert.Equal(t, false, failing["reported_uncorrect.pre_fail"])
+	assert.Equal(t, false, failing["reported_uncorrect.failing_now"])
+	assert.Equal(t, false, failing["reported_uncorrect.failed_ever"])
+
+	assert.Equal(t, 45, failing["attribute_190.threshold"])
+	assert.Equal(t, false, failing["attribute_190.failing_now"])
+
+	healthy := result["/dev/sda"].(map[string]interface{})
+	assert.Equal(t, 140, healthy["reallocated_sector_ct.threshold"])
+	assert.Equal(t, false, healthy["reallocated_sector_ct.failing_now"])
+	assert.Equal(t, false, healthy["reallocated_sector_ct.failed_ever"])
+	assert.Equal(t, false, healthy["power_on_hours.failed_ever"])
+}
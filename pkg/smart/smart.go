@@ -4,6 +4,8 @@ import (
 	"fmt"
 
 	"github.com/pkg/errors"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
 const atLeastMajorVersion = 7
@@ -11,11 +13,14 @@ const atLeastMajorVersion = 7
 type SMART struct {
 	smartctl         string
 	smartctlDetected bool
+	invoker          common.Invoker
+	healthOnly       bool
 }
 
 func New(opts ...Option) (*SMART, error) {
 	sm := &SMART{
 		smartctlDetected: false,
+		invoker:          common.Invoke{},
 	}
 
 	var err error
@@ -35,6 +40,15 @@ func New(opts ...Option) (*SMART, error) {
 	return sm, nil
 }
 
+// smartctlInfoFlag returns the smartctl flag selecting which information to retrieve
+func (sm *SMART) smartctlInfoFlag() string {
+	if sm.healthOnly {
+		return "-H"
+	}
+
+	return "-a"
+}
+
 func (sm *SMART) detectTools(smartctl string) error {
 	buildStr, path, err := checkTools(smartctl)
 	if err != nil {
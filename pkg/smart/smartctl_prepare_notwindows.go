@@ -4,11 +4,11 @@ package smart
 
 import (
 	"fmt"
-	"os/exec"
 	"runtime"
 )
 
-func (sm *SMART) smartctlPrepare(disk string) *exec.Cmd {
+// smartctlPrepare returns name and arguments of the command retrieving S.M.A.R.T data of the disk
+func (sm *SMART) smartctlPrepare(disk string) (string, []string) {
 	var smartctlPrefix string
 
 	// on linux smartctl should be invoked with sudo rights
@@ -16,5 +16,5 @@ func (sm *SMART) smartctlPrepare(disk string) *exec.Cmd {
 		smartctlPrefix = "sudo "
 	}
 
-	return exec.Command("/bin/sh", "-c", fmt.Sprintf("%s%s -j -a %s", smartctlPrefix, sm.smartctl, disk))
+	return "/bin/sh", []string{"-c", fmt.Sprintf("%s%s -j %s %s", smartctlPrefix, sm.smartctl, sm.smartctlInfoFlag(), disk)}
 }
@@ -2,10 +2,7 @@
 
 package smart
 
-import (
-	"os/exec"
-)
-
-func (sm *SMART) smartctlPrepare(disk string) *exec.Cmd {
-	return exec.Command("cmd", "/c", sm.smartctl, "-j", "-a", disk)
+// smartctlPrepare returns name and arguments of the command retrieving S.M.A.R.T data of the disk
+func (sm *SMART) smartctlPrepare(disk string) (string, []string) {
+	return "cmd", []string{"/c", sm.smartctl, "-j", sm.smartctlInfoFlag(), disk}
 }
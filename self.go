@@ -0,0 +1,77 @@
+package cagent
+
+import (
+	"os"
+	"runtime"
+
+	"github.com/pkg/errors"
+	"github.com/shirou/gopsutil/process"
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// SelfResults reports resources used by the cagent process itself, it helps to catch leaks and runaway instances.
+// cpu_percent is calculated since the previous call (or since the first call if there was no previous one)
+// and may exceed 100 on multi-core systems
+func (ca *Cagent) SelfResults() (common.MeasurementsMap, error) {
+	results := common.MeasurementsMap{
+		"cpu_percent": nil,
+		"mem_rss_B":   nil,
+		"goroutines":  runtime.NumGoroutine(),
+		"open_fds":    nil,
+	}
+
+	p, err := ca.getSelfProcess()
+	if err != nil {
+		return results, errors.Wrap(err, "SELF")
+	}
+
+	errs := common.ErrorCollector{}
+
+	cpuPercent, err := p.Percent(0)
+	if err != nil {
+		errs.Add(errors.Wrap(err, "SELF: failed to get CPU usage"))
+	} else {
+		results["cpu_percent"] = cpuPercent
+	}
+
+	memInfo, err := p.MemoryInfo()
+	if err != nil {
+		errs.Add(errors.Wrap(err, "SELF: failed to get memory usage"))
+	} else {
+		results["mem_rss_B"] = memInfo.RSS
+	}
+
+	// not implemented on every OS, so it is not treated as an error
+	openFDs, err := p.NumFDs()
+	if err != nil {
+		log.WithError(err).Debug("[SELF] failed to get the number of open file descriptors")
+	} else {
+		results["open_fds"] = openFDs
+	}
+
+	return results, errs.Combine()
+}
+
+func (ca *Cagent) getSelfProcess() (*process.Process, error) {
+	var err error
+	ca.selfProcessOnce.Do(func() {
+		ca.selfProcess, err = process.NewProcess(int32(os.Getpid()))
+		if err != nil {
+			return
+		}
+
+		// the first call only stores the CPU times to compare with
+		_, err = ca.selfProcess.Percent(0)
+	})
+
+	if ca.selfProcess == nil {
+		if err == nil {
+			err = errors.New("process info is not available")
+		}
+		return nil, err
+	}
+
+	return ca.selfProcess, nil
+}
@@ -0,0 +1,28 @@
+package cagent
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSelfResults(t *testing.T) {
+	ca := &Cagent{Config: NewConfig()}
+
+	results, err := ca.SelfResults()
+	require.NoError(t, err)
+
+	for _, key := range []string{"cpu_percent", "mem_rss_B", "goroutines", "open_fds"} {
+		assert.Contains(t, results, key)
+	}
+
+	rss, ok := results["mem_rss_B"].(uint64)
+	require.True(t, ok)
+	assert.NotZero(t, rss)
+	assert.True(t, results["goroutines"].(int) > 0)
+
+	cpuPercent, ok := results["cpu_percent"].(float64)
+	require.True(t, ok)
+	assert.True(t, cpuPercent >= 0)
+}
@@ -0,0 +1,112 @@
+package cagent
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+const (
+	SelfTestStatusOK     = "ok"
+	SelfTestStatusNoData = "no_data"
+	SelfTestStatusError  = "error"
+)
+
+// selfTestMandatoryCollectors are the collectors of the minimal operation mode, the self-test fails if any of them fails
+var selfTestMandatoryCollectors = []string{"cpu", "mem", "fs"}
+
+// CollectorSelfTest is the outcome of a single collector run by the self-test
+type CollectorSelfTest struct {
+	Name string
+	// Status is one of SelfTestStatusOK, SelfTestStatusNoData and SelfTestStatusError
+	Status       string
+	Message      string
+	Measurements int
+	Duration     time.Duration
+	Mandatory    bool
+}
+
+// SelfTestReport lists the collectors in the order they were run
+type SelfTestReport struct {
+	Collectors []CollectorSelfTest
+
+	mu sync.Mutex
+}
+
+// FailedMandatory returns names of the mandatory collectors which returned an error
+func (r *SelfTestReport) FailedMandatory() []string {
+	var failed []string
+	for _, c := range r.Collectors {
+		if c.Mandatory && c.Status == SelfTestStatusError {
+			failed = append(failed, c.Name)
+		}
+	}
+
+	return failed
+}
+
+func (r *SelfTestReport) add(name string, measurements common.MeasurementsMap, err error, took time.Duration) {
+	res := CollectorSelfTest{
+		Name:         name,
+		Status:       SelfTestStatusOK,
+		Measurements: len(measurements),
+		Duration:     took,
+		Mandatory:    common.StrInSlice(name, selfTestMandatoryCollectors),
+	}
+
+	switch {
+	case err != nil:
+		res.Status = SelfTestStatusError
+		res.Message = err.Error()
+	case len(measurements) == 0:
+		res.Status = SelfTestStatusNoData
+	}
+
+	r.mu.Lock()
+	r.Collectors = append(r.Collectors, res)
+	r.mu.Unlock()
+}
+
+// SelfTest runs every enabled collector once and reports which of them produced data, which failed and how long they took.
+// Nothing is sent to the Hub or written to the output file.
+// Returns an error if any mandatory collector (cpu, mem or fs) failed
+func (ca *Cagent) SelfTest() (*SelfTestReport, error) {
+	return ca.runSelfTest(func() {
+		ca.collectMeasurements(true)
+	})
+}
+
+func (ca *Cagent) runSelfTest(collect func()) (*SelfTestReport, error) {
+	report := &SelfTestReport{}
+	ca.selfTestReport = report
+	defer func() {
+		ca.selfTestReport = nil
+	}()
+
+	collect()
+
+	if failed := report.FailedMandatory(); len(failed) > 0 {
+		return report, fmt.Errorf("mandatory collectors failed: %s", strings.Join(failed, ", "))
+	}
+
+	return report, nil
+}
+
+// recordSelfTest adds the outcome of the collector to the self-test report if the self-test is running
+func (ca *Cagent) recordSelfTest(name string, measurements common.MeasurementsMap, err error, took time.Duration) {
+	if ca.selfTestReport != nil {
+		ca.selfTestReport.add(name, measurements, err, took)
+	}
+}
+
+// runCollector runs the collector till the deadline, see collectorRunner.Run
+func (ca *Cagent) runCollector(name string, deadline time.Time, f collectorFunc) (common.MeasurementsMap, error) {
+	startedAt := time.Now()
+	res, err := ca.collectors.Run(name, deadline, f)
+	ca.recordSelfTest(name, res, err, time.Since(startedAt))
+
+	return res, err
+}
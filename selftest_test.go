@@ -0,0 +1,63 @@
+package cagent
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestCagentSelfTest(t *testing.T) {
+	ca := &Cagent{collectors: newCollectorRunner(1, nil)}
+
+	collectors := []struct {
+		name string
+		f    collectorFunc
+	}{
+		{"fs", func(context.Context) (common.MeasurementsMap, error) {
+			return common.MeasurementsMap{"fs.free_B./": 1024, "fs.total_B./": 2048}, nil
+		}},
+		{"docker", func(context.Context) (common.MeasurementsMap, error) {
+			return nil, errors.New("docker daemon is not running")
+		}},
+		{"services", func(context.Context) (common.MeasurementsMap, error) {
+			return common.MeasurementsMap{}, nil
+		}},
+	}
+
+	report, err := ca.runSelfTest(func() {
+		for _, c := range collectors {
+			ca.runCollector(c.name, time.Time{}, c.f)
+		}
+	})
+	require.NoError(t, err, "only optional collectors failed")
+	require.Len(t, report.Collectors, 3)
+
+	assert.Equal(t, "fs", report.Collectors[0].Name)
+	assert.Equal(t, SelfTestStatusOK, report.Collectors[0].Status)
+	assert.Equal(t, 2, report.Collectors[0].Measurements)
+	assert.True(t, report.Collectors[0].Mandatory)
+
+	assert.Equal(t, SelfTestStatusError, report.Collectors[1].Status)
+	assert.Equal(t, "docker daemon is not running", report.Collectors[1].Message)
+	assert.False(t, report.Collectors[1].Mandatory)
+
+	assert.Equal(t, SelfTestStatusNoData, report.Collectors[2].Status)
+
+	report, err = ca.runSelfTest(func() {
+		ca.runCollector("fs", time.Time{}, func(context.Context) (common.MeasurementsMap, error) {
+			return nil, errors.New("permission denied")
+		})
+	})
+	assert.EqualError(t, err, "mandatory collectors failed: fs")
+	assert.Equal(t, []string{"fs"}, report.FailedMandatory())
+
+	// collectors run outside of the self-test are not recorded
+	ca.runCollector("fs", time.Time{}, collectors[0].f)
+	assert.Len(t, report.Collectors, 1)
+}
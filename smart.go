@@ -2,29 +2,50 @@ package cagent
 
 import (
 	"strings"
+	"time"
 
 	"github.com/cloudradar-monitoring/cagent/pkg/common"
 )
 
+// smartCache refreshes S.M.A.R.T. measurements not more often than smart_interval, the cached ones are reported meanwhile
+type smartCache struct {
+	interval  time.Duration
+	updatedAt time.Time
+	results   common.MeasurementsMap
+}
+
+func (c *smartCache) get(now time.Time, parse func() common.MeasurementsMap) common.MeasurementsMap {
+	if c.updatedAt.IsZero() || now.Before(c.updatedAt) || now.Sub(c.updatedAt) >= c.interval {
+		c.results = parse()
+		c.updatedAt = now
+	}
+
+	return c.results
+}
+
 func (ca *Cagent) getSMARTMeasurements() common.MeasurementsMap {
-	if ca.smart != nil {
-		res, errs := ca.smart.Parse()
+	if ca.smart == nil {
+		return nil
+	}
 
-		if len(errs) > 0 {
-			var errStr []string
-			for _, e := range errs {
-				errStr = append(errStr, e.Error())
-			}
+	return ca.smartCache.get(time.Now(), ca.parseSMART)
+}
 
-			if res == nil {
-				res = make(common.MeasurementsMap)
-			}
+func (ca *Cagent) parseSMART() common.MeasurementsMap {
+	res, errs := ca.smart.Parse()
+
+	if len(errs) > 0 {
+		var errStr []string
+		for _, e := range errs {
+			errStr = append(errStr, e.Error())
+		}
 
-			res["messages"] = strings.Join(errStr, "; ")
+		if res == nil {
+			res = make(common.MeasurementsMap)
 		}
 
-		return res
+		res["messages"] = strings.Join(errStr, "; ")
 	}
 
-	return nil
+	return res
 }
@@ -0,0 +1,39 @@
+package cagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestSMARTCacheInterval(t *testing.T) {
+	cache := smartCache{interval: 10 * time.Minute}
+
+	var runs int
+	parse := func() common.MeasurementsMap {
+		runs++
+		return common.MeasurementsMap{"runs": runs}
+	}
+
+	// main collection runs every 90 seconds
+	start := time.Now()
+	for i := 0; i < 7; i++ {
+		res := cache.get(start.Add(time.Duration(i)*90*time.Second), parse)
+		assert.Equal(t, common.MeasurementsMap{"runs": 1}, res, "cached measurements are reported")
+	}
+	assert.Equal(t, 1, runs)
+
+	res := cache.get(start.Add(10*time.Minute+30*time.Second), parse)
+	assert.Equal(t, common.MeasurementsMap{"runs": 2}, res)
+
+	res = cache.get(start.Add(12*time.Minute), parse)
+	assert.Equal(t, common.MeasurementsMap{"runs": 2}, res)
+
+	// clock stepped back
+	res = cache.get(start, parse)
+	assert.Equal(t, common.MeasurementsMap{"runs": 3}, res)
+	assert.Equal(t, 3, runs)
+}
@@ -3,6 +3,7 @@ package cagent
 import (
 	"context"
 	"errors"
+	"runtime"
 	"strings"
 	"time"
 
@@ -14,6 +15,14 @@ import (
 
 const swapGetTimeout = time.Second * 10
 
+// gopsutil reports swapped in/out amounts in bytes assuming 4KiB pages
+const swapPageSize = 4 * 1024
+
+type swapStatMeasurement struct {
+	timestamp time.Time
+	stat      *mem.SwapMemoryStat
+}
+
 func (ca *Cagent) SwapResults() (common.MeasurementsMap, error) {
 	results := common.MeasurementsMap{}
 
@@ -26,10 +35,21 @@ func (ca *Cagent) SwapResults() (common.MeasurementsMap, error) {
 	if err != nil {
 		log.Errorf("[SWAP] Failed to get swap memory stat: %s", err.Error())
 		errs = append(errs, err.Error())
-	} else if swapStat.Total > 0 {
-		results["total_B"] = swapStat.Total
-		results["used_B"] = swapStat.Used
-		results["free_B"] = swapStat.Free
+	} else {
+		if swapStat.Total > 0 {
+			results["total_B"] = swapStat.Total
+			results["used_B"] = swapStat.Used
+			results["free_B"] = swapStat.Free
+		}
+
+		// pswpin/pswpout counters are available on Linux only
+		if runtime.GOOS == "linux" {
+			curr := &swapStatMeasurement{time.Now(), swapStat}
+			if ca.prevSwapStat != nil {
+				results["in_per_s"], results["out_per_s"] = calcSwapRates(ca.prevSwapStat, curr)
+			}
+			ca.prevSwapStat = curr
+		}
 	}
 
 	if len(errs) == 0 {
@@ -38,3 +58,21 @@ func (ca *Cagent) SwapResults() (common.MeasurementsMap, error) {
 
 	return results, errors.New("SWAP: " + strings.Join(errs, "; "))
 }
+
+// calcSwapRates returns the number of pages swapped in and out per second between two measurements.
+// nil is returned for a counter that was reset in between
+func calcSwapRates(prev, curr *swapStatMeasurement) (in, out interface{}) {
+	deltaSeconds := curr.timestamp.Sub(prev.timestamp).Seconds()
+	if deltaSeconds <= 0 {
+		return nil, nil
+	}
+
+	calcRate := func(prevValue, currValue uint64) interface{} {
+		if currValue < prevValue {
+			return nil
+		}
+		return common.RoundToTwoDecimalPlaces(float64((currValue-prevValue)/swapPageSize) / deltaSeconds)
+	}
+
+	return calcRate(prev.stat.Sin, curr.stat.Sin), calcRate(prev.stat.Sout, curr.stat.Sout)
+}
@@ -0,0 +1,25 @@
+package cagent
+
+import (
+	"testing"
+	"time"
+
+	"github.com/shirou/gopsutil/mem"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalcSwapRates(t *testing.T) {
+	now := time.Now()
+	prev := &swapStatMeasurement{now, &mem.SwapMemoryStat{Sin: 1000 * swapPageSize, Sout: 500 * swapPageSize}}
+
+	curr := &swapStatMeasurement{now.Add(10 * time.Second), &mem.SwapMemoryStat{Sin: 1250 * swapPageSize, Sout: 500 * swapPageSize}}
+	in, out := calcSwapRates(prev, curr)
+	assert.Equal(t, 25.0, in)
+	assert.Equal(t, 0.0, out)
+
+	// counters were reset, e.g. after reboot
+	curr = &swapStatMeasurement{now.Add(10 * time.Second), &mem.SwapMemoryStat{Sin: 10 * swapPageSize, Sout: 600 * swapPageSize}}
+	in, out = calcSwapRates(prev, curr)
+	assert.Nil(t, in)
+	assert.Equal(t, 10.0, out)
+}
@@ -3,6 +3,7 @@ package cagent
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net"
 	"os"
 	"runtime"
@@ -58,6 +59,51 @@ func (ca *Cagent) HostInfoResults() (common.MeasurementsMap, error) {
 			} else {
 				res[field] = nil
 			}
+		case "os_distro", "os_distro_version":
+			distro, err := osinfo.GetDistro()
+			if err != nil {
+				log.Errorf("[SYSTEM] Failed to read distribution info: %s", err.Error())
+				errs = append(errs, err.Error())
+				res[field] = nil
+				continue
+			}
+
+			if field == "os_distro" {
+				res[field] = distro.Name
+			} else {
+				res[field] = distro.Version
+			}
+		case "kernel_reboot_required":
+			res[field] = nil
+			if runtime.GOOS != "linux" || info == nil {
+				continue
+			}
+
+			required, err := osinfo.KernelRebootRequired(ctx, common.Invoke{}, info.KernelVersion)
+			if err != nil {
+				// e.g. the distribution uses neither dpkg nor rpm
+				log.Debugf("[SYSTEM] Failed to check if the kernel was updated: %s", err.Error())
+				continue
+			}
+			res[field] = required
+		case "entropy":
+			// /proc/sys/kernel/random/entropy_avail is Linux-specific
+			if runtime.GOOS != "linux" {
+				continue
+			}
+
+			avail, err := osinfo.EntropyAvail()
+			if err != nil {
+				log.Errorf("[SYSTEM] Failed to read available entropy: %s", err.Error())
+				errs = append(errs, err.Error())
+				res["entropy_avail"] = nil
+				res["entropy_low"] = nil
+				continue
+			}
+
+			for k, v := range entropyMeasurements(avail, ca.Config.EntropyLowThreshold) {
+				res[k] = v
+			}
 		case "uname":
 			uname, err := Uname()
 			if err != nil {
@@ -90,6 +136,18 @@ func (ca *Cagent) HostInfoResults() (common.MeasurementsMap, error) {
 			}
 
 			res[field] = memStat.Total
+		case "users":
+			users, err := host.UsersWithContext(ctx)
+			if err != nil {
+				log.Errorf("[SYSTEM] Failed to read logged in users: %s", err.Error())
+				errs = append(errs, err.Error())
+				res["users_count"] = nil
+				continue
+			}
+
+			for k, v := range userSessionsMeasurements(users) {
+				res[k] = v
+			}
 		}
 	}
 
@@ -100,6 +158,44 @@ func (ca *Cagent) HostInfoResults() (common.MeasurementsMap, error) {
 	return res, errors.New("SYSTEM: " + strings.Join(errs, "; "))
 }
 
+// entropyMeasurements reports the available entropy and whether it's below the threshold,
+// low entropy stalls reads from /dev/random and the crypto operations using it
+func entropyMeasurements(avail int, lowThreshold int) common.MeasurementsMap {
+	return common.MeasurementsMap{
+		"entropy_avail": avail,
+		"entropy_low":   avail < lowThreshold,
+	}
+}
+
+// userSessionsMeasurements reports the number of distinct logged in users and their sessions
+// utmp may contain stale duplicates, so sessions are deduplicated by user and terminal
+func userSessionsMeasurements(users []host.UserStat) common.MeasurementsMap {
+	res := common.MeasurementsMap{}
+	uniqueUsers := make(map[string]struct{})
+	seenSessions := make(map[string]struct{})
+
+	n := 0
+	for _, u := range users {
+		sessionKey := u.User + "\x00" + u.Terminal
+		if _, seen := seenSessions[sessionKey]; seen {
+			continue
+		}
+		seenSessions[sessionKey] = struct{}{}
+		uniqueUsers[u.User] = struct{}{}
+
+		prefix := fmt.Sprintf("session.%d.", n)
+		res[prefix+"user"] = u.User
+		res[prefix+"terminal"] = u.Terminal
+		res[prefix+"host"] = u.Host
+		n++
+	}
+
+	res["users_count"] = len(uniqueUsers)
+	res["sessions_count"] = n
+
+	return res
+}
+
 func getFQDN() string {
 	hostname, err := os.Hostname()
 	if err != nil {
@@ -0,0 +1,45 @@
+package cagent
+
+import (
+	"testing"
+
+	"github.com/shirou/gopsutil/host"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestUserSessionsMeasurements(t *testing.T) {
+	users := []host.UserStat{
+		{User: "alice", Terminal: "pts/0", Host: "10.0.0.5", Started: 1570000000},
+		{User: "bob", Terminal: "pts/1", Host: "10.0.0.6", Started: 1570000100},
+		// stale duplicate utmp entry
+		{User: "alice", Terminal: "pts/0", Host: "10.0.0.5", Started: 1560000000},
+		{User: "alice", Terminal: "tty1", Host: "", Started: 1570000200},
+	}
+
+	assert.Equal(t, common.MeasurementsMap{
+		"users_count":        2,
+		"sessions_count":     3,
+		"session.0.user":     "alice",
+		"session.0.terminal": "pts/0",
+		"session.0.host":     "10.0.0.5",
+		"session.1.user":     "bob",
+		"session.1.terminal": "pts/1",
+		"session.1.host":     "10.0.0.6",
+		"session.2.user":     "alice",
+		"session.2.terminal": "tty1",
+		"session.2.host":     "",
+	}, userSessionsMeasurements(users))
+
+	assert.Equal(t, common.MeasurementsMap{
+		"users_count":    0,
+		"sessions_count": 0,
+	}, userSessionsMeasurements(nil))
+}
+
+func TestEntropyMeasurements(t *testing.T) {
+	assert.Equal(t, common.MeasurementsMap{"entropy_avail": 3527, "entropy_low": false}, entropyMeasurements(3527, 256))
+	assert.Equal(t, common.MeasurementsMap{"entropy_avail": 256, "entropy_low": false}, entropyMeasurements(256, 256))
+	assert.Equal(t, common.MeasurementsMap{"entropy_avail": 180, "entropy_low": true}, entropyMeasurements(180, 256))
+}
@@ -0,0 +1,59 @@
+package cagent
+
+import (
+	"fmt"
+
+	log "github.com/sirupsen/logrus"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+// MeasurementsTransform derives, renames or drops measurements before they are reported
+type MeasurementsTransform func(measurements common.MeasurementsMap) common.MeasurementsMap
+
+// AddTransform registers the transform applied to the collected measurements just before they are reported.
+// Transforms are applied in the order they were added
+func (ca *Cagent) AddTransform(transform MeasurementsTransform) {
+	ca.transformsLock.Lock()
+	defer ca.transformsLock.Unlock()
+
+	ca.transforms = append(ca.transforms, transform)
+}
+
+func (ca *Cagent) applyTransforms(measurements common.MeasurementsMap) common.MeasurementsMap {
+	ca.transformsLock.Lock()
+	transforms := ca.transforms
+	ca.transformsLock.Unlock()
+
+	for i, transform := range transforms {
+		transformed, err := applyTransform(transform, measurements)
+		if err != nil {
+			log.WithError(err).Errorf("measurements transform #%d failed, skipping it", i+1)
+			continue
+		}
+		measurements = transformed
+	}
+
+	return measurements
+}
+
+// applyTransform passes a copy of measurements to the transform, so they are kept intact if it panics
+func applyTransform(transform MeasurementsTransform, measurements common.MeasurementsMap) (result common.MeasurementsMap, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	measurementsCopy := make(common.MeasurementsMap, len(measurements))
+	for key, value := range measurements {
+		measurementsCopy[key] = value
+	}
+
+	result = transform(measurementsCopy)
+	if result == nil {
+		return nil, fmt.Errorf("nil measurements returned")
+	}
+
+	return result, nil
+}
@@ -0,0 +1,49 @@
+package cagent
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/cloudradar-monitoring/cagent/pkg/common"
+)
+
+func TestCagentAddTransform(t *testing.T) {
+	ca := helperCreateCagent(t)
+	defer ca.Shutdown()
+
+	ca.AddTransform(func(m common.MeasurementsMap) common.MeasurementsMap {
+		m["mem.cached_ratio"] = float64(m["mem.cached_B"].(int)) / float64(m["mem.total_B"].(int))
+		return m
+	})
+	ca.AddTransform(func(m common.MeasurementsMap) common.MeasurementsMap {
+		m["panicked"] = true
+		panic("broken transform")
+	})
+	ca.AddTransform(func(m common.MeasurementsMap) common.MeasurementsMap {
+		delete(m, "mem.cached_B")
+		return m
+	})
+
+	output, err := ioutil.TempFile("", "cagent-transform")
+	require.NoError(t, err)
+	defer os.Remove(output.Name())
+	defer output.Close()
+
+	err = ca.reportMeasurements(common.MeasurementsMap{"mem.total_B": 1000, "mem.cached_B": 250}, output)
+	require.NoError(t, err)
+
+	data, err := ioutil.ReadFile(output.Name())
+	require.NoError(t, err)
+
+	var result Result
+	require.NoError(t, json.Unmarshal(data, &result))
+	assert.Equal(t, common.MeasurementsMap{
+		"mem.total_B":      1000.0,
+		"mem.cached_ratio": 0.25,
+	}, result.Measurements)
+}
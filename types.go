@@ -8,9 +8,14 @@ import (
 )
 
 type Result struct {
-	Timestamp    int64                  `json:"timestamp"`
-	Measurements common.MeasurementsMap `json:"measurements"`
-	Message      interface{}            `json:"message"`
+	Timestamp    interface{}                      `json:"timestamp"`
+	Measurements common.MeasurementsMap           `json:"measurements"`
+	Message      interface{}                      `json:"message"`
+	Meta         map[string]common.MetricMetadata `json:"meta,omitempty"`
+	// ChangedOnly is set when Measurements contain only the values changed since the previous send
+	ChangedOnly bool `json:"changed_only,omitempty"`
+	// CollectedAt holds the collection time of the measurements by the collector names, see collector_timestamps
+	CollectedAt map[string]interface{} `json:"collected_at,omitempty"`
 }
 
 func floatToIntPercentRoundUP(f float64) int {